@@ -0,0 +1,274 @@
+package permify_gorm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Permify/go-role/helpers"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/repositories/scopes"
+)
+
+// cachedUser represents the cached assignments of a user in a scope.
+type cachedUser struct {
+	roleIDs             []uint
+	permissionIDs       []uint
+	deniedPermissionIDs []uint
+	// validUntil is the time at which one of the assignments starts or expires, nil if none of them does.
+	validUntil *time.Time
+}
+
+// cachedRole represents the cached assignments of a role.
+type cachedRole struct {
+	childRoleIDs        []uint
+	permissionIDs       []uint
+	deniedPermissionIDs []uint
+	// validUntil is the time at which one of the assignments starts or expires, nil if none of them does.
+	validUntil *time.Time
+}
+
+// valid are the cached assignments still the ones that are valid at the given time?
+// @param *time.Time
+// @param time.Time
+// @return bool
+func valid(validUntil *time.Time, now time.Time) bool {
+	return validUntil == nil || now.Before(*validUntil)
+}
+
+// userCacheKey returns the cache key of the user. All the scopes of the user are stored under the same key,
+// so that a change of the user invalidates them together.
+// @param models.Subject
+// @return string
+func userCacheKey(subject models.Subject) string {
+	return fmt.Sprintf("permify:subjects:%s", subject)
+}
+
+// roleCacheKey returns the cache key of the role.
+// @param uint
+// @return string
+func roleCacheKey(roleID uint) string {
+	return fmt.Sprintf("permify:roles:%d", roleID)
+}
+
+// cacheScope returns the scope of the user assignments that are cached, the window is not a part of it.
+// @return scopes.Assignment
+func (s *Permify) cacheScope() scopes.Assignment {
+	return scopes.Assignment{
+		TenantID:     s.tenantID,
+		ResourceType: s.resourceType,
+		ResourceID:   s.resourceID,
+	}
+}
+
+// cachedUserAssignments returns the assignments of the user in the current scope, from the cache if possible.
+// The cached assignments are fetched again once one of the time windows of the user starts or expires.
+// @param context.Context
+// @param models.Subject
+// @return cachedUser, error
+func (s *Permify) cachedUserAssignments(ctx context.Context, subject models.Subject) (user cachedUser, err error) {
+	key := userCacheKey(subject)
+	scope := s.cacheScope()
+	now := time.Now()
+
+	users := map[scopes.Assignment]cachedUser{}
+	if value, found := s.cache.Get(key); found {
+		users = value.(map[scopes.Assignment]cachedUser)
+		if user, found = users[scope]; found && valid(user.validUntil, now) {
+			return user, nil
+		}
+	}
+
+	user = cachedUser{}
+	user.validUntil, err = s.UserRepository.GetNextWindowBound(ctx, subject, s.assignment(), now)
+	if err != nil {
+		return cachedUser{}, err
+	}
+
+	user.roleIDs, _, err = s.RoleRepository.GetRoleIDsOfUser(ctx, subject, s.assignment(), nil)
+	if err != nil {
+		return cachedUser{}, err
+	}
+
+	user.permissionIDs, _, err = s.PermissionRepository.GetDirectPermissionIDsOfUserByID(ctx, subject, s.assignment(), nil)
+	if err != nil {
+		return cachedUser{}, err
+	}
+
+	user.deniedPermissionIDs, _, err = s.PermissionRepository.GetDeniedPermissionIDsOfUserByID(ctx, subject, s.assignment(), nil)
+	if err != nil {
+		return cachedUser{}, err
+	}
+
+	// the cached map is shared, it is copied instead of modified.
+	updated := make(map[scopes.Assignment]cachedUser, len(users)+1)
+	for k, v := range users {
+		updated[k] = v
+	}
+	updated[scope] = user
+	s.cache.Set(key, updated)
+
+	return user, nil
+}
+
+// cachedRoleAssignments returns the assignments of the role, from the cache if possible.
+// The cached assignments are fetched again once one of the time windows of the role starts or expires.
+// @param context.Context
+// @param uint
+// @return cachedRole, error
+func (s *Permify) cachedRoleAssignments(ctx context.Context, roleID uint) (role cachedRole, err error) {
+	key := roleCacheKey(roleID)
+	now := time.Now()
+	if value, found := s.cache.Get(key); found && valid(value.(cachedRole).validUntil, now) {
+		return value.(cachedRole), nil
+	}
+
+	role.validUntil, err = s.RoleRepository.GetNextWindowBound(ctx, []uint{roleID}, now)
+	if err != nil {
+		return cachedRole{}, err
+	}
+
+	role.childRoleIDs, err = s.RoleRepository.GetChildRoleIDs(ctx, []uint{roleID})
+	if err != nil {
+		return cachedRole{}, err
+	}
+
+	role.permissionIDs, _, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(ctx, []uint{roleID}, nil)
+	if err != nil {
+		return cachedRole{}, err
+	}
+
+	role.deniedPermissionIDs, _, err = s.PermissionRepository.GetDeniedPermissionIDsOfRolesByIDs(ctx, []uint{roleID}, nil)
+	if err != nil {
+		return cachedRole{}, err
+	}
+
+	s.cache.Set(key, role)
+
+	return role, nil
+}
+
+// cachedPermissionAssignmentsOfUser is the cached variant of permissionAssignmentsOfUser, it returns all the permission ids of the user.
+// The user and each of the roles it inherits are cached separately, so that a change invalidates only them.
+// @param context.Context
+// @param models.Subject
+// @return permissionAssignments, error
+func (s *Permify) cachedPermissionAssignmentsOfUser(ctx context.Context, subject models.Subject) (assignments permissionAssignments, err error) {
+	var user cachedUser
+	user, err = s.cachedUserAssignments(ctx, subject)
+	if err != nil {
+		return permissionAssignments{}, err
+	}
+
+	assignments.directPermissionIDs = user.permissionIDs
+	assignments.directDeniedPermissionIDs = user.deniedPermissionIDs
+
+	visitedRoleIDs := helpers.RemoveDuplicateValues(user.roleIDs)
+	parentRoleIDs := visitedRoleIDs
+	for len(parentRoleIDs) > 0 {
+		var childRoleIDs []uint
+		for _, roleID := range parentRoleIDs {
+			var role cachedRole
+			role, err = s.cachedRoleAssignments(ctx, roleID)
+			if err != nil {
+				return permissionAssignments{}, err
+			}
+
+			assignments.rolePermissionIDs = append(assignments.rolePermissionIDs, role.permissionIDs...)
+			assignments.roleDeniedPermissionIDs = append(assignments.roleDeniedPermissionIDs, role.deniedPermissionIDs...)
+
+			for _, childRoleID := range role.childRoleIDs {
+				if !helpers.InArray(childRoleID, visitedRoleIDs) {
+					visitedRoleIDs = append(visitedRoleIDs, childRoleID)
+					childRoleIDs = append(childRoleIDs, childRoleID)
+				}
+			}
+		}
+		parentRoleIDs = childRoleIDs
+	}
+
+	return assignments, nil
+}
+
+// pendingInvalidationsKey is the context key of the cache keys invalidated in the outermost transaction of permify.
+type pendingInvalidationsKey struct{}
+
+// pendingInvalidations are the cache keys invalidated in a transaction, they are invalidated again when it ends,
+// since the assignments read by other calls before the commit are the old ones. (see Permify.transaction)
+type pendingInvalidations struct {
+	mu    sync.Mutex
+	keys  []string
+	clear bool
+}
+
+// forget invalidates the cache keys, and again when the outermost transaction of the context ends.
+// @param context.Context
+// @param ...string
+func (s *Permify) forget(ctx context.Context, keys ...string) {
+	s.cache.Delete(keys...)
+	if pending, ok := ctx.Value(pendingInvalidationsKey{}).(*pendingInvalidations); ok {
+		pending.mu.Lock()
+		pending.keys = append(pending.keys, keys...)
+		pending.mu.Unlock()
+	}
+}
+
+// invalidate invalidates the cache keys of the ended transaction.
+// @param *pendingInvalidations
+func (s *Permify) invalidate(pending *pendingInvalidations) {
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+	if pending.clear {
+		s.cache.Clear()
+	} else if len(pending.keys) > 0 {
+		s.cache.Delete(pending.keys...)
+	}
+}
+
+// forgetUsers invalidates the cached assignments of the users in every scope.
+// @param context.Context
+// @param ...models.Subject
+func (s *Permify) forgetUsers(ctx context.Context, subjects ...models.Subject) {
+	if s.cache == nil {
+		return
+	}
+
+	var keys []string
+	for _, subject := range subjects {
+		keys = append(keys, userCacheKey(subject))
+	}
+	s.forget(ctx, keys...)
+}
+
+// forgetRoles invalidates the cached assignments of the roles.
+// The users of the roles and the roles that inherit them do not need to be invalidated while the roles exist, they only cache the role ids.
+// @param context.Context
+// @param ...uint
+func (s *Permify) forgetRoles(ctx context.Context, roleIDs ...uint) {
+	if s.cache == nil {
+		return
+	}
+
+	var keys []string
+	for _, roleID := range roleIDs {
+		keys = append(keys, roleCacheKey(roleID))
+	}
+	s.forget(ctx, keys...)
+}
+
+// forgetAll invalidates all the cached assignments, and again when the outermost transaction of the context ends.
+// It is used when a role or a permission is deleted: the users and the roles cache its id, and the database can give the id to a new one. (e.g. sqlite)
+// @param context.Context
+func (s *Permify) forgetAll(ctx context.Context) {
+	if s.cache == nil {
+		return
+	}
+
+	s.cache.Clear()
+	if pending, ok := ctx.Value(pendingInvalidationsKey{}).(*pendingInvalidations); ok {
+		pending.mu.Lock()
+		pending.clear = true
+		pending.mu.Unlock()
+	}
+}
@@ -0,0 +1,394 @@
+package permify_gorm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/options"
+	"github.com/Permify/go-role/repositories"
+	"github.com/Permify/go-role/repositories/scopes"
+	"github.com/Permify/go-role/utils"
+)
+
+// auditVerifyBatchSize is the number of audit entries fetched at once by VerifyAuditLog.
+const auditVerifyBatchSize = 1000
+
+// AuditTamperedError is returned by VerifyAuditLog when an audit entry has been changed, or an entry before it has been deleted.
+type AuditTamperedError struct {
+	EntryID uint
+	Reason  string
+}
+
+// Error returns the error message.
+// @return string
+func (e *AuditTamperedError) Error() string {
+	return fmt.Sprintf("err audit entry %d is tampered: %s", e.EntryID, e.Reason)
+}
+
+// By returns a copy of Permify whose changes are recorded in the audit log with the given actor. example: permify.By("user:5").AddRolesToUser(1, "admin")
+// @param string
+// @return *Permify
+func (s *Permify) By(actor string) *Permify {
+	by := *s
+	by.actor = actor
+	return &by
+}
+
+// auditActor returns the actor of the audit entries, the actor set with By or else the acting user. (see ActingAs)
+// @return string
+func (s *Permify) auditActor() string {
+	if s.actor != "" || s.actingUser == nil {
+		return s.actor
+	}
+	if actor, err := s.parseSubject(s.actingUser); err == nil {
+		return actor.String()
+	}
+	return ""
+}
+
+// transaction runs fc in a transaction of the Transactor, or without a transaction if there is no Transactor.
+// The cached assignments invalidated in the outermost transaction are invalidated again when it ends,
+// so that the assignments cached by other calls before the commit are not kept.
+// @param context.Context
+// @param func(ctx context.Context) error
+// @return error
+func (s *Permify) transaction(ctx context.Context, fc func(ctx context.Context) error) error {
+	if s.transactor == nil {
+		return fc(ctx)
+	}
+	if _, nested := ctx.Value(pendingInvalidationsKey{}).(*pendingInvalidations); nested || s.cache == nil {
+		return s.transactor.Transaction(ctx, fc)
+	}
+
+	pending := &pendingInvalidations{}
+	defer s.invalidate(pending)
+	return s.transactor.Transaction(context.WithValue(ctx, pendingInvalidationsKey{}, pending), fc)
+}
+
+// audited makes the change, and if the audit log is enabled, records it in the same transaction.
+// The before and after names of the entry are returned by names, before and after the change.
+// @param context.Context
+// @param models.AuditEntry
+// @param func(ctx context.Context) ([]string, error)
+// @param func(ctx context.Context) error
+// @return error
+func (s *Permify) audited(ctx context.Context, entry models.AuditEntry, names func(ctx context.Context) ([]string, error), change func(ctx context.Context) error) error {
+	if s.AuditRepository == nil {
+		return change(ctx)
+	}
+
+	return s.transaction(ctx, func(ctx context.Context) (err error) {
+		if entry.Before, err = names(ctx); err != nil {
+			return
+		}
+		if err = change(ctx); err != nil {
+			return
+		}
+		if entry.After, err = names(ctx); err != nil {
+			return
+		}
+		entry.Actor = s.auditActor()
+		return s.AuditRepository.Record(ctx, &entry)
+	})
+}
+
+// roleAuditEntry returns the audit entry of a change of the role.
+// @param string
+// @param models.Role
+// @param models.AuditKind
+// @return models.AuditEntry
+func (s *Permify) roleAuditEntry(op string, role models.Role, kind models.AuditKind) models.AuditEntry {
+	return models.AuditEntry{Operation: op, Role: role.GuardName, Kind: kind}
+}
+
+// userAuditEntry returns the audit entry of a change of the user in the current scope.
+// @param string
+// @param models.Subject
+// @param models.AuditKind
+// @return models.AuditEntry
+func (s *Permify) userAuditEntry(op string, subject models.Subject, kind models.AuditKind) models.AuditEntry {
+	return models.AuditEntry{
+		Operation:    op,
+		SubjectType:  subject.Type,
+		SubjectID:    subject.ID.String(),
+		TenantID:     s.tenantID,
+		ResourceType: s.resourceType,
+		ResourceID:   s.resourceID,
+		Kind:         kind,
+	}
+}
+
+// roleNames returns the names of the audit entries of the role, the guard name if the role exists.
+// @param string
+// @return func(ctx context.Context) ([]string, error)
+func (s *Permify) roleNames(guardName string) func(ctx context.Context) ([]string, error) {
+	return func(ctx context.Context) ([]string, error) {
+		roles, err := s.RoleRepository.GetRolesByGuardNames(ctx, []string{guardName})
+		return roles.GuardNames(), err
+	}
+}
+
+// permissionNames returns the names of the audit entries of the permission, the guard name if the permission exists.
+// @param string
+// @return func(ctx context.Context) ([]string, error)
+func (s *Permify) permissionNames(guardName string) func(ctx context.Context) ([]string, error) {
+	return func(ctx context.Context) ([]string, error) {
+		permissions, err := s.PermissionRepository.GetPermissionsByGuardNames(ctx, []string{guardName})
+		return permissions.GuardNames(), err
+	}
+}
+
+// auditAssignment is a role or a permission given to a user or a role, with its window.
+type auditAssignment struct {
+	id        uint
+	startsAt  *time.Time
+	expiresAt *time.Time
+}
+
+// rolePermissionNames returns the names of the audit entries of the permissions or the denied permissions of the role, with their windows.
+// The permissions that are not valid now are included, so that a change of their windows is recorded.
+// @param models.Role
+// @param bool
+// @return func(ctx context.Context) ([]string, error)
+func (s *Permify) rolePermissionNames(role models.Role, denied bool) func(ctx context.Context) ([]string, error) {
+	return func(ctx context.Context) ([]string, error) {
+		var assignments []auditAssignment
+		if denied {
+			roleDeniedPermissions, err := s.RoleRepository.GetDeniedPermissionWindows(ctx, []uint{role.ID})
+			if err != nil {
+				return nil, err
+			}
+			for _, roleDeniedPermission := range roleDeniedPermissions {
+				assignments = append(assignments, auditAssignment{id: roleDeniedPermission.PermissionID, startsAt: roleDeniedPermission.StartsAt, expiresAt: roleDeniedPermission.ExpiresAt})
+			}
+		} else {
+			rolePermissions, err := s.RoleRepository.GetPermissionWindows(ctx, []uint{role.ID})
+			if err != nil {
+				return nil, err
+			}
+			for _, rolePermission := range rolePermissions {
+				assignments = append(assignments, auditAssignment{id: rolePermission.PermissionID, startsAt: rolePermission.StartsAt, expiresAt: rolePermission.ExpiresAt})
+			}
+		}
+		return s.permissionAuditNames(ctx, assignments)
+	}
+}
+
+// childRoleNames returns the names of the audit entries of the child roles of the role.
+// @param models.Role
+// @return func(ctx context.Context) ([]string, error)
+func (s *Permify) childRoleNames(role models.Role) func(ctx context.Context) ([]string, error) {
+	return func(ctx context.Context) ([]string, error) {
+		childRoleIDs, err := s.RoleRepository.GetChildRoleIDs(ctx, []uint{role.ID})
+		if err != nil {
+			return nil, err
+		}
+		return s.roleGuardNames(ctx, childRoleIDs)
+	}
+}
+
+// userPermissionNames returns the names of the audit entries of the direct or the denied permissions of the user made exactly in the current scope, with their windows.
+// The permissions that are not valid now are included, and the ones of the enclosing scopes are not, so that every change of the scope is recorded.
+// @param models.Subject
+// @param bool
+// @return func(ctx context.Context) ([]string, error)
+func (s *Permify) userPermissionNames(subject models.Subject, denied bool) func(ctx context.Context) ([]string, error) {
+	return func(ctx context.Context) ([]string, error) {
+		var assignments []auditAssignment
+		if denied {
+			userDeniedPermissions, err := s.UserRepository.GetDeniedPermissionWindows(ctx, subject, s.assignment())
+			if err != nil {
+				return nil, err
+			}
+			for _, userDeniedPermission := range userDeniedPermissions {
+				assignments = append(assignments, auditAssignment{id: userDeniedPermission.PermissionID, startsAt: userDeniedPermission.StartsAt, expiresAt: userDeniedPermission.ExpiresAt})
+			}
+		} else {
+			userPermissions, err := s.UserRepository.GetPermissionWindows(ctx, subject, s.assignment())
+			if err != nil {
+				return nil, err
+			}
+			for _, userPermission := range userPermissions {
+				assignments = append(assignments, auditAssignment{id: userPermission.PermissionID, startsAt: userPermission.StartsAt, expiresAt: userPermission.ExpiresAt})
+			}
+		}
+		return s.permissionAuditNames(ctx, assignments)
+	}
+}
+
+// userRoleNames returns the names of the audit entries of the roles of the user made exactly in the current scope, with their windows.
+// The roles that are not valid now are included, and the ones of the enclosing scopes are not, so that every change of the scope is recorded.
+// @param models.Subject
+// @return func(ctx context.Context) ([]string, error)
+func (s *Permify) userRoleNames(subject models.Subject) func(ctx context.Context) ([]string, error) {
+	return func(ctx context.Context) ([]string, error) {
+		userRoles, err := s.UserRepository.GetRoleWindows(ctx, subject, s.assignment())
+		if err != nil || len(userRoles) == 0 {
+			return nil, err
+		}
+
+		var roleIDs []uint
+		for _, userRole := range userRoles {
+			roleIDs = append(roleIDs, userRole.RoleID)
+		}
+		roles, err := s.RoleRepository.GetRoles(ctx, roleIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		guardNames := make(map[uint]string, len(roles))
+		for _, role := range roles {
+			guardNames[role.ID] = role.GuardName
+		}
+		var names []string
+		for _, userRole := range userRoles {
+			names = append(names, models.AuditName(guardNames[userRole.RoleID], userRole.StartsAt, userRole.ExpiresAt))
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+}
+
+// permissionAuditNames returns the sorted names of the audit entries of the assigned permissions. (see models.AuditName)
+// @param context.Context
+// @param []auditAssignment
+// @return []string, error
+func (s *Permify) permissionAuditNames(ctx context.Context, assignments []auditAssignment) (names []string, err error) {
+	if len(assignments) == 0 {
+		return nil, nil
+	}
+
+	var permissionIDs []uint
+	for _, assignment := range assignments {
+		permissionIDs = append(permissionIDs, assignment.id)
+	}
+	var permissions collections.Permission
+	if permissions, err = s.PermissionRepository.GetPermissions(ctx, permissionIDs); err != nil {
+		return nil, err
+	}
+
+	guardNames := make(map[uint]string, len(permissions))
+	for _, permission := range permissions {
+		guardNames[permission.ID] = permission.GuardName
+	}
+	for _, assignment := range assignments {
+		names = append(names, models.AuditName(guardNames[assignment.id], assignment.startsAt, assignment.expiresAt))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// roleGuardNames returns the sorted guard names of the roles.
+// @param context.Context
+// @param []uint
+// @return []string, error
+func (s *Permify) roleGuardNames(ctx context.Context, roleIDs []uint) (names []string, err error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+	roles, err := s.RoleRepository.GetRoles(ctx, roleIDs)
+	names = roles.GuardNames()
+	sort.Strings(names)
+	return
+}
+
+// GetAuditEntries fetch the audit entries in the order they are recorded. (with pagination option)
+// The entries can be filtered by the user, the role, the actor and the time range of the changes. (see options.AuditOption)
+// It returns ErrAuditDisabled if the audit log is not enabled.
+// @param options.AuditOption
+// @return []models.AuditEntry, int64, error
+func (s *Permify) GetAuditEntries(option options.AuditOption) (entries []models.AuditEntry, totalCount int64, err error) {
+	return s.GetAuditEntriesCtx(context.Background(), option)
+}
+
+// GetAuditEntriesCtx is the context-aware variant of GetAuditEntries.
+// @param context.Context
+// @param options.AuditOption
+// @return []models.AuditEntry, int64, error
+func (s *Permify) GetAuditEntriesCtx(ctx context.Context, option options.AuditOption) (entries []models.AuditEntry, totalCount int64, err error) {
+	defer wrapError(&err, "GetAuditEntries", nil)
+
+	if s.AuditRepository == nil {
+		return nil, 0, ErrAuditDisabled
+	}
+
+	filter := repositories.AuditFilter{
+		Actor: option.Actor,
+		Since: option.Since,
+		Until: option.Until,
+	}
+	if option.User != nil {
+		var subject models.Subject
+		if subject, err = s.parseSubject(option.User); err != nil {
+			return
+		}
+		filter.Subject = &subject
+	}
+	if option.Role != "" {
+		filter.Role = s.guardName(option.Role)
+	}
+
+	if option.Pagination == nil {
+		return s.AuditRepository.GetAuditEntries(ctx, filter, nil)
+	}
+	return s.AuditRepository.GetAuditEntries(ctx, filter, &scopes.GormPagination{Pagination: option.Pagination.Get()})
+}
+
+// VerifyAuditLog checks the hash chain of the audit log. It returns *AuditTamperedError if an entry has been changed or deleted,
+// the deletion of the last entries is detected with the head of the chain, which has the hash of the last recorded entry.
+// It returns ErrAuditDisabled if the audit log is not enabled.
+// @return error
+func (s *Permify) VerifyAuditLog() (err error) {
+	return s.VerifyAuditLogCtx(context.Background())
+}
+
+// VerifyAuditLogCtx is the context-aware variant of VerifyAuditLog.
+// @param context.Context
+// @return error
+func (s *Permify) VerifyAuditLogCtx(ctx context.Context) (err error) {
+	defer wrapError(&err, "VerifyAuditLog", nil)
+
+	if s.AuditRepository == nil {
+		return ErrAuditDisabled
+	}
+
+	// the head is read first, the entries recorded after it are chained after the entry that has its hash.
+	var head string
+	if head, err = s.AuditRepository.GetChainHead(ctx); err != nil {
+		return err
+	}
+
+	previousHash := ""
+	var lastID uint
+	headFound := head == ""
+	for page := 1; ; page++ {
+		var entries []models.AuditEntry
+		entries, _, err = s.AuditRepository.GetAuditEntries(ctx, repositories.AuditFilter{}, &scopes.GormPagination{Pagination: &utils.Pagination{Page: page, Limit: auditVerifyBatchSize}})
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if entry.Hash != entry.ComputeHash() {
+				return &AuditTamperedError{EntryID: entry.ID, Reason: "the hash does not match the entry"}
+			}
+			if entry.PreviousHash != previousHash {
+				return &AuditTamperedError{EntryID: entry.ID, Reason: "the previous hash does not match the previous entry"}
+			}
+			previousHash = entry.Hash
+			lastID = entry.ID
+			headFound = headFound || entry.Hash == head
+		}
+
+		if len(entries) < auditVerifyBatchSize {
+			if !headFound {
+				return &AuditTamperedError{EntryID: lastID, Reason: "the last entries have been deleted, the head of the chain does not match any entry"}
+			}
+			return nil
+		}
+	}
+}
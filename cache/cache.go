@@ -0,0 +1,14 @@
+package cache
+
+// Cache stores the role and permission assignments used by the permission checks.
+// Implementations must be safe for concurrent use. The stored values must not be modified.
+type Cache interface {
+	// Get returns the value of the key, found is false if the key does not exist or expired.
+	Get(key string) (value interface{}, found bool)
+	// Set stores the value of the key.
+	Set(key string, value interface{})
+	// Delete removes the keys.
+	Delete(keys ...string)
+	// Clear removes all the keys.
+	Clear()
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process Cache that evicts the least recently used keys when it is full.
+// The keys expire after the ttl, so that the changes made by other processes are seen eventually.
+type LRU struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[string]*list.Element
+	order *list.List
+	now   func() time.Time
+}
+
+// entry is an element of the LRU.
+type entry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// NewLRU initializer for LRU
+// Size is the maximum number of keys, zero means unlimited. Ttl is the lifetime of the keys, zero means they never expire.
+// @param int
+// @param time.Duration
+// @return *LRU
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+		now:   time.Now,
+	}
+}
+
+// SetClock sets the clock of the expiration of the keys, e.g. a fake clock in the tests. (default time.Now)
+// @param func() time.Time
+func (c *LRU) SetClock(now func() time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
+
+// Get returns the value of the key, found is false if the key does not exist or expired.
+// @param string
+// @return interface{}, bool
+func (c *LRU) Get(key string) (value interface{}, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := element.Value.(*entry)
+	if c.ttl > 0 && !c.now().Before(e.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+// Set stores the value of the key, the least recently used key is evicted if the cache is full.
+// @param string
+// @param interface{}
+func (c *LRU) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if c.ttl > 0 {
+		expiresAt = c.now().Add(c.ttl)
+	}
+
+	if element, ok := c.items[key]; ok {
+		e := element.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+
+	if c.size > 0 && c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Delete removes the keys.
+// @param ...string
+func (c *LRU) Delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.items[key]; ok {
+			c.remove(element)
+		}
+	}
+}
+
+// Clear removes all the keys.
+func (c *LRU) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// Len returns the number of keys, including the expired keys that are not removed yet.
+// @return int
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// remove removes the element, the caller must hold the lock.
+// @param *list.Element
+func (c *LRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry).key)
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LRU", func() {
+	Context("Get", func() {
+		It("found", func() {
+			c := NewLRU(2, 0)
+			c.Set("a", 1)
+
+			value, found := c.Get("a")
+			Expect(found).Should(BeTrue())
+			Expect(value).Should(Equal(1))
+		})
+
+		It("not found", func() {
+			c := NewLRU(2, 0)
+
+			_, found := c.Get("a")
+			Expect(found).Should(BeFalse())
+		})
+
+		It("expired", func() {
+			now := time.Now()
+			c := NewLRU(2, time.Minute)
+			c.now = func() time.Time { return now }
+			c.Set("a", 1)
+
+			now = now.Add(time.Minute)
+			_, found := c.Get("a")
+			Expect(found).Should(BeFalse())
+			Expect(c.Len()).Should(Equal(0))
+		})
+	})
+
+	Context("Set", func() {
+		It("evicts the least recently used", func() {
+			c := NewLRU(2, 0)
+			c.Set("a", 1)
+			c.Set("b", 2)
+			c.Get("a")
+			c.Set("c", 3)
+
+			_, found := c.Get("b")
+			Expect(found).Should(BeFalse())
+			_, found = c.Get("a")
+			Expect(found).Should(BeTrue())
+			_, found = c.Get("c")
+			Expect(found).Should(BeTrue())
+		})
+
+		It("replaces the value", func() {
+			c := NewLRU(2, 0)
+			c.Set("a", 1)
+			c.Set("a", 2)
+
+			value, _ := c.Get("a")
+			Expect(value).Should(Equal(2))
+			Expect(c.Len()).Should(Equal(1))
+		})
+	})
+
+	Context("Delete", func() {
+		It("removes the keys", func() {
+			c := NewLRU(0, 0)
+			c.Set("a", 1)
+			c.Set("b", 2)
+			c.Set("c", 3)
+			c.Delete("a", "b", "d")
+
+			Expect(c.Len()).Should(Equal(1))
+			_, found := c.Get("c")
+			Expect(found).Should(BeTrue())
+		})
+
+		It("clears", func() {
+			c := NewLRU(0, 0)
+			c.Set("a", 1)
+			c.Clear()
+
+			Expect(c.Len()).Should(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	permify "github.com/Permify/go-role"
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/options"
+)
+
+// cli runs the commands with Permify.
+type cli struct {
+	permify *permify.Permify
+	out     output
+	stdin   io.Reader
+}
+
+// run runs the command of the arguments.
+// @param []string
+// @return error
+func (c *cli) run(args []string) error {
+	switch args[0] {
+	case "roles":
+		return c.roles(args[1:])
+	case "permissions":
+		return c.permissions(args[1:])
+	case "grant":
+		return c.grant(args[1:], true)
+	case "revoke":
+		return c.grant(args[1:], false)
+	case "check":
+		return c.check(args[1:])
+	case "explain":
+		return c.explain(args[1:])
+	case "import":
+		return c.importPolicy(args[1:])
+	case "export":
+		return c.exportPolicy(args[1:])
+	case "reconcile":
+		return c.reconcile(args[1:])
+	}
+	return fmt.Errorf("%w: unknown command %s", errUsage, args[0])
+}
+
+// roles lists, creates or deletes the roles.
+// @param []string
+// @return error
+func (c *cli) roles(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: roles needs list, create or delete", errUsage)
+	}
+
+	flags, user, description := recordFlags(args[0])
+	if err := flags.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	switch {
+	case args[0] == "list" && flags.NArg() == 0:
+		var roles collections.Role
+		var err error
+		if *user != "" {
+			roles, _, err = c.permify.GetRolesOfUser(*user, options.RoleOption{WithPermissions: true})
+		} else {
+			roles, _, err = c.permify.GetAllRoles(options.RoleOption{WithPermissions: true})
+		}
+		if err != nil {
+			return err
+		}
+		return c.out.roles(roles)
+	case args[0] == "create" && flags.NArg() == 1:
+		if err := c.permify.CreateRole(flags.Arg(0), *description); err != nil {
+			return err
+		}
+		role, err := c.permify.GetRole(flags.Arg(0), false)
+		if err != nil {
+			return err
+		}
+		return c.out.roles(collections.Role{role})
+	case args[0] == "delete" && flags.NArg() == 1:
+		return c.permify.DeleteRole(flags.Arg(0))
+	}
+	return fmt.Errorf("%w: roles %s", errUsage, args[0])
+}
+
+// permissions lists, creates or deletes the permissions.
+// @param []string
+// @return error
+func (c *cli) permissions(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: permissions needs list, create or delete", errUsage)
+	}
+
+	flags, user, description := recordFlags(args[0])
+	if err := flags.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	switch {
+	case args[0] == "list" && flags.NArg() == 0:
+		var permissions collections.Permission
+		var err error
+		if *user != "" {
+			permissions, err = c.permify.GetAllPermissionsOfUser(*user)
+		} else {
+			permissions, _, err = c.permify.GetAllPermissions(options.PermissionOption{})
+		}
+		if err != nil {
+			return err
+		}
+		return c.out.permissions(permissions)
+	case args[0] == "create" && flags.NArg() == 1:
+		if err := c.permify.CreatePermission(flags.Arg(0), *description); err != nil {
+			return err
+		}
+		permission, err := c.permify.GetPermission(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		return c.out.permissions(collections.Permission{permission})
+	case args[0] == "delete" && flags.NArg() == 1:
+		return c.permify.DeletePermission(flags.Arg(0))
+	}
+	return fmt.Errorf("%w: permissions %s", errUsage, args[0])
+}
+
+// recordFlags returns the flags of the subcommands of roles and permissions, the -user flag of list and the -description flag of create.
+// @param string
+// @return *flag.FlagSet, *string, *string
+func recordFlags(subcommand string) (flags *flag.FlagSet, user *string, description *string) {
+	flags = flag.NewFlagSet(subcommand, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	user, description = new(string), new(string)
+	switch subcommand {
+	case "list":
+		flags.StringVar(user, "user", "", "the user id")
+	case "create":
+		flags.StringVar(description, "description", "", "the description")
+	}
+	return
+}
+
+// grant gives the roles or the permissions to the user, or takes them if give is false.
+// @param []string
+// @param bool
+// @return error
+func (c *cli) grant(args []string, give bool) error {
+	if len(args) < 3 {
+		return fmt.Errorf("%w: needs role or permission, the user and the names", errUsage)
+	}
+
+	user, names := args[1], args[2:]
+	switch {
+	case args[0] == "role" && give:
+		return c.permify.AddRolesToUser(user, names)
+	case args[0] == "role":
+		return c.permify.RemoveRolesFromUser(user, names)
+	case args[0] == "permission" && give:
+		return c.permify.AddPermissionsToUser(user, names)
+	case args[0] == "permission":
+		return c.permify.RemovePermissionsFromUser(user, names)
+	}
+	return fmt.Errorf("%w: needs role or permission, not %s", errUsage, args[0])
+}
+
+// check writes whether the user has the permission, and returns errDenied if not.
+// @param []string
+// @return error
+func (c *cli) check(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%w: check needs the user and the permission", errUsage)
+	}
+
+	allowed, err := c.permify.UserHasPermission(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	decision := "denied"
+	if allowed {
+		decision = "allowed"
+	}
+	result := struct {
+		User       string `json:"user"`
+		Permission string `json:"permission"`
+		Allowed    bool   `json:"allowed"`
+	}{User: args[0], Permission: args[1], Allowed: allowed}
+	if err = c.out.text(result, decision); err != nil {
+		return err
+	}
+
+	if !allowed {
+		return errDenied
+	}
+	return nil
+}
+
+// explain writes the explanation of the permission decision of the user.
+// @param []string
+// @return error
+func (c *cli) explain(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%w: explain needs the user and the permission", errUsage)
+	}
+
+	explanation, err := c.permify.ExplainUserPermission(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return c.out.text(explanation, explanation.String())
+}
+
+// importPolicy adds the policy of the file, or of stdin if the file is -.
+// @param []string
+// @return error
+func (c *cli) importPolicy(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: import needs the file", errUsage)
+	}
+
+	reader, closeFile, err := c.open(args[0])
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+	return c.permify.ImportPolicy(reader)
+}
+
+// exportPolicy writes the policy in the format of the -format flag.
+// @param []string
+// @return error
+func (c *cli) exportPolicy(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	format := flags.String("format", "yaml", "yaml or json")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return fmt.Errorf("%w: export [-format yaml|json]", errUsage)
+	}
+
+	switch *format {
+	case "yaml":
+		return c.permify.ExportPolicy(c.out.writer, permify.PolicyYAML)
+	case "json":
+		return c.permify.ExportPolicy(c.out.writer, permify.PolicyJSON)
+	}
+	return fmt.Errorf("%w: unknown format %s", errUsage, *format)
+}
+
+// reconcile converges the database to the policy of the file, and writes the plan.
+// @param []string
+// @return error
+func (c *cli) reconcile(args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	prune := flags.Bool("prune", false, "delete what is not in the policy")
+	dryRun := flags.Bool("dry-run", false, "only write the plan")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return fmt.Errorf("%w: reconcile [-prune] [-dry-run] FILE", errUsage)
+	}
+
+	reader, closeFile, err := c.open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	policy, err := permify.ReadPolicy(reader)
+	if err != nil {
+		return err
+	}
+	plan, err := c.permify.Reconcile(policy, options.ReconcileOption{Prune: *prune, DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+	return c.out.text(plan, plan.String())
+}
+
+// open opens the file, or returns stdin if the name is -.
+// @param string
+// @return io.Reader, func(), error
+func (c *cli) open(name string) (io.Reader, func(), error) {
+	if name == "-" {
+		return c.stdin, func() {}, nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, func() { _ = file.Close() }, nil
+}
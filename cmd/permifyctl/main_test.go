@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestPermifyctl(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "permifyctl suite")
+}
+
+var _ = Describe("permifyctl", func() {
+	var dir string
+
+	// permifyctl runs the command on the sqlite database of the spec, and returns the exit status and the outputs.
+	permifyctl := func(stdin string, args ...string) (status int, stdout string, stderr string) {
+		var out, errOut bytes.Buffer
+		args = append([]string{"-dsn", "sqlite://" + filepath.Join(dir, "permify.db"), "-migrate"}, args...)
+		status = run(args, strings.NewReader(stdin), &out, &errOut)
+		return status, out.String(), errOut.String()
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "permifyctl")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).ShouldNot(HaveOccurred())
+	})
+
+	It("Manages the Roles and Permissions", func() {
+		status, stdout, _ := permifyctl("", "roles", "create", "-description", "all access", "admin")
+		Expect(status).Should(Equal(0))
+		Expect(stdout).Should(ContainSubstring("admin"))
+		Expect(stdout).Should(ContainSubstring("all access"))
+
+		status, _, _ = permifyctl("", "permissions", "create", "edit posts")
+		Expect(status).Should(Equal(0))
+
+		status, stdout, _ = permifyctl("", "-output", "json", "permissions", "list")
+		Expect(status).Should(Equal(0))
+		var permissions []map[string]interface{}
+		Expect(json.Unmarshal([]byte(stdout), &permissions)).ShouldNot(HaveOccurred())
+		Expect(permissions).Should(HaveLen(1))
+		Expect(permissions[0]["guard_name"]).Should(Equal("edit-posts"))
+
+		status, _, _ = permifyctl("", "roles", "delete", "admin")
+		Expect(status).Should(Equal(0))
+		status, stdout, _ = permifyctl("", "-output", "json", "roles", "list")
+		Expect(status).Should(Equal(0))
+		Expect(stdout).Should(MatchJSON(`[]`))
+
+		status, _, stderr := permifyctl("", "roles", "delete", "admin")
+		Expect(status).Should(Equal(exitError))
+		Expect(stderr).Should(ContainSubstring("err role not found"))
+	})
+
+	It("Grants, Checks and Explains", func() {
+		status, _, _ := permifyctl("permissions:\n  - name: edit posts\nroles:\n  - name: editor\n    permissions: [edit posts]\n", "import", "-")
+		Expect(status).Should(Equal(0))
+
+		status, _, _ = permifyctl("", "grant", "role", "1", "editor")
+		Expect(status).Should(Equal(0))
+
+		status, stdout, _ := permifyctl("", "check", "1", "edit posts")
+		Expect(status).Should(Equal(0))
+		Expect(stdout).Should(Equal("allowed\n"))
+
+		status, stdout, _ = permifyctl("", "-output", "json", "check", "2", "edit posts")
+		Expect(status).Should(Equal(exitDenied))
+		Expect(stdout).Should(MatchJSON(`{"user": "2", "permission": "edit posts", "allowed": false}`))
+
+		status, stdout, _ = permifyctl("", "explain", "1", "edit posts")
+		Expect(status).Should(Equal(0))
+		Expect(stdout).Should(ContainSubstring("user:1 is allowed edit posts"))
+		Expect(stdout).Should(ContainSubstring("via role editor"))
+
+		status, stdout, _ = permifyctl("", "roles", "list", "-user", "1")
+		Expect(status).Should(Equal(0))
+		Expect(stdout).Should(ContainSubstring("editor"))
+
+		status, _, _ = permifyctl("", "revoke", "role", "1", "editor")
+		Expect(status).Should(Equal(0))
+		status, _, _ = permifyctl("", "check", "1", "edit posts")
+		Expect(status).Should(Equal(exitDenied))
+
+		// the grants are in the tenant.
+		status, _, _ = permifyctl("", "-tenant", "org-a", "grant", "permission", "3", "edit posts")
+		Expect(status).Should(Equal(0))
+		status, _, _ = permifyctl("", "-tenant", "org-a", "check", "3", "edit posts")
+		Expect(status).Should(Equal(0))
+		status, _, _ = permifyctl("", "check", "3", "edit posts")
+		Expect(status).Should(Equal(exitDenied))
+	})
+
+	It("Lists the Permissions of the User Without the Denied Ones", func() {
+		status, _, _ := permifyctl("permissions:\n  - name: edit posts\n  - name: view posts\nroles:\n  - name: editor\n    permissions: [edit posts, view posts]\n  - name: reader\n    denied_permissions: [edit posts]\n", "import", "-")
+		Expect(status).Should(Equal(0))
+
+		status, _, _ = permifyctl("", "grant", "role", "1", "editor")
+		Expect(status).Should(Equal(0))
+		status, _, _ = permifyctl("", "grant", "role", "1", "reader")
+		Expect(status).Should(Equal(0))
+
+		status, stdout, _ := permifyctl("", "permissions", "list", "-user", "1")
+		Expect(status).Should(Equal(0))
+		Expect(stdout).Should(ContainSubstring("view-posts"))
+		Expect(stdout).ShouldNot(ContainSubstring("edit-posts"))
+	})
+
+	It("Imports, Exports and Reconciles", func() {
+		const policy = "permissions:\n  - name: edit posts\nroles:\n  - name: editor\n    permissions:\n      - edit posts\n"
+		file := filepath.Join(dir, "policy.yaml")
+		Expect(os.WriteFile(file, []byte(policy), 0o600)).ShouldNot(HaveOccurred())
+
+		status, _, _ := permifyctl("", "import", file)
+		Expect(status).Should(Equal(0))
+
+		status, stdout, _ := permifyctl("", "export")
+		Expect(status).Should(Equal(0))
+		Expect(stdout).Should(Equal(policy))
+
+		status, stdout, _ = permifyctl("", "export", "-format", "json")
+		Expect(status).Should(Equal(0))
+		Expect(stdout).Should(MatchJSON(`{"permissions": [{"name": "edit posts"}], "roles": [{"name": "editor", "permissions": ["edit posts"]}]}`))
+
+		status, _, _ = permifyctl("", "roles", "create", "intern")
+		Expect(status).Should(Equal(0))
+
+		status, stdout, _ = permifyctl("", "reconcile", "-prune", "-dry-run", file)
+		Expect(status).Should(Equal(0))
+		Expect(stdout).Should(Equal("- delete role intern\n"))
+
+		status, _, _ = permifyctl("", "reconcile", "-prune", file)
+		Expect(status).Should(Equal(0))
+		status, stdout, _ = permifyctl("", "reconcile", "-prune", file)
+		Expect(status).Should(Equal(0))
+		Expect(stdout).Should(Equal("no changes\n"))
+	})
+
+	It("Refuses the Wrong Arguments", func() {
+		for _, args := range [][]string{
+			{"bogus"},
+			{"roles"},
+			{"roles", "create"},
+			{"grant", "group", "1", "editor"},
+			{"check", "1"},
+			{"export", "-format", "xml"},
+		} {
+			status, _, stderr := permifyctl("", args...)
+			Expect(status).Should(Equal(exitUsage), strings.Join(args, " "))
+			Expect(stderr).Should(ContainSubstring("run permifyctl -h"))
+		}
+
+		var out, errOut bytes.Buffer
+		Expect(run([]string{"-dsn", "oracle://db", "roles", "list"}, nil, &out, &errOut)).Should(Equal(exitError))
+		Expect(errOut.String()).Should(ContainSubstring("unsupported dsn"))
+		Expect(run([]string{"-output", "xml", "roles", "list"}, nil, &out, &errOut)).Should(Equal(exitUsage))
+	})
+})
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Permify/go-role/collections"
+)
+
+// output writes the results of the commands as tables or as json.
+type output struct {
+	writer io.Writer
+	json   bool
+}
+
+// print writes the value as indented json, or else the rows as a table with the headers.
+// @param interface{}
+// @param []string
+// @param [][]string
+// @return error
+func (o output) print(value interface{}, headers []string, rows [][]string) error {
+	if o.json {
+		encoder := json.NewEncoder(o.writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	table := tabwriter.NewWriter(o.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+	return table.Flush()
+}
+
+// text writes the value as indented json, or else the text.
+// @param interface{}
+// @param string
+// @return error
+func (o output) text(value interface{}, text string) error {
+	if o.json {
+		return o.print(value, nil, nil)
+	}
+	_, err := io.WriteString(o.writer, strings.TrimSuffix(text, "\n")+"\n")
+	return err
+}
+
+// roles writes the roles with the names of their permissions.
+// @param collections.Role
+// @return error
+func (o output) roles(roles collections.Role) error {
+	if roles == nil {
+		roles = collections.Role{}
+	}
+
+	var rows [][]string
+	for _, role := range roles {
+		rows = append(rows, []string{fmt.Sprint(role.ID), role.Name, role.GuardName, role.Description, strings.Join(collections.Permission(role.Permissions).Names(), ", ")})
+	}
+	return o.print(roles, []string{"ID", "NAME", "GUARD NAME", "DESCRIPTION", "PERMISSIONS"}, rows)
+}
+
+// permissions writes the permissions.
+// @param collections.Permission
+// @return error
+func (o output) permissions(permissions collections.Permission) error {
+	if permissions == nil {
+		permissions = collections.Permission{}
+	}
+
+	var rows [][]string
+	for _, permission := range permissions {
+		rows = append(rows, []string{fmt.Sprint(permission.ID), permission.Name, permission.GuardName, permission.Description})
+	}
+	return o.print(permissions, []string{"ID", "NAME", "GUARD NAME", "DESCRIPTION"}, rows)
+}
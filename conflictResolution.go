@@ -0,0 +1,76 @@
+package permify_gorm
+
+import (
+	"fmt"
+)
+
+// ConflictResolution decides whether the user has a permission that is both allowed and denied to the user.
+type ConflictResolution int
+
+const (
+	// DenyOverrides denies the permission if any of the assignments of the user or the roles of the user denies it. (default)
+	DenyOverrides ConflictResolution = iota
+	// AllowOverrides allows the permission if any of the assignments of the user or the roles of the user allows it, the denies are ignored.
+	AllowOverrides
+	// MostSpecificWins prefers the direct assignments of the user over the assignments of the roles of the user.
+	// If the user is both allowed and denied on the same level, deny wins.
+	MostSpecificWins
+)
+
+// resolve decides whether the permission is given according to the allow and deny assignments.
+// @param bool
+// @param bool
+// @param bool
+// @param bool
+// @return bool
+func (c ConflictResolution) resolve(directAllowed bool, roleAllowed bool, directDenied bool, roleDenied bool) bool {
+	switch c {
+	case AllowOverrides:
+		return directAllowed || roleAllowed
+	case MostSpecificWins:
+		if directDenied || directAllowed {
+			return !directDenied
+		}
+		return roleAllowed && !roleDenied
+	default:
+		return (directAllowed || roleAllowed) && !(directDenied || roleDenied)
+	}
+}
+
+// conflictResolutionNames are the names of the strategies, used by String and the text encodings.
+var conflictResolutionNames = map[ConflictResolution]string{
+	DenyOverrides:    "deny_overrides",
+	AllowOverrides:   "allow_overrides",
+	MostSpecificWins: "most_specific_wins",
+}
+
+// String returns the name of the strategy. example: deny_overrides
+// @return string
+func (c ConflictResolution) String() string {
+	if name, ok := conflictResolutionNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("ConflictResolution(%d)", int(c))
+}
+
+// MarshalText encodes the strategy by its name, e.g. in json.
+// @return []byte, error
+func (c ConflictResolution) MarshalText() ([]byte, error) {
+	if _, ok := conflictResolutionNames[c]; !ok {
+		return nil, fmt.Errorf("err unknown conflict resolution %d", int(c))
+	}
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes the strategy from its name.
+// @param []byte
+// @return error
+func (c *ConflictResolution) UnmarshalText(text []byte) error {
+	for resolution, name := range conflictResolutionNames {
+		if name == string(text) {
+			*c = resolution
+			return nil
+		}
+	}
+	return fmt.Errorf("err unknown conflict resolution %q", text)
+}
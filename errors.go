@@ -0,0 +1,93 @@
+package permify_gorm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRoleNotFound is returned when the role that is given by name or id does not exist.
+	ErrRoleNotFound = errors.New("err role not found")
+	// ErrPermissionNotFound is returned when the permission that is given by name or id does not exist.
+	ErrPermissionNotFound = errors.New("err permission not found")
+	// ErrUnsupportedIdentifier is returned when a role or permission is given by a value that is not a name, an id or an array of them,
+	// or when a user id cannot be converted to the UserIDType.
+	ErrUnsupportedIdentifier = errors.New("err unsupported identifier")
+	// ErrAuditDisabled is returned when the audit log is read but it is not enabled. (see Options.Audit)
+	ErrAuditDisabled = errors.New("err audit log is disabled")
+)
+
+// Error is returned by the methods of Permify. It wraps the error with the operation and the identifier that the operation was called with.
+// errors.Is matches both the sentinel errors of this package and the wrapped error. example: errors.Is(err, ErrRoleNotFound)
+type Error struct {
+	// Op is the operation that failed. example: AddRolesToUser
+	Op string
+	// ID is the role or permission name(s) or id(s), or the user id or Subject, that the operation was called with. It is nil if there is none.
+	ID interface{}
+	// Kind is the sentinel error of the failure, it can be nil. example: ErrRoleNotFound
+	Kind error
+	// Err is the wrapped error, usually returned by the repositories.
+	Err error
+}
+
+// Error returns the error message.
+// @return string
+func (e *Error) Error() string {
+	message := e.Err.Error()
+	if e.Kind != nil && e.Kind != e.Err {
+		message = fmt.Sprintf("%s: %s", e.Kind, message)
+	}
+	if e.ID == nil {
+		return fmt.Sprintf("%s: %s", e.Op, message)
+	}
+	return fmt.Sprintf("%s %v: %s", e.Op, e.ID, message)
+}
+
+// Unwrap returns the wrapped error.
+// @return error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is the sentinel error of the failure.
+// @param error
+// @return bool
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// wrapError wraps the error with the operation and the identifier.
+// It is deferred by the methods of Permify, so err must be their named result.
+// The errors of the nested operations are wrapped again, unless they have the same identifier.
+// example: AddRolesToUser 1: GetRole admin: err role not found: record not found
+// @param *error
+// @param string
+// @param interface{}
+func wrapError(err *error, op string, ID interface{}) {
+	if *err == nil {
+		return
+	}
+	if nested, ok := (*err).(*Error); ok && reflect.DeepEqual(nested.ID, ID) {
+		return
+	}
+	*err = &Error{Op: op, ID: ID, Err: *err}
+}
+
+// wrapNotFoundError is wrapError, which also marks the record not found errors of the repositories with the sentinel error.
+// @param *error
+// @param string
+// @param interface{}
+// @param error
+func wrapNotFoundError(err *error, op string, ID interface{}, notFound error) {
+	if *err == nil {
+		return
+	}
+	var kind error
+	if errors.Is(*err, gorm.ErrRecordNotFound) {
+		kind = notFound
+	}
+	*err = &Error{Op: op, ID: ID, Kind: kind, Err: *err}
+}
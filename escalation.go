@@ -0,0 +1,160 @@
+package permify_gorm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/helpers"
+	"github.com/Permify/go-role/models"
+)
+
+// grantRolePrefix and grantPermissionPrefix are the prefixes of the permissions that allow the acting users to grant a role or a permission they do not have.
+// The prefixes are kept in the guard names, only the name after them is guarded. example: grant:role:Team Lead -> grant:role:team-lead
+const (
+	grantRolePrefix       = "grant:role:"
+	grantPermissionPrefix = "grant:permission:"
+)
+
+// PrivilegeEscalationError is returned when the acting user grants roles or permissions that it could not grant. (see Permify.ActingAs)
+type PrivilegeEscalationError struct {
+	Actor models.Subject
+	// Roles and Permissions are the guard names of the refused roles and permissions.
+	Roles       []string
+	Permissions []string
+}
+
+// Error returns the error message.
+// @return string
+func (e *PrivilegeEscalationError) Error() string {
+	var refused []string
+	if len(e.Roles) > 0 {
+		refused = append(refused, "roles "+strings.Join(e.Roles, ", "))
+	}
+	if len(e.Permissions) > 0 {
+		refused = append(refused, "permissions "+strings.Join(e.Permissions, ", "))
+	}
+	return fmt.Sprintf("err privilege escalation: %s cannot grant %s", e.Actor, strings.Join(refused, " and "))
+}
+
+// ActingAs returns a copy of Permify whose grants are made by the given user, the grants that the user could not make are refused with *PrivilegeEscalationError.
+// A user can grant the roles it has (directly or inherited) and the permissions it has, and the roles it has the grant:role:<name> permission of
+// and the permissions it has the grant:permission:<name> permission of.
+// example: a team lead with the grant:role:developer permission -> permify.ActingAs(lead).AddRolesToUser(7, "developer")
+// The roles and permissions of the acting user are checked in the tenant and on the resource of Permify.
+// The grants are the roles and permissions given to users, the permissions given to roles, the child roles added to roles and the removed denied permissions of users and roles.
+// Removing roles from users or child roles from roles, and deleting roles, grant the permissions denied by these roles.
+// The acting user is the actor of the audit log too, unless it is set with By.
+// @param interface{}
+// @return *Permify
+func (s *Permify) ActingAs(user interface{}) *Permify {
+	acting := *s
+	acting.actingUser = user
+	return &acting
+}
+
+// checkGrant returns *PrivilegeEscalationError if the acting user could not grant the roles and the permissions. It does nothing if there is no acting user.
+// @param context.Context
+// @param collections.Role
+// @param collections.Permission
+// @return error
+func (s *Permify) checkGrant(ctx context.Context, roles collections.Role, permissions collections.Permission) (err error) {
+	if s.actingUser == nil || roles.Len()+permissions.Len() == 0 {
+		return nil
+	}
+
+	var actor models.Subject
+	if actor, err = s.parseSubject(s.actingUser); err != nil {
+		return err
+	}
+
+	var heldRoleIDs []uint
+	if roles.Len() > 0 {
+		heldRoleIDs, _, err = s.RoleRepository.GetRoleIDsOfUser(ctx, actor, s.assignment(), nil)
+		if err != nil {
+			return err
+		}
+		heldRoleIDs, err = s.withInheritedRoleIDs(ctx, heldRoleIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the grant permissions of all the roles and the permissions, and the permissions themselves, are checked at once.
+	var grantNames []string
+	for _, role := range roles {
+		grantNames = append(grantNames, grantRolePrefix+role.GuardName)
+	}
+	for _, permission := range permissions {
+		grantNames = append(grantNames, grantPermissionPrefix+permission.GuardName)
+	}
+
+	var grants collections.Permission
+	if len(grantNames) > 0 {
+		grants, err = s.PermissionRepository.GetPermissionsByGuardNames(ctx, grantNames)
+		if err != nil {
+			return err
+		}
+	}
+
+	var decisions map[uint]bool
+	decisions, err = s.userPermissionDecisions(ctx, actor, append(append(collections.Permission{}, grants...), permissions...))
+	if err != nil {
+		return err
+	}
+
+	canGrant := func(grantName string) bool {
+		for _, grant := range grants {
+			if grant.GuardName == grantName && decisions[grant.ID] {
+				return true
+			}
+		}
+		return false
+	}
+
+	refused := &PrivilegeEscalationError{Actor: actor}
+	for _, role := range roles {
+		if !helpers.InArray(role.ID, heldRoleIDs) && !canGrant(grantRolePrefix+role.GuardName) {
+			refused.Roles = append(refused.Roles, role.GuardName)
+		}
+	}
+	for _, permission := range permissions {
+		if !decisions[permission.ID] && !canGrant(grantPermissionPrefix+permission.GuardName) {
+			refused.Permissions = append(refused.Permissions, permission.GuardName)
+		}
+	}
+
+	if len(refused.Roles) > 0 || len(refused.Permissions) > 0 {
+		return refused
+	}
+	return nil
+}
+
+// checkRemoval returns *PrivilegeEscalationError if the acting user could not grant the permissions denied by the roles or by the roles they inherit.
+// Removing the roles from a user or deleting them gives these permissions back, so they are checked like the removed denied permissions.
+// It does nothing if there is no acting user.
+// @param context.Context
+// @param []uint
+// @return error
+func (s *Permify) checkRemoval(ctx context.Context, roleIDs []uint) (err error) {
+	if s.actingUser == nil || len(roleIDs) == 0 {
+		return nil
+	}
+
+	if roleIDs, err = s.withInheritedRoleIDs(ctx, roleIDs); err != nil {
+		return err
+	}
+
+	var deniedIDs []uint
+	deniedIDs, _, err = s.PermissionRepository.GetDeniedPermissionIDsOfRolesByIDs(ctx, roleIDs, nil)
+	if err != nil || len(deniedIDs) == 0 {
+		return err
+	}
+
+	var denied collections.Permission
+	if denied, err = s.PermissionRepository.GetPermissions(ctx, deniedIDs); err != nil {
+		return err
+	}
+	return s.checkGrant(ctx, nil, denied)
+}
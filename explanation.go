@@ -0,0 +1,106 @@
+package permify_gorm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/models"
+)
+
+// PathKind tells how a permission reaches the user.
+type PathKind string
+
+const (
+	// PathDirect the permission is given or denied to the user directly.
+	PathDirect PathKind = "direct"
+	// PathRole the permission is given or denied to one of the roles of the user.
+	PathRole PathKind = "role"
+	// PathInheritedRole the permission is given or denied to a role that one of the roles of the user inherits.
+	PathInheritedRole PathKind = "inherited_role"
+)
+
+// Path is one of the ways a permission is given or denied to the user.
+type Path struct {
+	Kind PathKind `json:"kind"`
+	// Denied is true if the path denies the permission, false if it grants it.
+	Denied bool `json:"denied"`
+	// Permission is the permission that is assigned, the checked permission or one of its wildcard ancestors.
+	Permission models.Permission `json:"permission"`
+	// Wildcard is true if the assigned permission is a wildcard ancestor of the checked permission. example: invoices.*
+	Wildcard bool `json:"wildcard"`
+	// Roles is the chain of roles from the role of the user to the role the permission is assigned to, it is empty for the direct paths.
+	// example: manager -> accountant, the user has the manager role, which inherits the accountant role that has the permission.
+	Roles collections.Role `json:"roles"`
+}
+
+// String returns the path in a sentence. example: allow invoices.* via role manager -> accountant
+// @return string
+func (p Path) String() string {
+	effect := "allow"
+	if p.Denied {
+		effect = "deny"
+	}
+
+	if p.Kind == PathDirect {
+		return fmt.Sprintf("%s %s directly", effect, p.Permission.Name)
+	}
+
+	var names []string
+	for _, role := range p.Roles {
+		names = append(names, role.Name)
+	}
+	return fmt.Sprintf("%s %s via %s %s", effect, p.Permission.Name, strings.ReplaceAll(string(p.Kind), "_", " "), strings.Join(names, " -> "))
+}
+
+// ConsideredAssignments are the assignments of the user that are considered by a decision, in the scope of the decision.
+type ConsideredAssignments struct {
+	// Roles are the roles of the user.
+	Roles collections.Role `json:"roles"`
+	// InheritedRoles are the roles that the roles of the user inherit, transitively.
+	InheritedRoles collections.Role `json:"inherited_roles"`
+	// Permissions are the permissions given to the user directly.
+	Permissions collections.Permission `json:"permissions"`
+	// DeniedPermissions are the permissions denied to the user directly.
+	DeniedPermissions collections.Permission `json:"denied_permissions"`
+	// GrantingPermissions are the checked permission and its wildcard ancestors, the permissions that give or deny it.
+	GrantingPermissions collections.Permission `json:"granting_permissions"`
+}
+
+// Explanation is the decision of UserHasPermission together with the paths that lead to it.
+type Explanation struct {
+	Subject    models.Subject    `json:"subject"`
+	Permission models.Permission `json:"permission"`
+
+	TenantID     string `json:"tenant_id"`
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+
+	// Allowed is the decision, the same as the result of UserHasPermission.
+	Allowed bool `json:"allowed"`
+	// ConflictResolution is the strategy that decided between the paths that grant and deny the permission.
+	ConflictResolution ConflictResolution `json:"conflict_resolution"`
+	// Paths are all the ways the permission is given or denied to the user, the denies are listed even if the conflict resolution ignores them.
+	Paths []Path `json:"paths"`
+	// Considered are the assignments that are looked up for the decision.
+	Considered ConsideredAssignments `json:"considered"`
+}
+
+// String returns the decision and its paths, a path per line, each path indented by two spaces.
+// example: user:12 is allowed invoices.delete, followed by the line allow invoices.* via role manager -> accountant
+// @return string
+func (e Explanation) String() string {
+	decision := "denied"
+	if e.Allowed {
+		decision = "allowed"
+	}
+
+	lines := []string{fmt.Sprintf("%s is %s %s", e.Subject, decision, e.Permission.Name)}
+	if len(e.Paths) == 0 {
+		lines = append(lines, "  no assignment gives or denies it")
+	}
+	for _, path := range e.Paths {
+		lines = append(lines, "  "+path.String())
+	}
+	return strings.Join(lines, "\n")
+}
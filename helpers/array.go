@@ -23,6 +23,19 @@ func InArray(val interface{}, array interface{}) (exists bool) {
 	return
 }
 
+// AnyInArray is any of the values in the first parameter an element of the array in the second parameter?
+// @param []uint
+// @param []uint
+// return bool
+func AnyInArray(values []uint, array []uint) bool {
+	for _, value := range values {
+		if InArray(value, array) {
+			return true
+		}
+	}
+	return false
+}
+
 // JoinUintArrays concatenates the given uint arrays and makes them a single array.
 // @param ...[]uint
 // return []uint
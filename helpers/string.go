@@ -2,6 +2,7 @@ package helpers
 
 import (
 	"reflect"
+	"strings"
 
 	"github.com/gosimple/slug"
 )
@@ -26,6 +27,43 @@ func GuardArray(b []string) (guardArray []string) {
 	return
 }
 
+// SegmentedGuard edits the given string like Guard but keeps the dot separated segments and the wildcard segments.
+// example: 'Billing.Invoices $#% View' -> 'billing.invoices-view', 'posts.*' -> 'posts.*'.
+// @param string
+// return string
+func SegmentedGuard(b string) string {
+	var segments []string
+	for _, segment := range strings.Split(b, ".") {
+		if strings.TrimSpace(segment) == "*" {
+			segments = append(segments, "*")
+			continue
+		}
+		if guard := slug.Make(segment); guard != "" {
+			segments = append(segments, guard)
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+// WildcardGuards returns the given segmented guard name with the wildcard guard names that match it.
+// example: 'billing.invoices.view' -> 'billing.invoices.view', 'billing.invoices.*', 'billing.*', '*'.
+// @param string
+// return []string
+func WildcardGuards(b string) (guards []string) {
+	guards = append(guards, b)
+	segments := strings.Split(b, ".")
+	for i := len(segments) - 1; i > 0; i-- {
+		guard := strings.Join(segments[:i], ".") + ".*"
+		if guard != b {
+			guards = append(guards, guard)
+		}
+	}
+	if b != "*" {
+		guards = append(guards, "*")
+	}
+	return
+}
+
 // IsInt is the given value an integer?
 // @param interface{}
 // return bool
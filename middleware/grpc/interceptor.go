@@ -0,0 +1,221 @@
+// Package grpc provides the gRPC server interceptors that authorize the calls with Permify.
+// It is imported with a name that does not hide google.golang.org/grpc. example: permifygrpc "github.com/Permify/go-role/middleware/grpc"
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	permify "github.com/Permify/go-role"
+	"github.com/Permify/go-role/middleware/grpc/permifypb"
+)
+
+var (
+	// ErrNoPermify is returned by New when the options have no Permify.
+	ErrNoPermify = errors.New("err permify is required")
+	// ErrNoSubjectExtractor is returned by New when the options have no SubjectExtractor.
+	ErrNoSubjectExtractor = errors.New("err subject extractor is required")
+)
+
+// SubjectExtractor returns the user id of the call, any user id or Subject that Permify takes, e.g. from the incoming metadata.
+// It returns an error, or a nil user id, if the call has no user.
+type SubjectExtractor func(ctx context.Context) (userID interface{}, err error)
+
+// Options are the options of the interceptors.
+type Options struct {
+	// Permify checks the permissions and the roles of the users.
+	Permify *permify.Permify
+	// SubjectExtractor returns the user id of the call. example: MetadataSubjectExtractor("x-user-id")
+	SubjectExtractor SubjectExtractor
+	// Rules are the rules of the methods by their full names, they replace the (permify.rule) options of the methods.
+	// example: map[string]*permifypb.Rule{"/users.Users/Delete": {Roles: []string{"admin"}}}
+	Rules map[string]*permifypb.Rule
+	// AllowUnlisted lets the calls of the methods without a rule through. (default false, they are denied)
+	AllowUnlisted bool
+	// Scope returns the Permify that the call is checked with, e.g. in the tenant of the call. (default nil, Permify is used)
+	Scope func(ctx context.Context, permify *permify.Permify) *permify.Permify
+}
+
+// Interceptor authorizes the calls with Permify, by the rule of the method in the Rules or in the (permify.rule) option of the method.
+type Interceptor struct {
+	permify          *permify.Permify
+	subjectExtractor SubjectExtractor
+	rules            map[string]*permifypb.Rule
+	allowUnlisted    bool
+	scope            func(ctx context.Context, permify *permify.Permify) *permify.Permify
+
+	// options caches the rules of the method options by the full method names, nil if the method has no rule.
+	options sync.Map
+}
+
+// New returns the interceptor of the options.
+// @param Options
+// @return *Interceptor, error
+func New(options Options) (*Interceptor, error) {
+	if options.Permify == nil {
+		return nil, ErrNoPermify
+	}
+	if options.SubjectExtractor == nil {
+		return nil, ErrNoSubjectExtractor
+	}
+
+	return &Interceptor{
+		permify:          options.Permify,
+		subjectExtractor: options.SubjectExtractor,
+		rules:            options.Rules,
+		allowUnlisted:    options.AllowUnlisted,
+		scope:            options.Scope,
+	}, nil
+}
+
+// Unary returns the unary server interceptor. example: grpc.NewServer(grpc.UnaryInterceptor(interceptor.Unary()))
+// @return grpc.UnaryServerInterceptor
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := i.Authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns the stream server interceptor. example: grpc.NewServer(grpc.StreamInterceptor(interceptor.Stream()))
+// @return grpc.StreamServerInterceptor
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := i.Authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// Authorize returns nil if the user of the call can call the method.
+// Otherwise it returns the status error, codes.Unauthenticated if the call has no user, codes.PermissionDenied if the user does not pass the rule of the method,
+// or codes.Internal if the check fails. The messages of the internal errors are not returned, since they can have the details of the database.
+// @param context.Context
+// @param string
+// @return error
+func (i *Interceptor) Authorize(ctx context.Context, fullMethod string) error {
+	rule := i.rule(fullMethod)
+	if rule == nil {
+		if i.allowUnlisted {
+			return nil
+		}
+		return status.Errorf(codes.PermissionDenied, "method %s has no rule", fullMethod)
+	}
+
+	userID, err := i.subjectExtractor(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	if userID == nil {
+		return status.Error(codes.Unauthenticated, "no user")
+	}
+
+	p := i.permify
+	if i.scope != nil {
+		p = i.scope(ctx, p)
+	}
+
+	allowed, err := check(ctx, p, userID, rule)
+	if err != nil {
+		return status.Error(codes.Internal, "internal error")
+	}
+	if !allowed {
+		return status.Errorf(codes.PermissionDenied, "permission denied to %s", fullMethod)
+	}
+	return nil
+}
+
+// rule returns the rule of the method, from the Rules or else from the options of the method. It returns nil if the method has no rule.
+// @param string
+// @return *permifypb.Rule
+func (i *Interceptor) rule(fullMethod string) *permifypb.Rule {
+	if rule, ok := i.rules[fullMethod]; ok {
+		return rule
+	}
+	if rule, ok := i.options.Load(fullMethod); ok {
+		return rule.(*permifypb.Rule)
+	}
+
+	rule := methodRule(fullMethod)
+	i.options.Store(fullMethod, rule)
+	return rule
+}
+
+// methodRule returns the (permify.rule) option of the method in the registered proto files, or nil if it has none.
+// @param string
+// @return *permifypb.Rule
+func methodRule(fullMethod string) *permifypb.Rule {
+	// the full method names are /package.Service/Method.
+	name := strings.TrimPrefix(fullMethod, "/")
+	slash := strings.LastIndex(name, "/")
+	if slash < 0 {
+		return nil
+	}
+
+	descriptor, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name[:slash]))
+	if err != nil {
+		return nil
+	}
+	service, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	method := service.Methods().ByName(protoreflect.Name(name[slash+1:]))
+	if method == nil || method.Options() == nil || !proto.HasExtension(method.Options(), permifypb.E_Rule) {
+		return nil
+	}
+	return proto.GetExtension(method.Options(), permifypb.E_Rule).(*permifypb.Rule)
+}
+
+// check returns true if the user has all the permissions, any of the any permissions and any of the roles of the rule.
+// @param context.Context
+// @param *permify.Permify
+// @param interface{}
+// @param *permifypb.Rule
+// @return bool, error
+func check(ctx context.Context, p *permify.Permify, userID interface{}, rule *permifypb.Rule) (bool, error) {
+	if permissions := rule.GetPermissions(); len(permissions) > 0 {
+		if allowed, err := p.UserHasAllPermissionsCtx(ctx, userID, permissions); err != nil || !allowed {
+			return false, err
+		}
+	}
+	if permissions := rule.GetAnyPermissions(); len(permissions) > 0 {
+		if allowed, err := p.UserHasAnyPermissionsCtx(ctx, userID, permissions); err != nil || !allowed {
+			return false, err
+		}
+	}
+	if roles := rule.GetRoles(); len(roles) > 0 {
+		if allowed, err := p.UserHasAnyRolesCtx(ctx, userID, roles); err != nil || !allowed {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// MetadataSubjectExtractor returns the SubjectExtractor that reads the user id from the incoming metadata of the key, e.g. set by an authenticating proxy.
+// It must not be used with keys that the clients can set.
+// @param string
+// @return SubjectExtractor
+func MetadataSubjectExtractor(key string) SubjectExtractor {
+	return func(ctx context.Context) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get(key); len(values) > 0 && values[0] != "" {
+			return values[0], nil
+		}
+		return nil, fmt.Errorf("no %s metadata", key)
+	}
+}
@@ -0,0 +1,242 @@
+package grpc_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	permify "github.com/Permify/go-role"
+	permifygrpc "github.com/Permify/go-role/middleware/grpc"
+	"github.com/Permify/go-role/middleware/grpc/internal/testpb"
+	"github.com/Permify/go-role/middleware/grpc/permifypb"
+	"github.com/Permify/go-role/repositories/memory"
+)
+
+func TestInterceptor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "grpc interceptor suite")
+}
+
+// usersServer echoes the users.
+type usersServer struct {
+	testpb.UnimplementedUsersServer
+}
+
+func (usersServer) Get(_ context.Context, user *testpb.User) (*testpb.User, error) {
+	return user, nil
+}
+
+func (usersServer) Update(_ context.Context, user *testpb.User) (*testpb.User, error) {
+	return user, nil
+}
+
+func (usersServer) Delete(_ context.Context, user *testpb.User) (*testpb.User, error) {
+	return user, nil
+}
+
+func (usersServer) Ping(_ context.Context, user *testpb.User) (*testpb.User, error) {
+	return user, nil
+}
+
+func (usersServer) Watch(user *testpb.User, stream testpb.Users_WatchServer) error {
+	return stream.Send(user)
+}
+
+var _ = Describe("Interceptor", func() {
+	var p *permify.Permify
+	var server *grpc.Server
+	var conn *grpc.ClientConn
+	var client testpb.UsersClient
+
+	// start serves the users on a new bufconn listener with the interceptors of the options.
+	start := func(options permifygrpc.Options) {
+		options.Permify = p
+		if options.SubjectExtractor == nil {
+			options.SubjectExtractor = permifygrpc.MetadataSubjectExtractor("x-user-id")
+		}
+		interceptor, err := permifygrpc.New(options)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		listener := bufconn.Listen(1024 * 1024)
+		server = grpc.NewServer(grpc.UnaryInterceptor(interceptor.Unary()), grpc.StreamInterceptor(interceptor.Stream()))
+		testpb.RegisterUsersServer(server, usersServer{})
+		go func() {
+			_ = server.Serve(listener)
+		}()
+
+		conn, err = grpc.DialContext(context.Background(), "bufnet",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).ShouldNot(HaveOccurred())
+		client = testpb.NewUsersClient(conn)
+	}
+
+	as := func(userID string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-user-id", userID)
+	}
+
+	code := func(err error) codes.Code {
+		return status.Code(err)
+	}
+
+	BeforeEach(func() {
+		database := memory.NewDatabase()
+		var err error
+		p, err = permify.New(permify.Options{
+			RoleRepository:       &memory.RoleRepository{Database: database},
+			PermissionRepository: &memory.PermissionRepository{Database: database},
+			UserRepository:       &memory.UserRepository{Database: database},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(p.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+		Expect(p.CreateRole("auditor", "")).ShouldNot(HaveOccurred())
+		Expect(p.CreatePermission("view users", "")).ShouldNot(HaveOccurred())
+		Expect(p.CreatePermission("edit users", "")).ShouldNot(HaveOccurred())
+		Expect(p.AddPermissionsToRole("admin", []string{"view users", "edit users"})).ShouldNot(HaveOccurred())
+		Expect(p.AddRolesToUser(1, "admin")).ShouldNot(HaveOccurred())
+		Expect(p.AddPermissionsToUser(2, "view users")).ShouldNot(HaveOccurred())
+		Expect(p.AddRolesToUser(3, "auditor")).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(conn.Close()).ShouldNot(HaveOccurred())
+		server.Stop()
+	})
+
+	Context("New", func() {
+		It("Requires the Options", func() {
+			start(permifygrpc.Options{})
+
+			_, err := permifygrpc.New(permifygrpc.Options{SubjectExtractor: permifygrpc.MetadataSubjectExtractor("x-user-id")})
+			Expect(err).Should(Equal(permifygrpc.ErrNoPermify))
+			_, err = permifygrpc.New(permifygrpc.Options{Permify: p})
+			Expect(err).Should(Equal(permifygrpc.ErrNoSubjectExtractor))
+		})
+	})
+
+	Context("Method Options", func() {
+		BeforeEach(func() {
+			start(permifygrpc.Options{})
+		})
+
+		It("Unary", func() {
+			user, err := client.Get(as("2"), &testpb.User{Id: 7})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(user.GetId()).Should(Equal(uint64(7)))
+
+			_, err = client.Update(as("1"), &testpb.User{Id: 7})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = client.Update(as("2"), &testpb.User{Id: 7})
+			Expect(code(err)).Should(Equal(codes.PermissionDenied))
+
+			_, err = client.Get(as("3"), &testpb.User{Id: 7})
+			Expect(code(err)).Should(Equal(codes.PermissionDenied))
+		})
+
+		It("Stream", func() {
+			for _, userID := range []string{"1", "3"} {
+				stream, err := client.Watch(as(userID), &testpb.User{Id: 7})
+				Expect(err).ShouldNot(HaveOccurred())
+				user, err := stream.Recv()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(user.GetId()).Should(Equal(uint64(7)))
+				_, err = stream.Recv()
+				Expect(err).Should(Equal(io.EOF))
+			}
+
+			stream, err := client.Watch(as("2"), &testpb.User{Id: 7})
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = stream.Recv()
+			Expect(code(err)).Should(Equal(codes.PermissionDenied))
+		})
+
+		It("Unauthenticated", func() {
+			_, err := client.Get(context.Background(), &testpb.User{Id: 7})
+			Expect(code(err)).Should(Equal(codes.Unauthenticated))
+
+			stream, err := client.Watch(context.Background(), &testpb.User{Id: 7})
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = stream.Recv()
+			Expect(code(err)).Should(Equal(codes.Unauthenticated))
+		})
+
+		It("Denies the Unlisted Methods", func() {
+			_, err := client.Delete(as("1"), &testpb.User{Id: 7})
+			Expect(code(err)).Should(Equal(codes.PermissionDenied))
+		})
+
+		It("Hides the Errors", func() {
+			_, err := client.Get(as("not a number"), &testpb.User{Id: 7})
+			Expect(code(err)).Should(Equal(codes.Internal))
+			Expect(status.Convert(err).Message()).Should(Equal("internal error"))
+		})
+	})
+
+	Context("Rules", func() {
+		It("Replace the Method Options", func() {
+			start(permifygrpc.Options{
+				Rules: map[string]*permifypb.Rule{
+					"/permify.test.Users/Delete": {Roles: []string{"admin"}},
+					"/permify.test.Users/Get":    {AnyPermissions: []string{"edit users", "view users"}, Roles: []string{"auditor"}},
+					"/permify.test.Users/Ping":   {},
+				},
+			})
+
+			_, err := client.Delete(as("1"), &testpb.User{Id: 7})
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = client.Delete(as("2"), &testpb.User{Id: 7})
+			Expect(code(err)).Should(Equal(codes.PermissionDenied))
+
+			Expect(p.AddPermissionsToUser(3, "view users")).ShouldNot(HaveOccurred())
+			_, err = client.Get(as("3"), &testpb.User{Id: 7})
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = client.Get(as("1"), &testpb.User{Id: 7})
+			Expect(code(err)).Should(Equal(codes.PermissionDenied))
+
+			// the empty rule lets all the users through.
+			_, err = client.Ping(as("4"), &testpb.User{Id: 7})
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = client.Ping(context.Background(), &testpb.User{Id: 7})
+			Expect(code(err)).Should(Equal(codes.Unauthenticated))
+		})
+
+		It("Allow the Unlisted Methods", func() {
+			start(permifygrpc.Options{AllowUnlisted: true})
+
+			_, err := client.Delete(context.Background(), &testpb.User{Id: 7})
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = client.Get(as("3"), &testpb.User{Id: 7})
+			Expect(code(err)).Should(Equal(codes.PermissionDenied))
+		})
+
+		It("Check in the Scope", func() {
+			Expect(p.Tenant("org-a").AddRolesToUser(4, "admin")).ShouldNot(HaveOccurred())
+			start(permifygrpc.Options{
+				Scope: func(ctx context.Context, p *permify.Permify) *permify.Permify {
+					md, _ := metadata.FromIncomingContext(ctx)
+					if tenants := md.Get("x-tenant-id"); len(tenants) > 0 {
+						return p.Tenant(tenants[0])
+					}
+					return p
+				},
+			})
+
+			_, err := client.Update(metadata.AppendToOutgoingContext(as("4"), "x-tenant-id", "org-a"), &testpb.User{Id: 7})
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = client.Update(as("4"), &testpb.User{Id: 7})
+			Expect(code(err)).Should(Equal(codes.PermissionDenied))
+		})
+	})
+})
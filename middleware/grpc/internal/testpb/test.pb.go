@@ -0,0 +1,178 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.26.0
+// 	protoc        (unknown)
+// source: internal/testpb/test.proto
+
+package testpb
+
+import (
+	_ "github.com/Permify/go-role/middleware/grpc/permifypb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type User struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_testpb_test_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_testpb_test_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_internal_testpb_test_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+var File_internal_testpb_test_proto protoreflect.FileDescriptor
+
+var file_internal_testpb_test_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x70,
+	0x62, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0c, 0x70, 0x65,
+	0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x70, 0x65, 0x72, 0x6d,
+	0x69, 0x66, 0x79, 0x70, 0x62, 0x2f, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0x16, 0x0a, 0x04, 0x55, 0x73, 0x65, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x32, 0xc3, 0x02, 0x0a, 0x05,
+	0x55, 0x73, 0x65, 0x72, 0x73, 0x12, 0x3f, 0x0a, 0x03, 0x47, 0x65, 0x74, 0x12, 0x12, 0x2e, 0x70,
+	0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x55, 0x73, 0x65, 0x72,
+	0x1a, 0x12, 0x2e, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e,
+	0x55, 0x73, 0x65, 0x72, 0x22, 0x10, 0xfa, 0xa9, 0x19, 0x0c, 0x0a, 0x0a, 0x76, 0x69, 0x65, 0x77,
+	0x20, 0x75, 0x73, 0x65, 0x72, 0x73, 0x12, 0x4e, 0x0a, 0x06, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x12, 0x12, 0x2e, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e,
+	0x55, 0x73, 0x65, 0x72, 0x1a, 0x12, 0x2e, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e, 0x74,
+	0x65, 0x73, 0x74, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x22, 0x1c, 0xfa, 0xa9, 0x19, 0x18, 0x0a, 0x0a,
+	0x76, 0x69, 0x65, 0x77, 0x20, 0x75, 0x73, 0x65, 0x72, 0x73, 0x0a, 0x0a, 0x65, 0x64, 0x69, 0x74,
+	0x20, 0x75, 0x73, 0x65, 0x72, 0x73, 0x12, 0x30, 0x0a, 0x06, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x12, 0x12, 0x2e, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e,
+	0x55, 0x73, 0x65, 0x72, 0x1a, 0x12, 0x2e, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e, 0x74,
+	0x65, 0x73, 0x74, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x12, 0x47, 0x0a, 0x05, 0x57, 0x61, 0x74, 0x63,
+	0x68, 0x12, 0x12, 0x2e, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e, 0x74, 0x65, 0x73, 0x74,
+	0x2e, 0x55, 0x73, 0x65, 0x72, 0x1a, 0x12, 0x2e, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e,
+	0x74, 0x65, 0x73, 0x74, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x22, 0x14, 0xfa, 0xa9, 0x19, 0x10, 0x1a,
+	0x05, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x1a, 0x07, 0x61, 0x75, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x30,
+	0x01, 0x12, 0x2e, 0x0a, 0x04, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x12, 0x2e, 0x70, 0x65, 0x72, 0x6d,
+	0x69, 0x66, 0x79, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x1a, 0x12, 0x2e,
+	0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x55, 0x73, 0x65,
+	0x72, 0x42, 0x3c, 0x5a, 0x3a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x50, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2f, 0x67, 0x6f, 0x2d, 0x72, 0x6f, 0x6c, 0x65, 0x2f,
+	0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2f,
+	0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x70, 0x62, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_internal_testpb_test_proto_rawDescOnce sync.Once
+	file_internal_testpb_test_proto_rawDescData = file_internal_testpb_test_proto_rawDesc
+)
+
+func file_internal_testpb_test_proto_rawDescGZIP() []byte {
+	file_internal_testpb_test_proto_rawDescOnce.Do(func() {
+		file_internal_testpb_test_proto_rawDescData = protoimpl.X.CompressGZIP(file_internal_testpb_test_proto_rawDescData)
+	})
+	return file_internal_testpb_test_proto_rawDescData
+}
+
+var file_internal_testpb_test_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_internal_testpb_test_proto_goTypes = []interface{}{
+	(*User)(nil), // 0: permify.test.User
+}
+var file_internal_testpb_test_proto_depIdxs = []int32{
+	0, // 0: permify.test.Users.Get:input_type -> permify.test.User
+	0, // 1: permify.test.Users.Update:input_type -> permify.test.User
+	0, // 2: permify.test.Users.Delete:input_type -> permify.test.User
+	0, // 3: permify.test.Users.Watch:input_type -> permify.test.User
+	0, // 4: permify.test.Users.Ping:input_type -> permify.test.User
+	0, // 5: permify.test.Users.Get:output_type -> permify.test.User
+	0, // 6: permify.test.Users.Update:output_type -> permify.test.User
+	0, // 7: permify.test.Users.Delete:output_type -> permify.test.User
+	0, // 8: permify.test.Users.Watch:output_type -> permify.test.User
+	0, // 9: permify.test.Users.Ping:output_type -> permify.test.User
+	5, // [5:10] is the sub-list for method output_type
+	0, // [0:5] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_internal_testpb_test_proto_init() }
+func file_internal_testpb_test_proto_init() {
+	if File_internal_testpb_test_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_internal_testpb_test_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*User); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_internal_testpb_test_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_internal_testpb_test_proto_goTypes,
+		DependencyIndexes: file_internal_testpb_test_proto_depIdxs,
+		MessageInfos:      file_internal_testpb_test_proto_msgTypes,
+	}.Build()
+	File_internal_testpb_test_proto = out.File
+	file_internal_testpb_test_proto_rawDesc = nil
+	file_internal_testpb_test_proto_goTypes = nil
+	file_internal_testpb_test_proto_depIdxs = nil
+}
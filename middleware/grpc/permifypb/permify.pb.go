@@ -0,0 +1,198 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.26.0
+// 	protoc        (unknown)
+// source: permifypb/permify.proto
+
+package permifypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Rule is the permissions and roles that the users must have to call a method.
+// The user must have all the permissions, any of the any permissions and any of the roles, the empty fields are not checked.
+type Rule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Permissions    []string `protobuf:"bytes,1,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	AnyPermissions []string `protobuf:"bytes,2,rep,name=any_permissions,json=anyPermissions,proto3" json:"any_permissions,omitempty"`
+	Roles          []string `protobuf:"bytes,3,rep,name=roles,proto3" json:"roles,omitempty"`
+}
+
+func (x *Rule) Reset() {
+	*x = Rule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_permifypb_permify_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Rule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rule) ProtoMessage() {}
+
+func (x *Rule) ProtoReflect() protoreflect.Message {
+	mi := &file_permifypb_permify_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rule.ProtoReflect.Descriptor instead.
+func (*Rule) Descriptor() ([]byte, []int) {
+	return file_permifypb_permify_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Rule) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *Rule) GetAnyPermissions() []string {
+	if x != nil {
+		return x.AnyPermissions
+	}
+	return nil
+}
+
+func (x *Rule) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+var file_permifypb_permify_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*Rule)(nil),
+		Field:         51871,
+		Name:          "permify.rule",
+		Tag:           "bytes,51871,opt,name=rule",
+		Filename:      "permifypb/permify.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// rule authorizes the calls of the method. example: option (permify.rule) = { permissions: "view users" };
+	//
+	// optional permify.Rule rule = 51871;
+	E_Rule = &file_permifypb_permify_proto_extTypes[0]
+)
+
+var File_permifypb_permify_proto protoreflect.FileDescriptor
+
+var file_permifypb_permify_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x70, 0x62, 0x2f, 0x70, 0x65, 0x72, 0x6d,
+	0x69, 0x66, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x70, 0x65, 0x72, 0x6d, 0x69,
+	0x66, 0x79, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x67, 0x0a, 0x04, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x20, 0x0a, 0x0b,
+	0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0b, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x27,
+	0x0a, 0x0f, 0x61, 0x6e, 0x79, 0x5f, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x6e, 0x79, 0x50, 0x65, 0x72, 0x6d,
+	0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x6c, 0x65, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x72, 0x6f, 0x6c, 0x65, 0x73, 0x3a, 0x43, 0x0a,
+	0x04, 0x72, 0x75, 0x6c, 0x65, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x9f, 0x95, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e,
+	0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2e, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x04, 0x72, 0x75,
+	0x6c, 0x65, 0x42, 0x36, 0x5a, 0x34, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x2f, 0x67, 0x6f, 0x2d, 0x72, 0x6f, 0x6c, 0x65,
+	0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2f, 0x67, 0x72, 0x70, 0x63,
+	0x2f, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x66, 0x79, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_permifypb_permify_proto_rawDescOnce sync.Once
+	file_permifypb_permify_proto_rawDescData = file_permifypb_permify_proto_rawDesc
+)
+
+func file_permifypb_permify_proto_rawDescGZIP() []byte {
+	file_permifypb_permify_proto_rawDescOnce.Do(func() {
+		file_permifypb_permify_proto_rawDescData = protoimpl.X.CompressGZIP(file_permifypb_permify_proto_rawDescData)
+	})
+	return file_permifypb_permify_proto_rawDescData
+}
+
+var file_permifypb_permify_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_permifypb_permify_proto_goTypes = []interface{}{
+	(*Rule)(nil),                       // 0: permify.Rule
+	(*descriptorpb.MethodOptions)(nil), // 1: google.protobuf.MethodOptions
+}
+var file_permifypb_permify_proto_depIdxs = []int32{
+	1, // 0: permify.rule:extendee -> google.protobuf.MethodOptions
+	0, // 1: permify.rule:type_name -> permify.Rule
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	1, // [1:2] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_permifypb_permify_proto_init() }
+func file_permifypb_permify_proto_init() {
+	if File_permifypb_permify_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_permifypb_permify_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Rule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_permifypb_permify_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_permifypb_permify_proto_goTypes,
+		DependencyIndexes: file_permifypb_permify_proto_depIdxs,
+		MessageInfos:      file_permifypb_permify_proto_msgTypes,
+		ExtensionInfos:    file_permifypb_permify_proto_extTypes,
+	}.Build()
+	File_permifypb_permify_proto = out.File
+	file_permifypb_permify_proto_rawDesc = nil
+	file_permifypb_permify_proto_goTypes = nil
+	file_permifypb_permify_proto_depIdxs = nil
+}
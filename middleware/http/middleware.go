@@ -0,0 +1,199 @@
+// Package http provides the net/http middleware that authorizes the requests with Permify.
+// It is imported with a name that does not hide net/http. example: permifyhttp "github.com/Permify/go-role/middleware/http"
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	permify "github.com/Permify/go-role"
+)
+
+var (
+	// ErrUnauthenticated is given to the DenialHandler when the user of the request cannot be extracted.
+	ErrUnauthenticated = errors.New("err unauthenticated")
+	// ErrForbidden is given to the DenialHandler when the user of the request does not have the required permissions or role.
+	ErrForbidden = errors.New("err forbidden")
+	// ErrNoPermify is returned by New when the options have no Permify.
+	ErrNoPermify = errors.New("err permify is required")
+	// ErrNoUserIDExtractor is returned by New when the options have no UserIDExtractor.
+	ErrNoUserIDExtractor = errors.New("err user id extractor is required")
+)
+
+// UserIDExtractor returns the user id of the request, any user id or Subject that Permify takes.
+// It returns an error, or a nil user id, if the request has no user.
+type UserIDExtractor func(r *http.Request) (userID interface{}, err error)
+
+// DenialHandler responds to the requests that are not authorized.
+// err is ErrUnauthenticated or ErrForbidden (errors.Is matches them), or the error of the permission check.
+type DenialHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Options are the options of the middleware.
+type Options struct {
+	// Permify checks the permissions and the roles of the users.
+	Permify *permify.Permify
+	// UserIDExtractor returns the user id of the request. example: HeaderUserIDExtractor("X-User-ID")
+	UserIDExtractor UserIDExtractor
+	// Scope returns the Permify that the request is checked with, e.g. in the tenant of the request. (default nil, Permify is used)
+	// example: func(r *http.Request, p *permify.Permify) *permify.Permify { return p.Tenant(r.Header.Get("X-Tenant-ID")) }
+	Scope func(r *http.Request, permify *permify.Permify) *permify.Permify
+	// DenialHandler responds to the requests that are not authorized. (default DefaultDenialHandler)
+	DenialHandler DenialHandler
+}
+
+// Middleware authorizes the requests with Permify.
+type Middleware struct {
+	permify         *permify.Permify
+	userIDExtractor UserIDExtractor
+	scope           func(r *http.Request, permify *permify.Permify) *permify.Permify
+	denialHandler   DenialHandler
+}
+
+// New returns the middleware of the options.
+// @param Options
+// @return *Middleware, error
+func New(options Options) (*Middleware, error) {
+	if options.Permify == nil {
+		return nil, ErrNoPermify
+	}
+	if options.UserIDExtractor == nil {
+		return nil, ErrNoUserIDExtractor
+	}
+
+	m := &Middleware{
+		permify:         options.Permify,
+		userIDExtractor: options.UserIDExtractor,
+		scope:           options.Scope,
+		denialHandler:   options.DenialHandler,
+	}
+	if m.denialHandler == nil {
+		m.denialHandler = DefaultDenialHandler
+	}
+	return m, nil
+}
+
+// RequirePermission returns the handler that passes the requests of the users that have the permission to next.
+// example: mux.Handle("/users", m.RequirePermission("view users")(usersHandler))
+// @param string
+// @return func(next http.Handler) http.Handler
+func (m *Middleware) RequirePermission(permission string) func(next http.Handler) http.Handler {
+	return m.require(func(ctx context.Context, p *permify.Permify, userID interface{}) (bool, error) {
+		return p.UserHasPermissionCtx(ctx, userID, permission)
+	})
+}
+
+// RequireAnyPermission returns the handler that passes the requests of the users that have any of the permissions to next.
+// @param ...string
+// @return func(next http.Handler) http.Handler
+func (m *Middleware) RequireAnyPermission(permissions ...string) func(next http.Handler) http.Handler {
+	return m.require(func(ctx context.Context, p *permify.Permify, userID interface{}) (bool, error) {
+		return p.UserHasAnyPermissionsCtx(ctx, userID, permissions)
+	})
+}
+
+// RequireAllPermissions returns the handler that passes the requests of the users that have all the permissions to next.
+// @param ...string
+// @return func(next http.Handler) http.Handler
+func (m *Middleware) RequireAllPermissions(permissions ...string) func(next http.Handler) http.Handler {
+	return m.require(func(ctx context.Context, p *permify.Permify, userID interface{}) (bool, error) {
+		return p.UserHasAllPermissionsCtx(ctx, userID, permissions)
+	})
+}
+
+// RequireRole returns the handler that passes the requests of the users that have the role to next.
+// @param string
+// @return func(next http.Handler) http.Handler
+func (m *Middleware) RequireRole(role string) func(next http.Handler) http.Handler {
+	return m.require(func(ctx context.Context, p *permify.Permify, userID interface{}) (bool, error) {
+		return p.UserHasRoleCtx(ctx, userID, role)
+	})
+}
+
+// require returns the handler that passes the requests of the users that pass the check to next, the others are given to the DenialHandler.
+// The check is given the context of the request.
+// @param func(ctx context.Context, p *permify.Permify, userID interface{}) (bool, error)
+// @return func(next http.Handler) http.Handler
+func (m *Middleware) require(check func(ctx context.Context, p *permify.Permify, userID interface{}) (bool, error)) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := m.userIDExtractor(r)
+			if err != nil {
+				m.denialHandler(w, r, fmt.Errorf("%w: %v", ErrUnauthenticated, err))
+				return
+			}
+			if userID == nil {
+				m.denialHandler(w, r, ErrUnauthenticated)
+				return
+			}
+
+			p := m.permify
+			if m.scope != nil {
+				p = m.scope(r, p)
+			}
+
+			allowed, err := check(r.Context(), p, userID)
+			if err != nil {
+				m.denialHandler(w, r, err)
+				return
+			}
+			if !allowed {
+				m.denialHandler(w, r, ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DefaultDenialHandler responds with the json error, 401 to ErrUnauthenticated, 403 to ErrForbidden and 500 to the other errors.
+// The messages of the other errors are not written, since they can have the details of the database.
+// example: {"error":"forbidden"}
+// @param http.ResponseWriter
+// @param *http.Request
+// @param error
+func DefaultDenialHandler(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		status = http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		status = http.StatusForbidden
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": deniedMessages[status]})
+}
+
+// deniedMessages are the messages of the statuses of DefaultDenialHandler.
+var deniedMessages = map[int]string{
+	http.StatusUnauthorized:        "unauthenticated",
+	http.StatusForbidden:           "forbidden",
+	http.StatusInternalServerError: "internal server error",
+}
+
+// HeaderUserIDExtractor returns the UserIDExtractor that reads the user id from the header, e.g. set by an authenticating proxy.
+// It must not be used with headers that the clients can set.
+// @param string
+// @return UserIDExtractor
+func HeaderUserIDExtractor(header string) UserIDExtractor {
+	return func(r *http.Request) (interface{}, error) {
+		if userID := r.Header.Get(header); userID != "" {
+			return userID, nil
+		}
+		return nil, fmt.Errorf("no %s header", header)
+	}
+}
+
+// ContextUserIDExtractor returns the UserIDExtractor that reads the user id from the value of the key in the context of the request,
+// e.g. set by an authentication middleware.
+// @param interface{}
+// @return UserIDExtractor
+func ContextUserIDExtractor(key interface{}) UserIDExtractor {
+	return func(r *http.Request) (interface{}, error) {
+		return r.Context().Value(key), nil
+	}
+}
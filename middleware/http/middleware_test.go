@@ -0,0 +1,189 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	permify "github.com/Permify/go-role"
+	permifyhttp "github.com/Permify/go-role/middleware/http"
+	"github.com/Permify/go-role/repositories/memory"
+)
+
+func TestMiddleware(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "http middleware suite")
+}
+
+var _ = Describe("Middleware", func() {
+	var p *permify.Permify
+	var middleware *permifyhttp.Middleware
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	serve := func(handler http.Handler, userID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if userID != "" {
+			r.Header.Set("X-User-ID", userID)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	BeforeEach(func() {
+		database := memory.NewDatabase()
+		var err error
+		p, err = permify.New(permify.Options{
+			RoleRepository:       &memory.RoleRepository{Database: database},
+			PermissionRepository: &memory.PermissionRepository{Database: database},
+			UserRepository:       &memory.UserRepository{Database: database},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(p.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+		Expect(p.CreatePermission("view users", "")).ShouldNot(HaveOccurred())
+		Expect(p.CreatePermission("edit users", "")).ShouldNot(HaveOccurred())
+		Expect(p.AddPermissionsToRole("admin", []string{"view users", "edit users"})).ShouldNot(HaveOccurred())
+		Expect(p.AddRolesToUser(1, "admin")).ShouldNot(HaveOccurred())
+		Expect(p.AddPermissionsToUser(2, "view users")).ShouldNot(HaveOccurred())
+
+		middleware, err = permifyhttp.New(permifyhttp.Options{
+			Permify:         p,
+			UserIDExtractor: permifyhttp.HeaderUserIDExtractor("X-User-ID"),
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	Context("New", func() {
+		It("Requires the Options", func() {
+			_, err := permifyhttp.New(permifyhttp.Options{UserIDExtractor: permifyhttp.HeaderUserIDExtractor("X-User-ID")})
+			Expect(err).Should(Equal(permifyhttp.ErrNoPermify))
+			_, err = permifyhttp.New(permifyhttp.Options{Permify: p})
+			Expect(err).Should(Equal(permifyhttp.ErrNoUserIDExtractor))
+		})
+	})
+
+	Context("Require", func() {
+		It("Permission", func() {
+			handler := middleware.RequirePermission("edit users")(ok)
+
+			Expect(serve(handler, "1").Code).Should(Equal(http.StatusNoContent))
+
+			w := serve(handler, "2")
+			Expect(w.Code).Should(Equal(http.StatusForbidden))
+			Expect(w.Header().Get("Content-Type")).Should(Equal("application/json"))
+			Expect(w.Body.String()).Should(MatchJSON(`{"error":"forbidden"}`))
+		})
+
+		It("Any Permission", func() {
+			handler := middleware.RequireAnyPermission("edit users", "view users")(ok)
+
+			Expect(serve(handler, "1").Code).Should(Equal(http.StatusNoContent))
+			Expect(serve(handler, "2").Code).Should(Equal(http.StatusNoContent))
+			Expect(serve(handler, "3").Code).Should(Equal(http.StatusForbidden))
+		})
+
+		It("All Permissions", func() {
+			handler := middleware.RequireAllPermissions("edit users", "view users")(ok)
+
+			Expect(serve(handler, "1").Code).Should(Equal(http.StatusNoContent))
+			Expect(serve(handler, "2").Code).Should(Equal(http.StatusForbidden))
+		})
+
+		It("Role", func() {
+			handler := middleware.RequireRole("admin")(ok)
+
+			Expect(serve(handler, "1").Code).Should(Equal(http.StatusNoContent))
+			Expect(serve(handler, "2").Code).Should(Equal(http.StatusForbidden))
+		})
+
+		It("Authenticated User", func() {
+			w := serve(middleware.RequirePermission("view users")(ok), "")
+			Expect(w.Code).Should(Equal(http.StatusUnauthorized))
+			Expect(w.Body.String()).Should(MatchJSON(`{"error":"unauthenticated"}`))
+		})
+
+		It("Checks in the Scope", func() {
+			Expect(p.Tenant("org-a").AddRolesToUser(3, "admin")).ShouldNot(HaveOccurred())
+
+			scoped, err := permifyhttp.New(permifyhttp.Options{
+				Permify:         p,
+				UserIDExtractor: permifyhttp.HeaderUserIDExtractor("X-User-ID"),
+				Scope: func(r *http.Request, p *permify.Permify) *permify.Permify {
+					return p.Tenant(r.Header.Get("X-Tenant-ID"))
+				},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			handler := scoped.RequireRole("admin")(ok)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("X-User-ID", "3")
+			r.Header.Set("X-Tenant-ID", "org-a")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			Expect(w.Code).Should(Equal(http.StatusNoContent))
+
+			Expect(serve(handler, "3").Code).Should(Equal(http.StatusForbidden))
+		})
+	})
+
+	Context("Denial", func() {
+		It("Custom Handler", func() {
+			var denied error
+			custom, err := permifyhttp.New(permifyhttp.Options{
+				Permify:         p,
+				UserIDExtractor: permifyhttp.HeaderUserIDExtractor("X-User-ID"),
+				DenialHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+					denied = err
+					http.Redirect(w, r, "/login", http.StatusFound)
+				},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			handler := custom.RequirePermission("edit users")(ok)
+
+			w := serve(handler, "2")
+			Expect(w.Code).Should(Equal(http.StatusFound))
+			Expect(w.Header().Get("Location")).Should(Equal("/login"))
+			Expect(denied).Should(Equal(permifyhttp.ErrForbidden))
+
+			serve(handler, "")
+			Expect(errors.Is(denied, permifyhttp.ErrUnauthenticated)).Should(BeTrue())
+		})
+
+		It("Hides the Errors", func() {
+			// the permission does not exist.
+			w := serve(middleware.RequirePermission("delete users")(ok), "1")
+			Expect(w.Code).Should(Equal(http.StatusInternalServerError))
+			Expect(w.Body.String()).Should(MatchJSON(`{"error":"internal server error"}`))
+		})
+	})
+
+	Context("User ID Extractor", func() {
+		It("From the Context", func() {
+			type userKey struct{}
+			extracting, err := permifyhttp.New(permifyhttp.Options{
+				Permify:         p,
+				UserIDExtractor: permifyhttp.ContextUserIDExtractor(userKey{}),
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			handler := extracting.RequireRole("admin")(ok)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, 1)))
+			Expect(w.Code).Should(Equal(http.StatusNoContent))
+
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			Expect(w.Code).Should(Equal(http.StatusUnauthorized))
+		})
+	})
+})
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditKind tells what the names of an audit entry are.
+type AuditKind string
+
+const (
+	// AuditRole the names are the role that is created or deleted.
+	AuditRole AuditKind = "role"
+	// AuditPermission the names are the permission that is created or deleted.
+	AuditPermission AuditKind = "permission"
+	// AuditRoles the names are the roles of the user.
+	AuditRoles AuditKind = "roles"
+	// AuditPermissions the names are the permissions of the role or the direct permissions of the user.
+	AuditPermissions AuditKind = "permissions"
+	// AuditDeniedPermissions the names are the denied permissions of the role or of the user.
+	AuditDeniedPermissions AuditKind = "denied_permissions"
+	// AuditChildRoles the names are the child roles of the role.
+	AuditChildRoles AuditKind = "child_roles"
+)
+
+// AuditNames are the names of the roles or permissions before or after a change, stored as a json array. (see AuditName)
+type AuditNames []string
+
+// Value implements driver.Valuer.
+// @return driver.Value, error
+func (n AuditNames) Value() (driver.Value, error) {
+	if n == nil {
+		return "[]", nil
+	}
+	value, err := json.Marshal(n)
+	return string(value), err
+}
+
+// Scan implements sql.Scanner.
+// @param interface{}
+// @return error
+func (n *AuditNames) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(value), n)
+	case []byte:
+		return json.Unmarshal(value, n)
+	default:
+		return fmt.Errorf("err unsupported audit names %T", src)
+	}
+}
+
+// AuditName returns the name of a role or a permission in the audit entries, its guard name followed by its window if it has one.
+// example: admin (from 2030-01-01T00:00:00Z until 2030-02-01T00:00:00Z)
+// @param string
+// @param *time.Time
+// @param *time.Time
+// @return string
+func AuditName(guardName string, startsAt *time.Time, expiresAt *time.Time) string {
+	var bounds []string
+	if startsAt != nil {
+		bounds = append(bounds, "from "+startsAt.UTC().Format(time.RFC3339Nano))
+	}
+	if expiresAt != nil {
+		bounds = append(bounds, "until "+expiresAt.UTC().Format(time.RFC3339Nano))
+	}
+	if len(bounds) == 0 {
+		return guardName
+	}
+	return guardName + " (" + strings.Join(bounds, " ") + ")"
+}
+
+// GuardNames returns the guard names of the names, without their windows. (see AuditName)
+// @return []string
+func (n AuditNames) GuardNames() []string {
+	guardNames := make([]string, 0, len(n))
+	for _, name := range n {
+		if i := strings.Index(name, " ("); i >= 0 {
+			name = name[:i]
+		}
+		guardNames = append(guardNames, name)
+	}
+	return guardNames
+}
+
+// AuditEntry represents the database model of the audit log, an entry is recorded for every change made with permify.
+// The entries are chained, each entry has the hash of the previous one, so that the changed and the deleted entries can be detected.
+type AuditEntry struct {
+	ID uint `gorm:"primary_key" json:"id"`
+	// Actor is the one who made the change, it is empty if it is not known. example: user:5
+	Actor string `gorm:"size:255;index" json:"actor"`
+	// Operation is the method of permify that made the change. example: AddRolesToUser
+	Operation string `gorm:"size:255;not null" json:"operation"`
+
+	// SubjectType and SubjectID are the user whose assignments changed, they are empty for the changes of roles and permissions.
+	SubjectType string `gorm:"size:255;index:idx_audit_entries_subject" json:"subject_type"`
+	SubjectID   string `gorm:"size:255;index:idx_audit_entries_subject" json:"subject_id"`
+	// Role is the guard name of the role that is created, deleted or whose assignments changed, it is empty for the changes of users.
+	Role string `gorm:"size:255;index" json:"role"`
+
+	// TenantID, ResourceType and ResourceID are the scope of the changed user assignments.
+	TenantID     string `gorm:"size:255" json:"tenant_id"`
+	ResourceType string `gorm:"size:255" json:"resource_type"`
+	ResourceID   string `gorm:"size:255" json:"resource_id"`
+
+	// Kind tells what Before and After are, e.g. the roles of the user.
+	Kind   AuditKind  `gorm:"size:255;not null" json:"kind"`
+	Before AuditNames `gorm:"column:before_names;type:text" json:"before"`
+	After  AuditNames `gorm:"column:after_names;type:text" json:"after"`
+
+	// PreviousHash is the hash of the previous entry, it is empty for the first entry.
+	PreviousHash string `gorm:"size:64" json:"previous_hash"`
+	// Hash is the hash of the entry, see ComputeHash.
+	Hash string `gorm:"size:64;index" json:"hash"`
+
+	// Time
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName sets the table name
+func (AuditEntry) TableName() string {
+	return "audit_entries"
+}
+
+// ComputeHash returns the sha256 hash of the entry and the hash of the previous entry. The ID and the Hash of the entry are not a part of it.
+// CreatedAt is hashed in milliseconds, the precision kept by all the databases, and the nil names are hashed as empty.
+// @return string
+func (e AuditEntry) ComputeHash() string {
+	content, _ := json.Marshal([]interface{}{
+		e.PreviousHash,
+		e.Actor,
+		e.Operation,
+		e.SubjectType,
+		e.SubjectID,
+		e.Role,
+		e.TenantID,
+		e.ResourceType,
+		e.ResourceID,
+		e.Kind,
+		append(AuditNames{}, e.Before...),
+		append(AuditNames{}, e.After...),
+		e.CreatedAt.UnixMilli(),
+	})
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// AuditChainHead represents the database model of the head of the audit log, its single row has the hash of the last entry.
+// The row is locked while an entry is recorded, so that the entries of the concurrent changes are chained one after another, also the first ones.
+type AuditChainHead struct {
+	ID   uint   `gorm:"primary_key" json:"id"`
+	Hash string `gorm:"size:64" json:"hash"`
+}
+
+// TableName sets the table name
+func (AuditChainHead) TableName() string {
+	return "audit_chain_heads"
+}
@@ -0,0 +1,22 @@
+package pivot
+
+import (
+	"time"
+)
+
+// RoleDeniedPermissions represents the database model of role denied permissions relationships
+// A denied permission blocks the permission for the users of the role. (see ConflictResolution)
+// The deny is only valid between StartsAt and ExpiresAt, nil bounds are open.
+type RoleDeniedPermissions struct {
+	RoleID       uint `gorm:"primary_key" json:"role_id"`
+	PermissionID uint `gorm:"primary_key" json:"permission_id"`
+
+	// Time
+	StartsAt  *time.Time `json:"starts_at"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at"`
+}
+
+// TableName sets the table name
+func (RoleDeniedPermissions) TableName() string {
+	return "role_denied_permissions"
+}
@@ -0,0 +1,21 @@
+package pivot
+
+import (
+	"time"
+)
+
+// RolePermissions represents the database model of role permissions relationships
+// The assignment is only valid between StartsAt and ExpiresAt, nil bounds are open.
+type RolePermissions struct {
+	RoleID       uint `gorm:"primary_key" json:"role_id"`
+	PermissionID uint `gorm:"primary_key" json:"permission_id"`
+
+	// Time
+	StartsAt  *time.Time `json:"starts_at"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at"`
+}
+
+// TableName sets the table name
+func (RolePermissions) TableName() string {
+	return "role_permissions"
+}
@@ -1,9 +1,26 @@
 package pivot
 
+import (
+	"time"
+
+	"github.com/Permify/go-role/models"
+)
+
 // UserPermissions represents the database model of user permissions relationships
+// SubjectType tells apart the owners of the same id, e.g. users and devices. It is "user" for the users.
+// TenantID is empty for the global assignments, ResourceType and ResourceID are empty for the assignments that are not on a resource.
+// The assignment is only valid between StartsAt and ExpiresAt, nil bounds are open.
 type UserPermissions struct {
-	UserID       uint `gorm:"primary_key" json:"user_id"`
-	PermissionID uint `gorm:"primary_key" json:"permission_id"`
+	SubjectType  string        `gorm:"primary_key;size:255;default:'user'" json:"subject_type"`
+	UserID       models.UserID `gorm:"primary_key" json:"user_id"`
+	PermissionID uint          `gorm:"primary_key" json:"permission_id"`
+	TenantID     string        `gorm:"primary_key;size:255;default:''" json:"tenant_id"`
+	ResourceType string        `gorm:"primary_key;size:255;default:''" json:"resource_type"`
+	ResourceID   string        `gorm:"primary_key;size:255;default:''" json:"resource_id"`
+
+	// Time
+	StartsAt  *time.Time `json:"starts_at"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at"`
 }
 
 // TableName sets the table name
@@ -14,6 +14,10 @@ type Role struct {
 	// Many to Many
 	Permissions []Permission `gorm:"many2many:role_permissions;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"permissions"`
 
+	// Children are the roles whose permissions this role inherits.
+	// example: admin -> editor -> viewer, admin has the permissions of editor and viewer.
+	Children []Role `gorm:"many2many:role_children;joinForeignKey:RoleID;joinReferences:ChildID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"children"`
+
 	// Time
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -0,0 +1,24 @@
+package models
+
+// DefaultSubjectType is the subject type of the users, the subjects that are given by their id only are of this type.
+const DefaultSubjectType = "user"
+
+// Subject is the owner of the user assignments, e.g. a user, an api client, a service account or a device.
+// The ids of the different types can collide, the type tells them apart. The ids of all the types are of the UserIDType.
+type Subject struct {
+	Type string `gorm:"column:subject_type" json:"type"`
+	ID   UserID `gorm:"column:user_id" json:"id"`
+}
+
+// UserSubject returns the subject of the user, of the DefaultSubjectType.
+// @param UserID
+// @return Subject
+func UserSubject(ID UserID) Subject {
+	return Subject{Type: DefaultSubjectType, ID: ID}
+}
+
+// String returns the type and the id of the subject. example: device:7
+// @return string
+func (s Subject) String() string {
+	return s.Type + ":" + s.ID.String()
+}
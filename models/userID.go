@@ -0,0 +1,223 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// UserIDType is the type of the user ids, it decides the type of the user_id columns and how the given ids are converted.
+type UserIDType int
+
+const (
+	// UserIDUint is the default, the user ids are unsigned integers.
+	UserIDUint UserIDType = iota
+	// UserIDInt64 the user ids are signed integers.
+	UserIDInt64
+	// UserIDString the user ids are strings of at most 255 characters.
+	UserIDString
+	// UserIDUUID the user ids are uuids, stored as uuid in postgres and as strings in the other databases.
+	UserIDUUID
+)
+
+// UserIDTypeKey is the gorm setting that tells the type of the user_id columns to the migrations.
+// example: db.Set(models.UserIDTypeKey, models.UserIDUUID).AutoMigrate(pivot.UserRoles{})
+const UserIDTypeKey = "permify:user_id_type"
+
+// UserID is the identifier of a user. It holds an integer or a string, so that it can be compared and used as a map key.
+// Build it with UserIDFromInt or UserIDFromString, or convert a value with UserIDType.Parse.
+type UserID struct {
+	value   string
+	numeric bool
+}
+
+// UserIDFromInt returns the user id of an integer.
+// @param int64
+// @return UserID
+func UserIDFromInt(ID int64) UserID {
+	return UserID{value: strconv.FormatInt(ID, 10), numeric: true}
+}
+
+// UserIDFromString returns the user id of a string, e.g. a uuid.
+// @param string
+// @return UserID
+func UserIDFromString(ID string) UserID {
+	return UserID{value: ID}
+}
+
+// Parse converts the value to a user id of the type. The value can be an integer, a string,
+// a fmt.Stringer such as github.com/google/uuid.UUID, or a UserID.
+// It returns false if the value cannot be a user id of the type.
+// @param interface{}
+// @return UserID, bool
+func (t UserIDType) Parse(v interface{}) (UserID, bool) {
+	var s string
+	switch value := v.(type) {
+	case UserID:
+		s = value.value
+	case uint:
+		s = strconv.FormatUint(uint64(value), 10)
+	case uint32:
+		s = strconv.FormatUint(uint64(value), 10)
+	case uint64:
+		s = strconv.FormatUint(value, 10)
+	case int:
+		s = strconv.FormatInt(int64(value), 10)
+	case int32:
+		s = strconv.FormatInt(int64(value), 10)
+	case int64:
+		s = strconv.FormatInt(value, 10)
+	case string:
+		s = value
+	case fmt.Stringer:
+		s = value.String()
+	default:
+		return UserID{}, false
+	}
+
+	switch t {
+	case UserIDUint:
+		ID, err := strconv.ParseUint(s, 10, 63)
+		return UserID{value: strconv.FormatUint(ID, 10), numeric: true}, err == nil
+	case UserIDInt64:
+		ID, err := strconv.ParseInt(s, 10, 64)
+		return UserIDFromInt(ID), err == nil
+	case UserIDUUID:
+		s = strings.ToLower(s)
+		return UserIDFromString(s), isUUID(s)
+	}
+	return UserIDFromString(s), s != "" && len(s) <= 255
+}
+
+// String returns the id as a string.
+// @return string
+func (u UserID) String() string {
+	return u.value
+}
+
+// Int64 returns the id as an integer, it returns false if the id is not an integer.
+// @return int64, bool
+func (u UserID) Int64() (int64, bool) {
+	if !u.numeric {
+		return 0, false
+	}
+	ID, err := strconv.ParseInt(u.value, 10, 64)
+	return ID, err == nil
+}
+
+// Value implements driver.Valuer, the integer ids are int64 and the others are strings.
+// @return driver.Value, error
+func (u UserID) Value() (driver.Value, error) {
+	if ID, ok := u.Int64(); ok {
+		return ID, nil
+	}
+	return u.value, nil
+}
+
+// Scan implements sql.Scanner. The text that is an integer is scanned as an integer id,
+// since some drivers scan the integer columns as text. (e.g. the text protocol of mysql)
+// @param interface{}
+// @return error
+func (u *UserID) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case int64:
+		*u = UserIDFromInt(value)
+	case string:
+		*u = userIDFromText(value)
+	case []byte:
+		*u = userIDFromText(string(value))
+	default:
+		return fmt.Errorf("err unsupported user id %T", src)
+	}
+	return nil
+}
+
+// userIDFromText returns the integer id of the text if it is an integer in the canonical form, otherwise its string id.
+// @param string
+// @return UserID
+func userIDFromText(s string) UserID {
+	if ID, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(ID, 10) == s {
+		return UserIDFromInt(ID)
+	}
+	return UserIDFromString(s)
+}
+
+// MarshalJSON implements json.Marshaler, the integer ids are numbers and the others are strings.
+// @return []byte, error
+func (u UserID) MarshalJSON() ([]byte, error) {
+	if ID, ok := u.Int64(); ok {
+		return json.Marshal(ID)
+	}
+	return json.Marshal(u.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// @param []byte
+// @return error
+func (u *UserID) UnmarshalJSON(data []byte) error {
+	var ID int64
+	if err := json.Unmarshal(data, &ID); err == nil {
+		*u = UserIDFromInt(ID)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("err unsupported user id %s", data)
+	}
+	*u = UserIDFromString(s)
+	return nil
+}
+
+// GormDBDataType returns the type of the user_id columns, set by UserIDTypeKey. (default UserIDUint)
+// @param *gorm.DB
+// @param *schema.Field
+// @return string
+func (UserID) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	userIDType := UserIDUint
+	if value, ok := db.Get(UserIDTypeKey); ok {
+		userIDType, _ = value.(UserIDType)
+	}
+
+	column := *field
+	switch userIDType {
+	case UserIDInt64:
+		column.DataType, column.Size = schema.Int, 64
+	case UserIDString:
+		column.DataType, column.Size = schema.String, 255
+	case UserIDUUID:
+		if db.Dialector.Name() == "postgres" {
+			return "uuid"
+		}
+		column.DataType, column.Size = schema.String, 36
+	default:
+		column.DataType, column.Size = schema.Uint, 64
+	}
+	return db.Dialector.DataTypeOf(&column)
+}
+
+// isUUID is the string a uuid in the canonical form? (8-4-4-4-12 lowercase hex digits)
+// @param string
+// @return bool
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+				return false
+			}
+		}
+	}
+	return true
+}
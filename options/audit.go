@@ -0,0 +1,19 @@
+package options
+
+import (
+	"time"
+
+	"github.com/Permify/go-role/utils"
+)
+
+// AuditOption represents options when fetching audit entries.
+// User is the user id or Subject whose changes are fetched, Role is the role name, the zero values fetch all.
+// Since and Until are the time range of the changes, Until is excluded.
+type AuditOption struct {
+	User       interface{}
+	Role       string
+	Actor      string
+	Since      time.Time
+	Until      time.Time
+	Pagination *utils.Pagination
+}
@@ -0,0 +1,14 @@
+package options
+
+import (
+	"io"
+)
+
+// ReconcileOption represents options when reconciling a policy.
+// Prune deletes the permissions and the roles that are not in the policy, and removes the links of the roles that the policy does not give them.
+// DryRun only plans the changes, and Output is written the plan before it is applied.
+type ReconcileOption struct {
+	Prune  bool
+	DryRun bool
+	Output io.Writer
+}
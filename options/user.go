@@ -0,0 +1,10 @@
+package options
+
+import (
+	"github.com/Permify/go-role/utils"
+)
+
+// UserOption represents options when fetching users.
+type UserOption struct {
+	Pagination *utils.Pagination
+}
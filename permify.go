@@ -1,54 +1,333 @@
 package permify_gorm
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 
+	"github.com/Permify/go-role/cache"
 	"github.com/Permify/go-role/collections"
 	"github.com/Permify/go-role/helpers"
 	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/models/pivot"
 	"github.com/Permify/go-role/options"
 	"github.com/Permify/go-role/repositories"
 	"github.com/Permify/go-role/repositories/scopes"
 )
 
-var errUnsupportedValueType = errors.New("err unsupported value type")
+// purgeBatchSize is the number of rows deleted at once by PurgeExpiredAssignments.
+const purgeBatchSize = 1000
+
+// batchCheckSize is the number of users checked at once by UsersHavePermission.
+const batchCheckSize = 500
+
+// CircularInheritanceError is returned when adding a child role would make a role inherit itself.
+type CircularInheritanceError struct {
+	Role  string
+	Child string
+}
+
+// Error returns the error message.
+// @return string
+func (e *CircularInheritanceError) Error() string {
+	return fmt.Sprintf("err circular role inheritance: %s cannot inherit %s", e.Role, e.Child)
+}
 
 // Options has the options for initiating the Permify
 type Options struct {
 	Migrate bool
 	DB      *gorm.DB
+
+	// Guard converts the role and permission names to guard names. (default helpers.Guard)
+	Guard func(name string) string
+	// Wildcard makes the permissions with wildcard segments match their descendants. example: posts.* matches posts.edit and posts.delete.
+	// Guard names must keep the dot separated segments, helpers.SegmentedGuard is used if Guard is nil.
+	Wildcard bool
+	// ConflictResolution decides whether the user has a permission that is both allowed and denied. (default DenyOverrides)
+	ConflictResolution ConflictResolution
+	// Cache caches the assignments used by the permission checks, the mutations invalidate the affected users and roles. (default nil, disabled)
+	// The changes made by other processes and the expired assignments are seen after the ttl of the cache. example: cache.NewLRU(10000, time.Minute)
+	Cache cache.Cache
+
+	// RoleRepository, PermissionRepository and UserRepository replace the gorm repositories of DB if they are given.
+	// example: the repositories of repositories/memory, to run without a database.
+	RoleRepository       repositories.IRoleRepository
+	PermissionRepository repositories.IPermissionRepository
+	UserRepository       repositories.IUserRepository
+
+	// UserIDType is the type of the user ids, the user_id columns are migrated with it. (default models.UserIDUint)
+	// The user methods take integers, strings, fmt.Stringers such as uuid.UUID, or models.UserID, and convert them to the type.
+	// The ids of the other subject types, given as Subject, are converted to it too.
+	UserIDType models.UserIDType
+
+	// Audit records every change made with permify in the audit log, in the same transaction as the change. (default false, disabled)
+	// The entries are recorded in the audit_entries table of DB, or by AuditRepository if it is given. (see Permify.By to record the actor)
+	Audit           bool
+	AuditRepository repositories.IAuditRepository
+	// Transactor runs the changes and their audit entries in transactions, it replaces the transactions of DB if it is given.
+	// example: &memory.Transactor{Database: database}, with the repositories of repositories/memory.
+	Transactor repositories.ITransactor
 }
 
 // New initializer for Permify
 // If migration is true, it generate all tables in the database if they don't exist.
 func New(opts Options) (p *Permify, err error) {
-	roleRepository := &repositories.RoleRepository{Database: opts.DB}
-	permissionRepository := &repositories.PermissionRepository{Database: opts.DB}
-	userRepository := &repositories.UserRepository{Database: opts.DB}
+	var roleRepository repositories.IRoleRepository = &repositories.RoleRepository{Database: opts.DB, UserIDType: opts.UserIDType}
+	if opts.RoleRepository != nil {
+		roleRepository = opts.RoleRepository
+	}
+
+	var permissionRepository repositories.IPermissionRepository = &repositories.PermissionRepository{Database: opts.DB, UserIDType: opts.UserIDType}
+	if opts.PermissionRepository != nil {
+		permissionRepository = opts.PermissionRepository
+	}
+
+	var userRepository repositories.IUserRepository = &repositories.UserRepository{Database: opts.DB}
+	if opts.UserRepository != nil {
+		userRepository = opts.UserRepository
+	}
+
+	var auditRepository repositories.IAuditRepository
+	if opts.Audit {
+		auditRepository = &repositories.AuditRepository{Database: opts.DB}
+		if opts.AuditRepository != nil {
+			auditRepository = opts.AuditRepository
+		}
+	}
+
+	var transactor repositories.ITransactor
+	if opts.DB != nil {
+		transactor = &repositories.Transactor{Database: opts.DB}
+	}
+	if opts.Transactor != nil {
+		transactor = opts.Transactor
+	}
 
 	if opts.Migrate {
 		err = repositories.Migrates(roleRepository, permissionRepository)
 		if err != nil {
 			return nil, err
 		}
+		if auditRepository != nil {
+			err = auditRepository.Migrate()
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	p = &Permify{
 		RoleRepository:       roleRepository,
 		PermissionRepository: permissionRepository,
 		UserRepository:       userRepository,
+		AuditRepository:      auditRepository,
+		transactor:           transactor,
+		guard:                opts.Guard,
+		wildcard:             opts.Wildcard,
+		conflictResolution:   opts.ConflictResolution,
+		cache:                opts.Cache,
+		userIDType:           opts.UserIDType,
+	}
+
+	if p.wildcard && p.guard == nil {
+		p.guard = helpers.SegmentedGuard
 	}
 
 	return
 }
 
 // Permify is main struct of this package.
+// Every method has a context-aware variant with the Ctx suffix, its context is passed to every repository call.
 type Permify struct {
 	RoleRepository       repositories.IRoleRepository
 	PermissionRepository repositories.IPermissionRepository
 	UserRepository       repositories.IUserRepository
+	// AuditRepository is nil if the audit log is not enabled.
+	AuditRepository repositories.IAuditRepository
+
+	transactor         repositories.ITransactor
+	guard              func(name string) string
+	wildcard           bool
+	conflictResolution ConflictResolution
+	cache              cache.Cache
+	userIDType         models.UserIDType
+
+	tenantID     string
+	resourceType string
+	resourceID   string
+
+	window scopes.Window
+
+	actor      string
+	actingUser interface{}
+}
+
+// Tenant returns a copy of Permify whose user methods work in the given tenant. (team, organization, etc.)
+// Roles and permissions added to a user in a tenant only apply in that tenant, global assignments apply in every tenant.
+// @param string
+// @return *Permify
+func (s *Permify) Tenant(tenantID string) *Permify {
+	tenant := *s
+	tenant.tenantID = tenantID
+	return &tenant
+}
+
+// Resource returns a copy of Permify whose user methods work on the given resource. (example: project 42)
+// Roles and permissions added to a user on a resource only apply on that resource, the assignments that are not on a resource apply on every resource.
+// @param string
+// @param string
+// @return *Permify
+func (s *Permify) Resource(resourceType string, resourceID string) *Permify {
+	resource := *s
+	resource.resourceType = resourceType
+	resource.resourceID = resourceID
+	return &resource
+}
+
+// Between returns a copy of Permify whose added roles and permissions are only valid between startsAt and expiresAt.
+// A zero startsAt is valid immediately, a zero expiresAt never expires. It applies to the assignments of users and roles.
+// example: permify.Between(shiftStart, shiftEnd).AddRolesToUser(1, "on call")
+// @param time.Time
+// @param time.Time
+// @return *Permify
+func (s *Permify) Between(startsAt time.Time, expiresAt time.Time) *Permify {
+	between := *s
+	between.window = scopes.Window{}
+	if !startsAt.IsZero() {
+		between.window.StartsAt = &startsAt
+	}
+	if !expiresAt.IsZero() {
+		between.window.ExpiresAt = &expiresAt
+	}
+	return &between
+}
+
+// until returns a copy of Permify whose added roles and permissions expire at expiresAt, the start of its window is kept.
+// @param time.Time
+// @return *Permify
+func (s *Permify) until(expiresAt time.Time) *Permify {
+	until := *s
+	until.window.ExpiresAt = nil
+	if !expiresAt.IsZero() {
+		until.window.ExpiresAt = &expiresAt
+	}
+	return &until
+}
+
+// Transaction runs fc in a transaction of the Transactor, the changes made with the context of fc are a part of it.
+// The cached assignments changed in fc are invalidated again when the transaction ends, so that the ones read before the commit are not kept.
+// example: permify.Transaction(func(ctx context.Context) error { return permify.AddRolesToUserCtx(ctx, 1, "admin") })
+// @param func(ctx context.Context) error
+// @return error
+func (s *Permify) Transaction(fc func(ctx context.Context) error) (err error) {
+	return s.TransactionCtx(context.Background(), fc)
+}
+
+// TransactionCtx is the context-aware variant of Transaction.
+// @param context.Context
+// @param func(ctx context.Context) error
+// @return error
+func (s *Permify) TransactionCtx(ctx context.Context, fc func(ctx context.Context) error) (err error) {
+	return s.transaction(ctx, fc)
+}
+
+// guardName converts the name to guard name. The prefixes of the grant permissions are kept. (see ActingAs)
+// @param string
+// @return string
+func (s *Permify) guardName(name string) string {
+	for _, prefix := range []string{grantRolePrefix, grantPermissionPrefix} {
+		if strings.HasPrefix(name, prefix) {
+			return prefix + s.guardName(strings.TrimPrefix(name, prefix))
+		}
+	}
+	if s.guard == nil {
+		return helpers.Guard(name)
+	}
+	return s.guard(name)
+}
+
+// guardNames converts the names to guard names.
+// @param []string
+// @return []string
+func (s *Permify) guardNames(names []string) (guardNames []string) {
+	for _, name := range names {
+		guardNames = append(guardNames, s.guardName(name))
+	}
+	return
+}
+
+// assignment returns the scope of the user assignments.
+// @return repositories_scopes.Assignment
+func (s *Permify) assignment() scopes.Assignment {
+	return scopes.Assignment{
+		TenantID:     s.tenantID,
+		ResourceType: s.resourceType,
+		ResourceID:   s.resourceID,
+		Window:       s.window,
+	}
+}
+
+// parseSubject converts the user given to the user methods to the subject, its id is converted to the UserIDType.
+// The ids that are not given as a Subject are the ids of the users, they are of the DefaultSubjectType.
+// @param interface{}
+// @return models.Subject, error
+func (s *Permify) parseSubject(user interface{}) (models.Subject, error) {
+	subjectType := models.DefaultSubjectType
+	switch value := user.(type) {
+	case Subject:
+		subjectType, user = value.Type, value.ID
+	case models.Subject:
+		subjectType, user = value.Type, value.ID
+	}
+	if subjectType == "" {
+		subjectType = models.DefaultSubjectType
+	}
+
+	userID, ok := s.userIDType.Parse(user)
+	if !ok || len(subjectType) > 255 {
+		return models.Subject{}, ErrUnsupportedIdentifier
+	}
+	return models.Subject{Type: subjectType, ID: userID}, nil
+}
+
+// parseSubjects converts the users given to the multi-user methods to subjects. It can be an array of user ids or Subjects, or a single one.
+// @param interface{}
+// @return []models.Subject, error
+func (s *Permify) parseSubjects(users interface{}) (subjects []models.Subject, err error) {
+	value := reflect.ValueOf(users)
+	if value.Kind() != reflect.Slice {
+		var subject models.Subject
+		if subject, err = s.parseSubject(users); err != nil {
+			return nil, err
+		}
+		return []models.Subject{subject}, nil
+	}
+
+	for i := 0; i < value.Len(); i++ {
+		var subject models.Subject
+		if subject, err = s.parseSubject(value.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, subject)
+	}
+	return
+}
+
+// foundSubjects converts the ids of the subjects found by the repositories to the UserIDType, so that they equal the parsed ones.
+// The integer ids that the driver scans as text are scanned as integers, so a string id that is an integer has to be converted back.
+// @param []models.Subject
+// @return []models.Subject
+func (s *Permify) foundSubjects(subjects []models.Subject) []models.Subject {
+	for i, subject := range subjects {
+		if userID, ok := s.userIDType.Parse(subject.ID); ok {
+			subjects[i].ID = userID
+		}
+	}
+	return subjects
 }
 
 // ROLE
@@ -61,9 +340,25 @@ type Permify struct {
 // @param bool
 // @return models.Role, error
 func (s *Permify) GetRole(r interface{}, withPermissions bool) (role models.Role, err error) {
-	if helpers.IsArray(r) {
+	return s.GetRoleCtx(context.Background(), r, withPermissions)
+}
+
+// GetRoleCtx is the context-aware variant of GetRole.
+// @param context.Context
+// @param interface{}
+// @param bool
+// @return models.Role, error
+func (s *Permify) GetRoleCtx(ctx context.Context, r interface{}, withPermissions bool) (role models.Role, err error) {
+	defer wrapNotFoundError(&err, "GetRole", r, ErrRoleNotFound)
+
+	ref := roleRefOf(r)
+	if ref.err != nil {
+		return models.Role{}, ref.err
+	}
+
+	if !ref.one {
 		var roles []models.Role
-		roles, err = s.GetRoles(r, withPermissions)
+		roles, err = s.GetRolesCtx(ctx, r, withPermissions)
 		if err != nil {
 			return models.Role{}, err
 		}
@@ -73,28 +368,17 @@ func (s *Permify) GetRole(r interface{}, withPermissions bool) (role models.Role
 		return
 	}
 
-	if helpers.IsString(r) {
-		if withPermissions {
-			return s.RoleRepository.GetRoleByGuardNameWithPermissions(helpers.Guard(r.(string)))
-		}
-		return s.RoleRepository.GetRoleByGuardName(helpers.Guard(r.(string)))
-	}
-
-	if helpers.IsInt(r) {
+	if ref.byName {
 		if withPermissions {
-			return s.RoleRepository.GetRoleByIDWithPermissions(uint(r.(int)))
+			return s.RoleRepository.GetRoleByGuardNameWithPermissions(ctx, s.guardName(ref.names[0]))
 		}
-		return s.RoleRepository.GetRoleByID(uint(r.(int)))
+		return s.RoleRepository.GetRoleByGuardName(ctx, s.guardName(ref.names[0]))
 	}
 
-	if helpers.IsUInt(r) {
-		if withPermissions {
-			return s.RoleRepository.GetRoleByIDWithPermissions(r.(uint))
-		}
-		return s.RoleRepository.GetRoleByID(r.(uint))
+	if withPermissions {
+		return s.RoleRepository.GetRoleByIDWithPermissions(ctx, ref.ids[0])
 	}
-
-	return models.Role{}, errUnsupportedValueType
+	return s.RoleRepository.GetRoleByID(ctx, ref.ids[0])
 }
 
 // GetRoles fetch roles according to the role names or ids.
@@ -104,9 +388,25 @@ func (s *Permify) GetRole(r interface{}, withPermissions bool) (role models.Role
 // @param bool
 // @return collections.Role, error
 func (s *Permify) GetRoles(r interface{}, withPermissions bool) (roles collections.Role, err error) {
-	if !helpers.IsArray(r) {
+	return s.GetRolesCtx(context.Background(), r, withPermissions)
+}
+
+// GetRolesCtx is the context-aware variant of GetRoles.
+// @param context.Context
+// @param interface{}
+// @param bool
+// @return collections.Role, error
+func (s *Permify) GetRolesCtx(ctx context.Context, r interface{}, withPermissions bool) (roles collections.Role, err error) {
+	defer wrapError(&err, "GetRoles", r)
+
+	ref := roleRefOf(r)
+	if ref.err != nil {
+		return collections.Role{}, ref.err
+	}
+
+	if ref.one {
 		var role models.Role
-		role, err = s.GetRole(r, withPermissions)
+		role, err = s.GetRoleCtx(ctx, r, withPermissions)
 		if err != nil {
 			return collections.Role{}, err
 		}
@@ -114,21 +414,17 @@ func (s *Permify) GetRoles(r interface{}, withPermissions bool) (roles collectio
 		return
 	}
 
-	if helpers.IsStringArray(r) {
+	if ref.byName {
 		if withPermissions {
-			return s.RoleRepository.GetRolesByGuardNamesWithPermissions(helpers.GuardArray(r.([]string)))
+			return s.RoleRepository.GetRolesByGuardNamesWithPermissions(ctx, s.guardNames(ref.names))
 		}
-		return s.RoleRepository.GetRolesByGuardNames(helpers.GuardArray(r.([]string)))
+		return s.RoleRepository.GetRolesByGuardNames(ctx, s.guardNames(ref.names))
 	}
 
-	if helpers.IsUIntArray(r) {
-		if withPermissions {
-			return s.RoleRepository.GetRolesWithPermissions(r.([]uint))
-		}
-		return s.RoleRepository.GetRoles(r.([]uint))
+	if withPermissions {
+		return s.RoleRepository.GetRolesWithPermissions(ctx, ref.ids)
 	}
-
-	return collections.Role{}, errUnsupportedValueType
+	return s.RoleRepository.GetRoles(ctx, ref.ids)
 }
 
 // GetAllRoles fetch all the roles. (with pagination option).
@@ -137,35 +433,93 @@ func (s *Permify) GetRoles(r interface{}, withPermissions bool) (roles collectio
 // @param options.RoleOption
 // @return collections.Role, int64, error
 func (s *Permify) GetAllRoles(option options.RoleOption) (roles collections.Role, totalCount int64, err error) {
+	return s.GetAllRolesCtx(context.Background(), option)
+}
+
+// GetAllRolesCtx is the context-aware variant of GetAllRoles.
+// @param context.Context
+// @param options.RoleOption
+// @return collections.Role, int64, error
+func (s *Permify) GetAllRolesCtx(ctx context.Context, option options.RoleOption) (roles collections.Role, totalCount int64, err error) {
+	defer wrapError(&err, "GetAllRoles", nil)
+
 	var roleIDs []uint
 	if option.Pagination == nil {
-		roleIDs, totalCount, err = s.RoleRepository.GetRoleIDs(nil)
+		roleIDs, totalCount, err = s.RoleRepository.GetRoleIDs(ctx, nil)
 	} else {
-		roleIDs, totalCount, err = s.RoleRepository.GetRoleIDs(&scopes.GormPagination{Pagination: option.Pagination.Get()})
+		roleIDs, totalCount, err = s.RoleRepository.GetRoleIDs(ctx, &scopes.GormPagination{Pagination: option.Pagination.Get()})
 	}
 
-	roles, err = s.GetRoles(roleIDs, option.WithPermissions)
+	roles, err = s.GetRolesCtx(ctx, roleIDs, option.WithPermissions)
 	return
 }
 
 // GetRolesOfUser fetch all the roles of the user. (with pagination option).
 // If withPermissions is true, it will preload the permissions to the role.
 // First parameter is user id, second parameter is role option.
-// @param uint
+// @param interface{}
+// @param options.RoleOption
+// @return collections.Role, int64, error
+func (s *Permify) GetRolesOfUser(user interface{}, option options.RoleOption) (roles collections.Role, totalCount int64, err error) {
+	return s.GetRolesOfUserCtx(context.Background(), user, option)
+}
+
+// GetRolesOfUserCtx is the context-aware variant of GetRolesOfUser.
+// @param context.Context
+// @param interface{}
 // @param options.RoleOption
 // @return collections.Role, int64, error
-func (s *Permify) GetRolesOfUser(userID uint, option options.RoleOption) (roles collections.Role, totalCount int64, err error) {
+func (s *Permify) GetRolesOfUserCtx(ctx context.Context, user interface{}, option options.RoleOption) (roles collections.Role, totalCount int64, err error) {
+	defer wrapError(&err, "GetRolesOfUser", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
 	var roleIDs []uint
 	if option.Pagination == nil {
-		roleIDs, totalCount, err = s.RoleRepository.GetRoleIDsOfUser(userID, nil)
+		roleIDs, totalCount, err = s.RoleRepository.GetRoleIDsOfUser(ctx, subject, s.assignment(), nil)
 	} else {
-		roleIDs, totalCount, err = s.RoleRepository.GetRoleIDsOfUser(userID, &scopes.GormPagination{Pagination: option.Pagination.Get()})
+		roleIDs, totalCount, err = s.RoleRepository.GetRoleIDsOfUser(ctx, subject, s.assignment(), &scopes.GormPagination{Pagination: option.Pagination.Get()})
 	}
 
-	roles, err = s.GetRoles(roleIDs, option.WithPermissions)
+	roles, err = s.GetRolesCtx(ctx, roleIDs, option.WithPermissions)
 	return
 }
 
+// GetUserIDsOfRole fetch the users that have the role. (with pagination option)
+// The users are the subjects of every type, ordered by subject type and id. The roles of the users that inherit the role are not included, like UserHasRole.
+// First parameter is can be role name or id, second parameter is user option.
+// @param interface{}
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (s *Permify) GetUserIDsOfRole(r interface{}, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	return s.GetUserIDsOfRoleCtx(context.Background(), r, option)
+}
+
+// GetUserIDsOfRoleCtx is the context-aware variant of GetUserIDsOfRole.
+// @param context.Context
+// @param interface{}
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (s *Permify) GetUserIDsOfRoleCtx(ctx context.Context, r interface{}, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	defer wrapError(&err, "GetUserIDsOfRole", r)
+
+	var role models.Role
+	role, err = s.GetRoleCtx(ctx, r, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if option.Pagination == nil {
+		subjects, totalCount, err = s.UserRepository.GetUserIDsOfRole(ctx, role.ID, s.assignment(), nil)
+	} else {
+		subjects, totalCount, err = s.UserRepository.GetUserIDsOfRole(ctx, role.ID, s.assignment(), &scopes.GormPagination{Pagination: option.Pagination.Get()})
+	}
+	return s.foundSubjects(subjects), totalCount, err
+}
+
 // CreateRole create new role.
 // Name parameter is converted to guard name. example: senior $#% associate -> senior-associate.
 // If a role with the same name has been created before, it will not create it again. (FirstOrCreate)
@@ -174,25 +528,64 @@ func (s *Permify) GetRolesOfUser(userID uint, option options.RoleOption) (roles
 // @param string
 // @return error
 func (s *Permify) CreateRole(name string, description string) (err error) {
-	return s.RoleRepository.FirstOrCreate(&models.Role{
+	return s.CreateRoleCtx(context.Background(), name, description)
+}
+
+// CreateRoleCtx is the context-aware variant of CreateRole.
+// @param context.Context
+// @param string
+// @param string
+// @return error
+func (s *Permify) CreateRoleCtx(ctx context.Context, name string, description string) (err error) {
+	defer wrapError(&err, "CreateRole", name)
+
+	return s.createRole(ctx, &models.Role{
 		Name:        name,
-		GuardName:   helpers.Guard(name),
+		GuardName:   s.guardName(name),
 		Description: description,
 	})
 }
 
+// createRole creates the role if there is no role with its guard name, the role is replaced by the created or the existing role.
+// @param context.Context
+// @param *models.Role
+// @return error
+func (s *Permify) createRole(ctx context.Context, role *models.Role) error {
+	return s.audited(ctx, models.AuditEntry{Operation: "CreateRole", Role: role.GuardName, Kind: models.AuditRole}, s.roleNames(role.GuardName), func(ctx context.Context) error {
+		return s.RoleRepository.FirstOrCreate(ctx, role)
+	})
+}
+
 // DeleteRole delete role.
 // If the role is in use, its relations from the pivot tables are deleted.
 // First parameter can be role name or id.
 // @param interface{}
 // @return error
 func (s *Permify) DeleteRole(r interface{}) (err error) {
+	return s.DeleteRoleCtx(context.Background(), r)
+}
+
+// DeleteRoleCtx is the context-aware variant of DeleteRole.
+// @param context.Context
+// @param interface{}
+// @return error
+func (s *Permify) DeleteRoleCtx(ctx context.Context, r interface{}) (err error) {
+	defer wrapError(&err, "DeleteRole", r)
+
 	var role models.Role
-	role, err = s.GetRole(r, false)
+	role, err = s.GetRoleCtx(ctx, r, false)
 	if err != nil {
 		return err
 	}
-	return s.RoleRepository.Delete(&role)
+
+	if err = s.checkRemoval(ctx, []uint{role.ID}); err != nil {
+		return err
+	}
+
+	defer s.forgetAll(ctx)
+	return s.audited(ctx, s.roleAuditEntry("DeleteRole", role, models.AuditRole), s.roleNames(role.GuardName), func(ctx context.Context) error {
+		return s.RoleRepository.Delete(ctx, &role)
+	})
 }
 
 // AddPermissionsToRole add permission to role.
@@ -202,25 +595,67 @@ func (s *Permify) DeleteRole(r interface{}) (err error) {
 // @param interface{}
 // @return error
 func (s *Permify) AddPermissionsToRole(r interface{}, p interface{}) (err error) {
+	return s.AddPermissionsToRoleCtx(context.Background(), r, p)
+}
+
+// AddPermissionsToRoleCtx is the context-aware variant of AddPermissionsToRole.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) AddPermissionsToRoleCtx(ctx context.Context, r interface{}, p interface{}) (err error) {
+	defer wrapError(&err, "AddPermissionsToRole", r)
+
 	var role models.Role
-	role, err = s.GetRole(r, false)
+	role, err = s.GetRoleCtx(ctx, r, false)
 	if err != nil {
 		return err
 	}
 
 	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
+	permissions, err = s.GetPermissionsCtx(ctx, p)
 	if err != nil {
 		return err
 	}
 
+	if err = s.checkGrant(ctx, nil, permissions); err != nil {
+		return err
+	}
+
 	if permissions.Len() > 0 {
-		err = s.RoleRepository.AddPermissions(&role, permissions)
+		err = s.audited(ctx, s.roleAuditEntry("AddPermissionsToRole", role, models.AuditPermissions), s.rolePermissionNames(role, false), func(ctx context.Context) error {
+			return s.RoleRepository.AddPermissions(ctx, &role, permissions, s.window)
+		})
+		s.forgetRoles(ctx, role.ID)
 	}
 
 	return
 }
 
+// AddPermissionsToRoleUntil add permission to role until the expiry time.
+// First parameter can be role name or id, second parameter can be permission name(s) or id(s), third parameter is the expiry time.
+// The start set with Between is kept.
+// If the first parameter is an array, the first element of the first parameter is used.
+// @param interface{}
+// @param interface{}
+// @param time.Time
+// @return error
+func (s *Permify) AddPermissionsToRoleUntil(r interface{}, p interface{}, expiresAt time.Time) (err error) {
+	return s.AddPermissionsToRoleUntilCtx(context.Background(), r, p, expiresAt)
+}
+
+// AddPermissionsToRoleUntilCtx is the context-aware variant of AddPermissionsToRoleUntil.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @param time.Time
+// @return error
+func (s *Permify) AddPermissionsToRoleUntilCtx(ctx context.Context, r interface{}, p interface{}, expiresAt time.Time) (err error) {
+	defer wrapError(&err, "AddPermissionsToRoleUntil", r)
+
+	return s.until(expiresAt).AddPermissionsToRoleCtx(ctx, r, p)
+}
+
 // ReplacePermissionsToRole overwrites the permissions of the role according to the permission names or ids.
 // First parameter can be role name or id, second parameter can be permission name(s) or id(s).
 // If the first parameter is an array, the first element of the first parameter is used.
@@ -228,23 +663,42 @@ func (s *Permify) AddPermissionsToRole(r interface{}, p interface{}) (err error)
 // @param interface{}
 // @return error
 func (s *Permify) ReplacePermissionsToRole(r interface{}, p interface{}) (err error) {
+	return s.ReplacePermissionsToRoleCtx(context.Background(), r, p)
+}
+
+// ReplacePermissionsToRoleCtx is the context-aware variant of ReplacePermissionsToRole.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) ReplacePermissionsToRoleCtx(ctx context.Context, r interface{}, p interface{}) (err error) {
+	defer wrapError(&err, "ReplacePermissionsToRole", r)
+
 	var role models.Role
-	role, err = s.GetRole(r, false)
+	role, err = s.GetRoleCtx(ctx, r, false)
 	if err != nil {
 		return err
 	}
 
 	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
+	permissions, err = s.GetPermissionsCtx(ctx, p)
 	if err != nil {
 		return err
 	}
 
-	if permissions.Len() > 0 {
-		return s.RoleRepository.ReplacePermissions(&role, permissions)
+	if err = s.checkGrant(ctx, nil, permissions); err != nil {
+		return err
 	}
 
-	return s.RoleRepository.ClearPermissions(&role)
+	defer s.forgetRoles(ctx, role.ID)
+
+	return s.audited(ctx, s.roleAuditEntry("ReplacePermissionsToRole", role, models.AuditPermissions), s.rolePermissionNames(role, false), func(ctx context.Context) error {
+		if permissions.Len() > 0 {
+			return s.RoleRepository.ReplacePermissions(ctx, &role, permissions, s.window)
+		}
+
+		return s.RoleRepository.ClearPermissions(ctx, &role)
+	})
 }
 
 // RemovePermissionsFromRole remove permissions from role according to the permission names or ids.
@@ -254,591 +708,2255 @@ func (s *Permify) ReplacePermissionsToRole(r interface{}, p interface{}) (err er
 // @param interface{}
 // @return error
 func (s *Permify) RemovePermissionsFromRole(r interface{}, p interface{}) (err error) {
+	return s.RemovePermissionsFromRoleCtx(context.Background(), r, p)
+}
+
+// RemovePermissionsFromRoleCtx is the context-aware variant of RemovePermissionsFromRole.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RemovePermissionsFromRoleCtx(ctx context.Context, r interface{}, p interface{}) (err error) {
+	defer wrapError(&err, "RemovePermissionsFromRole", r)
+
 	var role models.Role
-	role, err = s.GetRole(r, false)
+	role, err = s.GetRoleCtx(ctx, r, false)
 	if err != nil {
 		return err
 	}
 
 	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
+	permissions, err = s.GetPermissionsCtx(ctx, p)
 	if err != nil {
 		return err
 	}
 
 	if permissions.Len() > 0 {
-		err = s.RoleRepository.RemovePermissions(&role, permissions)
+		err = s.audited(ctx, s.roleAuditEntry("RemovePermissionsFromRole", role, models.AuditPermissions), s.rolePermissionNames(role, false), func(ctx context.Context) error {
+			return s.RoleRepository.RemovePermissions(ctx, &role, permissions)
+		})
+		s.forgetRoles(ctx, role.ID)
 	}
 
 	return
 }
 
-// PERMISSION
+// DenyPermissionsToRole deny permissions to role according to the permission names or ids.
+// The denied permissions block the permissions for the users of the role. (see ConflictResolution)
+// First parameter can be role name or id, second parameter can be permission name(s) or id(s).
+// If the first parameter is an array, the first element of the first parameter is used.
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) DenyPermissionsToRole(r interface{}, p interface{}) (err error) {
+	return s.DenyPermissionsToRoleCtx(context.Background(), r, p)
+}
 
-// GetPermission fetch permission according to the permission name or id.
-// First parameter can be permission name or id.
-// If the first parameter is an array, the first element of the given array is returned.
+// DenyPermissionsToRoleCtx is the context-aware variant of DenyPermissionsToRole.
+// @param context.Context
+// @param interface{}
 // @param interface{}
 // @return error
-func (s *Permify) GetPermission(p interface{}) (permission models.Permission, err error) {
-	if helpers.IsArray(p) {
-		var permissions []models.Permission
-		permissions, err = s.GetPermissions(p)
-		if err != nil {
-			return models.Permission{}, err
-		}
-		if len(permissions) > 0 {
-			permission = permissions[0]
-		}
-		return
-	}
+func (s *Permify) DenyPermissionsToRoleCtx(ctx context.Context, r interface{}, p interface{}) (err error) {
+	defer wrapError(&err, "DenyPermissionsToRole", r)
 
-	if helpers.IsString(p) {
-		return s.PermissionRepository.GetPermissionByGuardName(helpers.Guard(p.(string)))
+	var role models.Role
+	role, err = s.GetRoleCtx(ctx, r, false)
+	if err != nil {
+		return err
 	}
 
-	if helpers.IsInt(p) {
-		return s.PermissionRepository.GetPermissionByID(uint(p.(int)))
+	var permissions collections.Permission
+	permissions, err = s.GetPermissionsCtx(ctx, p)
+	if err != nil {
+		return err
 	}
 
-	if helpers.IsUInt(p) {
-		return s.PermissionRepository.GetPermissionByID(p.(uint))
+	if permissions.Len() > 0 {
+		err = s.audited(ctx, s.roleAuditEntry("DenyPermissionsToRole", role, models.AuditDeniedPermissions), s.rolePermissionNames(role, true), func(ctx context.Context) error {
+			return s.RoleRepository.DenyPermissions(ctx, &role, permissions, s.window)
+		})
+		s.forgetRoles(ctx, role.ID)
 	}
 
-	return models.Permission{}, errUnsupportedValueType
+	return
+}
+
+// RemoveDeniedPermissionsFromRole remove denied permissions from role according to the permission names or ids.
+// First parameter can be role name or id, second parameter can be permission name(s) or id(s).
+// If the first parameter is an array, the first element of the first parameter is used.
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RemoveDeniedPermissionsFromRole(r interface{}, p interface{}) (err error) {
+	return s.RemoveDeniedPermissionsFromRoleCtx(context.Background(), r, p)
 }
 
-// GetPermissions fetch permissions according to the permission names or ids.
-// First parameter is can be permission name(s) or id(s).
+// RemoveDeniedPermissionsFromRoleCtx is the context-aware variant of RemoveDeniedPermissionsFromRole.
+// @param context.Context
 // @param interface{}
-// @return collections.Permission, error
-func (s *Permify) GetPermissions(p interface{}) (permissions collections.Permission, err error) {
-	if !helpers.IsArray(p) {
-		var permission models.Permission
-		permission, err = s.GetPermission(p)
-		if err != nil {
-			return collections.Permission{}, err
-		}
-		permissions = collections.Permission{permission}
-		return
+// @param interface{}
+// @return error
+func (s *Permify) RemoveDeniedPermissionsFromRoleCtx(ctx context.Context, r interface{}, p interface{}) (err error) {
+	defer wrapError(&err, "RemoveDeniedPermissionsFromRole", r)
+
+	var role models.Role
+	role, err = s.GetRoleCtx(ctx, r, false)
+	if err != nil {
+		return err
+	}
+
+	var permissions collections.Permission
+	permissions, err = s.GetPermissionsCtx(ctx, p)
+	if err != nil {
+		return err
 	}
 
-	if helpers.IsStringArray(p) {
-		return s.PermissionRepository.GetPermissionsByGuardNames(helpers.GuardArray(p.([]string)))
+	// removing a denied permission gives it back, so it is a grant too.
+	if err = s.checkGrant(ctx, nil, permissions); err != nil {
+		return err
 	}
 
-	if helpers.IsUIntArray(p) {
-		return s.PermissionRepository.GetPermissions(p.([]uint))
+	if permissions.Len() > 0 {
+		err = s.audited(ctx, s.roleAuditEntry("RemoveDeniedPermissionsFromRole", role, models.AuditDeniedPermissions), s.rolePermissionNames(role, true), func(ctx context.Context) error {
+			return s.RoleRepository.RemoveDeniedPermissions(ctx, &role, permissions)
+		})
+		s.forgetRoles(ctx, role.ID)
 	}
 
-	return collections.Permission{}, errUnsupportedValueType
+	return
 }
 
-// GetAllPermissions fetch all the permissions. (with pagination option).
+// AddChildRolesToRole add child roles to role. The role inherits the permissions of its child roles transitively.
+// If one of the child roles already inherits the role, it returns *CircularInheritanceError.
+// First parameter can be role name or id, second parameter can be role name(s) or id(s).
+// If the first parameter is an array, the first element of the first parameter is used.
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) AddChildRolesToRole(r interface{}, c interface{}) (err error) {
+	return s.AddChildRolesToRoleCtx(context.Background(), r, c)
+}
+
+// AddChildRolesToRoleCtx is the context-aware variant of AddChildRolesToRole.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) AddChildRolesToRoleCtx(ctx context.Context, r interface{}, c interface{}) (err error) {
+	defer wrapError(&err, "AddChildRolesToRole", r)
+
+	var role models.Role
+	role, err = s.GetRoleCtx(ctx, r, false)
+	if err != nil {
+		return err
+	}
+
+	var children collections.Role
+	children, err = s.GetRolesCtx(ctx, c, false)
+	if err != nil {
+		return err
+	}
+
+	if err = s.checkGrant(ctx, children, nil); err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		var inheritedRoleIDs []uint
+		inheritedRoleIDs, err = s.withInheritedRoleIDs(ctx, []uint{child.ID})
+		if err != nil {
+			return err
+		}
+		if helpers.InArray(role.ID, inheritedRoleIDs) {
+			return &CircularInheritanceError{Role: role.GuardName, Child: child.GuardName}
+		}
+	}
+
+	if children.Len() > 0 {
+		err = s.audited(ctx, s.roleAuditEntry("AddChildRolesToRole", role, models.AuditChildRoles), s.childRoleNames(role), func(ctx context.Context) error {
+			return s.RoleRepository.AddChildren(ctx, &role, children)
+		})
+		s.forgetRoles(ctx, role.ID)
+	}
+
+	return
+}
+
+// RemoveChildRolesFromRole remove child roles from role according to the role names or ids.
+// First parameter can be role name or id, second parameter can be role name(s) or id(s).
+// If the first parameter is an array, the first element of the first parameter is used.
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RemoveChildRolesFromRole(r interface{}, c interface{}) (err error) {
+	return s.RemoveChildRolesFromRoleCtx(context.Background(), r, c)
+}
+
+// RemoveChildRolesFromRoleCtx is the context-aware variant of RemoveChildRolesFromRole.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RemoveChildRolesFromRoleCtx(ctx context.Context, r interface{}, c interface{}) (err error) {
+	defer wrapError(&err, "RemoveChildRolesFromRole", r)
+
+	var role models.Role
+	role, err = s.GetRoleCtx(ctx, r, false)
+	if err != nil {
+		return err
+	}
+
+	var children collections.Role
+	children, err = s.GetRolesCtx(ctx, c, false)
+	if err != nil {
+		return err
+	}
+
+	if err = s.checkRemoval(ctx, children.IDs()); err != nil {
+		return err
+	}
+
+	if children.Len() > 0 {
+		err = s.audited(ctx, s.roleAuditEntry("RemoveChildRolesFromRole", role, models.AuditChildRoles), s.childRoleNames(role), func(ctx context.Context) error {
+			return s.RoleRepository.RemoveChildren(ctx, &role, children)
+		})
+		s.forgetRoles(ctx, role.ID)
+	}
+
+	return
+}
+
+// withInheritedRoleIDs returns the given role ids together with the ids of all the roles they inherit, transitively.
+// @param context.Context
+// @param []uint
+// @return []uint, error
+func (s *Permify) withInheritedRoleIDs(ctx context.Context, roleIDs []uint) (allRoleIDs []uint, err error) {
+	allRoleIDs = helpers.RemoveDuplicateValues(roleIDs)
+	parentRoleIDs := allRoleIDs
+	for len(parentRoleIDs) > 0 {
+		var childRoleIDs []uint
+		childRoleIDs, err = s.RoleRepository.GetChildRoleIDs(ctx, parentRoleIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		parentRoleIDs = []uint{}
+		for _, childRoleID := range childRoleIDs {
+			if !helpers.InArray(childRoleID, allRoleIDs) {
+				allRoleIDs = append(allRoleIDs, childRoleID)
+				parentRoleIDs = append(parentRoleIDs, childRoleID)
+			}
+		}
+	}
+	return
+}
+
+// withInheritingRoleIDs returns the given role ids together with the ids of all the roles that inherit them, transitively.
+// @param context.Context
+// @param []uint
+// @return []uint, error
+func (s *Permify) withInheritingRoleIDs(ctx context.Context, roleIDs []uint) (allRoleIDs []uint, err error) {
+	allRoleIDs = helpers.RemoveDuplicateValues(roleIDs)
+	childRoleIDs := allRoleIDs
+	for len(childRoleIDs) > 0 {
+		var parentRoleIDs []uint
+		parentRoleIDs, err = s.RoleRepository.GetParentRoleIDs(ctx, childRoleIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		childRoleIDs = []uint{}
+		for _, parentRoleID := range parentRoleIDs {
+			if !helpers.InArray(parentRoleID, allRoleIDs) {
+				allRoleIDs = append(allRoleIDs, parentRoleID)
+				childRoleIDs = append(childRoleIDs, parentRoleID)
+			}
+		}
+	}
+	return
+}
+
+// roleIDsOfPermissions returns the ids of the roles that are returned by the getter for any of the permissions, together with the roles that inherit them.
+// example: s.roleIDsOfPermissions(ctx, permissionIDs, s.RoleRepository.GetRoleIDsOfPermission)
+// @param context.Context
+// @param []uint
+// @param func(context.Context, uint, repositories_scopes.GormPager) ([]uint, int64, error)
+// @return []uint, error
+func (s *Permify) roleIDsOfPermissions(ctx context.Context, permissionIDs []uint, get func(ctx context.Context, permissionID uint, pagination scopes.GormPager) ([]uint, int64, error)) (roleIDs []uint, err error) {
+	for _, permissionID := range permissionIDs {
+		var permissionRoleIDs []uint
+		permissionRoleIDs, _, err = get(ctx, permissionID, nil)
+		if err != nil {
+			return nil, err
+		}
+		roleIDs = append(roleIDs, permissionRoleIDs...)
+	}
+	return s.withInheritingRoleIDs(ctx, roleIDs)
+}
+
+// permissionHolders returns the holders that select the users who have the permission. (including the permissions of the roles)
+// The granting permissions, the roles that inherit them and the denies are resolved with the conflict resolution strategy.
+// @param context.Context
+// @param models.Permission
+// @return repositories.PermissionHolders, error
+func (s *Permify) permissionHolders(ctx context.Context, permission models.Permission) (holders repositories.PermissionHolders, err error) {
+	var grantingPermissions map[uint]collections.Permission
+	grantingPermissions, err = s.grantingPermissions(ctx, collections.Permission{permission})
+	if err != nil {
+		return repositories.PermissionHolders{}, err
+	}
+
+	holders.PermissionIDs = grantingPermissions[permission.ID].IDs()
+	holders.RoleIDs, err = s.roleIDsOfPermissions(ctx, holders.PermissionIDs, s.RoleRepository.GetRoleIDsOfPermission)
+	if err != nil {
+		return repositories.PermissionHolders{}, err
+	}
+
+	if s.conflictResolution != AllowOverrides {
+		holders.DeniedPermissionIDs = holders.PermissionIDs
+		holders.DeniedRoleIDs, err = s.roleIDsOfPermissions(ctx, holders.PermissionIDs, s.RoleRepository.GetRoleIDsOfDeniedPermission)
+		if err != nil {
+			return repositories.PermissionHolders{}, err
+		}
+		holders.DirectOverridesRoles = s.conflictResolution == MostSpecificWins
+	}
+
+	return
+}
+
+// withInheritedRoles returns the given roles together with all the roles they inherit, transitively.
+// @param context.Context
+// @param collections.Role
+// @return collections.Role, error
+func (s *Permify) withInheritedRoles(ctx context.Context, roles collections.Role) (allRoles collections.Role, err error) {
+	var allRoleIDs []uint
+	allRoleIDs, err = s.withInheritedRoleIDs(ctx, roles.IDs())
+	if err != nil {
+		return collections.Role{}, err
+	}
+
+	var inheritedRoleIDs []uint
+	for _, roleID := range allRoleIDs {
+		if !helpers.InArray(roleID, roles.IDs()) {
+			inheritedRoleIDs = append(inheritedRoleIDs, roleID)
+		}
+	}
+
+	if len(inheritedRoleIDs) == 0 {
+		return roles, nil
+	}
+
+	var inheritedRoles collections.Role
+	inheritedRoles, err = s.RoleRepository.GetRoles(ctx, inheritedRoleIDs)
+	if err != nil {
+		return collections.Role{}, err
+	}
+
+	return append(roles, inheritedRoles...), nil
+}
+
+// grantingPermissions returns the permissions that grant each of the given permissions, keyed by permission id.
+// A permission is granted by itself, and by its wildcard ancestors if wildcard matching is enabled. example: posts.edit is granted by posts.* and *.
+// @param context.Context
+// @param collections.Permission
+// @return map[uint]collections.Permission, error
+func (s *Permify) grantingPermissions(ctx context.Context, permissions collections.Permission) (granting map[uint]collections.Permission, err error) {
+	granting = make(map[uint]collections.Permission)
+	for _, permission := range permissions {
+		granting[permission.ID] = collections.Permission{permission}
+	}
+
+	if !s.wildcard || permissions.Len() == 0 {
+		return
+	}
+
+	var wildcardGuardNames []string
+	for _, permission := range permissions {
+		wildcardGuardNames = append(wildcardGuardNames, helpers.WildcardGuards(permission.GuardName)[1:]...)
+	}
+
+	if len(wildcardGuardNames) == 0 {
+		return
+	}
+
+	var wildcardPermissions collections.Permission
+	wildcardPermissions, err = s.PermissionRepository.GetPermissionsByGuardNames(ctx, wildcardGuardNames)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, permission := range permissions {
+		guardNames := helpers.WildcardGuards(permission.GuardName)[1:]
+		for _, wildcardPermission := range wildcardPermissions {
+			if helpers.InArray(wildcardPermission.GuardName, guardNames) {
+				granting[permission.ID] = append(granting[permission.ID], wildcardPermission)
+			}
+		}
+	}
+
+	return
+}
+
+// userPermissionDecisions decides whether the user has each of the permissions, keyed by permission id. (including the permissions of the roles)
+// The allow and deny assignments of the user and the roles of the user are resolved with the conflict resolution strategy.
+// @param context.Context
+// @param models.Subject
+// @param collections.Permission
+// @return map[uint]bool, error
+func (s *Permify) userPermissionDecisions(ctx context.Context, subject models.Subject, permissions collections.Permission) (decisions map[uint]bool, err error) {
+	var grantingPermissions map[uint]collections.Permission
+	grantingPermissions, err = s.grantingPermissions(ctx, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	var assignments permissionAssignments
+	if s.cache != nil {
+		assignments, err = s.cachedPermissionAssignmentsOfUser(ctx, subject)
+	} else {
+		var grantingPermissionIDs []uint
+		for _, permission := range permissions {
+			grantingPermissionIDs = append(grantingPermissionIDs, grantingPermissions[permission.ID].IDs()...)
+		}
+		assignments, err = s.permissionAssignmentsOfUser(ctx, subject, helpers.RemoveDuplicateValues(grantingPermissionIDs))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decisions = make(map[uint]bool)
+	for _, permission := range permissions {
+		grantingPermissionIDs := grantingPermissions[permission.ID].IDs()
+		decisions[permission.ID] = s.conflictResolution.resolve(
+			helpers.AnyInArray(grantingPermissionIDs, assignments.directPermissionIDs),
+			helpers.AnyInArray(grantingPermissionIDs, assignments.rolePermissionIDs),
+			helpers.AnyInArray(grantingPermissionIDs, assignments.directDeniedPermissionIDs),
+			helpers.AnyInArray(grantingPermissionIDs, assignments.roleDeniedPermissionIDs),
+		)
+	}
+
+	return
+}
+
+// permissionAssignments represents the permission ids allowed and denied to a user, directly or via roles.
+type permissionAssignments struct {
+	directPermissionIDs       []uint
+	rolePermissionIDs         []uint
+	directDeniedPermissionIDs []uint
+	roleDeniedPermissionIDs   []uint
+}
+
+// permissionAssignmentsOfUser returns which of the permission ids are allowed and denied to the user, including the inherited roles.
+// The repository answers it in a single query, whatever the number of roles of the user.
+// @param context.Context
+// @param models.Subject
+// @param []uint
+// @return permissionAssignments, error
+func (s *Permify) permissionAssignmentsOfUser(ctx context.Context, subject models.Subject, permissionIDs []uint) (assignments permissionAssignments, err error) {
+	if len(permissionIDs) == 0 {
+		return permissionAssignments{}, nil
+	}
+
+	var effective repositories.EffectivePermissionIDs
+	effective, err = s.PermissionRepository.GetEffectivePermissionIDsOfUser(ctx, subject, s.assignment(), permissionIDs)
+	if err != nil {
+		return permissionAssignments{}, err
+	}
+
+	return permissionAssignments{
+		directPermissionIDs:       effective.DirectPermissionIDs,
+		rolePermissionIDs:         effective.RolePermissionIDs,
+		directDeniedPermissionIDs: effective.DirectDeniedPermissionIDs,
+		roleDeniedPermissionIDs:   effective.RoleDeniedPermissionIDs,
+	}, nil
+}
+
+// PERMISSION
+
+// GetPermission fetch permission according to the permission name or id.
+// First parameter can be permission name or id.
+// If the first parameter is an array, the first element of the given array is returned.
+// @param interface{}
+// @return error
+func (s *Permify) GetPermission(p interface{}) (permission models.Permission, err error) {
+	return s.GetPermissionCtx(context.Background(), p)
+}
+
+// GetPermissionCtx is the context-aware variant of GetPermission.
+// @param context.Context
+// @param interface{}
+// @return error
+func (s *Permify) GetPermissionCtx(ctx context.Context, p interface{}) (permission models.Permission, err error) {
+	defer wrapNotFoundError(&err, "GetPermission", p, ErrPermissionNotFound)
+
+	ref := permissionRefOf(p)
+	if ref.err != nil {
+		return models.Permission{}, ref.err
+	}
+
+	if !ref.one {
+		var permissions []models.Permission
+		permissions, err = s.GetPermissionsCtx(ctx, p)
+		if err != nil {
+			return models.Permission{}, err
+		}
+		if len(permissions) > 0 {
+			permission = permissions[0]
+		}
+		return
+	}
+
+	if ref.byName {
+		return s.PermissionRepository.GetPermissionByGuardName(ctx, s.guardName(ref.names[0]))
+	}
+	return s.PermissionRepository.GetPermissionByID(ctx, ref.ids[0])
+}
+
+// GetPermissions fetch permissions according to the permission names or ids.
+// First parameter is can be permission name(s) or id(s).
+// @param interface{}
+// @return collections.Permission, error
+func (s *Permify) GetPermissions(p interface{}) (permissions collections.Permission, err error) {
+	return s.GetPermissionsCtx(context.Background(), p)
+}
+
+// GetPermissionsCtx is the context-aware variant of GetPermissions.
+// @param context.Context
+// @param interface{}
+// @return collections.Permission, error
+func (s *Permify) GetPermissionsCtx(ctx context.Context, p interface{}) (permissions collections.Permission, err error) {
+	defer wrapError(&err, "GetPermissions", p)
+
+	ref := permissionRefOf(p)
+	if ref.err != nil {
+		return collections.Permission{}, ref.err
+	}
+
+	if ref.one {
+		var permission models.Permission
+		permission, err = s.GetPermissionCtx(ctx, p)
+		if err != nil {
+			return collections.Permission{}, err
+		}
+		permissions = collections.Permission{permission}
+		return
+	}
+
+	if ref.byName {
+		return s.PermissionRepository.GetPermissionsByGuardNames(ctx, s.guardNames(ref.names))
+	}
+	return s.PermissionRepository.GetPermissions(ctx, ref.ids)
+}
+
+// GetAllPermissions fetch all the permissions. (with pagination option).
 // First parameter is permission option.
 // @param options.PermissionOption
 // @return collections.Permission, int64, error
 func (s *Permify) GetAllPermissions(option options.PermissionOption) (permissions collections.Permission, totalCount int64, err error) {
+	return s.GetAllPermissionsCtx(context.Background(), option)
+}
+
+// GetAllPermissionsCtx is the context-aware variant of GetAllPermissions.
+// @param context.Context
+// @param options.PermissionOption
+// @return collections.Permission, int64, error
+func (s *Permify) GetAllPermissionsCtx(ctx context.Context, option options.PermissionOption) (permissions collections.Permission, totalCount int64, err error) {
+	defer wrapError(&err, "GetAllPermissions", nil)
+
+	var permissionIDs []uint
+	if option.Pagination == nil {
+		permissionIDs, totalCount, err = s.PermissionRepository.GetPermissionIDs(ctx, nil)
+	} else {
+		permissionIDs, totalCount, err = s.PermissionRepository.GetPermissionIDs(ctx, &scopes.GormPagination{Pagination: option.Pagination.Get()})
+	}
+	permissions, err = s.GetPermissionsCtx(ctx, permissionIDs)
+	return
+}
+
+// GetDirectPermissionsOfUser fetch all direct permissions of the user. (with pagination option)
+// First parameter is user id, second parameter is permission option.
+// @param interface{}
+// @param options.PermissionOption
+// @return collections.Permission, int64, error
+func (s *Permify) GetDirectPermissionsOfUser(user interface{}, option options.PermissionOption) (permissions collections.Permission, totalCount int64, err error) {
+	return s.GetDirectPermissionsOfUserCtx(context.Background(), user, option)
+}
+
+// GetDirectPermissionsOfUserCtx is the context-aware variant of GetDirectPermissionsOfUser.
+// @param context.Context
+// @param interface{}
+// @param options.PermissionOption
+// @return collections.Permission, int64, error
+func (s *Permify) GetDirectPermissionsOfUserCtx(ctx context.Context, user interface{}, option options.PermissionOption) (permissions collections.Permission, totalCount int64, err error) {
+	defer wrapError(&err, "GetDirectPermissionsOfUser", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var permissionIDs []uint
+	if option.Pagination == nil {
+		permissionIDs, totalCount, err = s.PermissionRepository.GetDirectPermissionIDsOfUserByID(ctx, subject, s.assignment(), nil)
+	} else {
+		permissionIDs, totalCount, err = s.PermissionRepository.GetDirectPermissionIDsOfUserByID(ctx, subject, s.assignment(), &scopes.GormPagination{Pagination: option.Pagination.Get()})
+	}
+	permissions, err = s.GetPermissionsCtx(ctx, permissionIDs)
+	return
+}
+
+// GetPermissionsOfRoles fetch all permissions of the roles. (with pagination option)
+// First parameter can be role name(s) or id(s), second parameter is permission option.
+// @param interface{}
+// @param options.PermissionOption
+// @return collections.Permission, int64, error
+func (s *Permify) GetPermissionsOfRoles(r interface{}, option options.PermissionOption) (permissions collections.Permission, totalCount int64, err error) {
+	return s.GetPermissionsOfRolesCtx(context.Background(), r, option)
+}
+
+// GetPermissionsOfRolesCtx is the context-aware variant of GetPermissionsOfRoles.
+// @param context.Context
+// @param interface{}
+// @param options.PermissionOption
+// @return collections.Permission, int64, error
+func (s *Permify) GetPermissionsOfRolesCtx(ctx context.Context, r interface{}, option options.PermissionOption) (permissions collections.Permission, totalCount int64, err error) {
+	defer wrapError(&err, "GetPermissionsOfRoles", r)
+
+	var roles collections.Role
+	roles, err = s.GetRolesCtx(ctx, r, false)
+	if err != nil {
+		return collections.Permission{}, 0, err
+	}
+
 	var permissionIDs []uint
 	if option.Pagination == nil {
-		permissionIDs, totalCount, err = s.PermissionRepository.GetPermissionIDs(nil)
+		permissionIDs, totalCount, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(ctx, roles.IDs(), nil)
 	} else {
-		permissionIDs, totalCount, err = s.PermissionRepository.GetPermissionIDs(&scopes.GormPagination{Pagination: option.Pagination.Get()})
+		permissionIDs, totalCount, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(ctx, roles.IDs(), &scopes.GormPagination{Pagination: option.Pagination.Get()})
 	}
-	permissions, err = s.GetPermissions(permissionIDs)
+
+	permissions, err = s.GetPermissionsCtx(ctx, permissionIDs)
 	return
 }
 
-// GetDirectPermissionsOfUser fetch all direct permissions of the user. (with pagination option)
-// First parameter is user id, second parameter is permission option.
-// @param uint
-// @param options.PermissionOption
-// @return collections.Permission, int64, error
-func (s *Permify) GetDirectPermissionsOfUser(userID uint, option options.PermissionOption) (permissions collections.Permission, totalCount int64, err error) {
-	var permissionIDs []uint
-	if option.Pagination == nil {
-		permissionIDs, totalCount, err = s.PermissionRepository.GetDirectPermissionIDsOfUserByID(userID, nil)
-	} else {
-		permissionIDs, totalCount, err = s.PermissionRepository.GetDirectPermissionIDsOfUserByID(userID, &scopes.GormPagination{Pagination: option.Pagination.Get()})
+// GetAllPermissionsOfUser fetch all permissions of the user that come with direct and roles.
+// The permissions denied to the user or to its roles are left out, resolved with the conflict resolution strategy like UserHasPermission.
+// First parameter is user id.
+// @param interface{}
+// @return collections.Permission, error
+func (s *Permify) GetAllPermissionsOfUser(user interface{}) (permissions collections.Permission, err error) {
+	return s.GetAllPermissionsOfUserCtx(context.Background(), user)
+}
+
+// GetAllPermissionsOfUserCtx is the context-aware variant of GetAllPermissionsOfUser.
+// @param context.Context
+// @param interface{}
+// @return collections.Permission, error
+func (s *Permify) GetAllPermissionsOfUserCtx(ctx context.Context, user interface{}) (permissions collections.Permission, err error) {
+	defer wrapError(&err, "GetAllPermissionsOfUser", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var userRoleIDs []uint
+	userRoleIDs, _, err = s.RoleRepository.GetRoleIDsOfUser(ctx, subject, s.assignment(), nil)
+	if err != nil {
+		return collections.Permission{}, err
+	}
+
+	userRoleIDs, err = s.withInheritedRoleIDs(ctx, userRoleIDs)
+	if err != nil {
+		return collections.Permission{}, err
+	}
+
+	var rolePermissionIDs []uint
+	rolePermissionIDs, _, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(ctx, userRoleIDs, nil)
+	if err != nil {
+		return collections.Permission{}, err
+	}
+
+	var userDirectPermissionIDs []uint
+	userDirectPermissionIDs, _, err = s.PermissionRepository.GetDirectPermissionIDsOfUserByID(ctx, subject, s.assignment(), nil)
+	if err != nil {
+		return collections.Permission{}, err
+	}
+
+	var granted collections.Permission
+	granted, err = s.GetPermissionsCtx(ctx, helpers.RemoveDuplicateValues(helpers.JoinUintArrays(rolePermissionIDs, userDirectPermissionIDs)))
+	if err != nil || granted.Len() == 0 {
+		return granted, err
+	}
+
+	// the granted permissions are resolved like UserHasPermission, so that the denied ones are left out.
+	var decisions map[uint]bool
+	decisions, err = s.userPermissionDecisions(ctx, subject, granted)
+	if err != nil {
+		return collections.Permission{}, err
+	}
+
+	permissions = collections.Permission{}
+	for _, permission := range granted {
+		if decisions[permission.ID] {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
+
+// GetUserIDsWithDirectPermission fetch the users that have the permission directly. (with pagination option)
+// The users are the subjects of every type, ordered by subject type and id. Like UserHasDirectPermission, the roles, the wildcards and the denies are not taken into account.
+// First parameter is can be permission name or id, second parameter is user option.
+// @param interface{}
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (s *Permify) GetUserIDsWithDirectPermission(p interface{}, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	return s.GetUserIDsWithDirectPermissionCtx(context.Background(), p, option)
+}
+
+// GetUserIDsWithDirectPermissionCtx is the context-aware variant of GetUserIDsWithDirectPermission.
+// @param context.Context
+// @param interface{}
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (s *Permify) GetUserIDsWithDirectPermissionCtx(ctx context.Context, p interface{}, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	defer wrapError(&err, "GetUserIDsWithDirectPermission", p)
+
+	var permission models.Permission
+	permission, err = s.GetPermissionCtx(ctx, p)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if option.Pagination == nil {
+		subjects, totalCount, err = s.UserRepository.GetUserIDsWithDirectPermission(ctx, permission.ID, s.assignment(), nil)
+	} else {
+		subjects, totalCount, err = s.UserRepository.GetUserIDsWithDirectPermission(ctx, permission.ID, s.assignment(), &scopes.GormPagination{Pagination: option.Pagination.Get()})
+	}
+	return s.foundSubjects(subjects), totalCount, err
+}
+
+// GetUserIDsWithPermission fetch the users that have the permission directly or via roles. (with pagination option)
+// The users are the subjects of every type, ordered by subject type and id. They are the users for whom UserHasPermission is true:
+// the inherited roles and the wildcards are taken into account, the denied users are left out according to the conflict resolution.
+// First parameter is can be permission name or id, second parameter is user option.
+// @param interface{}
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (s *Permify) GetUserIDsWithPermission(p interface{}, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	return s.GetUserIDsWithPermissionCtx(context.Background(), p, option)
+}
+
+// GetUserIDsWithPermissionCtx is the context-aware variant of GetUserIDsWithPermission.
+// @param context.Context
+// @param interface{}
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (s *Permify) GetUserIDsWithPermissionCtx(ctx context.Context, p interface{}, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	defer wrapError(&err, "GetUserIDsWithPermission", p)
+
+	var permission models.Permission
+	permission, err = s.GetPermissionCtx(ctx, p)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var holders repositories.PermissionHolders
+	holders, err = s.permissionHolders(ctx, permission)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if option.Pagination == nil {
+		subjects, totalCount, err = s.UserRepository.GetUserIDsWithPermission(ctx, holders, s.assignment(), nil)
+	} else {
+		subjects, totalCount, err = s.UserRepository.GetUserIDsWithPermission(ctx, holders, s.assignment(), &scopes.GormPagination{Pagination: option.Pagination.Get()})
+	}
+	return s.foundSubjects(subjects), totalCount, err
+}
+
+// CreatePermission create new permission.
+// Name parameter is converted to guard name. example: create $#% contact -> create-contact.
+// If a permission with the same name has been created before, it will not create it again. (FirstOrCreate)
+// @param string
+// @param string
+// @return error
+func (s *Permify) CreatePermission(name string, description string) (err error) {
+	return s.CreatePermissionCtx(context.Background(), name, description)
+}
+
+// CreatePermissionCtx is the context-aware variant of CreatePermission.
+// @param context.Context
+// @param string
+// @param string
+// @return error
+func (s *Permify) CreatePermissionCtx(ctx context.Context, name string, description string) (err error) {
+	defer wrapError(&err, "CreatePermission", name)
+
+	return s.createPermission(ctx, &models.Permission{
+		Name:        name,
+		GuardName:   s.guardName(name),
+		Description: description,
+	})
+}
+
+// createPermission creates the permission if there is no permission with its guard name, the permission is replaced by the created or the existing permission.
+// @param context.Context
+// @param *models.Permission
+// @return error
+func (s *Permify) createPermission(ctx context.Context, permission *models.Permission) error {
+	return s.audited(ctx, models.AuditEntry{Operation: "CreatePermission", Kind: models.AuditPermission}, s.permissionNames(permission.GuardName), func(ctx context.Context) error {
+		return s.PermissionRepository.FirstOrCreate(ctx, permission)
+	})
+}
+
+// DeletePermission delete permission.
+// If the permission is in use, its relations from the pivot tables are deleted.
+// First parameter can be permission name or id.
+// If the first parameter is an array, the first element of the given array is used.
+// @param interface{}
+// @return error
+func (s *Permify) DeletePermission(p interface{}) (err error) {
+	return s.DeletePermissionCtx(context.Background(), p)
+}
+
+// DeletePermissionCtx is the context-aware variant of DeletePermission.
+// @param context.Context
+// @param interface{}
+// @return error
+func (s *Permify) DeletePermissionCtx(ctx context.Context, p interface{}) (err error) {
+	defer wrapError(&err, "DeletePermission", p)
+
+	var permission models.Permission
+	permission, err = s.GetPermissionCtx(ctx, p)
+	if err != nil {
+		return err
+	}
+	defer s.forgetAll(ctx)
+	return s.audited(ctx, models.AuditEntry{Operation: "DeletePermission", Kind: models.AuditPermission}, s.permissionNames(permission.GuardName), func(ctx context.Context) error {
+		return s.PermissionRepository.Delete(ctx, &permission)
+	})
+}
+
+// USER
+
+// AddPermissionsToUser add direct permission or permissions to user according to the permission names or ids.
+// First parameter is the user id, second parameter is can be permission name(s) or id(s).
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) AddPermissionsToUser(user interface{}, p interface{}) (err error) {
+	return s.AddPermissionsToUserCtx(context.Background(), user, p)
+}
+
+// AddPermissionsToUserCtx is the context-aware variant of AddPermissionsToUser.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) AddPermissionsToUserCtx(ctx context.Context, user interface{}, p interface{}) (err error) {
+	defer wrapError(&err, "AddPermissionsToUser", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var permissions collections.Permission
+	permissions, err = s.GetPermissionsCtx(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	if err = s.checkGrant(ctx, nil, permissions); err != nil {
+		return err
+	}
+
+	if permissions.Len() > 0 {
+		err = s.audited(ctx, s.userAuditEntry("AddPermissionsToUser", subject, models.AuditPermissions), s.userPermissionNames(subject, false), func(ctx context.Context) error {
+			return s.UserRepository.AddPermissions(ctx, subject, s.assignment(), permissions)
+		})
+		s.forgetUsers(ctx, subject)
+	}
+
+	return
+}
+
+// AddPermissionsToUserUntil add direct permission or permissions to user until the expiry time.
+// example: on call access -> AddPermissionsToUserUntil(1, "restart servers", time.Now().Add(8 * time.Hour))
+// First parameter is the user id, second parameter is can be permission name(s) or id(s), third parameter is the expiry time.
+// The start set with Between is kept.
+// @param interface{}
+// @param interface{}
+// @param time.Time
+// @return error
+func (s *Permify) AddPermissionsToUserUntil(user interface{}, p interface{}, expiresAt time.Time) (err error) {
+	return s.AddPermissionsToUserUntilCtx(context.Background(), user, p, expiresAt)
+}
+
+// AddPermissionsToUserUntilCtx is the context-aware variant of AddPermissionsToUserUntil.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @param time.Time
+// @return error
+func (s *Permify) AddPermissionsToUserUntilCtx(ctx context.Context, user interface{}, p interface{}, expiresAt time.Time) (err error) {
+	defer wrapError(&err, "AddPermissionsToUserUntil", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	return s.until(expiresAt).AddPermissionsToUserCtx(ctx, subject, p)
+}
+
+// ReplacePermissionsToUser overwrites the direct permissions of the user according to the permission names or ids.
+// First parameter is the user id, second parameter is can be permission name(s) or id(s).
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) ReplacePermissionsToUser(user interface{}, p interface{}) (err error) {
+	return s.ReplacePermissionsToUserCtx(context.Background(), user, p)
+}
+
+// ReplacePermissionsToUserCtx is the context-aware variant of ReplacePermissionsToUser.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) ReplacePermissionsToUserCtx(ctx context.Context, user interface{}, p interface{}) (err error) {
+	defer wrapError(&err, "ReplacePermissionsToUser", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var permissions collections.Permission
+	permissions, err = s.GetPermissionsCtx(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	if err = s.checkGrant(ctx, nil, permissions); err != nil {
+		return err
+	}
+
+	defer s.forgetUsers(ctx, subject)
+
+	return s.audited(ctx, s.userAuditEntry("ReplacePermissionsToUser", subject, models.AuditPermissions), s.userPermissionNames(subject, false), func(ctx context.Context) error {
+		if permissions.Len() > 0 {
+			return s.UserRepository.ReplacePermissions(ctx, subject, s.assignment(), permissions)
+		}
+
+		return s.UserRepository.ClearPermissions(ctx, subject, s.assignment())
+	})
+}
+
+// RemovePermissionsFromUser remove direct permissions from user according to the permission names or ids.
+// First parameter is the user id, second parameter is can be permission name(s) or id(s).
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RemovePermissionsFromUser(user interface{}, p interface{}) (err error) {
+	return s.RemovePermissionsFromUserCtx(context.Background(), user, p)
+}
+
+// RemovePermissionsFromUserCtx is the context-aware variant of RemovePermissionsFromUser.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RemovePermissionsFromUserCtx(ctx context.Context, user interface{}, p interface{}) (err error) {
+	defer wrapError(&err, "RemovePermissionsFromUser", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var permissions collections.Permission
+	permissions, err = s.GetPermissionsCtx(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	if permissions.Len() > 0 {
+		err = s.audited(ctx, s.userAuditEntry("RemovePermissionsFromUser", subject, models.AuditPermissions), s.userPermissionNames(subject, false), func(ctx context.Context) error {
+			return s.UserRepository.RemovePermissions(ctx, subject, s.assignment(), permissions)
+		})
+		s.forgetUsers(ctx, subject)
+	}
+
+	return
+}
+
+// DenyPermissionsToUser deny permission or permissions to user according to the permission names or ids.
+// The denied permissions block the permissions given to the user directly or via roles. (see ConflictResolution)
+// example: suspend refunds of user 1 -> DenyPermissionsToUser(1, "payments.refund")
+// First parameter is the user id, second parameter is can be permission name(s) or id(s).
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) DenyPermissionsToUser(user interface{}, p interface{}) (err error) {
+	return s.DenyPermissionsToUserCtx(context.Background(), user, p)
+}
+
+// DenyPermissionsToUserCtx is the context-aware variant of DenyPermissionsToUser.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) DenyPermissionsToUserCtx(ctx context.Context, user interface{}, p interface{}) (err error) {
+	defer wrapError(&err, "DenyPermissionsToUser", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var permissions collections.Permission
+	permissions, err = s.GetPermissionsCtx(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	if permissions.Len() > 0 {
+		err = s.audited(ctx, s.userAuditEntry("DenyPermissionsToUser", subject, models.AuditDeniedPermissions), s.userPermissionNames(subject, true), func(ctx context.Context) error {
+			return s.UserRepository.DenyPermissions(ctx, subject, s.assignment(), permissions)
+		})
+		s.forgetUsers(ctx, subject)
+	}
+
+	return
+}
+
+// RemoveDeniedPermissionsFromUser remove denied permissions of the user according to the permission names or ids.
+// First parameter is the user id, second parameter is can be permission name(s) or id(s).
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RemoveDeniedPermissionsFromUser(user interface{}, p interface{}) (err error) {
+	return s.RemoveDeniedPermissionsFromUserCtx(context.Background(), user, p)
+}
+
+// RemoveDeniedPermissionsFromUserCtx is the context-aware variant of RemoveDeniedPermissionsFromUser.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RemoveDeniedPermissionsFromUserCtx(ctx context.Context, user interface{}, p interface{}) (err error) {
+	defer wrapError(&err, "RemoveDeniedPermissionsFromUser", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var permissions collections.Permission
+	permissions, err = s.GetPermissionsCtx(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	// removing a denied permission gives it back, so it is a grant too.
+	if err = s.checkGrant(ctx, nil, permissions); err != nil {
+		return err
+	}
+
+	if permissions.Len() > 0 {
+		err = s.audited(ctx, s.userAuditEntry("RemoveDeniedPermissionsFromUser", subject, models.AuditDeniedPermissions), s.userPermissionNames(subject, true), func(ctx context.Context) error {
+			return s.UserRepository.RemoveDeniedPermissions(ctx, subject, s.assignment(), permissions)
+		})
+		s.forgetUsers(ctx, subject)
+	}
+
+	return
+}
+
+// AddRolesToUser add role or roles to user according to the role names or ids.
+// First parameter is the user id, second parameter is can be role name(s) or id(s).
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) AddRolesToUser(user interface{}, r interface{}) (err error) {
+	return s.AddRolesToUserCtx(context.Background(), user, r)
+}
+
+// AddRolesToUserCtx is the context-aware variant of AddRolesToUser.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) AddRolesToUserCtx(ctx context.Context, user interface{}, r interface{}) (err error) {
+	defer wrapError(&err, "AddRolesToUser", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var roles collections.Role
+	roles, err = s.GetRolesCtx(ctx, r, false)
+	if err != nil {
+		return err
+	}
+
+	if err = s.checkGrant(ctx, roles, nil); err != nil {
+		return err
+	}
+
+	if roles.Len() > 0 {
+		err = s.audited(ctx, s.userAuditEntry("AddRolesToUser", subject, models.AuditRoles), s.userRoleNames(subject), func(ctx context.Context) error {
+			return s.UserRepository.AddRoles(ctx, subject, s.assignment(), roles)
+		})
+		s.forgetUsers(ctx, subject)
+	}
+
+	return
+}
+
+// AddRolesToUserUntil add role or roles to user until the expiry time.
+// example: contractor access -> AddRolesToUserUntil(1, "developer", contractEnd)
+// First parameter is the user id, second parameter is can be role name(s) or id(s), third parameter is the expiry time.
+// The start set with Between is kept.
+// @param interface{}
+// @param interface{}
+// @param time.Time
+// @return error
+func (s *Permify) AddRolesToUserUntil(user interface{}, r interface{}, expiresAt time.Time) (err error) {
+	return s.AddRolesToUserUntilCtx(context.Background(), user, r, expiresAt)
+}
+
+// AddRolesToUserUntilCtx is the context-aware variant of AddRolesToUserUntil.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @param time.Time
+// @return error
+func (s *Permify) AddRolesToUserUntilCtx(ctx context.Context, user interface{}, r interface{}, expiresAt time.Time) (err error) {
+	defer wrapError(&err, "AddRolesToUserUntil", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	return s.until(expiresAt).AddRolesToUserCtx(ctx, subject, r)
+}
+
+// ReplaceRolesToUser overwrites the roles of the user according to the role names or ids.
+// First parameter is the user id, second parameter is can be role name(s) or id(s).
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) ReplaceRolesToUser(user interface{}, r interface{}) (err error) {
+	return s.ReplaceRolesToUserCtx(context.Background(), user, r)
+}
+
+// ReplaceRolesToUserCtx is the context-aware variant of ReplaceRolesToUser.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) ReplaceRolesToUserCtx(ctx context.Context, user interface{}, r interface{}) (err error) {
+	defer wrapError(&err, "ReplaceRolesToUser", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var roles collections.Role
+	roles, err = s.GetRolesCtx(ctx, r, false)
+	if err != nil {
+		return err
+	}
+
+	if err = s.checkGrant(ctx, roles, nil); err != nil {
+		return err
+	}
+
+	if s.actingUser != nil {
+		// the roles of the user in the scope that are not given again are removed.
+		var userRoles []pivot.UserRoles
+		userRoles, err = s.UserRepository.GetRoleWindows(ctx, subject, s.assignment())
+		if err != nil {
+			return err
+		}
+		var removedRoleIDs []uint
+		for _, userRole := range userRoles {
+			if !helpers.InArray(userRole.RoleID, roles.IDs()) {
+				removedRoleIDs = append(removedRoleIDs, userRole.RoleID)
+			}
+		}
+		if err = s.checkRemoval(ctx, removedRoleIDs); err != nil {
+			return err
+		}
+	}
+
+	defer s.forgetUsers(ctx, subject)
+
+	return s.audited(ctx, s.userAuditEntry("ReplaceRolesToUser", subject, models.AuditRoles), s.userRoleNames(subject), func(ctx context.Context) error {
+		if roles.Len() > 0 {
+			return s.UserRepository.ReplaceRoles(ctx, subject, s.assignment(), roles)
+		}
+
+		return s.UserRepository.ClearRoles(ctx, subject, s.assignment())
+	})
+}
+
+// RemoveRolesFromUser remove roles from user according to the role names or ids.
+// First parameter is the user id, second parameter is can be role name(s) or id(s).
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RemoveRolesFromUser(user interface{}, r interface{}) (err error) {
+	return s.RemoveRolesFromUserCtx(context.Background(), user, r)
+}
+
+// RemoveRolesFromUserCtx is the context-aware variant of RemoveRolesFromUser.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RemoveRolesFromUserCtx(ctx context.Context, user interface{}, r interface{}) (err error) {
+	defer wrapError(&err, "RemoveRolesFromUser", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var roles collections.Role
+	roles, err = s.GetRolesCtx(ctx, r, false)
+	if err != nil {
+		return err
+	}
+
+	if err = s.checkRemoval(ctx, roles.IDs()); err != nil {
+		return err
+	}
+
+	if roles.Len() > 0 {
+		err = s.audited(ctx, s.userAuditEntry("RemoveRolesFromUser", subject, models.AuditRoles), s.userRoleNames(subject), func(ctx context.Context) error {
+			return s.UserRepository.RemoveRoles(ctx, subject, s.assignment(), roles)
+		})
+		s.forgetUsers(ctx, subject)
+	}
+
+	return
+}
+
+// AddRolesToUserOn add role or roles to user on the resource according to the role names or ids.
+// example: user 7 is editor on project 42 -> AddRolesToUserOn(7, "editor", "project", "42")
+// First parameter is the user id, second parameter is can be role name(s) or id(s), third and fourth parameters are the resource type and id.
+// @param interface{}
+// @param interface{}
+// @param string
+// @param string
+// @return error
+func (s *Permify) AddRolesToUserOn(user interface{}, r interface{}, resourceType string, resourceID string) (err error) {
+	return s.AddRolesToUserOnCtx(context.Background(), user, r, resourceType, resourceID)
+}
+
+// AddRolesToUserOnCtx is the context-aware variant of AddRolesToUserOn.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @param string
+// @param string
+// @return error
+func (s *Permify) AddRolesToUserOnCtx(ctx context.Context, user interface{}, r interface{}, resourceType string, resourceID string) (err error) {
+	defer wrapError(&err, "AddRolesToUserOn", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	return s.Resource(resourceType, resourceID).AddRolesToUserCtx(ctx, subject, r)
+}
+
+// RemoveRolesFromUserOn remove roles of the user on the resource according to the role names or ids.
+// First parameter is the user id, second parameter is can be role name(s) or id(s), third and fourth parameters are the resource type and id.
+// @param interface{}
+// @param interface{}
+// @param string
+// @param string
+// @return error
+func (s *Permify) RemoveRolesFromUserOn(user interface{}, r interface{}, resourceType string, resourceID string) (err error) {
+	return s.RemoveRolesFromUserOnCtx(context.Background(), user, r, resourceType, resourceID)
+}
+
+// RemoveRolesFromUserOnCtx is the context-aware variant of RemoveRolesFromUserOn.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @param string
+// @param string
+// @return error
+func (s *Permify) RemoveRolesFromUserOnCtx(ctx context.Context, user interface{}, r interface{}, resourceType string, resourceID string) (err error) {
+	defer wrapError(&err, "RemoveRolesFromUserOn", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	return s.Resource(resourceType, resourceID).RemoveRolesFromUserCtx(ctx, subject, r)
+}
+
+// AddPermissionsToUserOn add direct permission or permissions to user on the resource according to the permission names or ids.
+// First parameter is the user id, second parameter is can be permission name(s) or id(s), third and fourth parameters are the resource type and id.
+// @param interface{}
+// @param interface{}
+// @param string
+// @param string
+// @return error
+func (s *Permify) AddPermissionsToUserOn(user interface{}, p interface{}, resourceType string, resourceID string) (err error) {
+	return s.AddPermissionsToUserOnCtx(context.Background(), user, p, resourceType, resourceID)
+}
+
+// AddPermissionsToUserOnCtx is the context-aware variant of AddPermissionsToUserOn.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @param string
+// @param string
+// @return error
+func (s *Permify) AddPermissionsToUserOnCtx(ctx context.Context, user interface{}, p interface{}, resourceType string, resourceID string) (err error) {
+	defer wrapError(&err, "AddPermissionsToUserOn", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	return s.Resource(resourceType, resourceID).AddPermissionsToUserCtx(ctx, subject, p)
+}
+
+// RemovePermissionsFromUserOn remove direct permissions of the user on the resource according to the permission names or ids.
+// First parameter is the user id, second parameter is can be permission name(s) or id(s), third and fourth parameters are the resource type and id.
+// @param interface{}
+// @param interface{}
+// @param string
+// @param string
+// @return error
+func (s *Permify) RemovePermissionsFromUserOn(user interface{}, p interface{}, resourceType string, resourceID string) (err error) {
+	return s.RemovePermissionsFromUserOnCtx(context.Background(), user, p, resourceType, resourceID)
+}
+
+// RemovePermissionsFromUserOnCtx is the context-aware variant of RemovePermissionsFromUserOn.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @param string
+// @param string
+// @return error
+func (s *Permify) RemovePermissionsFromUserOnCtx(ctx context.Context, user interface{}, p interface{}, resourceType string, resourceID string) (err error) {
+	defer wrapError(&err, "RemovePermissionsFromUserOn", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
 	}
-	permissions, err = s.GetPermissions(permissionIDs)
-	return
+
+	return s.Resource(resourceType, resourceID).RemovePermissionsFromUserCtx(ctx, subject, p)
 }
 
-// GetPermissionsOfRoles fetch all permissions of the roles. (with pagination option)
-// First parameter can be role name(s) or id(s), second parameter is permission option.
+// CONTROLS
+
+// ROLE
+
+// RoleHasPermission does the role or any of the roles have given permission? (including the permissions of the inherited roles)
+// First parameter is can be role name(s) or id(s), second parameter is can be permission name or id.
+// If the second parameter is an array, the first element of the given array is used.
 // @param interface{}
-// @param options.PermissionOption
-// @return collections.Permission, int64, error
-func (s *Permify) GetPermissionsOfRoles(r interface{}, option options.PermissionOption) (permissions collections.Permission, totalCount int64, err error) {
+// @param interface{}
+// @return error
+func (s *Permify) RoleHasPermission(r interface{}, p interface{}) (b bool, err error) {
+	return s.RoleHasPermissionCtx(context.Background(), r, p)
+}
+
+// RoleHasPermissionCtx is the context-aware variant of RoleHasPermission.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RoleHasPermissionCtx(ctx context.Context, r interface{}, p interface{}) (b bool, err error) {
+	defer wrapError(&err, "RoleHasPermission", r)
+
 	var roles collections.Role
-	roles, err = s.GetRoles(r, false)
+	roles, err = s.GetRolesCtx(ctx, r, false)
 	if err != nil {
-		return collections.Permission{}, 0, err
+		return false, err
 	}
 
-	var permissionIDs []uint
-	if option.Pagination == nil {
-		permissionIDs, totalCount, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(roles.IDs(), nil)
-	} else {
-		permissionIDs, totalCount, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(roles.IDs(), &scopes.GormPagination{Pagination: option.Pagination.Get()})
+	roles, err = s.withInheritedRoles(ctx, roles)
+	if err != nil {
+		return false, err
 	}
 
-	permissions, err = s.GetPermissions(permissionIDs)
-	return
-}
-
-// GetAllPermissionsOfUser fetch all permissions of the user that come with direct and roles.
-// First parameter is user id.
-// @param uint
-// @return collections.Permission, error
-func (s *Permify) GetAllPermissionsOfUser(userID uint) (permissions collections.Permission, err error) {
-	var userRoleIDs []uint
-	userRoleIDs, _, err = s.RoleRepository.GetRoleIDsOfUser(userID, nil)
+	var permission models.Permission
+	permission, err = s.GetPermissionCtx(ctx, p)
 	if err != nil {
-		return collections.Permission{}, err
+		return false, err
 	}
 
-	var rolePermissionIDs []uint
-	rolePermissionIDs, _, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(userRoleIDs, nil)
-	if err != nil {
-		return collections.Permission{}, err
+	if !s.wildcard {
+		return s.RoleRepository.HasPermission(ctx, roles, permission)
 	}
 
-	var userDirectPermissionIDs []uint
-	userDirectPermissionIDs, _, err = s.PermissionRepository.GetDirectPermissionIDsOfUserByID(userID, nil)
+	var grantingPermissions map[uint]collections.Permission
+	grantingPermissions, err = s.grantingPermissions(ctx, collections.Permission{permission})
 	if err != nil {
-		return collections.Permission{}, err
+		return false, err
 	}
 
-	return s.GetPermissions(helpers.RemoveDuplicateValues(helpers.JoinUintArrays(rolePermissionIDs, userDirectPermissionIDs)))
+	return s.RoleRepository.HasAnyPermissions(ctx, roles, grantingPermissions[permission.ID])
 }
 
-// CreatePermission create new permission.
-// Name parameter is converted to guard name. example: create $#% contact -> create-contact.
-// If a permission with the same name has been created before, it will not create it again. (FirstOrCreate)
-// @param string
-// @param string
+// RoleHasAllPermissions does each of the roles have all the given permissions? (including the permissions of the inherited roles)
+// First parameter is can be role name(s) or id(s), second parameter is can be permission name(s) or id(s).
+// @param interface{}
+// @param interface{}
 // @return error
-func (s *Permify) CreatePermission(name string, description string) (err error) {
-	return s.PermissionRepository.FirstOrCreate(&models.Permission{
-		Name:        name,
-		GuardName:   helpers.Guard(name),
-		Description: description,
-	})
+func (s *Permify) RoleHasAllPermissions(r interface{}, p interface{}) (b bool, err error) {
+	return s.RoleHasAllPermissionsCtx(context.Background(), r, p)
 }
 
-// DeletePermission delete permission.
-// If the permission is in use, its relations from the pivot tables are deleted.
-// First parameter can be permission name or id.
-// If the first parameter is an array, the first element of the given array is used.
+// RoleHasAllPermissionsCtx is the context-aware variant of RoleHasAllPermissions.
+// @param context.Context
+// @param interface{}
 // @param interface{}
 // @return error
-func (s *Permify) DeletePermission(p interface{}) (err error) {
-	var permission models.Permission
-	permission, err = s.GetPermission(p)
+func (s *Permify) RoleHasAllPermissionsCtx(ctx context.Context, r interface{}, p interface{}) (b bool, err error) {
+	defer wrapError(&err, "RoleHasAllPermissions", r)
+
+	var roles collections.Role
+	roles, err = s.GetRolesCtx(ctx, r, false)
 	if err != nil {
-		return err
+		return false, err
 	}
-	return s.PermissionRepository.Delete(&permission)
-}
 
-// USER
-
-// AddPermissionsToUser add direct permission or permissions to user according to the permission names or ids.
-// First parameter is the user id, second parameter is can be permission name(s) or id(s).
-// @param uint
-// @param interface{}
-// @return error
-func (s *Permify) AddPermissionsToUser(userID uint, p interface{}) (err error) {
 	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
+	permissions, err = s.GetPermissionsCtx(ctx, p)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	if permissions.Len() > 0 {
-		err = s.UserRepository.AddPermissions(userID, permissions)
+	var grantingPermissions map[uint]collections.Permission
+	grantingPermissions, err = s.grantingPermissions(ctx, permissions)
+	if err != nil {
+		return false, err
 	}
 
-	return
+	for _, role := range roles {
+		var roleIDs []uint
+		roleIDs, err = s.withInheritedRoleIDs(ctx, []uint{role.ID})
+		if err != nil {
+			return false, err
+		}
+
+		var permissionIDs []uint
+		permissionIDs, _, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(ctx, roleIDs, nil)
+		if err != nil {
+			return false, err
+		}
+
+		for _, permission := range permissions {
+			if !helpers.AnyInArray(grantingPermissions[permission.ID].IDs(), permissionIDs) {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
 }
 
-// ReplacePermissionsToUser overwrites the direct permissions of the user according to the permission names or ids.
-// First parameter is the user id, second parameter is can be permission name(s) or id(s).
-// @param uint
+// RoleHasAnyPermissions does the role or any of the roles have any of the given permissions? (including the permissions of the inherited roles)
+// First parameter is can be role name(s) or id(s), second parameter is can be permission name(s) or id(s).
+// @param interface{}
 // @param interface{}
 // @return error
-func (s *Permify) ReplacePermissionsToUser(userID uint, p interface{}) (err error) {
-	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
+func (s *Permify) RoleHasAnyPermissions(r interface{}, p interface{}) (b bool, err error) {
+	return s.RoleHasAnyPermissionsCtx(context.Background(), r, p)
+}
+
+// RoleHasAnyPermissionsCtx is the context-aware variant of RoleHasAnyPermissions.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return error
+func (s *Permify) RoleHasAnyPermissionsCtx(ctx context.Context, r interface{}, p interface{}) (b bool, err error) {
+	defer wrapError(&err, "RoleHasAnyPermissions", r)
+
+	var roles collections.Role
+	roles, err = s.GetRolesCtx(ctx, r, false)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	if permissions.Len() > 0 {
-		return s.UserRepository.ReplacePermissions(userID, permissions)
+	roles, err = s.withInheritedRoles(ctx, roles)
+	if err != nil {
+		return false, err
 	}
 
-	return s.UserRepository.ClearPermissions(userID)
-}
-
-// RemovePermissionsFromUser remove direct permissions from user according to the permission names or ids.
-// First parameter is the user id, second parameter is can be permission name(s) or id(s).
-// @param uint
-// @param interface{}
-// @return error
-func (s *Permify) RemovePermissionsFromUser(userID uint, p interface{}) (err error) {
 	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
+	permissions, err = s.GetPermissionsCtx(ctx, p)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	if permissions.Len() > 0 {
-		err = s.UserRepository.RemovePermissions(userID, permissions)
+	var grantingPermissions map[uint]collections.Permission
+	grantingPermissions, err = s.grantingPermissions(ctx, permissions)
+	if err != nil {
+		return false, err
 	}
 
-	return
+	var anyPermissions collections.Permission
+	for _, permission := range permissions {
+		anyPermissions = append(anyPermissions, grantingPermissions[permission.ID]...)
+	}
+
+	return s.RoleRepository.HasAnyPermissions(ctx, roles, anyPermissions)
 }
 
-// AddRolesToUser add role or roles to user according to the role names or ids.
-// First parameter is the user id, second parameter is can be role name(s) or id(s).
-// @param uint
+// USER
+
+// UserHasRole does the user have the given role?
+// First parameter is the user id, second parameter is can be role name or id.
+// If the second parameter is an array, the first element of the given array is used.
 // @param interface{}
-// @return error
-func (s *Permify) AddRolesToUser(userID uint, r interface{}) (err error) {
-	var roles collections.Role
-	roles, err = s.GetRoles(r, false)
-	if err != nil {
-		return err
-	}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasRole(user interface{}, r interface{}) (b bool, err error) {
+	return s.UserHasRoleCtx(context.Background(), user, r)
+}
 
-	if roles.Len() > 0 {
-		err = s.UserRepository.AddRoles(userID, roles)
+// UserHasRoleCtx is the context-aware variant of UserHasRole.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasRoleCtx(ctx context.Context, user interface{}, r interface{}) (b bool, err error) {
+	defer wrapError(&err, "UserHasRole", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
 	}
 
-	return
+	var role models.Role
+	role, err = s.GetRoleCtx(ctx, r, false)
+	if err != nil {
+		return false, err
+	}
+	return s.UserRepository.HasRole(ctx, subject, s.assignment(), role)
 }
 
-// ReplaceRolesToUser overwrites the roles of the user according to the role names or ids.
+// UserHasAllRoles does the user have all the given roles?
 // First parameter is the user id, second parameter is can be role name(s) or id(s).
-// @param uint
 // @param interface{}
-// @return error
-func (s *Permify) ReplaceRolesToUser(userID uint, r interface{}) (err error) {
-	var roles collections.Role
-	roles, err = s.GetRoles(r, false)
-	if err != nil {
-		return err
-	}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasAllRoles(user interface{}, r interface{}) (b bool, err error) {
+	return s.UserHasAllRolesCtx(context.Background(), user, r)
+}
 
-	if roles.Len() > 0 {
-		return s.UserRepository.ReplaceRoles(userID, roles)
+// UserHasAllRolesCtx is the context-aware variant of UserHasAllRoles.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasAllRolesCtx(ctx context.Context, user interface{}, r interface{}) (b bool, err error) {
+	defer wrapError(&err, "UserHasAllRoles", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
 	}
 
-	return s.UserRepository.ClearRoles(userID)
+	var roles collections.Role
+	roles, err = s.GetRolesCtx(ctx, r, false)
+	if err != nil {
+		return false, err
+	}
+	return s.UserRepository.HasAllRoles(ctx, subject, s.assignment(), roles)
 }
 
-// RemoveRolesFromUser remove roles from user according to the role names or ids.
+// UserHasAnyRoles does the user have any of the given roles?
 // First parameter is the user id, second parameter is can be role name(s) or id(s).
-// @param uint
 // @param interface{}
-// @return error
-func (s *Permify) RemoveRolesFromUser(userID uint, r interface{}) (err error) {
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasAnyRoles(user interface{}, r interface{}) (b bool, err error) {
+	return s.UserHasAnyRolesCtx(context.Background(), user, r)
+}
+
+// UserHasAnyRolesCtx is the context-aware variant of UserHasAnyRoles.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasAnyRolesCtx(ctx context.Context, user interface{}, r interface{}) (b bool, err error) {
+	defer wrapError(&err, "UserHasAnyRoles", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
 	var roles collections.Role
-	roles, err = s.GetRoles(r, false)
+	roles, err = s.GetRolesCtx(ctx, r, false)
 	if err != nil {
-		return err
+		return false, err
 	}
+	return s.UserRepository.HasAnyRoles(ctx, subject, s.assignment(), roles)
+}
 
-	if roles.Len() > 0 {
-		err = s.UserRepository.RemoveRoles(userID, roles)
+// UserHasDirectPermission does the user have the given permission? (not including the permissions of the roles)
+// First parameter is the user id, second parameter is can be permission name or id.
+// If the second parameter is an array, the first element of the given array is used.
+// @param interface{}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasDirectPermission(user interface{}, p interface{}) (b bool, err error) {
+	return s.UserHasDirectPermissionCtx(context.Background(), user, p)
+}
+
+// UserHasDirectPermissionCtx is the context-aware variant of UserHasDirectPermission.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasDirectPermissionCtx(ctx context.Context, user interface{}, p interface{}) (b bool, err error) {
+	defer wrapError(&err, "UserHasDirectPermission", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
 	}
 
-	return
+	var permission models.Permission
+	permission, err = s.GetPermissionCtx(ctx, p)
+	if err != nil {
+		return false, err
+	}
+	return s.UserRepository.HasDirectPermission(ctx, subject, s.assignment(), permission)
 }
 
-// CONTROLS
-
-// ROLE
+// UserHasAllDirectPermissions does the user have all the given permissions? (not including the permissions of the roles)
+// First parameter is the user id, second parameter is can be permission name(s) or id(s).
+// @param interface{}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasAllDirectPermissions(user interface{}, p interface{}) (b bool, err error) {
+	return s.UserHasAllDirectPermissionsCtx(context.Background(), user, p)
+}
 
-// RoleHasPermission does the role or any of the roles have given permission?
-// First parameter is can be role name(s) or id(s), second parameter is can be permission name or id.
-// If the second parameter is an array, the first element of the given array is used.
+// UserHasAllDirectPermissionsCtx is the context-aware variant of UserHasAllDirectPermissions.
+// @param context.Context
 // @param interface{}
 // @param interface{}
-// @return error
-func (s *Permify) RoleHasPermission(r interface{}, p interface{}) (b bool, err error) {
-	var roles collections.Role
-	roles, err = s.GetRoles(r, false)
-	if err != nil {
-		return false, err
+// @return bool, error
+func (s *Permify) UserHasAllDirectPermissionsCtx(ctx context.Context, user interface{}, p interface{}) (b bool, err error) {
+	defer wrapError(&err, "UserHasAllDirectPermissions", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
 	}
 
-	var permission models.Permission
-	permission, err = s.GetPermission(p)
+	var permissions collections.Permission
+	permissions, err = s.GetPermissionsCtx(ctx, p)
 	if err != nil {
 		return false, err
 	}
+	return s.UserRepository.HasAllDirectPermissions(ctx, subject, s.assignment(), permissions)
+}
 
-	return s.RoleRepository.HasPermission(roles, permission)
+// UserHasAnyDirectPermissions does the user have any of the given permissions? (not including the permissions of the roles)
+// First parameter is the user id, second parameter is can be permission name(s) or id(s).
+// @param interface{}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasAnyDirectPermissions(user interface{}, p interface{}) (b bool, err error) {
+	return s.UserHasAnyDirectPermissionsCtx(context.Background(), user, p)
 }
 
-// RoleHasAllPermissions does the role or roles have all the given permissions?
-// First parameter is can be role name(s) or id(s), second parameter is can be permission name(s) or id(s).
+// UserHasAnyDirectPermissionsCtx is the context-aware variant of UserHasAnyDirectPermissions.
+// @param context.Context
 // @param interface{}
 // @param interface{}
-// @return error
-func (s *Permify) RoleHasAllPermissions(r interface{}, p interface{}) (b bool, err error) {
-	var roles collections.Role
-	roles, err = s.GetRoles(r, false)
-	if err != nil {
-		return false, err
+// @return bool, error
+func (s *Permify) UserHasAnyDirectPermissionsCtx(ctx context.Context, user interface{}, p interface{}) (b bool, err error) {
+	defer wrapError(&err, "UserHasAnyDirectPermissions", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
 	}
 
 	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
+	permissions, err = s.GetPermissionsCtx(ctx, p)
 	if err != nil {
 		return false, err
 	}
+	return s.UserRepository.HasAnyDirectPermissions(ctx, subject, s.assignment(), permissions)
+}
 
-	return s.RoleRepository.HasAllPermissions(roles, permissions)
+// UserHasPermission does the user have the given permission? (including the permissions of the roles)
+// First parameter is the user id, second parameter is can be permission name or id.
+// If the second parameter is an array, the first element of the given array is used.
+// @param interface{}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasPermission(user interface{}, p interface{}) (b bool, err error) {
+	return s.UserHasPermissionCtx(context.Background(), user, p)
 }
 
-// RoleHasAnyPermissions does the role or roles have any of the given permissions?
-// First parameter is can be role name(s) or id(s), second parameter is can be permission name(s) or id(s).
+// UserHasPermissionCtx is the context-aware variant of UserHasPermission.
+// @param context.Context
 // @param interface{}
 // @param interface{}
-// @return error
-func (s *Permify) RoleHasAnyPermissions(r interface{}, p interface{}) (b bool, err error) {
-	var roles collections.Role
-	roles, err = s.GetRoles(r, false)
+// @return bool, error
+func (s *Permify) UserHasPermissionCtx(ctx context.Context, user interface{}, p interface{}) (b bool, err error) {
+	defer wrapError(&err, "UserHasPermission", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var permission models.Permission
+	permission, err = s.GetPermissionCtx(ctx, p)
 	if err != nil {
 		return false, err
 	}
 
-	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
+	var decisions map[uint]bool
+	decisions, err = s.userPermissionDecisions(ctx, subject, collections.Permission{permission})
 	if err != nil {
 		return false, err
 	}
 
-	return s.RoleRepository.HasAnyPermissions(roles, permissions)
+	return decisions[permission.ID], err
 }
 
-// USER
-
-// UserHasRole does the user have the given role?
-// First parameter is the user id, second parameter is can be role name or id.
-// If the second parameter is an array, the first element of the given array is used.
-// @param uint
+// UserHasRoleOn does the user have the given role on the resource? (including the roles that are not on a resource)
+// First parameter is the user id, second parameter is can be role name or id, third and fourth parameters are the resource type and id.
 // @param interface{}
+// @param interface{}
+// @param string
+// @param string
 // @return bool, error
-func (s *Permify) UserHasRole(userID uint, r interface{}) (b bool, err error) {
-	var role models.Role
-	role, err = s.GetRole(r, false)
-	if err != nil {
-		return false, err
-	}
-	return s.UserRepository.HasRole(userID, role)
+func (s *Permify) UserHasRoleOn(user interface{}, r interface{}, resourceType string, resourceID string) (b bool, err error) {
+	return s.UserHasRoleOnCtx(context.Background(), user, r, resourceType, resourceID)
 }
 
-// UserHasAllRoles does the user have all the given roles?
-// First parameter is the user id, second parameter is can be role name(s) or id(s).
-// @param uint
+// UserHasRoleOnCtx is the context-aware variant of UserHasRoleOn.
+// @param context.Context
+// @param interface{}
 // @param interface{}
+// @param string
+// @param string
 // @return bool, error
-func (s *Permify) UserHasAllRoles(userID uint, r interface{}) (b bool, err error) {
-	var roles collections.Role
-	roles, err = s.GetRoles(r, false)
-	if err != nil {
-		return false, err
+func (s *Permify) UserHasRoleOnCtx(ctx context.Context, user interface{}, r interface{}, resourceType string, resourceID string) (b bool, err error) {
+	defer wrapError(&err, "UserHasRoleOn", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
 	}
-	return s.UserRepository.HasAllRoles(userID, roles)
+
+	return s.Resource(resourceType, resourceID).UserHasRoleCtx(ctx, subject, r)
 }
 
-// UserHasAnyRoles does the user have any of the given roles?
-// First parameter is the user id, second parameter is can be role name(s) or id(s).
-// @param uint
+// UserHasPermissionOn does the user have the given permission on the resource? (including the permissions of the roles and the assignments that are not on a resource)
+// example: can user 7 edit project 42 -> UserHasPermissionOn(7, "edit project", "project", "42")
+// First parameter is the user id, second parameter is can be permission name or id, third and fourth parameters are the resource type and id.
 // @param interface{}
+// @param interface{}
+// @param string
+// @param string
 // @return bool, error
-func (s *Permify) UserHasAnyRoles(userID uint, r interface{}) (b bool, err error) {
-	var roles collections.Role
-	roles, err = s.GetRoles(r, false)
-	if err != nil {
-		return false, err
-	}
-	return s.UserRepository.HasAnyRoles(userID, roles)
+func (s *Permify) UserHasPermissionOn(user interface{}, p interface{}, resourceType string, resourceID string) (b bool, err error) {
+	return s.UserHasPermissionOnCtx(context.Background(), user, p, resourceType, resourceID)
 }
 
-// UserHasDirectPermission does the user have the given permission? (not including the permissions of the roles)
-// First parameter is the user id, second parameter is can be permission name or id.
-// If the second parameter is an array, the first element of the given array is used.
-// @param uint
+// UserHasPermissionOnCtx is the context-aware variant of UserHasPermissionOn.
+// @param context.Context
 // @param interface{}
+// @param interface{}
+// @param string
+// @param string
 // @return bool, error
-func (s *Permify) UserHasDirectPermission(userID uint, p interface{}) (b bool, err error) {
-	var permission models.Permission
-	permission, err = s.GetPermission(p)
-	if err != nil {
-		return false, err
+func (s *Permify) UserHasPermissionOnCtx(ctx context.Context, user interface{}, p interface{}, resourceType string, resourceID string) (b bool, err error) {
+	defer wrapError(&err, "UserHasPermissionOn", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
 	}
-	return s.UserRepository.HasDirectPermission(userID, permission)
+
+	return s.Resource(resourceType, resourceID).UserHasPermissionCtx(ctx, subject, p)
 }
 
-// UserHasAllDirectPermissions does the user have all the given permissions? (not including the permissions of the roles)
+// UserHasAllPermissions does the user have all the given permissions? (including the permissions of the roles).
 // First parameter is the user id, second parameter is can be permission name(s) or id(s).
-// @param uint
+// @param interface{}
 // @param interface{}
 // @return bool, error
-func (s *Permify) UserHasAllDirectPermissions(userID uint, p interface{}) (b bool, err error) {
-	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
-	if err != nil {
-		return false, err
-	}
-	return s.UserRepository.HasAllDirectPermissions(userID, permissions)
+func (s *Permify) UserHasAllPermissions(user interface{}, p interface{}) (b bool, err error) {
+	return s.UserHasAllPermissionsCtx(context.Background(), user, p)
 }
 
-// UserHasAnyDirectPermissions does the user have any of the given permissions? (not including the permissions of the roles)
-// First parameter is the user id, second parameter is can be permission name(s) or id(s).
-// @param uint
+// UserHasAllPermissionsCtx is the context-aware variant of UserHasAllPermissions.
+// @param context.Context
+// @param interface{}
 // @param interface{}
 // @return bool, error
-func (s *Permify) UserHasAnyDirectPermissions(userID uint, p interface{}) (b bool, err error) {
+func (s *Permify) UserHasAllPermissionsCtx(ctx context.Context, user interface{}, p interface{}) (b bool, err error) {
+	defer wrapError(&err, "UserHasAllPermissions", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
 	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
+	permissions, err = s.GetPermissionsCtx(ctx, p)
 	if err != nil {
 		return false, err
 	}
-	return s.UserRepository.HasAnyDirectPermissions(userID, permissions)
-}
 
-// UserHasPermission does the user have the given permission? (including the permissions of the roles)
-// First parameter is the user id, second parameter is can be permission name or id.
-// If the second parameter is an array, the first element of the given array is used.
-// @param uint
-// @param interface{}
-// @return bool, error
-func (s *Permify) UserHasPermission(userID uint, p interface{}) (b bool, err error) {
-	var permission models.Permission
-	permission, err = s.GetPermission(p)
+	var decisions map[uint]bool
+	decisions, err = s.userPermissionDecisions(ctx, subject, permissions)
 	if err != nil {
 		return false, err
 	}
 
-	var directPermissionIDs []uint
-	directPermissionIDs, _, err = s.PermissionRepository.GetDirectPermissionIDsOfUserByID(userID, nil)
-	if err != nil {
-		return false, err
+	for _, permissionID := range permissions.IDs() {
+		if !decisions[permissionID] {
+			return false, err
+		}
 	}
 
-	if helpers.InArray(permission.ID, directPermissionIDs) {
-		return true, err
+	return true, err
+}
+
+// UserHasAnyPermissions does the user have any of the given permissions? (including the permissions of the roles).
+// First parameter is the user id, second parameter is can be permission name(s) or id(s).
+// @param interface{}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasAnyPermissions(user interface{}, p interface{}) (b bool, err error) {
+	return s.UserHasAnyPermissionsCtx(context.Background(), user, p)
+}
+
+// UserHasAnyPermissionsCtx is the context-aware variant of UserHasAnyPermissions.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return bool, error
+func (s *Permify) UserHasAnyPermissionsCtx(ctx context.Context, user interface{}, p interface{}) (b bool, err error) {
+	defer wrapError(&err, "UserHasAnyPermissions", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
 	}
 
-	var roleIDs []uint
-	roleIDs, _, err = s.RoleRepository.GetRoleIDsOfUser(userID, nil)
+	var permissions collections.Permission
+	permissions, err = s.GetPermissionsCtx(ctx, p)
 	if err != nil {
 		return false, err
 	}
 
-	var permissionIDs []uint
-	permissionIDs, _, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(roleIDs, nil)
+	var decisions map[uint]bool
+	decisions, err = s.userPermissionDecisions(ctx, subject, permissions)
 	if err != nil {
 		return false, err
 	}
 
-	if helpers.InArray(permission.ID, permissionIDs) {
-		return true, err
+	for _, permissionID := range permissions.IDs() {
+		if decisions[permissionID] {
+			return true, err
+		}
 	}
 
 	return false, err
 }
 
-// UserHasAllPermissions does the user have all the given permissions? (including the permissions of the roles).
+// UserPermissionsMap which of the given permissions does the user have? (including the permissions of the roles)
+// The permissions are keyed by the given names, or by their names if they are given by ids. The permissions that do not exist are false, or missing if they are given by ids.
+// The permissions are checked together, with the same number of queries as UserHasPermission.
 // First parameter is the user id, second parameter is can be permission name(s) or id(s).
-// @param uint
 // @param interface{}
-// @return bool, error
-func (s *Permify) UserHasAllPermissions(userID uint, p interface{}) (b bool, err error) {
-	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
+// @param interface{}
+// @return map[string]bool, error
+func (s *Permify) UserPermissionsMap(user interface{}, p interface{}) (permissions map[string]bool, err error) {
+	return s.UserPermissionsMapCtx(context.Background(), user, p)
+}
+
+// UserPermissionsMapCtx is the context-aware variant of UserPermissionsMap.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return map[string]bool, error
+func (s *Permify) UserPermissionsMapCtx(ctx context.Context, user interface{}, p interface{}) (permissions map[string]bool, err error) {
+	defer wrapError(&err, "UserPermissionsMap", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	ref := permissionRefOf(p)
+	if ref.err != nil {
+		return nil, ref.err
+	}
+
+	var found collections.Permission
+	if ref.byName {
+		found, err = s.PermissionRepository.GetPermissionsByGuardNames(ctx, s.guardNames(ref.names))
+	} else {
+		found, err = s.PermissionRepository.GetPermissions(ctx, ref.ids)
+	}
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	var userPermissionIDs []uint
-	userPermissionIDs, _, err = s.PermissionRepository.GetDirectPermissionIDsOfUserByID(userID, nil)
+	var decisions map[uint]bool
+	decisions, err = s.userPermissionDecisions(ctx, subject, found)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	var roleIDs []uint
-	roleIDs, _, err = s.RoleRepository.GetRoleIDsOfUser(userID, nil)
+	permissions = make(map[string]bool)
+	if !ref.byName {
+		for _, permission := range found {
+			permissions[permission.Name] = decisions[permission.ID]
+		}
+		return
+	}
+
+	for _, name := range ref.names {
+		permissions[name] = false
+		for _, permission := range found {
+			if permission.GuardName == s.guardName(name) {
+				permissions[name] = decisions[permission.ID]
+			}
+		}
+	}
+	return
+}
+
+// UsersHavePermission which of the given users have the permission? (including the permissions of the roles)
+// The users are keyed by the given ids. The users are checked together, with a query for every 500 users.
+// First parameter is the user ids, second parameter is can be permission name or id.
+// @param []uint
+// @param interface{}
+// @return map[uint]bool, error
+func (s *Permify) UsersHavePermission(userIDs []uint, p interface{}) (users map[uint]bool, err error) {
+	return s.UsersHavePermissionCtx(context.Background(), userIDs, p)
+}
+
+// UsersHavePermissionCtx is the context-aware variant of UsersHavePermission.
+// @param context.Context
+// @param []uint
+// @param interface{}
+// @return map[uint]bool, error
+func (s *Permify) UsersHavePermissionCtx(ctx context.Context, userIDs []uint, p interface{}) (users map[uint]bool, err error) {
+	var subjects map[models.Subject]bool
+	if subjects, err = s.SubjectsHavePermissionCtx(ctx, userIDs, p); err != nil {
+		return nil, err
+	}
+
+	users = make(map[uint]bool, len(userIDs))
+	for _, userID := range userIDs {
+		var subject models.Subject
+		if subject, err = s.parseSubject(userID); err != nil {
+			return nil, err
+		}
+		users[userID] = subjects[subject]
+	}
+	return users, nil
+}
+
+// SubjectsHavePermission which of the given users or subjects have the permission? (including the permissions of the roles)
+// The users are keyed by their subject, models.UserSubject(id) for the users given by id, since the user ids can be of any UserIDType.
+// The users are checked together, with a query for every 500 users.
+// First parameter is the user ids or Subjects, second parameter is can be permission name or id.
+// @param interface{}
+// @param interface{}
+// @return map[models.Subject]bool, error
+func (s *Permify) SubjectsHavePermission(users interface{}, p interface{}) (subjects map[models.Subject]bool, err error) {
+	return s.SubjectsHavePermissionCtx(context.Background(), users, p)
+}
+
+// SubjectsHavePermissionCtx is the context-aware variant of SubjectsHavePermission.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return map[models.Subject]bool, error
+func (s *Permify) SubjectsHavePermissionCtx(ctx context.Context, users interface{}, p interface{}) (subjects map[models.Subject]bool, err error) {
+	defer wrapError(&err, "SubjectsHavePermission", p)
+
+	var given []models.Subject
+	if given, err = s.parseSubjects(users); err != nil {
+		return
+	}
+
+	var permission models.Permission
+	permission, err = s.GetPermissionCtx(ctx, p)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	var rolePermissionIDs []uint
-	rolePermissionIDs, _, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(roleIDs, nil)
+	var holders repositories.PermissionHolders
+	holders, err = s.permissionHolders(ctx, permission)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+
+	subjects = make(map[models.Subject]bool)
+	for _, subject := range given {
+		subjects[subject] = false
 	}
 
-	allPermissionIDsOfUser := helpers.RemoveDuplicateValues(helpers.JoinUintArrays(userPermissionIDs, rolePermissionIDs))
+	for start := 0; start < len(given); start += batchCheckSize {
+		end := start + batchCheckSize
+		if end > len(given) {
+			end = len(given)
+		}
 
-	for _, permissionID := range permissions.IDs() {
-		if !helpers.InArray(permissionID, allPermissionIDsOfUser) {
-			return false, err
+		holders.Subjects = given[start:end]
+		var allowed []models.Subject
+		allowed, _, err = s.UserRepository.GetUserIDsWithPermission(ctx, holders, s.assignment(), nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, subject := range s.foundSubjects(allowed) {
+			subjects[subject] = true
 		}
 	}
 
-	return true, err
+	return
 }
 
-// UserHasAnyPermissions does the user have any of the given permissions? (including the permissions of the roles).
-// First parameter is the user id, second parameter is can be permission name(s) or id(s).
-// @param uint
+// ExplainUserPermission why does the user have the given permission, or not? (including the permissions of the roles)
+// It returns the decision of UserHasPermission with every path that gives or denies the permission: directly, via a role or via an inherited role,
+// with the permission itself or with one of its wildcard ancestors, and the assignments of the user that are considered.
+// The assignments are looked up with the same repository methods as UserHasPermission, the cache is not used.
+// First parameter is the user id, second parameter is can be permission name or id.
 // @param interface{}
-// @return bool, error
-func (s *Permify) UserHasAnyPermissions(userID uint, p interface{}) (b bool, err error) {
-	var permissions collections.Permission
-	permissions, err = s.GetPermissions(p)
+// @param interface{}
+// @return Explanation, error
+func (s *Permify) ExplainUserPermission(user interface{}, p interface{}) (explanation Explanation, err error) {
+	return s.ExplainUserPermissionCtx(context.Background(), user, p)
+}
+
+// ExplainUserPermissionCtx is the context-aware variant of ExplainUserPermission.
+// @param context.Context
+// @param interface{}
+// @param interface{}
+// @return Explanation, error
+func (s *Permify) ExplainUserPermissionCtx(ctx context.Context, user interface{}, p interface{}) (explanation Explanation, err error) {
+	defer wrapError(&err, "ExplainUserPermission", user)
+
+	var subject models.Subject
+	if subject, err = s.parseSubject(user); err != nil {
+		return
+	}
+
+	var permission models.Permission
+	permission, err = s.GetPermissionCtx(ctx, p)
 	if err != nil {
-		return false, err
+		return Explanation{}, err
 	}
 
-	var directPermissionIDs []uint
-	directPermissionIDs, _, err = s.PermissionRepository.GetDirectPermissionIDsOfUserByID(userID, nil)
+	var grantingPermissions map[uint]collections.Permission
+	grantingPermissions, err = s.grantingPermissions(ctx, collections.Permission{permission})
 	if err != nil {
-		return false, err
+		return Explanation{}, err
 	}
 
-	for _, permissionID := range permissions.IDs() {
-		if helpers.InArray(permissionID, directPermissionIDs) {
-			return true, err
+	explanation = Explanation{
+		Subject:            subject,
+		Permission:         permission,
+		TenantID:           s.tenantID,
+		ResourceType:       s.resourceType,
+		ResourceID:         s.resourceID,
+		ConflictResolution: s.conflictResolution,
+		Considered:         ConsideredAssignments{GrantingPermissions: grantingPermissions[permission.ID]},
+	}
+
+	explanation.Considered.Permissions, err = s.directPermissionsOfUser(ctx, subject, s.PermissionRepository.GetDirectPermissionIDsOfUserByID)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	explanation.Considered.DeniedPermissions, err = s.directPermissionsOfUser(ctx, subject, s.PermissionRepository.GetDeniedPermissionIDsOfUserByID)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	for _, granting := range explanation.Considered.GrantingPermissions {
+		if helpers.InArray(granting.ID, explanation.Considered.Permissions.IDs()) {
+			explanation.Paths = append(explanation.Paths, Path{Kind: PathDirect, Permission: granting, Wildcard: granting.ID != permission.ID})
+		}
+		if helpers.InArray(granting.ID, explanation.Considered.DeniedPermissions.IDs()) {
+			explanation.Paths = append(explanation.Paths, Path{Kind: PathDirect, Denied: true, Permission: granting, Wildcard: granting.ID != permission.ID})
 		}
 	}
 
 	var roleIDs []uint
-	roleIDs, _, err = s.RoleRepository.GetRoleIDsOfUser(userID, nil)
+	roleIDs, _, err = s.RoleRepository.GetRoleIDsOfUser(ctx, subject, s.assignment(), nil)
 	if err != nil {
-		return false, err
+		return Explanation{}, err
+	}
+
+	// the chain of roles from a role of the user to each of the roles, the roles are visited breadth first so that the chains are the shortest.
+	chains := make(map[uint][]uint)
+	visitedRoleIDs := helpers.RemoveDuplicateValues(roleIDs)
+	for _, roleID := range visitedRoleIDs {
+		chains[roleID] = []uint{roleID}
+	}
+	for i := 0; i < len(visitedRoleIDs); i++ {
+		var childRoleIDs []uint
+		childRoleIDs, err = s.RoleRepository.GetChildRoleIDs(ctx, []uint{visitedRoleIDs[i]})
+		if err != nil {
+			return Explanation{}, err
+		}
+		for _, childRoleID := range childRoleIDs {
+			if _, ok := chains[childRoleID]; !ok {
+				chains[childRoleID] = append(append([]uint{}, chains[visitedRoleIDs[i]]...), childRoleID)
+				visitedRoleIDs = append(visitedRoleIDs, childRoleID)
+			}
+		}
+	}
+
+	roles := make(map[uint]models.Role)
+	if len(visitedRoleIDs) > 0 {
+		var found collections.Role
+		found, err = s.RoleRepository.GetRoles(ctx, visitedRoleIDs)
+		if err != nil {
+			return Explanation{}, err
+		}
+		for _, role := range found {
+			roles[role.ID] = role
+		}
+	}
+
+	for _, roleID := range visitedRoleIDs {
+		if len(chains[roleID]) == 1 {
+			explanation.Considered.Roles = append(explanation.Considered.Roles, roles[roleID])
+		} else {
+			explanation.Considered.InheritedRoles = append(explanation.Considered.InheritedRoles, roles[roleID])
+		}
+
+		var rolePermissionIDs, roleDeniedPermissionIDs []uint
+		rolePermissionIDs, _, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(ctx, []uint{roleID}, nil)
+		if err != nil {
+			return Explanation{}, err
+		}
+		roleDeniedPermissionIDs, _, err = s.PermissionRepository.GetDeniedPermissionIDsOfRolesByIDs(ctx, []uint{roleID}, nil)
+		if err != nil {
+			return Explanation{}, err
+		}
+
+		kind := PathRole
+		if len(chains[roleID]) > 1 {
+			kind = PathInheritedRole
+		}
+
+		var chain collections.Role
+		for _, chainRoleID := range chains[roleID] {
+			chain = append(chain, roles[chainRoleID])
+		}
+
+		for _, granting := range explanation.Considered.GrantingPermissions {
+			if helpers.InArray(granting.ID, rolePermissionIDs) {
+				explanation.Paths = append(explanation.Paths, Path{Kind: kind, Permission: granting, Wildcard: granting.ID != permission.ID, Roles: chain})
+			}
+			if helpers.InArray(granting.ID, roleDeniedPermissionIDs) {
+				explanation.Paths = append(explanation.Paths, Path{Kind: kind, Denied: true, Permission: granting, Wildcard: granting.ID != permission.ID, Roles: chain})
+			}
+		}
+	}
+
+	var directAllowed, roleAllowed, directDenied, roleDenied bool
+	for _, path := range explanation.Paths {
+		switch {
+		case path.Kind == PathDirect && !path.Denied:
+			directAllowed = true
+		case path.Kind == PathDirect:
+			directDenied = true
+		case !path.Denied:
+			roleAllowed = true
+		default:
+			roleDenied = true
+		}
 	}
+	explanation.Allowed = s.conflictResolution.resolve(directAllowed, roleAllowed, directDenied, roleDenied)
+
+	return explanation, nil
+}
 
+// directPermissionsOfUser returns the permissions that are returned by the getter for the user in the current scope.
+// example: s.directPermissionsOfUser(ctx, subject, s.PermissionRepository.GetDeniedPermissionIDsOfUserByID)
+// @param context.Context
+// @param models.Subject
+// @param func(context.Context, models.Subject, repositories_scopes.Assignment, repositories_scopes.GormPager) ([]uint, int64, error)
+// @return collections.Permission, error
+func (s *Permify) directPermissionsOfUser(ctx context.Context, subject models.Subject, get func(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) ([]uint, int64, error)) (permissions collections.Permission, err error) {
 	var permissionIDs []uint
-	permissionIDs, _, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(roleIDs, nil)
-	if err != nil {
-		return false, err
+	permissionIDs, _, err = get(ctx, subject, s.assignment(), nil)
+	if err != nil || len(permissionIDs) == 0 {
+		return nil, err
 	}
+	return s.PermissionRepository.GetPermissions(ctx, permissionIDs)
+}
 
-	for _, permissionID := range permissions.IDs() {
-		if helpers.InArray(permissionID, permissionIDs) {
-			return true, err
+// MAINTENANCE
+
+// PurgeExpiredAssignments deletes the expired role, permission and deny assignments of users and roles.
+// The rows are deleted in batches so that the tables are not locked for long. It can be run periodically. (cron, ticker, etc.)
+// @return int64, error
+func (s *Permify) PurgeExpiredAssignments() (deleted int64, err error) {
+	return s.PurgeExpiredAssignmentsCtx(context.Background())
+}
+
+// PurgeExpiredAssignmentsCtx is the context-aware variant of PurgeExpiredAssignments.
+// @param context.Context
+// @return int64, error
+func (s *Permify) PurgeExpiredAssignmentsCtx(ctx context.Context) (deleted int64, err error) {
+	defer wrapError(&err, "PurgeExpiredAssignments", nil)
+
+	now := time.Now()
+
+	purges := []func(ctx context.Context, before time.Time, batchSize int) (int64, error){
+		s.UserRepository.PurgeExpiredRoles,
+		s.UserRepository.PurgeExpiredPermissions,
+		s.UserRepository.PurgeExpiredDeniedPermissions,
+		s.RoleRepository.PurgeExpiredPermissions,
+		s.RoleRepository.PurgeExpiredDeniedPermissions,
+	}
+
+	for _, purge := range purges {
+		var count int64
+		count, err = purge(ctx, now, purgeBatchSize)
+		deleted += count
+		if err != nil {
+			return
 		}
 	}
 
-	return false, err
+	return
 }
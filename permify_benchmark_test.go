@@ -0,0 +1,178 @@
+package permify_gorm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/helpers"
+	"github.com/Permify/go-role/models"
+)
+
+// BenchmarkUserHasPermission compares the single query permission check with the round trips it replaced,
+// on sqlite, for users with many roles. Each role inherits a child role, the permission is given to the last child.
+// go test -run ^$ -bench UserHasPermission
+func BenchmarkUserHasPermission(b *testing.B) {
+	for _, roleCount := range []int{10, 100, 500} {
+		permify, user := newBenchmarkPermify(b, roleCount)
+
+		b.Run(fmt.Sprintf("single query/%d roles", roleCount), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if has, err := permify.UserHasPermission(user, "edit"); err != nil || !has {
+					b.Fatal(has, err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("round trips/%d roles", roleCount), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if has, err := roundTripUserHasAllPermissions(permify, user, "edit"); err != nil || !has {
+					b.Fatal(has, err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkUserHasAllPermissions compares the single query check of many permissions with the round trips it replaced.
+// go test -run ^$ -bench UserHasAllPermissions
+func BenchmarkUserHasAllPermissions(b *testing.B) {
+	for _, roleCount := range []int{10, 100, 500} {
+		permify, user := newBenchmarkPermify(b, roleCount)
+
+		b.Run(fmt.Sprintf("single query/%d roles", roleCount), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if has, err := permify.UserHasAllPermissions(user, []string{"edit", "view"}); err != nil || !has {
+					b.Fatal(has, err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("round trips/%d roles", roleCount), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if has, err := roundTripUserHasAllPermissions(permify, user, []string{"edit", "view"}); err != nil || !has {
+					b.Fatal(has, err)
+				}
+			}
+		})
+	}
+}
+
+// newBenchmarkPermify returns a Permify on a new sqlite database, with a user that has the given number of roles.
+// @param *testing.B
+// @param int
+// @return *Permify, uint
+func newBenchmarkPermify(b *testing.B, roleCount int) (*Permify, uint) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(b.TempDir(), "permify.db")+"?_foreign_keys=on"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	permify, err := New(Options{Migrate: true, DB: db})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, permission := range []string{"edit", "view"} {
+		if err = permify.CreatePermission(permission, ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	var roles []string
+	for i := 0; i < roleCount; i++ {
+		role, child := fmt.Sprintf("role %d", i), fmt.Sprintf("child role %d", i)
+		for _, name := range []string{role, child} {
+			if err = permify.CreateRole(name, ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err = permify.AddChildRolesToRole(role, child); err != nil {
+			b.Fatal(err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err = permify.AddPermissionsToRole(fmt.Sprintf("child role %d", roleCount-1), []string{"edit", "view"}); err != nil {
+		b.Fatal(err)
+	}
+	if err = permify.AddRolesToUser(uint(1), roles); err != nil {
+		b.Fatal(err)
+	}
+
+	return permify, 1
+}
+
+// roundTripUserHasAllPermissions is the permission check before the single query, it fetches the assignments of the user one by one.
+// @param *Permify
+// @param uint
+// @param interface{}
+// @return bool, error
+func roundTripUserHasAllPermissions(s *Permify, user uint, p interface{}) (b bool, err error) {
+	ctx := context.Background()
+	subject := models.UserSubject(models.UserIDFromInt(int64(user)))
+
+	var permissions collections.Permission
+	permissions, err = s.GetPermissionsCtx(ctx, p)
+	if err != nil {
+		return false, err
+	}
+
+	var assignments permissionAssignments
+	assignments.directPermissionIDs, _, err = s.PermissionRepository.GetDirectPermissionIDsOfUserByID(ctx, subject, s.assignment(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	var roleIDs []uint
+	roleIDs, _, err = s.RoleRepository.GetRoleIDsOfUser(ctx, subject, s.assignment(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	roleIDs, err = s.withInheritedRoleIDs(ctx, roleIDs)
+	if err != nil {
+		return false, err
+	}
+
+	assignments.rolePermissionIDs, _, err = s.PermissionRepository.GetPermissionIDsOfRolesByIDs(ctx, roleIDs, nil)
+	if err != nil {
+		return false, err
+	}
+
+	assignments.directDeniedPermissionIDs, _, err = s.PermissionRepository.GetDeniedPermissionIDsOfUserByID(ctx, subject, s.assignment(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	assignments.roleDeniedPermissionIDs, _, err = s.PermissionRepository.GetDeniedPermissionIDsOfRolesByIDs(ctx, roleIDs, nil)
+	if err != nil {
+		return false, err
+	}
+
+	for _, permissionID := range permissions.IDs() {
+		grantingPermissionIDs := []uint{permissionID}
+		if !s.conflictResolution.resolve(
+			helpers.AnyInArray(grantingPermissionIDs, assignments.directPermissionIDs),
+			helpers.AnyInArray(grantingPermissionIDs, assignments.rolePermissionIDs),
+			helpers.AnyInArray(grantingPermissionIDs, assignments.directDeniedPermissionIDs),
+			helpers.AnyInArray(grantingPermissionIDs, assignments.roleDeniedPermissionIDs),
+		) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
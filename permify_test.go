@@ -1,14 +1,26 @@
 package permify_gorm
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 
+	"github.com/Permify/go-role/cache"
 	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/helpers"
 	"github.com/Permify/go-role/models"
 	"github.com/Permify/go-role/options"
+	"github.com/Permify/go-role/repositories"
+	"github.com/Permify/go-role/repositories/memory"
 	"github.com/Permify/go-role/repositories/mocks"
 	"github.com/Permify/go-role/repositories/scopes"
 	"github.com/Permify/go-role/utils"
@@ -22,13 +34,29 @@ func TestPermify(t *testing.T) {
 var _ = Describe("Permify", func() {
 	var permify *Permify
 
+	// newMemoryPermify returns a Permify on the in-memory repositories of a new database.
+	var newMemoryPermify = func(options Options) *Permify {
+		database := memory.NewDatabase()
+		options.RoleRepository = &memory.RoleRepository{Database: database}
+		options.PermissionRepository = &memory.PermissionRepository{Database: database}
+		options.UserRepository = &memory.UserRepository{Database: database}
+		options.Transactor = &memory.Transactor{Database: database}
+		if options.Audit {
+			options.AuditRepository = &memory.AuditRepository{Database: database}
+		}
+
+		permify, err := New(options)
+		Expect(err).ShouldNot(HaveOccurred())
+		return permify
+	}
+
 	Context("Get Role", func() {
 		It("By ID", func() {
 			roleRepository := new(mocks.RoleRepository)
 			r := models.Role{
 				ID: 1,
 			}
-			roleRepository.On("GetRoleByID", uint(1)).Return(r, nil)
+			roleRepository.On("GetRoleByID", mock.Anything, uint(1)).Return(r, nil)
 			permify = &Permify{
 				RoleRepository: roleRepository,
 			}
@@ -43,7 +71,7 @@ var _ = Describe("Permify", func() {
 				Name:      "test role",
 				GuardName: "test-role",
 			}
-			roleRepository.On("GetRoleByGuardName", "test-role").Return(r, nil)
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "test-role").Return(r, nil)
 			permify = &Permify{
 				RoleRepository: roleRepository,
 			}
@@ -64,7 +92,7 @@ var _ = Describe("Permify", func() {
 					ID: 2,
 				},
 			}
-			roleRepository.On("GetRoles", []uint{1, 2}).Return(collections.Role(r), nil)
+			roleRepository.On("GetRoles", mock.Anything, []uint{1, 2}).Return(collections.Role(r), nil)
 			permify = &Permify{
 				RoleRepository: roleRepository,
 			}
@@ -85,7 +113,7 @@ var _ = Describe("Permify", func() {
 					GuardName: "test-role-2",
 				},
 			}
-			roleRepository.On("GetRolesByGuardNames", []string{"test-role", "test-role-2"}).Return(collections.Role(r), nil)
+			roleRepository.On("GetRolesByGuardNames", mock.Anything, []string{"test-role", "test-role-2"}).Return(collections.Role(r), nil)
 			permify = &Permify{
 				RoleRepository: roleRepository,
 			}
@@ -108,8 +136,8 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			roleRepository.On("GetRoleIDs", nil).Return([]uint{1, 2}, int64(2), nil)
-			roleRepository.On("GetRoles", []uint{1, 2}).Return(collections.Role(r), nil)
+			roleRepository.On("GetRoleIDs", mock.Anything, nil).Return([]uint{1, 2}, int64(2), nil)
+			roleRepository.On("GetRoles", mock.Anything, []uint{1, 2}).Return(collections.Role(r), nil)
 
 			permify = &Permify{
 				RoleRepository: roleRepository,
@@ -132,13 +160,13 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			roleRepository.On("GetRoleIDs", &scopes.GormPagination{
+			roleRepository.On("GetRoleIDs", mock.Anything, &scopes.GormPagination{
 				Pagination: &utils.Pagination{
 					Page:  1,
 					Limit: 1,
 				},
 			}).Return([]uint{1}, int64(1), nil)
-			roleRepository.On("GetRoles", []uint{1}).Return(collections.Role(r), nil)
+			roleRepository.On("GetRoles", mock.Anything, []uint{1}).Return(collections.Role(r), nil)
 
 			permify = &Permify{
 				RoleRepository: roleRepository,
@@ -163,8 +191,8 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			roleRepository.On("GetRoleIDsOfUser", uint(1), nil).Return([]uint{1, 2}, int64(2), nil)
-			roleRepository.On("GetRoles", []uint{1, 2}).Return(collections.Role(r), nil)
+			roleRepository.On("GetRoleIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, nil).Return([]uint{1, 2}, int64(2), nil)
+			roleRepository.On("GetRoles", mock.Anything, []uint{1, 2}).Return(collections.Role(r), nil)
 
 			permify = &Permify{
 				RoleRepository: roleRepository,
@@ -187,13 +215,13 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			roleRepository.On("GetRoleIDsOfUser", uint(1), &scopes.GormPagination{
+			roleRepository.On("GetRoleIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, &scopes.GormPagination{
 				Pagination: &utils.Pagination{
 					Page:  1,
 					Limit: 1,
 				},
 			}).Return([]uint{1}, int64(1), nil)
-			roleRepository.On("GetRoles", []uint{1}).Return(collections.Role(r), nil)
+			roleRepository.On("GetRoles", mock.Anything, []uint{1}).Return(collections.Role(r), nil)
 
 			permify = &Permify{
 				RoleRepository: roleRepository,
@@ -214,7 +242,7 @@ var _ = Describe("Permify", func() {
 				GuardName: "test",
 			}
 
-			roleRepository.On("FirstOrCreate", &r).Return(nil)
+			roleRepository.On("FirstOrCreate", mock.Anything, &r).Return(nil)
 
 			permify = &Permify{
 				RoleRepository: roleRepository,
@@ -234,8 +262,8 @@ var _ = Describe("Permify", func() {
 				GuardName: "test",
 			}
 
-			roleRepository.On("GetRoleByID", uint(1)).Return(r, nil)
-			roleRepository.On("Delete", &r).Return(nil)
+			roleRepository.On("GetRoleByID", mock.Anything, uint(1)).Return(r, nil)
+			roleRepository.On("Delete", mock.Anything, &r).Return(nil)
 
 			permify = &Permify{
 				RoleRepository: roleRepository,
@@ -252,8 +280,8 @@ var _ = Describe("Permify", func() {
 				GuardName: "test",
 			}
 
-			roleRepository.On("GetRoleByGuardName", "test").Return(r, nil)
-			roleRepository.On("Delete", &r).Return(nil)
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "test").Return(r, nil)
+			roleRepository.On("Delete", mock.Anything, &r).Return(nil)
 
 			permify = &Permify{
 				RoleRepository: roleRepository,
@@ -282,9 +310,9 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissions", []uint{1, 2}).Return(collections.Permission(p), nil)
-			roleRepository.On("GetRoleByGuardName", "test-role").Return(r, nil)
-			roleRepository.On("AddPermissions", &r, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2}).Return(collections.Permission(p), nil)
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "test-role").Return(r, nil)
+			roleRepository.On("AddPermissions", mock.Anything, &r, collections.Permission(p), scopes.Window{}).Return(nil)
 			permify = &Permify{
 				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
@@ -311,9 +339,9 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissionsByGuardNames", []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
-			roleRepository.On("GetRoleByID", uint(1)).Return(r, nil)
-			roleRepository.On("AddPermissions", &r, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissionsByGuardNames", mock.Anything, []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
+			roleRepository.On("GetRoleByID", mock.Anything, uint(1)).Return(r, nil)
+			roleRepository.On("AddPermissions", mock.Anything, &r, collections.Permission(p), scopes.Window{}).Return(nil)
 			permify = &Permify{
 				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
@@ -322,6 +350,42 @@ var _ = Describe("Permify", func() {
 		})
 	})
 
+	Context("Add Permissions to Role Until", func() {
+		It("By Names", func() {
+			roleRepository := new(mocks.RoleRepository)
+			permissionRepository := new(mocks.PermissionRepository)
+
+			r := models.Role{
+				ID:        1,
+				Name:      "contractor",
+				GuardName: "contractor",
+			}
+
+			p := []models.Permission{
+				{
+					ID:        1,
+					Name:      "deploy",
+					GuardName: "deploy",
+				},
+			}
+
+			expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "contractor").Return(r, nil)
+			permissionRepository.On("GetPermissionsByGuardNames", mock.Anything, []string{"deploy"}).Return(collections.Permission(p), nil)
+			roleRepository.On("AddPermissions", mock.Anything, &r, collections.Permission(p), scopes.Window{ExpiresAt: &expiresAt}).Return(nil)
+
+			permify = &Permify{
+				RoleRepository:       roleRepository,
+				PermissionRepository: permissionRepository,
+			}
+
+			err := permify.AddPermissionsToRoleUntil("contractor", []string{"deploy"}, expiresAt)
+			Expect(err).ShouldNot(HaveOccurred())
+			roleRepository.AssertExpectations(GinkgoT())
+		})
+	})
+
 	Context("Replace Permissions to Role", func() {
 		It("By IDs", func() {
 			roleRepository := new(mocks.RoleRepository)
@@ -340,9 +404,9 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissions", []uint{1, 2}).Return(collections.Permission(p), nil)
-			roleRepository.On("GetRoleByGuardName", "test-role").Return(r, nil)
-			roleRepository.On("ReplacePermissions", &r, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2}).Return(collections.Permission(p), nil)
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "test-role").Return(r, nil)
+			roleRepository.On("ReplacePermissions", mock.Anything, &r, collections.Permission(p), scopes.Window{}).Return(nil)
 			permify = &Permify{
 				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
@@ -369,9 +433,9 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissionsByGuardNames", []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
-			roleRepository.On("GetRoleByID", uint(1)).Return(r, nil)
-			roleRepository.On("ReplacePermissions", &r, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissionsByGuardNames", mock.Anything, []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
+			roleRepository.On("GetRoleByID", mock.Anything, uint(1)).Return(r, nil)
+			roleRepository.On("ReplacePermissions", mock.Anything, &r, collections.Permission(p), scopes.Window{}).Return(nil)
 			permify = &Permify{
 				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
@@ -387,9 +451,9 @@ var _ = Describe("Permify", func() {
 				GuardName: "test-role",
 			}
 
-			permissionRepository.On("GetPermissions", []uint{}).Return(collections.Permission{}, nil)
-			roleRepository.On("GetRoleByGuardName", "test-role").Return(r, nil)
-			roleRepository.On("ClearPermissions", &r).Return(nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{}).Return(collections.Permission{}, nil)
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "test-role").Return(r, nil)
+			roleRepository.On("ClearPermissions", mock.Anything, &r).Return(nil)
 			permify = &Permify{
 				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
@@ -416,9 +480,9 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissions", []uint{1, 2}).Return(collections.Permission(p), nil)
-			roleRepository.On("GetRoleByGuardName", "test-role").Return(r, nil)
-			roleRepository.On("RemovePermissions", &r, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2}).Return(collections.Permission(p), nil)
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "test-role").Return(r, nil)
+			roleRepository.On("RemovePermissions", mock.Anything, &r, collections.Permission(p)).Return(nil)
 			permify = &Permify{
 				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
@@ -445,9 +509,9 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissionsByGuardNames", []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
-			roleRepository.On("GetRoleByID", uint(1)).Return(r, nil)
-			roleRepository.On("RemovePermissions", &r, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissionsByGuardNames", mock.Anything, []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
+			roleRepository.On("GetRoleByID", mock.Anything, uint(1)).Return(r, nil)
+			roleRepository.On("RemovePermissions", mock.Anything, &r, collections.Permission(p)).Return(nil)
 			permify = &Permify{
 				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
@@ -456,13 +520,131 @@ var _ = Describe("Permify", func() {
 		})
 	})
 
+	Context("Add Child Roles to Role", func() {
+		It("Success", func() {
+			roleRepository := new(mocks.RoleRepository)
+
+			r := models.Role{
+				ID:        1,
+				Name:      "admin",
+				GuardName: "admin",
+			}
+
+			c := []models.Role{
+				{
+					ID:        2,
+					Name:      "editor",
+					GuardName: "editor",
+				},
+			}
+
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "admin").Return(r, nil)
+			roleRepository.On("GetRolesByGuardNames", mock.Anything, []string{"editor"}).Return(collections.Role(c), nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{2}).Return([]uint{3}, nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{3}).Return([]uint{}, nil)
+			roleRepository.On("AddChildren", mock.Anything, &r, collections.Role(c)).Return(nil)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+			}
+
+			err := permify.AddChildRolesToRole("admin", []string{"editor"})
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Circular", func() {
+			roleRepository := new(mocks.RoleRepository)
+
+			r := models.Role{
+				ID:        1,
+				Name:      "viewer",
+				GuardName: "viewer",
+			}
+
+			c := []models.Role{
+				{
+					ID:        2,
+					Name:      "admin",
+					GuardName: "admin",
+				},
+			}
+
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "viewer").Return(r, nil)
+			roleRepository.On("GetRolesByGuardNames", mock.Anything, []string{"admin"}).Return(collections.Role(c), nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{2}).Return([]uint{3}, nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{3}).Return([]uint{1}, nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{1}).Return([]uint{}, nil)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+			}
+
+			err := permify.AddChildRolesToRole("viewer", []string{"admin"})
+			var circularInheritanceError *CircularInheritanceError
+			Expect(errors.As(err, &circularInheritanceError)).Should(BeTrue())
+			Expect(circularInheritanceError.Role).Should(Equal("viewer"))
+			Expect(circularInheritanceError.Child).Should(Equal("admin"))
+			roleRepository.AssertNotCalled(GinkgoT(), "AddChildren", mock.Anything, &r, collections.Role(c))
+		})
+
+		It("Self", func() {
+			roleRepository := new(mocks.RoleRepository)
+
+			r := models.Role{
+				ID:        1,
+				Name:      "admin",
+				GuardName: "admin",
+			}
+
+			roleRepository.On("GetRoleByID", mock.Anything, uint(1)).Return(r, nil)
+			roleRepository.On("GetRoles", mock.Anything, []uint{1}).Return(collections.Role{r}, nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{1}).Return([]uint{}, nil)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+			}
+
+			err := permify.AddChildRolesToRole(uint(1), []uint{1})
+			var circularInheritanceError *CircularInheritanceError
+			Expect(errors.As(err, &circularInheritanceError)).Should(BeTrue())
+			Expect(circularInheritanceError).Should(Equal(&CircularInheritanceError{Role: "admin", Child: "admin"}))
+		})
+	})
+
+	Context("Remove Child Roles from Role", func() {
+		It("By IDs", func() {
+			roleRepository := new(mocks.RoleRepository)
+
+			r := models.Role{
+				ID: 1,
+			}
+
+			c := []models.Role{
+				{
+					ID: 2,
+				},
+			}
+
+			roleRepository.On("GetRoleByID", mock.Anything, uint(1)).Return(r, nil)
+			roleRepository.On("GetRoles", mock.Anything, []uint{2}).Return(collections.Role(c), nil)
+			roleRepository.On("RemoveChildren", mock.Anything, &r, collections.Role(c)).Return(nil)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+			}
+
+			err := permify.RemoveChildRolesFromRole(uint(1), []uint{2})
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
 	Context("Get Permission", func() {
 		It("By ID", func() {
 			permissionRepository := new(mocks.PermissionRepository)
 			r := models.Permission{
 				ID: 1,
 			}
-			permissionRepository.On("GetPermissionByID", uint(1)).Return(r, nil)
+			permissionRepository.On("GetPermissionByID", mock.Anything, uint(1)).Return(r, nil)
 			permify = &Permify{
 				PermissionRepository: permissionRepository,
 			}
@@ -477,7 +659,7 @@ var _ = Describe("Permify", func() {
 				Name:      "test permission",
 				GuardName: "test-permission",
 			}
-			permissionRepository.On("GetPermissionByGuardName", "test-permission").Return(r, nil)
+			permissionRepository.On("GetPermissionByGuardName", mock.Anything, "test-permission").Return(r, nil)
 			permify = &Permify{
 				PermissionRepository: permissionRepository,
 			}
@@ -498,7 +680,7 @@ var _ = Describe("Permify", func() {
 					ID: 2,
 				},
 			}
-			permissionRepository.On("GetPermissions", []uint{1, 2}).Return(collections.Permission(p), nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2}).Return(collections.Permission(p), nil)
 			permify = &Permify{
 				PermissionRepository: permissionRepository,
 			}
@@ -519,7 +701,7 @@ var _ = Describe("Permify", func() {
 					GuardName: "test-permission-2",
 				},
 			}
-			permissionRepository.On("GetPermissionsByGuardNames", []string{"test-permission", "test-permission-2"}).Return(collections.Permission(p), nil)
+			permissionRepository.On("GetPermissionsByGuardNames", mock.Anything, []string{"test-permission", "test-permission-2"}).Return(collections.Permission(p), nil)
 			permify = &Permify{
 				PermissionRepository: permissionRepository,
 			}
@@ -542,8 +724,8 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissionIDs", nil).Return([]uint{1, 2}, int64(2), nil)
-			permissionRepository.On("GetPermissions", []uint{1, 2}).Return(collections.Permission(p), nil)
+			permissionRepository.On("GetPermissionIDs", mock.Anything, nil).Return([]uint{1, 2}, int64(2), nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
 				PermissionRepository: permissionRepository,
@@ -566,13 +748,13 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissionIDs", &scopes.GormPagination{
+			permissionRepository.On("GetPermissionIDs", mock.Anything, &scopes.GormPagination{
 				Pagination: &utils.Pagination{
 					Page:  1,
 					Limit: 1,
 				},
 			}).Return([]uint{1}, int64(1), nil)
-			permissionRepository.On("GetPermissions", []uint{1}).Return(collections.Permission(p), nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
 				PermissionRepository: permissionRepository,
@@ -597,8 +779,8 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetDirectPermissionIDsOfUserByID", uint(1), nil).Return([]uint{1, 2}, int64(2), nil)
-			permissionRepository.On("GetPermissions", []uint{1, 2}).Return(collections.Permission(p), nil)
+			permissionRepository.On("GetDirectPermissionIDsOfUserByID", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, nil).Return([]uint{1, 2}, int64(2), nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
 				PermissionRepository: permissionRepository,
@@ -621,13 +803,13 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetDirectPermissionIDsOfUserByID", uint(1), &scopes.GormPagination{
+			permissionRepository.On("GetDirectPermissionIDsOfUserByID", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, &scopes.GormPagination{
 				Pagination: &utils.Pagination{
 					Page:  1,
 					Limit: 1,
 				},
 			}).Return([]uint{1}, int64(1), nil)
-			permissionRepository.On("GetPermissions", []uint{1}).Return(collections.Permission(p), nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
 				PermissionRepository: permissionRepository,
@@ -662,9 +844,9 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissionIDsOfRolesByIDs", []uint{1, 2}, nil).Return([]uint{1, 2}, int64(2), nil)
-			permissionRepository.On("GetPermissions", []uint{1, 2}).Return(collections.Permission(p), nil)
-			roleRepository.On("GetRoles", []uint{1, 2}).Return(collections.Role(r), nil)
+			permissionRepository.On("GetPermissionIDsOfRolesByIDs", mock.Anything, []uint{1, 2}, nil).Return([]uint{1, 2}, int64(2), nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2}).Return(collections.Permission(p), nil)
+			roleRepository.On("GetRoles", mock.Anything, []uint{1, 2}).Return(collections.Role(r), nil)
 
 			permify = &Permify{
 				RoleRepository:       roleRepository,
@@ -698,14 +880,14 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissionIDsOfRolesByIDs", []uint{1, 2}, &scopes.GormPagination{
+			permissionRepository.On("GetPermissionIDsOfRolesByIDs", mock.Anything, []uint{1, 2}, &scopes.GormPagination{
 				Pagination: &utils.Pagination{
 					Page:  1,
 					Limit: 1,
 				},
 			}).Return([]uint{1}, int64(1), nil)
-			permissionRepository.On("GetPermissions", []uint{1}).Return(collections.Permission(p), nil)
-			roleRepository.On("GetRoles", []uint{1, 2}).Return(collections.Role(r), nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1}).Return(collections.Permission(p), nil)
+			roleRepository.On("GetRoles", mock.Anything, []uint{1, 2}).Return(collections.Role(r), nil)
 
 			permify = &Permify{
 				RoleRepository:       roleRepository,
@@ -732,11 +914,13 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			roleRepository.On("GetRoleIDsOfUser", uint(1), nil).Return([]uint{1, 2}, int64(2), nil)
+			roleRepository.On("GetRoleIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, nil).Return([]uint{1, 2}, int64(2), nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{1, 2}).Return([]uint{}, nil)
 
-			permissionRepository.On("GetDirectPermissionIDsOfUserByID", uint(1), nil).Return([]uint{1}, int64(1), nil)
-			permissionRepository.On("GetPermissionIDsOfRolesByIDs", []uint{1, 2}, nil).Return([]uint{1, 2}, int64(2), nil)
-			permissionRepository.On("GetPermissions", []uint{1, 2}).Return(collections.Permission(p), nil)
+			permissionRepository.On("GetDirectPermissionIDsOfUserByID", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, nil).Return([]uint{1}, int64(1), nil)
+			permissionRepository.On("GetPermissionIDsOfRolesByIDs", mock.Anything, []uint{1, 2}, nil).Return([]uint{1, 2}, int64(2), nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2}).Return(collections.Permission(p), nil)
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1, 2}).Return(repositories.EffectivePermissionIDs{DirectPermissionIDs: []uint{1}, RolePermissionIDs: []uint{1, 2}}, nil)
 
 			permify = &Permify{
 				RoleRepository:       roleRepository,
@@ -747,6 +931,25 @@ var _ = Describe("Permify", func() {
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(p).Should(Equal(actualResult.Origin()))
 		})
+
+		It("Leaves Out the Denied Permissions", func() {
+			permify := newMemoryPermify(Options{})
+
+			Expect(permify.CreateRole("editor", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreateRole("banned", "")).ShouldNot(HaveOccurred())
+			for _, permission := range []string{"view", "edit", "delete", "publish"} {
+				Expect(permify.CreatePermission(permission, "")).ShouldNot(HaveOccurred())
+			}
+			Expect(permify.AddPermissionsToRole("editor", []string{"view", "edit", "delete"})).ShouldNot(HaveOccurred())
+			Expect(permify.DenyPermissionsToRole("banned", "delete")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(1, []string{"editor", "banned"})).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToUser(1, "publish")).ShouldNot(HaveOccurred())
+			Expect(permify.DenyPermissionsToUser(1, "edit")).ShouldNot(HaveOccurred())
+
+			permissions, err := permify.GetAllPermissionsOfUser(1)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(permissions.GuardNames()).Should(ConsistOf("view", "publish"))
+		})
 	})
 
 	Context("Create Permission", func() {
@@ -758,7 +961,7 @@ var _ = Describe("Permify", func() {
 				GuardName: "test",
 			}
 
-			permissionRepository.On("FirstOrCreate", &p).Return(nil)
+			permissionRepository.On("FirstOrCreate", mock.Anything, &p).Return(nil)
 
 			permify = &Permify{
 				PermissionRepository: permissionRepository,
@@ -778,8 +981,8 @@ var _ = Describe("Permify", func() {
 				GuardName: "test",
 			}
 
-			permissionRepository.On("GetPermissionByID", uint(1)).Return(r, nil)
-			permissionRepository.On("Delete", &r).Return(nil)
+			permissionRepository.On("GetPermissionByID", mock.Anything, uint(1)).Return(r, nil)
+			permissionRepository.On("Delete", mock.Anything, &r).Return(nil)
 
 			permify = &Permify{
 				PermissionRepository: permissionRepository,
@@ -796,8 +999,8 @@ var _ = Describe("Permify", func() {
 				GuardName: "test",
 			}
 
-			permissionRepository.On("GetPermissionByGuardName", "test").Return(p, nil)
-			permissionRepository.On("Delete", &p).Return(nil)
+			permissionRepository.On("GetPermissionByGuardName", mock.Anything, "test").Return(p, nil)
+			permissionRepository.On("Delete", mock.Anything, &p).Return(nil)
 
 			permify = &Permify{
 				PermissionRepository: permissionRepository,
@@ -821,8 +1024,8 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			userRepository.On("AddPermissions", uint(1), collections.Permission(p)).Return(nil)
-			permissionRepository.On("GetPermissions", []uint{1, 2}).Return(collections.Permission(p), nil)
+			userRepository.On("AddPermissions", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
 				UserRepository:       userRepository,
@@ -847,8 +1050,8 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			userRepository.On("AddPermissions", uint(1), collections.Permission(p)).Return(nil)
-			permissionRepository.On("GetPermissionsByGuardNames", []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
+			userRepository.On("AddPermissions", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissionsByGuardNames", mock.Anything, []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
 				UserRepository:       userRepository,
@@ -873,8 +1076,8 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			userRepository.On("ReplacePermissions", uint(1), collections.Permission(p)).Return(nil)
-			permissionRepository.On("GetPermissions", []uint{1, 2}).Return(collections.Permission(p), nil)
+			userRepository.On("ReplacePermissions", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
 				UserRepository:       userRepository,
@@ -899,8 +1102,8 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			userRepository.On("ReplacePermissions", uint(1), collections.Permission(p)).Return(nil)
-			permissionRepository.On("GetPermissionsByGuardNames", []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
+			userRepository.On("ReplacePermissions", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissionsByGuardNames", mock.Anything, []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
 				UserRepository:       userRepository,
@@ -914,8 +1117,8 @@ var _ = Describe("Permify", func() {
 			userRepository := new(mocks.UserRepository)
 			permissionRepository := new(mocks.PermissionRepository)
 
-			permissionRepository.On("GetPermissions", []uint{}).Return(collections.Permission{}, nil)
-			userRepository.On("ClearPermissions", uint(1)).Return(nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{}).Return(collections.Permission{}, nil)
+			userRepository.On("ClearPermissions", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}).Return(nil)
 
 			permify = &Permify{
 				UserRepository:       userRepository,
@@ -940,8 +1143,8 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			userRepository.On("RemovePermissions", uint(1), collections.Permission(p)).Return(nil)
-			permissionRepository.On("GetPermissions", []uint{1, 2}).Return(collections.Permission(p), nil)
+			userRepository.On("RemovePermissions", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
 				UserRepository:       userRepository,
@@ -966,8 +1169,8 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			userRepository.On("RemovePermissions", uint(1), collections.Permission(p)).Return(nil)
-			permissionRepository.On("GetPermissionsByGuardNames", []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
+			userRepository.On("RemovePermissions", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissionsByGuardNames", mock.Anything, []string{"permission-1", "permission-2"}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
 				UserRepository:       userRepository,
@@ -978,59 +1181,55 @@ var _ = Describe("Permify", func() {
 		})
 	})
 
-	Context("Add Roles to User", func() {
-		It("By IDs", func() {
-			roleRepository := new(mocks.RoleRepository)
+	Context("Deny Permissions to User", func() {
+		It("By Names", func() {
+			permissionRepository := new(mocks.PermissionRepository)
 			userRepository := new(mocks.UserRepository)
 
-			r := []models.Role{
-				{
-					ID: 1,
-				},
+			p := []models.Permission{
 				{
-					ID: 2,
+					ID:        1,
+					Name:      "payments.refund",
+					GuardName: "payments-refund",
 				},
 			}
 
-			roleRepository.On("GetRoles", collections.Role(r).IDs()).Return(collections.Role(r), nil)
-			userRepository.On("AddRoles", uint(1), collections.Role(r)).Return(nil)
+			userRepository.On("DenyPermissions", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissionsByGuardNames", mock.Anything, []string{"payments-refund"}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
-				UserRepository: userRepository,
-				RoleRepository: roleRepository,
+				UserRepository:       userRepository,
+				PermissionRepository: permissionRepository,
 			}
 
-			Expect(permify.AddRolesToUser(uint(1), collections.Role(r).IDs())).ShouldNot(HaveOccurred())
+			Expect(permify.DenyPermissionsToUser(uint(1), collections.Permission(p).Names())).ShouldNot(HaveOccurred())
 		})
+	})
 
-		It("By Names", func() {
-			roleRepository := new(mocks.RoleRepository)
+	Context("Remove Denied Permissions from User", func() {
+		It("By IDs", func() {
+			permissionRepository := new(mocks.PermissionRepository)
 			userRepository := new(mocks.UserRepository)
 
-			r := []models.Role{
-				{
-					Name:      "role 1",
-					GuardName: "role-1",
-				},
+			p := []models.Permission{
 				{
-					Name:      "role 2",
-					GuardName: "role-2",
+					ID: 1,
 				},
 			}
 
-			roleRepository.On("GetRolesByGuardNames", collections.Role(r).GuardNames()).Return(collections.Role(r), nil)
-			userRepository.On("AddRoles", uint(1), collections.Role(r)).Return(nil)
+			userRepository.On("RemoveDeniedPermissions", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission(p)).Return(nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1}).Return(collections.Permission(p), nil)
 
 			permify = &Permify{
-				UserRepository: userRepository,
-				RoleRepository: roleRepository,
+				UserRepository:       userRepository,
+				PermissionRepository: permissionRepository,
 			}
 
-			Expect(permify.AddRolesToUser(uint(1), collections.Role(r).Names())).ShouldNot(HaveOccurred())
+			Expect(permify.RemoveDeniedPermissionsFromUser(uint(1), collections.Permission(p).IDs())).ShouldNot(HaveOccurred())
 		})
 	})
 
-	Context("Replace Roles to User", func() {
+	Context("Add Roles to User", func() {
 		It("By IDs", func() {
 			roleRepository := new(mocks.RoleRepository)
 			userRepository := new(mocks.UserRepository)
@@ -1044,15 +1243,15 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			roleRepository.On("GetRoles", collections.Role(r).IDs()).Return(collections.Role(r), nil)
-			userRepository.On("ReplaceRoles", uint(1), collections.Role(r)).Return(nil)
+			roleRepository.On("GetRoles", mock.Anything, collections.Role(r).IDs()).Return(collections.Role(r), nil)
+			userRepository.On("AddRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role(r)).Return(nil)
 
 			permify = &Permify{
 				UserRepository: userRepository,
 				RoleRepository: roleRepository,
 			}
 
-			Expect(permify.ReplaceRolesToUser(uint(1), collections.Role(r).IDs())).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(uint(1), collections.Role(r).IDs())).ShouldNot(HaveOccurred())
 		})
 
 		It("By Names", func() {
@@ -1070,179 +1269,159 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			roleRepository.On("GetRolesByGuardNames", collections.Role(r).GuardNames()).Return(collections.Role(r), nil)
-			userRepository.On("ReplaceRoles", uint(1), collections.Role(r)).Return(nil)
+			roleRepository.On("GetRolesByGuardNames", mock.Anything, collections.Role(r).GuardNames()).Return(collections.Role(r), nil)
+			userRepository.On("AddRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role(r)).Return(nil)
 
 			permify = &Permify{
 				UserRepository: userRepository,
 				RoleRepository: roleRepository,
 			}
 
-			Expect(permify.ReplaceRolesToUser(uint(1), collections.Role(r).Names())).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(uint(1), collections.Role(r).Names())).ShouldNot(HaveOccurred())
 		})
+	})
 
-		It("Clear", func() {
+	Context("Add Roles to User in Tenant", func() {
+		It("By Names", func() {
 			roleRepository := new(mocks.RoleRepository)
 			userRepository := new(mocks.UserRepository)
 
-			roleRepository.On("GetRoles", []uint{}).Return(collections.Role{}, nil)
-			userRepository.On("ClearRoles", uint(1)).Return(nil)
+			r := []models.Role{
+				{
+					ID:        1,
+					Name:      "admin",
+					GuardName: "admin",
+				},
+			}
+
+			roleRepository.On("GetRolesByGuardNames", mock.Anything, []string{"admin"}).Return(collections.Role(r), nil)
+			userRepository.On("AddRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{TenantID: "org-a"}, collections.Role(r)).Return(nil)
 
 			permify = &Permify{
-				UserRepository: userRepository,
 				RoleRepository: roleRepository,
+				UserRepository: userRepository,
 			}
 
-			Expect(permify.ReplaceRolesToUser(uint(1), []uint{})).ShouldNot(HaveOccurred())
+			err := permify.Tenant("org-a").AddRolesToUser(1, []string{"admin"})
+			Expect(err).ShouldNot(HaveOccurred())
+			userRepository.AssertExpectations(GinkgoT())
 		})
 	})
 
-	// Controls
-
-	Context("Role Has Permission", func() {
-		It("Success", func() {
+	Context("Add Roles to User Until", func() {
+		It("By Names", func() {
 			roleRepository := new(mocks.RoleRepository)
-			permissionRepository := new(mocks.PermissionRepository)
+			userRepository := new(mocks.UserRepository)
 
 			r := []models.Role{
 				{
-					ID: 1,
-				},
-				{
-					ID: 2,
+					ID:        1,
+					Name:      "on call",
+					GuardName: "on-call",
 				},
 			}
 
-			p := models.Permission{
-				ID:        1,
-				Name:      "permission 1",
-				GuardName: "permission-1",
-			}
+			expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
 
-			roleRepository.On("GetRoles", collections.Role(r).IDs()).Return(collections.Role(r), nil)
-			permissionRepository.On("GetPermissionByID", p.ID).Return(p, nil)
-			roleRepository.On("HasPermission", collections.Role(r), p).Return(true, nil)
+			roleRepository.On("GetRolesByGuardNames", mock.Anything, []string{"on-call"}).Return(collections.Role(r), nil)
+			userRepository.On("AddRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{Window: scopes.Window{ExpiresAt: &expiresAt}}, collections.Role(r)).Return(nil)
 
 			permify = &Permify{
-				RoleRepository:       roleRepository,
-				PermissionRepository: permissionRepository,
+				RoleRepository: roleRepository,
+				UserRepository: userRepository,
 			}
 
-			actualResult, err := permify.RoleHasPermission(collections.Role(r).IDs(), p.ID)
+			err := permify.AddRolesToUserUntil(1, []string{"on call"}, expiresAt)
 			Expect(err).ShouldNot(HaveOccurred())
-			Expect(true).Should(Equal(actualResult))
+			userRepository.AssertExpectations(GinkgoT())
 		})
-	})
 
-	Context("Role Has All Permission", func() {
-		It("Success", func() {
+		It("Between", func() {
 			roleRepository := new(mocks.RoleRepository)
-			permissionRepository := new(mocks.PermissionRepository)
+			userRepository := new(mocks.UserRepository)
 
 			r := []models.Role{
-				{
-					ID: 1,
-				},
-				{
-					ID: 2,
-				},
-			}
-
-			p := []models.Permission{
 				{
 					ID:        1,
-					Name:      "permission 1",
-					GuardName: "permission-1",
-				},
-				{
-					ID:        2,
-					Name:      "permission 2",
-					GuardName: "permission-2",
+					Name:      "on call",
+					GuardName: "on-call",
 				},
 			}
 
-			roleRepository.On("GetRoles", collections.Role(r).IDs()).Return(collections.Role(r), nil)
-			permissionRepository.On("GetPermissions", collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
-			roleRepository.On("HasAllPermissions", collections.Role(r), collections.Permission(p)).Return(true, nil)
+			startsAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+			expiresAt := startsAt.Add(8 * time.Hour)
+
+			roleRepository.On("GetRolesByGuardNames", mock.Anything, []string{"on-call"}).Return(collections.Role(r), nil)
+			userRepository.On("AddRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{TenantID: "org-a", Window: scopes.Window{StartsAt: &startsAt, ExpiresAt: &expiresAt}}, collections.Role(r)).Return(nil)
 
 			permify = &Permify{
-				RoleRepository:       roleRepository,
-				PermissionRepository: permissionRepository,
+				RoleRepository: roleRepository,
+				UserRepository: userRepository,
 			}
 
-			actualResult, err := permify.RoleHasAllPermissions(collections.Role(r).IDs(), collections.Role(r).IDs())
+			err := permify.Tenant("org-a").Between(startsAt, expiresAt).AddRolesToUser(1, []string{"on call"})
 			Expect(err).ShouldNot(HaveOccurred())
-			Expect(true).Should(Equal(actualResult))
+			userRepository.AssertExpectations(GinkgoT())
 		})
-	})
 
-	Context("Role Has Any Permission", func() {
-		It("Success", func() {
+		It("Until Keeps the Start of Between", func() {
 			roleRepository := new(mocks.RoleRepository)
-			permissionRepository := new(mocks.PermissionRepository)
+			userRepository := new(mocks.UserRepository)
 
 			r := []models.Role{
-				{
-					ID: 1,
-				},
-				{
-					ID: 2,
-				},
-			}
-
-			p := []models.Permission{
 				{
 					ID:        1,
-					Name:      "permission 1",
-					GuardName: "permission-1",
-				},
-				{
-					ID:        2,
-					Name:      "permission 2",
-					GuardName: "permission-2",
+					Name:      "on call",
+					GuardName: "on-call",
 				},
 			}
 
-			roleRepository.On("GetRoles", collections.Role(r).IDs()).Return(collections.Role(r), nil)
-			permissionRepository.On("GetPermissions", collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
-			roleRepository.On("HasAnyPermissions", collections.Role(r), collections.Permission(p)).Return(true, nil)
+			startsAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+			expiresAt := startsAt.Add(8 * time.Hour)
+
+			roleRepository.On("GetRolesByGuardNames", mock.Anything, []string{"on-call"}).Return(collections.Role(r), nil)
+			userRepository.On("AddRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{Window: scopes.Window{StartsAt: &startsAt, ExpiresAt: &expiresAt}}, collections.Role(r)).Return(nil)
 
 			permify = &Permify{
-				RoleRepository:       roleRepository,
-				PermissionRepository: permissionRepository,
+				RoleRepository: roleRepository,
+				UserRepository: userRepository,
 			}
 
-			actualResult, err := permify.RoleHasAnyPermissions(collections.Role(r).IDs(), collections.Role(r).IDs())
+			err := permify.Between(startsAt, time.Time{}).AddRolesToUserUntil(1, []string{"on call"}, expiresAt)
 			Expect(err).ShouldNot(HaveOccurred())
-			Expect(true).Should(Equal(actualResult))
+			userRepository.AssertExpectations(GinkgoT())
 		})
 	})
 
-	Context("User Has Role", func() {
-		It("Success", func() {
+	Context("Add Roles to User On", func() {
+		It("By Names", func() {
 			roleRepository := new(mocks.RoleRepository)
 			userRepository := new(mocks.UserRepository)
 
-			r := models.Role{
-				ID: 1,
+			r := []models.Role{
+				{
+					ID:        2,
+					Name:      "editor",
+					GuardName: "editor",
+				},
 			}
 
-			roleRepository.On("GetRoleByID", r.ID).Return(r, nil)
-			userRepository.On("HasRole", uint(1), r).Return(true, nil)
+			roleRepository.On("GetRolesByGuardNames", mock.Anything, []string{"editor"}).Return(collections.Role(r), nil)
+			userRepository.On("AddRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(7)), scopes.Assignment{TenantID: "org-a", ResourceType: "project", ResourceID: "42"}, collections.Role(r)).Return(nil)
 
 			permify = &Permify{
 				RoleRepository: roleRepository,
 				UserRepository: userRepository,
 			}
 
-			actualResult, err := permify.UserHasRole(uint(1), r.ID)
+			err := permify.Tenant("org-a").AddRolesToUserOn(7, []string{"editor"}, "project", "42")
 			Expect(err).ShouldNot(HaveOccurred())
-			Expect(true).Should(Equal(actualResult))
+			userRepository.AssertExpectations(GinkgoT())
 		})
 	})
 
-	Context("User Has All Roles", func() {
-		It("Success", func() {
+	Context("Replace Roles to User", func() {
+		It("By IDs", func() {
 			roleRepository := new(mocks.RoleRepository)
 			userRepository := new(mocks.UserRepository)
 
@@ -1255,24 +1434,65 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			roleRepository.On("GetRoles", collections.Role(r).IDs()).Return(collections.Role(r), nil)
-			userRepository.On("HasAllRoles", uint(1), collections.Role(r)).Return(true, nil)
+			roleRepository.On("GetRoles", mock.Anything, collections.Role(r).IDs()).Return(collections.Role(r), nil)
+			userRepository.On("ReplaceRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role(r)).Return(nil)
 
 			permify = &Permify{
-				RoleRepository: roleRepository,
 				UserRepository: userRepository,
+				RoleRepository: roleRepository,
 			}
 
-			actualResult, err := permify.UserHasAllRoles(uint(1), collections.Role(r).IDs())
-			Expect(err).ShouldNot(HaveOccurred())
-			Expect(true).Should(Equal(actualResult))
+			Expect(permify.ReplaceRolesToUser(uint(1), collections.Role(r).IDs())).ShouldNot(HaveOccurred())
+		})
+
+		It("By Names", func() {
+			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
+
+			r := []models.Role{
+				{
+					Name:      "role 1",
+					GuardName: "role-1",
+				},
+				{
+					Name:      "role 2",
+					GuardName: "role-2",
+				},
+			}
+
+			roleRepository.On("GetRolesByGuardNames", mock.Anything, collections.Role(r).GuardNames()).Return(collections.Role(r), nil)
+			userRepository.On("ReplaceRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role(r)).Return(nil)
+
+			permify = &Permify{
+				UserRepository: userRepository,
+				RoleRepository: roleRepository,
+			}
+
+			Expect(permify.ReplaceRolesToUser(uint(1), collections.Role(r).Names())).ShouldNot(HaveOccurred())
+		})
+
+		It("Clear", func() {
+			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
+
+			roleRepository.On("GetRoles", mock.Anything, []uint{}).Return(collections.Role{}, nil)
+			userRepository.On("ClearRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}).Return(nil)
+
+			permify = &Permify{
+				UserRepository: userRepository,
+				RoleRepository: roleRepository,
+			}
+
+			Expect(permify.ReplaceRolesToUser(uint(1), []uint{})).ShouldNot(HaveOccurred())
 		})
 	})
 
-	Context("User Has Any Roles", func() {
+	// Controls
+
+	Context("Role Has Permission", func() {
 		It("Success", func() {
 			roleRepository := new(mocks.RoleRepository)
-			userRepository := new(mocks.UserRepository)
+			permissionRepository := new(mocks.PermissionRepository)
 
 			r := []models.Role{
 				{
@@ -1283,49 +1503,67 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			roleRepository.On("GetRoles", collections.Role(r).IDs()).Return(collections.Role(r), nil)
-			userRepository.On("HasAnyRoles", uint(1), collections.Role(r)).Return(true, nil)
+			p := models.Permission{
+				ID:        1,
+				Name:      "permission 1",
+				GuardName: "permission-1",
+			}
+
+			roleRepository.On("GetRoles", mock.Anything, collections.Role(r).IDs()).Return(collections.Role(r), nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, collections.Role(r).IDs()).Return([]uint{}, nil)
+			permissionRepository.On("GetPermissionByID", mock.Anything, p.ID).Return(p, nil)
+			roleRepository.On("HasPermission", mock.Anything, collections.Role(r), p).Return(true, nil)
 
 			permify = &Permify{
-				RoleRepository: roleRepository,
-				UserRepository: userRepository,
+				RoleRepository:       roleRepository,
+				PermissionRepository: permissionRepository,
 			}
 
-			actualResult, err := permify.UserHasAnyRoles(uint(1), collections.Role(r).IDs())
+			actualResult, err := permify.RoleHasPermission(collections.Role(r).IDs(), p.ID)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(true).Should(Equal(actualResult))
 		})
-	})
 
-	Context("User Has Direct Permission", func() {
-		It("Success", func() {
+		It("Inherited Permission Success", func() {
+			roleRepository := new(mocks.RoleRepository)
 			permissionRepository := new(mocks.PermissionRepository)
-			userRepository := new(mocks.UserRepository)
+
+			r := models.Role{
+				ID: 1,
+			}
+
+			c := models.Role{
+				ID: 2,
+			}
 
 			p := models.Permission{
 				ID: 1,
 			}
 
-			permissionRepository.On("GetPermissionByID", p.ID).Return(p, nil)
-			userRepository.On("HasDirectPermission", uint(1), p).Return(true, nil)
+			roleRepository.On("GetRoleByID", mock.Anything, r.ID).Return(r, nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{1}).Return([]uint{2}, nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{2}).Return([]uint{}, nil)
+			roleRepository.On("GetRoles", mock.Anything, []uint{2}).Return(collections.Role{c}, nil)
+			permissionRepository.On("GetPermissionByID", mock.Anything, p.ID).Return(p, nil)
+			roleRepository.On("HasPermission", mock.Anything, collections.Role{r, c}, p).Return(true, nil)
 
 			permify = &Permify{
+				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
-				UserRepository:       userRepository,
 			}
 
-			actualResult, err := permify.UserHasDirectPermission(uint(1), p.ID)
+			actualResult, err := permify.RoleHasPermission(r.ID, p.ID)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(true).Should(Equal(actualResult))
 		})
 	})
 
-	Context("User Has All Direct Permissions", func() {
+	Context("Role Has All Permission", func() {
 		It("Success", func() {
+			roleRepository := new(mocks.RoleRepository)
 			permissionRepository := new(mocks.PermissionRepository)
-			userRepository := new(mocks.UserRepository)
 
-			p := []models.Permission{
+			r := []models.Role{
 				{
 					ID: 1,
 				},
@@ -1334,26 +1572,64 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissions", collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
-			userRepository.On("HasAllDirectPermissions", uint(1), collections.Permission(p)).Return(true, nil)
+			p := []models.Permission{
+				{
+					ID:        1,
+					Name:      "permission 1",
+					GuardName: "permission-1",
+				},
+				{
+					ID:        2,
+					Name:      "permission 2",
+					GuardName: "permission-2",
+				},
+			}
+
+			roleRepository.On("GetRoles", mock.Anything, collections.Role(r).IDs()).Return(collections.Role(r), nil)
+			permissionRepository.On("GetPermissions", mock.Anything, collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{1}).Return([]uint{}, nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{2}).Return([]uint{}, nil)
+			permissionRepository.On("GetPermissionIDsOfRolesByIDs", mock.Anything, []uint{1}, nil).Return([]uint{1, 2}, int64(2), nil)
+			permissionRepository.On("GetPermissionIDsOfRolesByIDs", mock.Anything, []uint{2}, nil).Return([]uint{2, 1}, int64(2), nil)
 
 			permify = &Permify{
+				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
-				UserRepository:       userRepository,
 			}
 
-			actualResult, err := permify.UserHasAllDirectPermissions(uint(1), collections.Permission(p).IDs())
+			actualResult, err := permify.RoleHasAllPermissions(collections.Role(r).IDs(), collections.Role(r).IDs())
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(true).Should(Equal(actualResult))
 		})
+
+		It("Inherited and Wildcard Permissions", func() {
+			permify := newMemoryPermify(Options{Wildcard: true})
+
+			for _, name := range []string{"posts.edit", "posts.delete", "posts.*", "users.invite"} {
+				Expect(permify.CreatePermission(name, "")).ShouldNot(HaveOccurred())
+			}
+			for _, name := range []string{"admin", "editor", "writer", "viewer"} {
+				Expect(permify.CreateRole(name, "")).ShouldNot(HaveOccurred())
+			}
+			Expect(permify.AddPermissionsToRole("writer", "posts.*")).ShouldNot(HaveOccurred())
+			Expect(permify.AddChildRolesToRole("editor", "writer")).ShouldNot(HaveOccurred())
+			Expect(permify.AddChildRolesToRole("admin", "editor")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToRole("admin", "users.invite")).ShouldNot(HaveOccurred())
+
+			Expect(permify.RoleHasAllPermissions("admin", []string{"posts.edit", "posts.delete", "users.invite"})).Should(BeTrue())
+			Expect(permify.RoleHasAllPermissions("editor", []string{"posts.edit", "posts.delete"})).Should(BeTrue())
+			Expect(permify.RoleHasAllPermissions("editor", []string{"posts.edit", "users.invite"})).Should(BeFalse())
+			// each of the roles must have all the permissions.
+			Expect(permify.RoleHasAllPermissions([]string{"admin", "viewer"}, []string{"posts.edit"})).Should(BeFalse())
+		})
 	})
 
-	Context("User Has Any Direct Permissions", func() {
+	Context("Role Has Any Permission", func() {
 		It("Success", func() {
+			roleRepository := new(mocks.RoleRepository)
 			permissionRepository := new(mocks.PermissionRepository)
-			userRepository := new(mocks.UserRepository)
 
-			p := []models.Permission{
+			r := []models.Role{
 				{
 					ID: 1,
 				},
@@ -1362,43 +1638,81 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissions", collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
-			userRepository.On("HasAnyDirectPermissions", uint(1), collections.Permission(p)).Return(true, nil)
+			p := []models.Permission{
+				{
+					ID:        1,
+					Name:      "permission 1",
+					GuardName: "permission-1",
+				},
+				{
+					ID:        2,
+					Name:      "permission 2",
+					GuardName: "permission-2",
+				},
+			}
+
+			roleRepository.On("GetRoles", mock.Anything, collections.Role(r).IDs()).Return(collections.Role(r), nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, collections.Role(r).IDs()).Return([]uint{}, nil)
+			permissionRepository.On("GetPermissions", mock.Anything, collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
+			roleRepository.On("HasAnyPermissions", mock.Anything, collections.Role(r), collections.Permission(p)).Return(true, nil)
 
 			permify = &Permify{
+				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
-				UserRepository:       userRepository,
 			}
 
-			actualResult, err := permify.UserHasAnyDirectPermissions(uint(1), collections.Permission(p).IDs())
+			actualResult, err := permify.RoleHasAnyPermissions(collections.Role(r).IDs(), collections.Role(r).IDs())
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(true).Should(Equal(actualResult))
 		})
+
+		It("Inherited and Wildcard Permissions", func() {
+			permify := newMemoryPermify(Options{Wildcard: true})
+
+			for _, name := range []string{"posts.edit", "posts.*", "users.invite"} {
+				Expect(permify.CreatePermission(name, "")).ShouldNot(HaveOccurred())
+			}
+			for _, name := range []string{"admin", "editor", "writer", "viewer"} {
+				Expect(permify.CreateRole(name, "")).ShouldNot(HaveOccurred())
+			}
+			Expect(permify.AddPermissionsToRole("writer", "posts.*")).ShouldNot(HaveOccurred())
+			Expect(permify.AddChildRolesToRole("editor", "writer")).ShouldNot(HaveOccurred())
+			Expect(permify.AddChildRolesToRole("admin", "editor")).ShouldNot(HaveOccurred())
+
+			Expect(permify.RoleHasAnyPermissions("admin", []string{"posts.edit", "users.invite"})).Should(BeTrue())
+			Expect(permify.RoleHasAnyPermissions([]string{"viewer", "editor"}, []string{"posts.edit"})).Should(BeTrue())
+			Expect(permify.RoleHasAnyPermissions("admin", []string{"users.invite"})).Should(BeFalse())
+			Expect(permify.RoleHasAnyPermissions("viewer", []string{"posts.edit"})).Should(BeFalse())
+		})
 	})
 
-	Context("User Has Permission", func() {
-		It("Has Direct Permission Success", func() {
-			permissionRepository := new(mocks.PermissionRepository)
+	Context("User Has Role", func() {
+		It("Success", func() {
+			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
 
-			p := models.Permission{
+			r := models.Role{
 				ID: 1,
 			}
 
-			permissionRepository.On("GetPermissionByID", p.ID).Return(p, nil)
-			permissionRepository.On("GetDirectPermissionIDsOfUserByID", uint(1), nil).Return([]uint{1}, int64(1), nil)
+			roleRepository.On("GetRoleByID", mock.Anything, r.ID).Return(r, nil)
+			userRepository.On("HasRole", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, r).Return(true, nil)
 
 			permify = &Permify{
-				PermissionRepository: permissionRepository,
+				RoleRepository: roleRepository,
+				UserRepository: userRepository,
 			}
 
-			actualResult, err := permify.UserHasPermission(uint(1), p.ID)
+			actualResult, err := permify.UserHasRole(uint(1), r.ID)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(true).Should(Equal(actualResult))
 		})
+	})
 
-		It("Non Direct Permission Success", func() {
-			permissionRepository := new(mocks.PermissionRepository)
+	Context("User Has All Roles", func() {
+		It("Success", func() {
 			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
 
 			r := []models.Role{
 				{
@@ -1409,30 +1723,24 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			p := models.Permission{
-				ID: 1,
-			}
-
-			permissionRepository.On("GetPermissionByID", p.ID).Return(p, nil)
-			permissionRepository.On("GetDirectPermissionIDsOfUserByID", uint(1), nil).Return([]uint{3}, int64(1), nil)
-			roleRepository.On("GetRoleIDsOfUser", uint(1), nil).Return(collections.Role(r).IDs(), int64(1), nil)
-			permissionRepository.On("GetPermissionIDsOfRolesByIDs", collections.Role(r).IDs(), nil).Return([]uint{1}, int64(1), nil)
+			roleRepository.On("GetRoles", mock.Anything, collections.Role(r).IDs()).Return(collections.Role(r), nil)
+			userRepository.On("HasAllRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role(r)).Return(true, nil)
 
 			permify = &Permify{
-				PermissionRepository: permissionRepository,
-				RoleRepository:       roleRepository,
+				RoleRepository: roleRepository,
+				UserRepository: userRepository,
 			}
 
-			actualResult, err := permify.UserHasPermission(uint(1), p.ID)
+			actualResult, err := permify.UserHasAllRoles(uint(1), collections.Role(r).IDs())
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(true).Should(Equal(actualResult))
 		})
 	})
 
-	Context("User Has All Permissions", func() {
+	Context("User Has Any Roles", func() {
 		It("Success", func() {
-			permissionRepository := new(mocks.PermissionRepository)
 			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
 
 			r := []models.Role{
 				{
@@ -1443,37 +1751,49 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			p := []models.Permission{
-				{
-					ID: 1,
-				},
-				{
-					ID: 2,
-				},
+			roleRepository.On("GetRoles", mock.Anything, collections.Role(r).IDs()).Return(collections.Role(r), nil)
+			userRepository.On("HasAnyRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role(r)).Return(true, nil)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+				UserRepository: userRepository,
 			}
 
-			permissionRepository.On("GetPermissions", collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
-			permissionRepository.On("GetDirectPermissionIDsOfUserByID", uint(1), nil).Return([]uint{1, 2}, int64(1), nil)
-			roleRepository.On("GetRoleIDsOfUser", uint(1), nil).Return(collections.Role(r).IDs(), int64(1), nil)
-			permissionRepository.On("GetPermissionIDsOfRolesByIDs", collections.Role(r).IDs(), nil).Return([]uint{1}, int64(1), nil)
+			actualResult, err := permify.UserHasAnyRoles(uint(1), collections.Role(r).IDs())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+	})
+
+	Context("User Has Direct Permission", func() {
+		It("Success", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			userRepository := new(mocks.UserRepository)
+
+			p := models.Permission{
+				ID: 1,
+			}
+
+			permissionRepository.On("GetPermissionByID", mock.Anything, p.ID).Return(p, nil)
+			userRepository.On("HasDirectPermission", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, p).Return(true, nil)
 
 			permify = &Permify{
-				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
+				UserRepository:       userRepository,
 			}
 
-			actualResult, err := permify.UserHasAllPermissions(uint(1), collections.Permission(p).IDs())
+			actualResult, err := permify.UserHasDirectPermission(uint(1), p.ID)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(true).Should(Equal(actualResult))
 		})
 	})
 
-	Context("User Has Any Permissions", func() {
+	Context("User Has All Direct Permissions", func() {
 		It("Success", func() {
 			permissionRepository := new(mocks.PermissionRepository)
-			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
 
-			r := []models.Role{
+			p := []models.Permission{
 				{
 					ID: 1,
 				},
@@ -1482,6 +1802,25 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
+			permissionRepository.On("GetPermissions", mock.Anything, collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
+			userRepository.On("HasAllDirectPermissions", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission(p)).Return(true, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				UserRepository:       userRepository,
+			}
+
+			actualResult, err := permify.UserHasAllDirectPermissions(uint(1), collections.Permission(p).IDs())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+	})
+
+	Context("User Has Any Direct Permissions", func() {
+		It("Success", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			userRepository := new(mocks.UserRepository)
+
 			p := []models.Permission{
 				{
 					ID: 1,
@@ -1491,19 +1830,1802 @@ var _ = Describe("Permify", func() {
 				},
 			}
 
-			permissionRepository.On("GetPermissions", collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
-			permissionRepository.On("GetDirectPermissionIDsOfUserByID", uint(1), nil).Return([]uint{1, 2}, int64(1), nil)
-			roleRepository.On("GetRoleIDsOfUser", uint(1), nil).Return(collections.Role(r).IDs(), int64(1), nil)
-			permissionRepository.On("GetPermissionIDsOfRolesByIDs", collections.Role(r).IDs(), nil).Return([]uint{1}, int64(1), nil)
+			permissionRepository.On("GetPermissions", mock.Anything, collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
+			userRepository.On("HasAnyDirectPermissions", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission(p)).Return(true, nil)
 
 			permify = &Permify{
-				RoleRepository:       roleRepository,
 				PermissionRepository: permissionRepository,
+				UserRepository:       userRepository,
 			}
 
-			actualResult, err := permify.UserHasAnyPermissions(uint(1), collections.Permission(p).IDs())
+			actualResult, err := permify.UserHasAnyDirectPermissions(uint(1), collections.Permission(p).IDs())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+	})
+
+	Context("User Has Permission", func() {
+		It("Has Direct Permission Success", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			roleRepository := new(mocks.RoleRepository)
+
+			p := models.Permission{
+				ID: 1,
+			}
+
+			permissionRepository.On("GetPermissionByID", mock.Anything, p.ID).Return(p, nil)
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1}).Return(repositories.EffectivePermissionIDs{DirectPermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+			}
+
+			actualResult, err := permify.UserHasPermission(uint(1), p.ID)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(true).Should(Equal(actualResult))
 		})
+
+		It("Non Direct Permission Success", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			roleRepository := new(mocks.RoleRepository)
+
+			p := models.Permission{
+				ID: 1,
+			}
+
+			permissionRepository.On("GetPermissionByID", mock.Anything, p.ID).Return(p, nil)
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1}).Return(repositories.EffectivePermissionIDs{RolePermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+			}
+
+			actualResult, err := permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+
+		It("Inherited Permission Success", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			roleRepository := new(mocks.RoleRepository)
+
+			p := models.Permission{
+				ID: 1,
+			}
+
+			permissionRepository.On("GetPermissionByID", mock.Anything, p.ID).Return(p, nil)
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1}).Return(repositories.EffectivePermissionIDs{RolePermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+			}
+
+			actualResult, err := permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+	})
+
+	Context("User Has Permission with Deny", func() {
+		var (
+			permissionRepository *mocks.PermissionRepository
+			roleRepository       *mocks.RoleRepository
+			p                    models.Permission
+		)
+
+		// the user has the permission via role 1 and directly, the permission is denied to role 1.
+		BeforeEach(func() {
+			permissionRepository = new(mocks.PermissionRepository)
+			roleRepository = new(mocks.RoleRepository)
+
+			p = models.Permission{
+				ID: 1,
+			}
+
+			permissionRepository.On("GetPermissionByID", mock.Anything, p.ID).Return(p, nil)
+		})
+
+		It("Deny Overrides by Default", func() {
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1}).Return(repositories.EffectivePermissionIDs{DirectPermissionIDs: []uint{1}, RolePermissionIDs: []uint{1}, RoleDeniedPermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+			}
+
+			actualResult, err := permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(false).Should(Equal(actualResult))
+		})
+
+		It("Allow Overrides", func() {
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1}).Return(repositories.EffectivePermissionIDs{RolePermissionIDs: []uint{1}, RoleDeniedPermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+				conflictResolution:   AllowOverrides,
+			}
+
+			actualResult, err := permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+
+		It("Most Specific Wins with Direct Allow", func() {
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1}).Return(repositories.EffectivePermissionIDs{DirectPermissionIDs: []uint{1}, RolePermissionIDs: []uint{1}, RoleDeniedPermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+				conflictResolution:   MostSpecificWins,
+			}
+
+			actualResult, err := permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+
+		It("Most Specific Wins without Direct Assignment", func() {
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1}).Return(repositories.EffectivePermissionIDs{RolePermissionIDs: []uint{1}, RoleDeniedPermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+				conflictResolution:   MostSpecificWins,
+			}
+
+			actualResult, err := permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(false).Should(Equal(actualResult))
+		})
+	})
+
+	Context("User Has Permission in Tenant", func() {
+		It("Non Direct Permission Success", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			roleRepository := new(mocks.RoleRepository)
+
+			p := models.Permission{
+				ID: 1,
+			}
+
+			tenant := scopes.Assignment{TenantID: "org-a"}
+
+			permissionRepository.On("GetPermissionByID", mock.Anything, p.ID).Return(p, nil)
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), tenant, []uint{1}).Return(repositories.EffectivePermissionIDs{RolePermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+			}
+
+			actualResult, err := permify.Tenant("org-a").UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+			Expect(permify.tenantID).Should(BeEmpty())
+		})
+	})
+
+	Context("User Has Permission On", func() {
+		It("Role on Resource Success", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			roleRepository := new(mocks.RoleRepository)
+
+			p := models.Permission{
+				ID:        1,
+				Name:      "edit project",
+				GuardName: "edit-project",
+			}
+
+			project := scopes.Assignment{ResourceType: "project", ResourceID: "42"}
+
+			permissionRepository.On("GetPermissionByGuardName", mock.Anything, "edit-project").Return(p, nil)
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(7)), project, []uint{1}).Return(repositories.EffectivePermissionIDs{RolePermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+			}
+
+			actualResult, err := permify.UserHasPermissionOn(7, "edit project", "project", "42")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+	})
+
+	Context("User Has Permission with Wildcard", func() {
+		It("Wildcard Permission Success", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			roleRepository := new(mocks.RoleRepository)
+
+			p := models.Permission{
+				ID:        1,
+				Name:      "billing.invoices.view",
+				GuardName: "billing.invoices.view",
+			}
+
+			wildcard := models.Permission{
+				ID:        2,
+				Name:      "billing.*",
+				GuardName: "billing.*",
+			}
+
+			permissionRepository.On("GetPermissionByGuardName", mock.Anything, "billing.invoices.view").Return(p, nil)
+			permissionRepository.On("GetPermissionsByGuardNames", mock.Anything, []string{"billing.invoices.*", "billing.*", "*"}).Return(collections.Permission{wildcard}, nil)
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1, 2}).Return(repositories.EffectivePermissionIDs{RolePermissionIDs: []uint{2}}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+				guard:                helpers.SegmentedGuard,
+				wildcard:             true,
+			}
+
+			actualResult, err := permify.UserHasPermission(uint(1), "Billing.Invoices.View")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+
+		It("Exact Match by Default", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			roleRepository := new(mocks.RoleRepository)
+
+			p := models.Permission{
+				ID:        1,
+				Name:      "billing.invoices.view",
+				GuardName: "billing-invoices-view",
+			}
+
+			permissionRepository.On("GetPermissionByGuardName", mock.Anything, "billing-invoices-view").Return(p, nil)
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1}).Return(repositories.EffectivePermissionIDs{}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+			}
+
+			actualResult, err := permify.UserHasPermission(uint(1), "billing.invoices.view")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(false).Should(Equal(actualResult))
+			permissionRepository.AssertNotCalled(GinkgoT(), "GetPermissionsByGuardNames", mock.Anything, mock.Anything)
+		})
+	})
+
+	Context("Role Has Permission with Wildcard", func() {
+		It("Wildcard Permission Success", func() {
+			roleRepository := new(mocks.RoleRepository)
+			permissionRepository := new(mocks.PermissionRepository)
+
+			r := models.Role{
+				ID:        1,
+				Name:      "billing admin",
+				GuardName: "billing-admin",
+			}
+
+			p := models.Permission{
+				ID:        1,
+				Name:      "billing.invoices.view",
+				GuardName: "billing.invoices.view",
+			}
+
+			wildcard := models.Permission{
+				ID:        2,
+				Name:      "billing.*",
+				GuardName: "billing.*",
+			}
+
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "billing-admin").Return(r, nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{1}).Return([]uint{}, nil)
+			permissionRepository.On("GetPermissionByGuardName", mock.Anything, "billing.invoices.view").Return(p, nil)
+			permissionRepository.On("GetPermissionsByGuardNames", mock.Anything, []string{"billing.invoices.*", "billing.*", "*"}).Return(collections.Permission{wildcard}, nil)
+			roleRepository.On("HasAnyPermissions", mock.Anything, collections.Role{r}, collections.Permission{p, wildcard}).Return(true, nil)
+
+			permify = &Permify{
+				RoleRepository:       roleRepository,
+				PermissionRepository: permissionRepository,
+				guard:                helpers.SegmentedGuard,
+				wildcard:             true,
+			}
+
+			actualResult, err := permify.RoleHasPermission("billing admin", "billing.invoices.view")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+	})
+
+	Context("User Has Permission Ctx", func() {
+		It("Passes Context to Repositories", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			roleRepository := new(mocks.RoleRepository)
+
+			type ctxKey struct{}
+			ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+
+			p := models.Permission{
+				ID: 1,
+			}
+
+			permissionRepository.On("GetPermissionByID", ctx, p.ID).Return(p, nil)
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", ctx, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1}).Return(repositories.EffectivePermissionIDs{DirectPermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+			}
+
+			actualResult, err := permify.UserHasPermissionCtx(ctx, uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+			permissionRepository.AssertExpectations(GinkgoT())
+		})
+	})
+
+	Context("User Has All Permissions", func() {
+		It("Success", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			roleRepository := new(mocks.RoleRepository)
+
+			p := []models.Permission{
+				{
+					ID: 1,
+				},
+				{
+					ID: 2,
+				},
+			}
+
+			permissionRepository.On("GetPermissions", mock.Anything, collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1, 2}).Return(repositories.EffectivePermissionIDs{DirectPermissionIDs: []uint{1, 2}, RolePermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				RoleRepository:       roleRepository,
+				PermissionRepository: permissionRepository,
+			}
+
+			actualResult, err := permify.UserHasAllPermissions(uint(1), collections.Permission(p).IDs())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+	})
+
+	Context("User Has Any Permissions", func() {
+		It("Success", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			roleRepository := new(mocks.RoleRepository)
+
+			p := []models.Permission{
+				{
+					ID: 1,
+				},
+				{
+					ID: 2,
+				},
+			}
+
+			permissionRepository.On("GetPermissions", mock.Anything, collections.Permission(p).IDs()).Return(collections.Permission(p), nil)
+			permissionRepository.On("GetEffectivePermissionIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, []uint{1, 2}).Return(repositories.EffectivePermissionIDs{DirectPermissionIDs: []uint{1, 2}, RolePermissionIDs: []uint{1}}, nil)
+
+			permify = &Permify{
+				RoleRepository:       roleRepository,
+				PermissionRepository: permissionRepository,
+			}
+
+			actualResult, err := permify.UserHasAnyPermissions(uint(1), collections.Permission(p).IDs())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(true).Should(Equal(actualResult))
+		})
+	})
+
+	Context("User Has Permission with Cache", func() {
+		var (
+			permissionRepository *mocks.PermissionRepository
+			roleRepository       *mocks.RoleRepository
+			userRepository       *mocks.UserRepository
+			p                    models.Permission
+			r                    models.Role
+		)
+
+		BeforeEach(func() {
+			permissionRepository = new(mocks.PermissionRepository)
+			roleRepository = new(mocks.RoleRepository)
+			userRepository = new(mocks.UserRepository)
+
+			p = models.Permission{
+				ID: 1,
+			}
+
+			r = models.Role{
+				ID: 1,
+			}
+
+			permissionRepository.On("GetPermissionByID", mock.Anything, p.ID).Return(p, nil)
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1}).Return(collections.Permission{p}, nil)
+			permissionRepository.On("GetDirectPermissionIDsOfUserByID", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, nil).Return([]uint{}, int64(0), nil)
+			permissionRepository.On("GetDeniedPermissionIDsOfUserByID", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, nil).Return([]uint{}, int64(0), nil)
+			roleRepository.On("GetRoleByID", mock.Anything, r.ID).Return(r, nil)
+			roleRepository.On("GetRoles", mock.Anything, []uint{1}).Return(collections.Role{r}, nil)
+			roleRepository.On("GetRoleIDsOfUser", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, nil).Return([]uint{1}, int64(1), nil)
+			roleRepository.On("GetChildRoleIDs", mock.Anything, []uint{1}).Return([]uint{}, nil)
+			permissionRepository.On("GetPermissionIDsOfRolesByIDs", mock.Anything, []uint{1}, nil).Return([]uint{1}, int64(1), nil)
+			permissionRepository.On("GetDeniedPermissionIDsOfRolesByIDs", mock.Anything, []uint{1}, nil).Return([]uint{}, int64(0), nil)
+			userRepository.On("GetNextWindowBound", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, mock.AnythingOfType("time.Time")).Return(nil, nil)
+			roleRepository.On("GetNextWindowBound", mock.Anything, []uint{1}, mock.AnythingOfType("time.Time")).Return(nil, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+				RoleRepository:       roleRepository,
+				UserRepository:       userRepository,
+				cache:                cache.NewLRU(100, time.Minute),
+			}
+		})
+
+		It("Caches the Assignments", func() {
+			for i := 0; i < 2; i++ {
+				actualResult, err := permify.UserHasPermission(uint(1), p.ID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(true).Should(Equal(actualResult))
+			}
+
+			roleRepository.AssertNumberOfCalls(GinkgoT(), "GetRoleIDsOfUser", 1)
+			permissionRepository.AssertNumberOfCalls(GinkgoT(), "GetDirectPermissionIDsOfUserByID", 1)
+			permissionRepository.AssertNumberOfCalls(GinkgoT(), "GetPermissionIDsOfRolesByIDs", 1)
+		})
+
+		It("Invalidates the User", func() {
+			userRepository.On("AddRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role{r}).Return(nil)
+
+			_, err := permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(uint(1), []uint{1})).ShouldNot(HaveOccurred())
+			_, err = permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			roleRepository.AssertNumberOfCalls(GinkgoT(), "GetRoleIDsOfUser", 2)
+			permissionRepository.AssertNumberOfCalls(GinkgoT(), "GetPermissionIDsOfRolesByIDs", 1)
+		})
+
+		It("Invalidates the Role", func() {
+			roleRepository.On("AddPermissions", mock.Anything, &r, collections.Permission{p}, scopes.Window{}).Return(nil)
+
+			_, err := permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToRole(r.ID, []uint{1})).ShouldNot(HaveOccurred())
+			_, err = permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			roleRepository.AssertNumberOfCalls(GinkgoT(), "GetRoleIDsOfUser", 1)
+			permissionRepository.AssertNumberOfCalls(GinkgoT(), "GetPermissionIDsOfRolesByIDs", 2)
+		})
+
+		It("Invalidates the Users When a Role Is Deleted", func() {
+			roleRepository.On("Delete", mock.Anything, &r).Return(nil)
+
+			_, err := permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(permify.DeleteRole(r.ID)).ShouldNot(HaveOccurred())
+			_, err = permify.UserHasPermission(uint(1), p.ID)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			roleRepository.AssertNumberOfCalls(GinkgoT(), "GetRoleIDsOfUser", 2)
+			permissionRepository.AssertNumberOfCalls(GinkgoT(), "GetPermissionIDsOfRolesByIDs", 2)
+		})
+
+		It("Fetches Again When a Window Starts or Expires", func() {
+			// the keys of the cache never expire, only the windows make the cached assignments stale.
+			lru := cache.NewLRU(100, 0)
+			now := time.Now()
+			lru.SetClock(func() time.Time { return now })
+			permify := newMemoryPermify(Options{Cache: lru})
+
+			Expect(permify.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreateRole("viewer", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("delete", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("view", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToRole("admin", "delete")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(2, "viewer")).ShouldNot(HaveOccurred())
+
+			bound := time.Now().Add(300 * time.Millisecond)
+			Expect(permify.AddRolesToUserUntil(1, "admin", bound)).ShouldNot(HaveOccurred())
+			Expect(permify.Between(bound, time.Time{}).AddPermissionsToUser(1, "view")).ShouldNot(HaveOccurred())
+			Expect(permify.Between(bound, time.Time{}).AddPermissionsToRole("viewer", "view")).ShouldNot(HaveOccurred())
+
+			Expect(permify.UserHasPermission(1, "delete")).Should(BeTrue())
+			Expect(permify.UserHasPermission(1, "view")).Should(BeFalse())
+			Expect(permify.UserHasPermission(2, "view")).Should(BeFalse())
+
+			time.Sleep(time.Until(bound) + 50*time.Millisecond)
+
+			Expect(permify.UserHasRole(1, "admin")).Should(BeFalse())
+			Expect(permify.UserHasPermission(1, "delete")).Should(BeFalse())
+			Expect(permify.UserHasPermission(1, "view")).Should(BeTrue())
+			Expect(permify.UserHasPermission(2, "view")).Should(BeTrue())
+		})
+
+		It("Invalidates Again When the Transaction Ends", func() {
+			permify := newMemoryPermify(Options{Cache: cache.NewLRU(100, 0)})
+			failure := errors.New("failure")
+
+			Expect(permify.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("delete", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToRole("admin", "delete")).ShouldNot(HaveOccurred())
+
+			Expect(permify.Transaction(func(ctx context.Context) error {
+				if err := permify.AddRolesToUserCtx(ctx, 1, "admin"); err != nil {
+					return err
+				}
+				// the assignments of the transaction are cached before the rollback.
+				Expect(permify.UserHasPermissionCtx(ctx, 1, "delete")).Should(BeTrue())
+				return failure
+			})).Should(MatchError(failure))
+
+			Expect(permify.UserHasPermission(1, "delete")).Should(BeFalse())
+		})
+	})
+
+	Context("Purge Expired Assignments", func() {
+		It("Success", func() {
+			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
+
+			userRepository.On("PurgeExpiredRoles", mock.Anything, mock.AnythingOfType("time.Time"), purgeBatchSize).Return(int64(3), nil)
+			userRepository.On("PurgeExpiredPermissions", mock.Anything, mock.AnythingOfType("time.Time"), purgeBatchSize).Return(int64(2), nil)
+			userRepository.On("PurgeExpiredDeniedPermissions", mock.Anything, mock.AnythingOfType("time.Time"), purgeBatchSize).Return(int64(0), nil)
+			roleRepository.On("PurgeExpiredPermissions", mock.Anything, mock.AnythingOfType("time.Time"), purgeBatchSize).Return(int64(1), nil)
+			roleRepository.On("PurgeExpiredDeniedPermissions", mock.Anything, mock.AnythingOfType("time.Time"), purgeBatchSize).Return(int64(0), nil)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+				UserRepository: userRepository,
+			}
+
+			deleted, err := permify.PurgeExpiredAssignments()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(deleted).Should(Equal(int64(6)))
+		})
+
+		It("Error", func() {
+			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
+
+			userRepository.On("PurgeExpiredRoles", mock.Anything, mock.AnythingOfType("time.Time"), purgeBatchSize).Return(int64(1), errors.New("err connection"))
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+				UserRepository: userRepository,
+			}
+
+			deleted, err := permify.PurgeExpiredAssignments()
+			Expect(err).Should(HaveOccurred())
+			Expect(deleted).Should(Equal(int64(1)))
+			userRepository.AssertNotCalled(GinkgoT(), "PurgeExpiredPermissions", mock.Anything, mock.Anything, mock.Anything)
+		})
+	})
+
+	Context("In-Memory Repositories", func() {
+		It("Success", func() {
+			database := memory.NewDatabase()
+
+			permify, err := New(Options{
+				Migrate:              true,
+				RoleRepository:       &memory.RoleRepository{Database: database},
+				PermissionRepository: &memory.PermissionRepository{Database: database},
+				UserRepository:       &memory.UserRepository{Database: database},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(permify.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("edit user details", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("delete user", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToRole("admin", []string{"edit user details", "delete user"})).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("org-a").AddRolesToUser(1, "admin")).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("org-a").DenyPermissionsToUser(1, "delete user")).ShouldNot(HaveOccurred())
+
+			Expect(permify.Tenant("org-a").UserHasPermission(1, "edit user details")).Should(BeTrue())
+			Expect(permify.Tenant("org-a").UserHasPermission(1, "delete user")).Should(BeFalse())
+			Expect(permify.Tenant("org-b").UserHasPermission(1, "edit user details")).Should(BeFalse())
+		})
+	})
+
+	Context("Errors", func() {
+		It("Role Not Found", func() {
+			roleRepository := new(mocks.RoleRepository)
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "admin").Return(models.Role{}, gorm.ErrRecordNotFound)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+			}
+
+			_, err := permify.GetRole("admin", false)
+			Expect(errors.Is(err, ErrRoleNotFound)).Should(BeTrue())
+			Expect(errors.Is(err, ErrPermissionNotFound)).Should(BeFalse())
+			Expect(errors.Is(err, gorm.ErrRecordNotFound)).Should(BeTrue())
+			Expect(err.Error()).Should(Equal("GetRole admin: err role not found: record not found"))
+		})
+
+		It("Nested Operation", func() {
+			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "admin").Return(models.Role{}, gorm.ErrRecordNotFound)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+				UserRepository: userRepository,
+			}
+
+			err := permify.AddRolesToUser(1, "admin")
+			Expect(errors.Is(err, ErrRoleNotFound)).Should(BeTrue())
+			Expect(err.Error()).Should(Equal("AddRolesToUser 1: GetRole admin: err role not found: record not found"))
+
+			var permifyError *Error
+			Expect(errors.As(err, &permifyError)).Should(BeTrue())
+			Expect(permifyError.Op).Should(Equal("AddRolesToUser"))
+			Expect(permifyError.ID).Should(Equal(1))
+			userRepository.AssertNotCalled(GinkgoT(), "AddRoles", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+
+		It("Permission Not Found", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+			permissionRepository.On("GetPermissionByID", mock.Anything, uint(1)).Return(models.Permission{}, gorm.ErrRecordNotFound)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+			}
+
+			_, err := permify.GetPermission(uint(1))
+			Expect(errors.Is(err, ErrPermissionNotFound)).Should(BeTrue())
+			Expect(errors.Is(err, ErrRoleNotFound)).Should(BeFalse())
+		})
+
+		It("Repository Error", func() {
+			connectionError := errors.New("err connection")
+			userRepository := new(mocks.UserRepository)
+			userRepository.On("HasRole", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, models.Role{ID: 1}).Return(false, connectionError)
+			roleRepository := new(mocks.RoleRepository)
+			roleRepository.On("GetRoleByID", mock.Anything, uint(1)).Return(models.Role{ID: 1}, nil)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+				UserRepository: userRepository,
+			}
+
+			_, err := permify.UserHasRole(1, uint(1))
+			Expect(errors.Is(err, connectionError)).Should(BeTrue())
+			Expect(errors.Is(err, ErrRoleNotFound)).Should(BeFalse())
+			Expect(err.Error()).Should(Equal("UserHasRole 1: err connection"))
+		})
+
+		It("Unsupported Identifier", func() {
+			permify = &Permify{}
+
+			_, err := permify.GetRole(1.5, false)
+			Expect(errors.Is(err, ErrUnsupportedIdentifier)).Should(BeTrue())
+		})
+	})
+
+	Context("Typed", func() {
+		It("By Name", func() {
+			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
+
+			r := models.Role{ID: 1, Name: "admin", GuardName: "admin"}
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "admin").Return(r, nil)
+			userRepository.On("AddRoles", mock.Anything, models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{TenantID: "org-a"}, collections.Role{r}).Return(nil)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+				UserRepository: userRepository,
+			}
+
+			err := permify.Typed().Tenant("org-a").AddRolesToUser(models.UserIDFromInt(1), RoleByName("admin"))
+			Expect(err).ShouldNot(HaveOccurred())
+			userRepository.AssertExpectations(GinkgoT())
+		})
+
+		It("By IDs", func() {
+			permissionRepository := new(mocks.PermissionRepository)
+
+			p := collections.Permission{{ID: 1}, {ID: 2}}
+			permissionRepository.On("GetPermissions", mock.Anything, []uint{1, 2, 3}).Return(p, nil)
+
+			permify = &Permify{
+				PermissionRepository: permissionRepository,
+			}
+
+			actualResult, err := permify.Typed().GetPermissions(PermissionByID(1, 2, 3))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(actualResult).Should(Equal(p))
+		})
+
+		It("Not Found", func() {
+			roleRepository := new(mocks.RoleRepository)
+			roleRepository.On("GetRoleByID", mock.Anything, uint(7)).Return(models.Role{}, gorm.ErrRecordNotFound)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+			}
+
+			_, err := permify.Typed().GetRoles(RoleByID(7), false)
+			Expect(errors.Is(err, ErrRoleNotFound)).Should(BeTrue())
+			Expect(err.Error()).Should(Equal("GetRole 7: err role not found: record not found"))
+		})
+
+		It("Untyped Methods", func() {
+			roleRepository := new(mocks.RoleRepository)
+
+			r := models.Role{ID: 1, Name: "admin", GuardName: "admin"}
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "admin").Return(r, nil)
+
+			permify = &Permify{
+				RoleRepository: roleRepository,
+			}
+
+			actualResult, err := permify.GetRole(RoleByName("admin"), false)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(actualResult).Should(Equal(r))
+		})
+
+		It("String", func() {
+			Expect(RoleByID(1).String()).Should(Equal("1"))
+			Expect(PermissionByID(1, 2).String()).Should(Equal("[1 2]"))
+			Expect(RoleByName("admin").String()).Should(Equal("admin"))
+			Expect(PermissionByName("edit", "delete").String()).Should(Equal("[edit delete]"))
+		})
+
+		It("Refuses the Negative IDs and the Refs of the Other Type", func() {
+			permify = &Permify{}
+
+			_, err := permify.GetRole(-1, false)
+			Expect(errors.Is(err, ErrUnsupportedIdentifier)).Should(BeTrue())
+
+			_, err = permify.GetRole(PermissionByID(1), false)
+			Expect(errors.Is(err, ErrUnsupportedIdentifier)).Should(BeTrue())
+
+			_, err = permify.GetPermission(RoleByName("admin"))
+			Expect(errors.Is(err, ErrUnsupportedIdentifier)).Should(BeTrue())
+		})
+	})
+
+	Context("User ID Types", func() {
+		It("UUID", func() {
+			database := memory.NewDatabase()
+
+			permify, err := New(Options{
+				RoleRepository:       &memory.RoleRepository{Database: database},
+				PermissionRepository: &memory.PermissionRepository{Database: database},
+				UserRepository:       &memory.UserRepository{Database: database},
+				UserIDType:           models.UserIDUUID,
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(permify.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser("0E4A2F1C-1111-4222-8333-444455556666", "admin")).ShouldNot(HaveOccurred())
+
+			Expect(permify.UserHasRole("0e4a2f1c-1111-4222-8333-444455556666", "admin")).Should(BeTrue())
+
+			_, err = permify.UserHasRole(1, "admin")
+			Expect(errors.Is(err, ErrUnsupportedIdentifier)).Should(BeTrue())
+		})
+
+		It("Converts the Found IDs to the Type", func() {
+			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
+			roleRepository.On("GetRoleByGuardName", mock.Anything, "admin").Return(models.Role{ID: 1, GuardName: "admin"}, nil)
+			userRepository.On("GetUserIDsOfRole", mock.Anything, uint(1), scopes.Assignment{}, nil).
+				Return([]models.Subject{models.UserSubject(models.UserIDFromString("5")), models.UserSubject(models.UserIDFromInt(123))}, int64(2), nil)
+
+			permify = &Permify{RoleRepository: roleRepository, UserRepository: userRepository}
+			subjects, _, err := permify.GetUserIDsOfRole("admin", options.UserOption{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(subjects).Should(Equal([]models.Subject{models.UserSubject(models.UserIDFromInt(5)), models.UserSubject(models.UserIDFromInt(123))}))
+
+			permify = &Permify{RoleRepository: roleRepository, UserRepository: userRepository, userIDType: models.UserIDString}
+			subjects, _, err = permify.GetUserIDsOfRole("admin", options.UserOption{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(subjects).Should(Equal([]models.Subject{models.UserSubject(models.UserIDFromString("5")), models.UserSubject(models.UserIDFromString("123"))}))
+		})
+
+		It("Unsupported", func() {
+			permify = &Permify{}
+
+			err := permify.AddRolesToUser(-1, "admin")
+			Expect(errors.Is(err, ErrUnsupportedIdentifier)).Should(BeTrue())
+
+			err = permify.AddRolesToUser(1.5, "admin")
+			Expect(errors.Is(err, ErrUnsupportedIdentifier)).Should(BeTrue())
+		})
+	})
+
+	Context("Subjects", func() {
+		It("Keeps the Subjects of the Same ID Apart", func() {
+			database := memory.NewDatabase()
+
+			permify, err := New(Options{
+				RoleRepository:       &memory.RoleRepository{Database: database},
+				PermissionRepository: &memory.PermissionRepository{Database: database},
+				UserRepository:       &memory.UserRepository{Database: database},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(permify.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreateRole("firmware updater", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(7, "admin")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(Subject{Type: "device", ID: 7}, "firmware updater")).ShouldNot(HaveOccurred())
+
+			Expect(permify.UserHasRole(Subject{Type: "user", ID: 7}, "admin")).Should(BeTrue())
+			Expect(permify.UserHasRole(Subject{ID: 7}, "admin")).Should(BeTrue())
+			Expect(permify.UserHasRole(Subject{Type: "device", ID: 7}, "admin")).Should(BeFalse())
+			Expect(permify.Typed().SubjectType("device").UserHasRole(models.UserIDFromInt(7), RoleByName("firmware updater"))).Should(BeTrue())
+			Expect(permify.Typed().UserHasRole(models.UserIDFromInt(7), RoleByName("firmware updater"))).Should(BeFalse())
+		})
+
+		It("Passes the Subject to Repositories", func() {
+			roleRepository := new(mocks.RoleRepository)
+			userRepository := new(mocks.UserRepository)
+
+			r := models.Role{
+				ID: 1,
+			}
+
+			roleRepository.On("GetRoleByID", mock.Anything, uint(1)).Return(r, nil)
+			userRepository.On("AddRoles", mock.Anything, models.Subject{Type: "api_client", ID: models.UserIDFromInt(3)}, scopes.Assignment{}, collections.Role([]models.Role{r})).Return(nil)
+
+			permify = &Permify{
+				UserRepository: userRepository,
+				RoleRepository: roleRepository,
+			}
+
+			err := permify.AddRolesToUser(Subject{Type: "api_client", ID: "3"}, 1)
+			Expect(err).ShouldNot(HaveOccurred())
+			userRepository.AssertExpectations(GinkgoT())
+		})
+
+		It("Unsupported", func() {
+			permify = &Permify{}
+
+			err := permify.AddRolesToUser(Subject{Type: "device", ID: "abc"}, "admin")
+			Expect(errors.Is(err, ErrUnsupportedIdentifier)).Should(BeTrue())
+		})
+	})
+
+	Context("Reverse Lookups", func() {
+		It("Users of Role", func() {
+			permify := newMemoryPermify(Options{})
+
+			Expect(permify.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(2, "admin")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(1, "admin")).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("org-a").AddRolesToUser(3, "admin")).ShouldNot(HaveOccurred())
+
+			subjects, totalCount, err := permify.GetUserIDsOfRole("admin", options.UserOption{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(subjects).Should(Equal([]models.Subject{models.UserSubject(models.UserIDFromInt(1)), models.UserSubject(models.UserIDFromInt(2))}))
+			Expect(totalCount).Should(Equal(int64(2)))
+
+			subjects, totalCount, err = permify.Tenant("org-a").GetUserIDsOfRole("admin", options.UserOption{Pagination: &utils.Pagination{Page: 2, Limit: 2}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(subjects).Should(Equal([]models.Subject{models.UserSubject(models.UserIDFromInt(3))}))
+			Expect(totalCount).Should(Equal(int64(3)))
+
+			_, _, err = permify.GetUserIDsOfRole("missing", options.UserOption{})
+			Expect(errors.Is(err, ErrRoleNotFound)).Should(BeTrue())
+		})
+
+		It("Users with Permission", func() {
+			permify := newMemoryPermify(Options{Wildcard: true})
+
+			Expect(permify.CreatePermission("invoices.approve", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("invoices.*", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreateRole("accountant", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreateRole("manager", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreateRole("intern", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToRole("accountant", "invoices.*")).ShouldNot(HaveOccurred())
+			Expect(permify.AddChildRolesToRole("manager", "accountant")).ShouldNot(HaveOccurred())
+			Expect(permify.DenyPermissionsToRole("intern", "invoices.approve")).ShouldNot(HaveOccurred())
+
+			Expect(permify.AddPermissionsToUser(1, "invoices.approve")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(2, "accountant")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(3, "manager")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(4, []string{"manager", "intern"})).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToUser(5, "invoices.approve")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(5, "intern")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(Subject{Type: "api_client", ID: 6}, "accountant")).ShouldNot(HaveOccurred())
+			Expect(permify.DenyPermissionsToUser(Subject{Type: "api_client", ID: 6}, "invoices.approve")).ShouldNot(HaveOccurred())
+
+			subjects, _, err := permify.GetUserIDsWithDirectPermission("invoices.approve", options.UserOption{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(subjects).Should(Equal([]models.Subject{models.UserSubject(models.UserIDFromInt(1)), models.UserSubject(models.UserIDFromInt(5))}))
+
+			subjects, totalCount, err := permify.GetUserIDsWithPermission("invoices.approve", options.UserOption{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(subjects).Should(Equal([]models.Subject{models.UserSubject(models.UserIDFromInt(1)), models.UserSubject(models.UserIDFromInt(2)), models.UserSubject(models.UserIDFromInt(3))}))
+			Expect(totalCount).Should(Equal(int64(3)))
+
+			for _, subject := range subjects {
+				Expect(permify.UserHasPermission(subject.ID, "invoices.approve")).Should(BeTrue())
+			}
+			for _, user := range []interface{}{4, 5, Subject{Type: "api_client", ID: 6}} {
+				Expect(permify.UserHasPermission(user, "invoices.approve")).Should(BeFalse())
+			}
+
+			permify.conflictResolution = MostSpecificWins
+			subjects, _, err = permify.Typed().GetUserIDsWithPermission(PermissionByName("invoices.approve"), options.UserOption{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(subjects).Should(Equal([]models.Subject{models.UserSubject(models.UserIDFromInt(1)), models.UserSubject(models.UserIDFromInt(2)), models.UserSubject(models.UserIDFromInt(3)), models.UserSubject(models.UserIDFromInt(5))}))
+
+			permify.conflictResolution = AllowOverrides
+			_, totalCount, err = permify.GetUserIDsWithPermission("invoices.approve", options.UserOption{Pagination: &utils.Pagination{Page: 1, Limit: 2}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(totalCount).Should(Equal(int64(6)))
+		})
+	})
+
+	Context("Batch Checks", func() {
+		It("User Permissions Map", func() {
+			permify := newMemoryPermify(Options{Wildcard: true})
+
+			for _, name := range []string{"posts.create", "posts.edit", "posts.delete", "posts.*", "users.invite"} {
+				Expect(permify.CreatePermission(name, "")).ShouldNot(HaveOccurred())
+			}
+			Expect(permify.CreateRole("editor", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToRole("editor", "posts.*")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(1, "editor")).ShouldNot(HaveOccurred())
+			Expect(permify.DenyPermissionsToUser(1, "posts.delete")).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("org-a").AddPermissionsToUser(1, "users.invite")).ShouldNot(HaveOccurred())
+
+			names := []string{"Posts.Create", "posts.edit", "posts.delete", "users.invite", "missing"}
+			permissions, err := permify.UserPermissionsMap(1, names)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(permissions).Should(Equal(map[string]bool{"Posts.Create": true, "posts.edit": true, "posts.delete": false, "users.invite": false, "missing": false}))
+
+			permissions, err = permify.Tenant("org-a").UserPermissionsMap(1, names)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(permissions["users.invite"]).Should(BeTrue())
+
+			for name, has := range permissions {
+				if name != "missing" {
+					Expect(permify.Tenant("org-a").UserHasPermission(1, name)).Should(Equal(has))
+				}
+			}
+
+			invite, err := permify.GetPermission("users.invite")
+			Expect(err).ShouldNot(HaveOccurred())
+			permissions, err = permify.Tenant("org-a").Typed().UserPermissionsMap(models.UserIDFromInt(1), PermissionByID(invite.ID, 99))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(permissions).Should(Equal(map[string]bool{"users.invite": true}))
+		})
+
+		It("Users Have Permission", func() {
+			permify := newMemoryPermify(Options{})
+
+			Expect(permify.CreatePermission("edit", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreateRole("editor", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToRole("editor", "edit")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToUser(1, "edit")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(2, "editor")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(3, "editor")).ShouldNot(HaveOccurred())
+			Expect(permify.DenyPermissionsToUser(3, "edit")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToUser(Subject{Type: "device", ID: 4}, "edit")).ShouldNot(HaveOccurred())
+
+			users, err := permify.UsersHavePermission([]uint{1, 2, 3, 4}, "edit")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(users).Should(Equal(map[uint]bool{1: true, 2: true, 3: false, 4: false}))
+
+			subjects, err := permify.SubjectsHavePermission([]interface{}{1, Subject{Type: "device", ID: 4}}, "edit")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(subjects).Should(Equal(map[models.Subject]bool{
+				models.UserSubject(models.UserIDFromInt(1)):   true,
+				{Type: "device", ID: models.UserIDFromInt(4)}: true,
+			}))
+
+			var many []uint
+			for ID := uint(1); ID <= 2*batchCheckSize; ID++ {
+				many = append(many, ID)
+			}
+			users, err = permify.UsersHavePermission(many, "edit")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(users).Should(HaveLen(2 * batchCheckSize))
+			Expect(users[2]).Should(BeTrue())
+			Expect(users[batchCheckSize+1]).Should(BeFalse())
+
+			typed, err := permify.Typed().SubjectType("device").UsersHavePermission([]models.UserID{models.UserIDFromInt(1), models.UserIDFromInt(4)}, PermissionByName("edit"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(typed).Should(Equal(map[models.UserID]bool{
+				models.UserIDFromInt(1): false,
+				models.UserIDFromInt(4): true,
+			}))
+
+			_, err = permify.SubjectsHavePermission([]float64{1.5}, "edit")
+			Expect(errors.Is(err, ErrUnsupportedIdentifier)).Should(BeTrue())
+		})
+	})
+
+	Context("Explain User Permission", func() {
+		var permify *Permify
+
+		BeforeEach(func() {
+			permify = newMemoryPermify(Options{Wildcard: true})
+
+			Expect(permify.CreatePermission("invoices.delete", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("invoices.*", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("reports.view", "")).ShouldNot(HaveOccurred())
+			for _, role := range []string{"manager", "accountant", "auditor"} {
+				Expect(permify.CreateRole(role, "")).ShouldNot(HaveOccurred())
+			}
+			Expect(permify.AddChildRolesToRole("manager", "accountant")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToRole("accountant", "invoices.*")).ShouldNot(HaveOccurred())
+			Expect(permify.DenyPermissionsToRole("auditor", "invoices.delete")).ShouldNot(HaveOccurred())
+
+			Expect(permify.AddRolesToUser(12, []string{"manager", "auditor"})).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToUser(12, []string{"invoices.delete", "reports.view"})).ShouldNot(HaveOccurred())
+		})
+
+		It("Lists the Paths", func() {
+			explanation, err := permify.ExplainUserPermission(12, "invoices.delete")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(explanation.Subject).Should(Equal(models.UserSubject(models.UserIDFromInt(12))))
+			Expect(explanation.Permission.Name).Should(Equal("invoices.delete"))
+			Expect(explanation.Allowed).Should(BeFalse())
+			Expect(explanation.Considered.Roles.Names()).Should(ConsistOf("manager", "auditor"))
+			Expect(explanation.Considered.InheritedRoles.Names()).Should(Equal([]string{"accountant"}))
+			Expect(explanation.Considered.Permissions.Names()).Should(ConsistOf("invoices.delete", "reports.view"))
+			Expect(explanation.Considered.DeniedPermissions).Should(BeEmpty())
+			Expect(explanation.Considered.GrantingPermissions.Names()).Should(Equal([]string{"invoices.delete", "invoices.*"}))
+
+			var paths []string
+			for _, path := range explanation.Paths {
+				paths = append(paths, path.String())
+			}
+			Expect(paths).Should(ConsistOf(
+				"allow invoices.delete directly",
+				"allow invoices.* via inherited role manager -> accountant",
+				"deny invoices.delete via role auditor",
+			))
+
+			for _, path := range explanation.Paths {
+				Expect(path.Wildcard).Should(Equal(path.Permission.Name == "invoices.*"))
+			}
+
+			Expect(explanation.String()).Should(HavePrefix("user:12 is denied invoices.delete\n  "))
+		})
+
+		It("Decides like User Has Permission", func() {
+			for _, resolution := range []ConflictResolution{DenyOverrides, AllowOverrides, MostSpecificWins} {
+				permify.conflictResolution = resolution
+				for _, user := range []uint{12, 13} {
+					for _, name := range []string{"invoices.delete", "invoices.*", "reports.view"} {
+						explanation, err := permify.ExplainUserPermission(user, name)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(permify.UserHasPermission(user, name)).Should(Equal(explanation.Allowed), "%s %d %s", resolution, user, name)
+					}
+				}
+			}
+
+			explanation, err := permify.Typed().ExplainUserPermission(models.UserIDFromInt(13), PermissionByName("reports.view"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(explanation.Paths).Should(BeEmpty())
+			Expect(explanation.String()).Should(Equal("user:13 is denied reports.view\n  no assignment gives or denies it"))
+
+			encoded, err := json.Marshal(explanation)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(encoded)).Should(ContainSubstring(`"conflict_resolution":"most_specific_wins"`))
+		})
+
+		It("Explains in the Scope", func() {
+			Expect(permify.Tenant("org-a").DenyPermissionsToUser(12, "invoices.*")).ShouldNot(HaveOccurred())
+
+			explanation, err := permify.Tenant("org-a").ExplainUserPermission(12, "reports.view")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(explanation.TenantID).Should(Equal("org-a"))
+			Expect(explanation.Allowed).Should(BeTrue())
+
+			explanation, err = permify.Tenant("org-a").ExplainUserPermission(12, "invoices.delete")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(explanation.Considered.DeniedPermissions.Names()).Should(Equal([]string{"invoices.*"}))
+			Expect(explanation.Paths).Should(ContainElement(Path{Kind: PathDirect, Denied: true, Permission: explanation.Considered.DeniedPermissions[0], Wildcard: true}))
+
+			_, err = permify.ExplainUserPermission(12, "missing")
+			Expect(errors.Is(err, ErrPermissionNotFound)).Should(BeTrue())
+		})
+	})
+
+	Context("Audit Log", func() {
+		type change struct {
+			Operation string
+			Subject   string
+			Role      string
+			TenantID  string
+			Kind      models.AuditKind
+			Before    models.AuditNames
+			After     models.AuditNames
+		}
+
+		changes := func(entries []models.AuditEntry) (changes []change) {
+			for _, entry := range entries {
+				changes = append(changes, change{entry.Operation, entry.SubjectID, entry.Role, entry.TenantID, entry.Kind, entry.Before, entry.After})
+			}
+			return
+		}
+
+		It("Records the Changes", func() {
+			permify := newMemoryPermify(Options{Audit: true}).By("user:9")
+
+			Expect(permify.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("edit", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("view", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToRole("admin", "edit")).ShouldNot(HaveOccurred())
+			Expect(permify.ReplacePermissionsToRole("admin", []string{"view"})).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("org-a").AddRolesToUser(1, "admin")).ShouldNot(HaveOccurred())
+			Expect(permify.DenyPermissionsToUser(1, "edit")).ShouldNot(HaveOccurred())
+			Expect(permify.DeleteRole("admin")).ShouldNot(HaveOccurred())
+
+			entries, totalCount, err := permify.GetAuditEntries(options.AuditOption{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(totalCount).Should(Equal(int64(8)))
+			Expect(changes(entries)).Should(Equal([]change{
+				{"CreateRole", "", "admin", "", models.AuditRole, nil, models.AuditNames{"admin"}},
+				{"CreatePermission", "", "", "", models.AuditPermission, nil, models.AuditNames{"edit"}},
+				{"CreatePermission", "", "", "", models.AuditPermission, nil, models.AuditNames{"view"}},
+				{"AddPermissionsToRole", "", "admin", "", models.AuditPermissions, nil, models.AuditNames{"edit"}},
+				{"ReplacePermissionsToRole", "", "admin", "", models.AuditPermissions, models.AuditNames{"edit"}, models.AuditNames{"view"}},
+				{"AddRolesToUser", "1", "", "org-a", models.AuditRoles, nil, models.AuditNames{"admin"}},
+				{"DenyPermissionsToUser", "1", "", "", models.AuditDeniedPermissions, nil, models.AuditNames{"edit"}},
+				{"DeleteRole", "", "admin", "", models.AuditRole, models.AuditNames{"admin"}, nil},
+			}))
+			for _, entry := range entries {
+				Expect(entry.Actor).Should(Equal("user:9"))
+				Expect(entry.SubjectType).Should(Equal(map[bool]string{true: models.DefaultSubjectType}[entry.SubjectID != ""]))
+			}
+
+			Expect(permify.VerifyAuditLog()).ShouldNot(HaveOccurred())
+		})
+
+		It("Filters the Entries", func() {
+			permify := newMemoryPermify(Options{Audit: true})
+
+			Expect(permify.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreateRole("editor", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(1, "admin")).ShouldNot(HaveOccurred())
+			Expect(permify.By("user:9").AddRolesToUser(2, "editor")).ShouldNot(HaveOccurred())
+			Expect(permify.AddChildRolesToRole("editor", "admin")).ShouldNot(HaveOccurred())
+
+			entries, _, err := permify.GetAuditEntries(options.AuditOption{User: 2})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(changes(entries)).Should(Equal([]change{{"AddRolesToUser", "2", "", "", models.AuditRoles, nil, models.AuditNames{"editor"}}}))
+
+			entries, _, err = permify.GetAuditEntries(options.AuditOption{Role: "Admin"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(changes(entries)).Should(Equal([]change{
+				{"CreateRole", "", "admin", "", models.AuditRole, nil, models.AuditNames{"admin"}},
+				{"AddRolesToUser", "1", "", "", models.AuditRoles, nil, models.AuditNames{"admin"}},
+				{"AddChildRolesToRole", "", "editor", "", models.AuditChildRoles, nil, models.AuditNames{"admin"}},
+			}))
+
+			entries, totalCount, err := permify.GetAuditEntries(options.AuditOption{Actor: "user:9"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(totalCount).Should(Equal(int64(1)))
+
+			entries, totalCount, err = permify.GetAuditEntries(options.AuditOption{Since: entries[0].CreatedAt, Until: time.Now().Add(time.Minute), Pagination: &utils.Pagination{Page: 1, Limit: 1}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(totalCount).Should(BeNumerically(">=", 2))
+			Expect(entries).Should(HaveLen(1))
+
+			entries, totalCount, err = permify.GetAuditEntries(options.AuditOption{Until: time.Now().Add(-time.Minute)})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(totalCount).Should(Equal(int64(0)))
+		})
+
+		It("Rolls Back the Change", func() {
+			database := memory.NewDatabase()
+			auditRepository := &mocks.AuditRepository{}
+			auditRepository.On("Record", mock.Anything, mock.Anything).Return(errors.New("err disk full"))
+
+			permify, err := New(Options{
+				RoleRepository:       &memory.RoleRepository{Database: database},
+				PermissionRepository: &memory.PermissionRepository{Database: database},
+				UserRepository:       &memory.UserRepository{Database: database},
+				Audit:                true,
+				AuditRepository:      auditRepository,
+				Transactor:           &memory.Transactor{Database: database},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(permify.CreateRole("admin", "")).Should(MatchError(ContainSubstring("err disk full")))
+			_, err = permify.GetRole("admin", false)
+			Expect(errors.Is(err, ErrRoleNotFound)).Should(BeTrue())
+		})
+
+		It("Detects the Tampered Entries", func() {
+			database := memory.NewDatabase()
+			recorder := &memory.AuditRepository{Database: database}
+			var entries []models.AuditEntry
+			for _, operation := range []string{"CreateRole", "CreatePermission", "AddPermissionsToRole"} {
+				entry := models.AuditEntry{Operation: operation, Kind: models.AuditPermissions, After: models.AuditNames{"edit"}}
+				Expect(recorder.Record(context.Background(), &entry)).ShouldNot(HaveOccurred())
+				entries = append(entries, entry)
+			}
+
+			head, err := recorder.GetChainHead(context.Background())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(head).Should(Equal(entries[2].Hash))
+
+			verify := func(entries []models.AuditEntry) error {
+				auditRepository := &mocks.AuditRepository{}
+				auditRepository.On("GetAuditEntries", mock.Anything, repositories.AuditFilter{}, mock.Anything).Return(entries, int64(len(entries)), nil)
+				auditRepository.On("GetChainHead", mock.Anything).Return(head, nil)
+				permify, err := New(Options{Audit: true, AuditRepository: auditRepository})
+				Expect(err).ShouldNot(HaveOccurred())
+				return permify.VerifyAuditLog()
+			}
+
+			Expect(verify(entries)).ShouldNot(HaveOccurred())
+
+			changed := append([]models.AuditEntry{}, entries...)
+			changed[1].After = models.AuditNames{"delete"}
+			var tampered *AuditTamperedError
+			Expect(errors.As(verify(changed), &tampered)).Should(BeTrue())
+			Expect(tampered.EntryID).Should(Equal(entries[1].ID))
+
+			deleted := []models.AuditEntry{entries[0], entries[2]}
+			Expect(errors.As(verify(deleted), &tampered)).Should(BeTrue())
+			Expect(tampered.EntryID).Should(Equal(entries[2].ID))
+
+			truncated := entries[:2]
+			Expect(errors.As(verify(truncated), &tampered)).Should(BeTrue())
+			Expect(tampered.EntryID).Should(Equal(entries[1].ID))
+		})
+
+		It("Records the Windows of the Assignments Made Exactly in the Scope", func() {
+			permify := newMemoryPermify(Options{Audit: true})
+			startsAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+			expiresAt := time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)
+
+			Expect(permify.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("edit", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(1, "admin")).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("org-a").AddRolesToUser(1, "admin")).ShouldNot(HaveOccurred())
+			Expect(permify.Between(startsAt, time.Time{}).AddPermissionsToUser(1, "edit")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToUserUntil(1, "edit", expiresAt)).ShouldNot(HaveOccurred())
+			Expect(permify.Between(startsAt, expiresAt).AddPermissionsToRole("admin", "edit")).ShouldNot(HaveOccurred())
+
+			entries, _, err := permify.GetAuditEntries(options.AuditOption{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(changes(entries[2:])).Should(Equal([]change{
+				{"AddRolesToUser", "1", "", "", models.AuditRoles, nil, models.AuditNames{"admin"}},
+				{"AddRolesToUser", "1", "", "org-a", models.AuditRoles, nil, models.AuditNames{"admin"}},
+				{"AddPermissionsToUser", "1", "", "", models.AuditPermissions, nil, models.AuditNames{"edit (from 2030-01-01T00:00:00Z)"}},
+				{"AddPermissionsToUser", "1", "", "", models.AuditPermissions, models.AuditNames{"edit (from 2030-01-01T00:00:00Z)"}, models.AuditNames{"edit (until 2030-02-01T00:00:00Z)"}},
+				{"AddPermissionsToRole", "", "admin", "", models.AuditPermissions, nil, models.AuditNames{"edit (from 2030-01-01T00:00:00Z until 2030-02-01T00:00:00Z)"}},
+			}))
+
+			entries, _, err = permify.GetAuditEntries(options.AuditOption{Role: "admin", User: 1})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(entries).Should(HaveLen(2))
+			Expect(permify.VerifyAuditLog()).ShouldNot(HaveOccurred())
+		})
+
+		It("Is Disabled", func() {
+			permify := newMemoryPermify(Options{})
+			Expect(permify.CreateRole("admin", "")).ShouldNot(HaveOccurred())
+
+			_, _, err := permify.GetAuditEntries(options.AuditOption{})
+			Expect(errors.Is(err, ErrAuditDisabled)).Should(BeTrue())
+			Expect(errors.Is(permify.VerifyAuditLog(), ErrAuditDisabled)).Should(BeTrue())
+		})
+	})
+
+	Context("Acting User", func() {
+		var permify *Permify
+
+		BeforeEach(func() {
+			permify = newMemoryPermify(Options{Audit: true})
+
+			for _, role := range []string{"admin", "team lead", "developer"} {
+				Expect(permify.CreateRole(role, "")).ShouldNot(HaveOccurred())
+			}
+			for _, permission := range []string{"deploy", "drop database", "grant:role:developer"} {
+				Expect(permify.CreatePermission(permission, "")).ShouldNot(HaveOccurred())
+			}
+			Expect(permify.AddPermissionsToRole("team lead", []string{"deploy", "grant:role:developer"})).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToRole("admin", "drop database")).ShouldNot(HaveOccurred())
+			Expect(permify.AddChildRolesToRole("admin", "team lead")).ShouldNot(HaveOccurred())
+
+			Expect(permify.Tenant("team-a").AddRolesToUser(1, "team lead")).ShouldNot(HaveOccurred())
+			Expect(permify.AddRolesToUser(9, "admin")).ShouldNot(HaveOccurred())
+		})
+
+		It("Grants What the Actor Can Grant", func() {
+			lead := permify.Tenant("team-a").ActingAs(1)
+
+			Expect(lead.AddRolesToUser(2, "developer")).ShouldNot(HaveOccurred())
+			Expect(lead.AddRolesToUser(2, "team lead")).ShouldNot(HaveOccurred())
+			Expect(lead.AddPermissionsToUser(2, "deploy")).ShouldNot(HaveOccurred())
+			Expect(lead.AddPermissionsToRole("developer", "deploy")).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("team-a").UserHasAllRoles(2, []string{"developer", "team lead"})).Should(BeTrue())
+
+			// the admin inherits the team lead role and its permissions.
+			admin := permify.ActingAs(9)
+			Expect(admin.AddRolesToUser(3, []string{"admin", "team lead"})).ShouldNot(HaveOccurred())
+			Expect(admin.ReplacePermissionsToUser(3, []string{"drop database", "deploy"})).ShouldNot(HaveOccurred())
+
+			Expect(permify.Typed().Tenant("team-a").ActingAs(models.UserIDFromInt(1)).AddRolesToUser(models.UserIDFromInt(4), RoleByName("developer"))).ShouldNot(HaveOccurred())
+
+			entries, _, err := permify.GetAuditEntries(options.AuditOption{User: 2})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(entries).Should(HaveLen(3))
+			for _, entry := range entries {
+				Expect(entry.Actor).Should(Equal("user:1"))
+			}
+		})
+
+		It("Refuses the Escalations", func() {
+			lead := permify.Tenant("team-a").ActingAs(1)
+
+			var escalation *PrivilegeEscalationError
+			err := lead.AddRolesToUser(2, []string{"developer", "admin"})
+			Expect(errors.As(err, &escalation)).Should(BeTrue())
+			Expect(escalation.Actor).Should(Equal(models.UserSubject(models.UserIDFromInt(1))))
+			Expect(escalation.Roles).Should(Equal([]string{"admin"}))
+			Expect(escalation.Permissions).Should(BeEmpty())
+			Expect(err.Error()).Should(ContainSubstring("err privilege escalation: user:1 cannot grant roles admin"))
+			Expect(permify.Tenant("team-a").UserHasAnyRoles(2, []string{"developer", "admin"})).Should(BeFalse())
+
+			Expect(errors.As(lead.ReplaceRolesToUser(2, "admin"), &escalation)).Should(BeTrue())
+			Expect(errors.As(lead.AddPermissionsToUser(2, []string{"deploy", "drop database"}), &escalation)).Should(BeTrue())
+			Expect(escalation.Permissions).Should(Equal([]string{"drop-database"}))
+			Expect(errors.As(lead.ReplacePermissionsToUser(2, "drop database"), &escalation)).Should(BeTrue())
+			Expect(errors.As(lead.AddPermissionsToRole("developer", "drop database"), &escalation)).Should(BeTrue())
+			Expect(errors.As(lead.ReplacePermissionsToRole("developer", "drop database"), &escalation)).Should(BeTrue())
+			Expect(errors.As(lead.AddChildRolesToRole("developer", "admin"), &escalation)).Should(BeTrue())
+			Expect(permify.RoleHasPermission("developer", "drop database")).Should(BeFalse())
+
+			// the team lead role is only given in team-a.
+			Expect(errors.As(permify.Tenant("team-b").ActingAs(1).AddRolesToUser(2, "developer"), &escalation)).Should(BeTrue())
+			Expect(errors.As(permify.ActingAs(5).AddRolesToUser(2, "developer"), &escalation)).Should(BeTrue())
+
+			// the removals are not checked.
+			Expect(permify.AddRolesToUser(2, "admin")).ShouldNot(HaveOccurred())
+			Expect(lead.RemoveRolesFromUser(2, "admin")).ShouldNot(HaveOccurred())
+
+			entries, _, err := permify.GetAuditEntries(options.AuditOption{Actor: "user:1"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(entries).Should(HaveLen(1))
+			Expect(entries[0].Operation).Should(Equal("RemoveRolesFromUser"))
+		})
+
+		It("Keeps the Grant Permissions Apart", func() {
+			Expect(permify.CreatePermission("grant role developer", "")).ShouldNot(HaveOccurred())
+			Expect(permify.CreatePermission("grant:permission:developer", "")).ShouldNot(HaveOccurred())
+			Expect(permify.AddPermissionsToUser(5, []string{"grant role developer", "grant:permission:developer"})).ShouldNot(HaveOccurred())
+
+			grant, err := permify.GetPermission("grant:role:Developer")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(grant.GuardName).Should(Equal("grant:role:developer"))
+
+			// neither an ordinary permission nor the grant of a permission with the same name allow granting the role.
+			var escalation *PrivilegeEscalationError
+			Expect(errors.As(permify.ActingAs(5).AddRolesToUser(2, "developer"), &escalation)).Should(BeTrue())
+			Expect(escalation.Roles).Should(Equal([]string{"developer"}))
+
+			Expect(permify.AddPermissionsToUser(5, "grant:role:developer")).ShouldNot(HaveOccurred())
+			Expect(permify.ActingAs(5).AddRolesToUser(2, "developer")).ShouldNot(HaveOccurred())
+		})
+
+		It("Refuses Removing the Denied Permissions", func() {
+			Expect(permify.AddPermissionsToUser(2, "drop database")).ShouldNot(HaveOccurred())
+			Expect(permify.DenyPermissionsToUser(2, "drop database")).ShouldNot(HaveOccurred())
+			Expect(permify.UserHasPermission(2, "drop database")).Should(BeFalse())
+
+			var escalation *PrivilegeEscalationError
+			Expect(errors.As(permify.ActingAs(3).RemoveDeniedPermissionsFromUser(2, "drop database"), &escalation)).Should(BeTrue())
+			Expect(escalation.Permissions).Should(Equal([]string{"drop-database"}))
+			Expect(permify.UserHasPermission(2, "drop database")).Should(BeFalse())
+
+			Expect(permify.ActingAs(9).RemoveDeniedPermissionsFromUser(2, "drop database")).ShouldNot(HaveOccurred())
+			Expect(permify.UserHasPermission(2, "drop database")).Should(BeTrue())
+
+			Expect(permify.DenyPermissionsToRole("developer", "drop database")).ShouldNot(HaveOccurred())
+			Expect(errors.As(permify.Tenant("team-a").ActingAs(1).RemoveDeniedPermissionsFromRole("developer", "drop database"), &escalation)).Should(BeTrue())
+			Expect(escalation.Permissions).Should(Equal([]string{"drop-database"}))
+
+			Expect(permify.ActingAs(9).RemoveDeniedPermissionsFromRole("developer", "drop database")).ShouldNot(HaveOccurred())
+		})
+
+		It("Refuses Removing and Deleting the Roles That Deny Permissions", func() {
+			Expect(permify.CreateRole("restricted", "")).ShouldNot(HaveOccurred())
+			Expect(permify.DenyPermissionsToRole("restricted", "drop database")).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("team-a").AddPermissionsToUser(2, "drop database")).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("team-a").AddRolesToUser(2, "restricted")).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("team-a").UserHasPermission(2, "drop database")).Should(BeFalse())
+
+			lead := permify.Tenant("team-a").ActingAs(1)
+			var escalation *PrivilegeEscalationError
+			Expect(errors.As(lead.RemoveRolesFromUser(2, "restricted"), &escalation)).Should(BeTrue())
+			Expect(escalation.Permissions).Should(Equal([]string{"drop-database"}))
+			Expect(errors.As(lead.ReplaceRolesToUser(2, "developer"), &escalation)).Should(BeTrue())
+			Expect(escalation.Permissions).Should(Equal([]string{"drop-database"}))
+			Expect(permify.Tenant("team-a").UserHasPermission(2, "drop database")).Should(BeFalse())
+
+			// the denies of an inherited role are given back too.
+			Expect(permify.AddChildRolesToRole("developer", "restricted")).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("team-a").RemoveRolesFromUser(2, "restricted")).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("team-a").AddRolesToUser(2, "developer")).ShouldNot(HaveOccurred())
+			Expect(errors.As(lead.RemoveRolesFromUser(2, "developer"), &escalation)).Should(BeTrue())
+			Expect(errors.As(lead.RemoveChildRolesFromRole("developer", "restricted"), &escalation)).Should(BeTrue())
+			Expect(escalation.Permissions).Should(Equal([]string{"drop-database"}))
+
+			Expect(errors.As(permify.ActingAs(3).DeleteRole("restricted"), &escalation)).Should(BeTrue())
+			Expect(escalation.Permissions).Should(Equal([]string{"drop-database"}))
+			Expect(permify.Tenant("team-a").UserHasPermission(2, "drop database")).Should(BeFalse())
+
+			// the admin has the denied permission.
+			Expect(permify.ActingAs(9).DeleteRole("restricted")).ShouldNot(HaveOccurred())
+			Expect(permify.Tenant("team-a").UserHasPermission(2, "drop database")).Should(BeTrue())
+		})
+	})
+
+	Context("Policy", func() {
+		const policyYAML = `permissions:
+  - name: delete posts
+  - name: edit posts
+    description: edit the posts of all the authors
+  - name: view posts
+    guard_name: posts.view
+roles:
+  - name: editor
+    description: edits the posts
+    permissions:
+      - edit posts
+    denied_permissions:
+      - delete posts
+    children:
+      - viewer
+  - name: viewer
+    permissions:
+      - view posts
+`
+
+		It("Imports", func() {
+			permify := newMemoryPermify(Options{})
+			Expect(permify.ImportPolicy(strings.NewReader(policyYAML))).ShouldNot(HaveOccurred())
+
+			role, err := permify.GetRole("editor", true)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(role.Description).Should(Equal("edits the posts"))
+			Expect(collections.Permission(role.Permissions).GuardNames()).Should(Equal([]string{"edit-posts"}))
+
+			permission, err := permify.PermissionRepository.GetPermissionByGuardName(context.Background(), "posts.view")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(permission.Name).Should(Equal("view posts"))
+
+			Expect(permify.AddRolesToUser(1, "editor")).ShouldNot(HaveOccurred())
+			Expect(permify.UserHasAllPermissions(1, []uint{permission.ID})).Should(BeTrue())
+			Expect(permify.UserHasPermission(1, "delete posts")).Should(BeFalse())
+
+			// the existing records are updated, and nothing is removed.
+			Expect(permify.ImportPolicy(strings.NewReader(`{"roles": [{"name": "editor", "description": "changed"}]}`))).ShouldNot(HaveOccurred())
+			role, err = permify.GetRole("editor", true)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(role.Description).Should(Equal("changed"))
+			Expect(collections.Permission(role.Permissions).GuardNames()).Should(Equal([]string{"edit-posts"}))
+		})
+
+		It("Imports the Windows", func() {
+			permify := newMemoryPermify(Options{})
+			Expect(permify.ImportPolicy(strings.NewReader(`roles:
+  - name: editor
+    permissions:
+      - name: edit posts
+        expires_at: 2000-01-01T00:00:00Z
+      - name: view posts
+        starts_at: 2000-01-01T00:00:00Z
+permissions:
+  - name: edit posts
+  - name: view posts
+`))).ShouldNot(HaveOccurred())
+			Expect(permify.RoleHasPermission("editor", "edit posts")).Should(BeFalse())
+			Expect(permify.RoleHasPermission("editor", "view posts")).Should(BeTrue())
+
+			// the window of an existing permission of the role is updated.
+			Expect(permify.ImportPolicy(strings.NewReader(`{"roles": [{"name": "editor", "permissions": ["edit posts"]}]}`))).ShouldNot(HaveOccurred())
+			Expect(permify.RoleHasPermission("editor", "edit posts")).Should(BeTrue())
+
+			err := permify.ImportPolicy(strings.NewReader(`{"roles": [{"name": "editor", "permissions": [{"name": "edit posts", "until": "2000-01-01T00:00:00Z"}]}]}`))
+			Expect(errors.Is(err, ErrInvalidPolicy)).Should(BeTrue())
+			err = permify.ImportPolicy(strings.NewReader("roles:\n  - name: editor\n    permissions:\n      - name: edit posts\n        until: 2000-01-01T00:00:00Z\n"))
+			Expect(errors.Is(err, ErrInvalidPolicy)).Should(BeTrue())
+		})
+
+		It("Exports", func() {
+			permify := newMemoryPermify(Options{})
+			Expect(permify.ImportPolicy(strings.NewReader(policyYAML))).ShouldNot(HaveOccurred())
+
+			var exported strings.Builder
+			Expect(permify.ExportPolicy(&exported, PolicyYAML)).ShouldNot(HaveOccurred())
+			Expect(exported.String()).Should(Equal(policyYAML))
+
+			Expect(permify.ExportPolicy(&exported, PolicyJSON)).ShouldNot(HaveOccurred())
+
+			empty := newMemoryPermify(Options{})
+			exported.Reset()
+			Expect(empty.ExportPolicy(&exported, PolicyJSON)).ShouldNot(HaveOccurred())
+			Expect(exported.String()).Should(MatchJSON(`{"permissions": [], "roles": []}`))
+		})
+
+		It("Round Trips", func() {
+			source := newMemoryPermify(Options{})
+			Expect(source.ImportPolicy(strings.NewReader(policyYAML))).ShouldNot(HaveOccurred())
+			Expect(source.CreateRole("admin", "all the things")).ShouldNot(HaveOccurred())
+			Expect(source.AddChildRolesToRole("admin", "editor")).ShouldNot(HaveOccurred())
+
+			for _, format := range []PolicyFormat{PolicyYAML, PolicyJSON} {
+				var exported bytes.Buffer
+				Expect(source.ExportPolicy(&exported, format)).ShouldNot(HaveOccurred())
+
+				target := newMemoryPermify(Options{})
+				Expect(target.ImportPolicy(bytes.NewReader(exported.Bytes()))).ShouldNot(HaveOccurred())
+
+				var reexported bytes.Buffer
+				Expect(target.ExportPolicy(&reexported, format)).ShouldNot(HaveOccurred())
+				Expect(reexported.String()).Should(Equal(exported.String()))
+
+				sourcePolicy, err := source.GetPolicy()
+				Expect(err).ShouldNot(HaveOccurred())
+				targetPolicy, err := target.GetPolicy()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(targetPolicy).Should(Equal(sourcePolicy))
+			}
+		})
+
+		It("Round Trips the Windows", func() {
+			source := newMemoryPermify(Options{})
+			Expect(source.ImportPolicy(strings.NewReader(policyYAML))).ShouldNot(HaveOccurred())
+			Expect(source.CreatePermission("publish posts", "")).ShouldNot(HaveOccurred())
+
+			expired := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+			pending := time.Now().Add(time.Hour).UTC()
+			Expect(source.Between(time.Time{}, expired).AddPermissionsToRole("editor", "publish posts")).ShouldNot(HaveOccurred())
+			Expect(source.Between(pending, time.Time{}).DenyPermissionsToRole("viewer", "delete posts")).ShouldNot(HaveOccurred())
+
+			var exported bytes.Buffer
+			Expect(source.ExportPolicy(&exported, PolicyYAML)).ShouldNot(HaveOccurred())
+			Expect(exported.String()).Should(ContainSubstring(`      - name: publish posts
+        expires_at: 2000-01-01T00:00:00Z
+`))
+
+			for _, format := range []PolicyFormat{PolicyYAML, PolicyJSON} {
+				exported.Reset()
+				Expect(source.ExportPolicy(&exported, format)).ShouldNot(HaveOccurred())
+
+				target := newMemoryPermify(Options{})
+				Expect(target.ImportPolicy(bytes.NewReader(exported.Bytes()))).ShouldNot(HaveOccurred())
+
+				sourcePolicy, err := source.GetPolicy()
+				Expect(err).ShouldNot(HaveOccurred())
+				targetPolicy, err := target.GetPolicy()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(targetPolicy).Should(Equal(sourcePolicy))
+				Expect(targetPolicy.Roles[1].DeniedPermissions).Should(Equal([]PolicyLink{{Name: "delete posts", StartsAt: &pending}}))
+			}
+		})
+
+		It("Refuses the Invalid Policies", func() {
+			permify := newMemoryPermify(Options{})
+
+			err := permify.ImportPolicy(strings.NewReader("roles:\n  - name: editor\n    permission: [edit posts]\n"))
+			Expect(errors.Is(err, ErrInvalidPolicy)).Should(BeTrue())
+			err = permify.ImportPolicy(strings.NewReader(`{"roles": [{"name": "editor"}, {"name": "Editor"}]}`))
+			Expect(errors.Is(err, ErrInvalidPolicy)).Should(BeTrue())
+			err = permify.ImportPolicy(strings.NewReader(`{"permissions": [{"description": "no name"}]}`))
+			Expect(errors.Is(err, ErrInvalidPolicy)).Should(BeTrue())
+
+			// the import is rolled back.
+			err = permify.ImportPolicy(strings.NewReader(`{"roles": [{"name": "editor", "permissions": ["edit posts"]}]}`))
+			Expect(errors.Is(err, ErrPermissionNotFound)).Should(BeTrue())
+			_, err = permify.GetRole("editor", false)
+			Expect(errors.Is(err, ErrRoleNotFound)).Should(BeTrue())
+		})
+	})
+
+	Context("Reconcile", func() {
+		var permify *Permify
+
+		desired := Policy{
+			Permissions: []PolicyPermission{
+				{Name: "edit posts", Description: "edit any post"},
+				{Name: "publish posts"},
+				{Name: "view posts"},
+			},
+			Roles: []PolicyRole{
+				{Name: "editor", Permissions: []PolicyLink{{Name: "edit posts"}, {Name: "publish posts"}}, Children: []string{"viewer"}},
+				{Name: "viewer", Description: "reads the posts", Permissions: []PolicyLink{{Name: "view posts"}}},
+			},
+		}
+
+		BeforeEach(func() {
+			permify = newMemoryPermify(Options{Audit: true})
+			Expect(permify.AddPolicy(Policy{
+				Permissions: []PolicyPermission{{Name: "edit posts"}, {Name: "delete posts"}, {Name: "view posts"}},
+				Roles: []PolicyRole{
+					{Name: "editor", Permissions: []PolicyLink{{Name: "edit posts"}, {Name: "delete posts"}}, DeniedPermissions: []PolicyLink{{Name: "view posts"}}},
+					{Name: "viewer", Permissions: []PolicyLink{{Name: "view posts"}}},
+					{Name: "intern"},
+				},
+			})).ShouldNot(HaveOccurred())
+		})
+
+		It("Adds Without Pruning", func() {
+			var output strings.Builder
+			plan, err := permify.Reconcile(desired, options.ReconcileOption{Output: &output})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(output.String()).Should(Equal(plan.String()))
+			Expect(plan.String()).Should(Equal(`+ create permission publish-posts
+~ update permission edit-posts: description "" -> "edit any post"
+~ update role viewer: description "" -> "reads the posts"
++ add permission publish-posts to role editor
++ add child role viewer to role editor
+`))
+
+			Expect(permify.RoleHasAllPermissions("editor", []string{"edit posts", "delete posts", "publish posts"})).Should(BeTrue())
+			permission, err := permify.GetPermission("edit posts")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(permission.Description).Should(Equal("edit any post"))
+			_, err = permify.GetRole("intern", false)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			plan, err = permify.PlanPolicy(desired, false)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(plan.Empty()).Should(BeTrue())
+			Expect(plan.String()).Should(Equal("no changes\n"))
+		})
+
+		It("Prunes", func() {
+			plan, err := permify.PlanPolicy(desired, true)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(plan.String()).Should(Equal(`+ create permission publish-posts
+~ update permission edit-posts: description "" -> "edit any post"
+~ update role viewer: description "" -> "reads the posts"
+- remove denied permission view-posts from role editor
++ add permission publish-posts to role editor
++ add child role viewer to role editor
+- delete role intern
+- delete permission delete-posts
+`))
+
+			applied, err := permify.Reconcile(desired, options.ReconcileOption{Prune: true})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(applied).Should(Equal(plan))
+
+			policy, err := permify.GetPolicy()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(policy).Should(Equal(desired))
+
+			plan, err = permify.PlanPolicy(desired, true)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(plan.Empty()).Should(BeTrue())
+
+			entries, _, err := permify.GetAuditEntries(options.AuditOption{Role: "editor"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(entries[len(entries)-1].Operation).Should(Equal("AddChildRolesToRole"))
+		})
+
+		It("Dry Runs", func() {
+			before, err := permify.GetPolicy()
+			Expect(err).ShouldNot(HaveOccurred())
+
+			plan, err := permify.Reconcile(desired, options.ReconcileOption{Prune: true, DryRun: true})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(plan.Changes).Should(HaveLen(8))
+
+			after, err := permify.GetPolicy()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(after).Should(Equal(before))
+		})
+
+		It("Rolls Back", func() {
+			before, err := permify.GetPolicy()
+			Expect(err).ShouldNot(HaveOccurred())
+
+			// the permissions are created, then the acting user cannot give back the denied permission.
+			_, err = permify.ActingAs(5).Reconcile(desired, options.ReconcileOption{Prune: true})
+			var escalation *PrivilegeEscalationError
+			Expect(errors.As(err, &escalation)).Should(BeTrue())
+			Expect(err.Error()).Should(ContainSubstring("remove denied permission view-posts from role editor"))
+
+			after, err := permify.GetPolicy()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(after).Should(Equal(before))
+		})
+
+		It("Removes the Links Before Adding Them", func() {
+			Expect(permify.AddChildRolesToRole("editor", "viewer")).ShouldNot(HaveOccurred())
+
+			// the inheritance is inverted and the permission is moved to the denied permissions.
+			inverted := Policy{
+				Permissions: []PolicyPermission{{Name: "edit posts"}, {Name: "view posts"}},
+				Roles: []PolicyRole{
+					{Name: "editor", DeniedPermissions: []PolicyLink{{Name: "edit posts"}}},
+					{Name: "viewer", Permissions: []PolicyLink{{Name: "view posts"}}, Children: []string{"editor"}},
+				},
+			}
+			plan, err := permify.Reconcile(inverted, options.ReconcileOption{Prune: true})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(plan.String()).Should(Equal(`- remove permission edit-posts from role editor
+- remove denied permission view-posts from role editor
+- remove child role viewer from role editor
++ add denied permission edit-posts to role editor
++ add child role editor to role viewer
+- delete role intern
+- delete permission delete-posts
+`))
+
+			policy, err := permify.GetPolicy()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(policy).Should(Equal(inverted))
+		})
+
+		It("Refuses the Circular Inheritance", func() {
+			Expect(permify.AddChildRolesToRole("editor", "viewer")).ShouldNot(HaveOccurred())
+			before, err := permify.GetPolicy()
+			Expect(err).ShouldNot(HaveOccurred())
+
+			// without prune, the inherited viewer role is kept.
+			inverted := Policy{Roles: []PolicyRole{{Name: "viewer", Children: []string{"editor"}}}}
+			_, err = permify.PlanPolicy(inverted, false)
+			var circular *CircularInheritanceError
+			Expect(errors.As(err, &circular)).Should(BeTrue())
+			Expect(circular).Should(Equal(&CircularInheritanceError{Role: "viewer", Child: "editor"}))
+
+			_, err = permify.Reconcile(Policy{
+				Roles: []PolicyRole{{Name: "editor", Children: []string{"intern"}}, {Name: "intern", Children: []string{"viewer"}}, {Name: "viewer", Children: []string{"editor"}}},
+			}, options.ReconcileOption{Prune: true})
+			Expect(errors.As(err, &circular)).Should(BeTrue())
+
+			after, err := permify.GetPolicy()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(after).Should(Equal(before))
+		})
+
+		It("Updates the Windows", func() {
+			expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+			windowed := Policy{
+				Permissions: []PolicyPermission{{Name: "edit posts"}, {Name: "delete posts"}, {Name: "view posts"}},
+				Roles: []PolicyRole{
+					{Name: "editor", Permissions: []PolicyLink{{Name: "edit posts", ExpiresAt: &expiresAt}, {Name: "delete posts"}}, DeniedPermissions: []PolicyLink{{Name: "view posts"}}},
+					{Name: "viewer", Permissions: []PolicyLink{{Name: "view posts"}}},
+					{Name: "intern", Permissions: []PolicyLink{{Name: "view posts", StartsAt: &expiresAt}}},
+				},
+			}
+
+			plan, err := permify.Reconcile(windowed, options.ReconcileOption{Prune: true})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(plan.String()).Should(Equal(`~ update permission edit-posts of role editor: window "" -> "until 2030-01-01T00:00:00Z"
++ add permission view-posts to role intern from 2030-01-01T00:00:00Z
+`))
+
+			policy, err := permify.GetPolicy()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(policy.Roles[0].Permissions).Should(Equal([]PolicyLink{{Name: "delete posts"}, {Name: "edit posts", ExpiresAt: &expiresAt}}))
+			Expect(permify.RoleHasPermission("intern", "view posts")).Should(BeFalse())
+
+			plan, err = permify.PlanPolicy(windowed, true)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(plan.Empty()).Should(BeTrue())
+		})
+
+		It("Refers to the Kept Records", func() {
+			policy := Policy{Roles: []PolicyRole{{Name: "editor", Permissions: []PolicyLink{{Name: "delete posts"}}}}}
+
+			_, err := permify.PlanPolicy(policy, false)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = permify.PlanPolicy(policy, true)
+			Expect(errors.Is(err, ErrPermissionNotFound)).Should(BeTrue())
+			_, err = permify.Reconcile(Policy{Roles: []PolicyRole{{Name: "editor", Children: []string{"writer"}}}}, options.ReconcileOption{})
+			Expect(errors.Is(err, ErrRoleNotFound)).Should(BeTrue())
+		})
 	})
 })
@@ -0,0 +1,487 @@
+package permify_gorm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/helpers"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/models/pivot"
+	"github.com/Permify/go-role/options"
+	"github.com/Permify/go-role/repositories/scopes"
+)
+
+// PolicyFormat is the file format of the policies.
+type PolicyFormat int
+
+const (
+	// PolicyYAML is the yaml format of the policies.
+	PolicyYAML PolicyFormat = iota
+	// PolicyJSON is the json format of the policies.
+	PolicyJSON
+)
+
+// ErrInvalidPolicy is returned when a policy has a role or a permission without a name, or two roles or permissions with the same guard name.
+var ErrInvalidPolicy = errors.New("err invalid policy")
+
+// Policy is the declarative list of the permissions and the roles, in the files of ImportPolicy and ExportPolicy.
+// The roles refer to the permissions and to the child roles by their names, which are the names in the policy, or else the names of the existing records.
+// The permissions and the denied permissions of the roles are written as their names, or with their time windows if they have one.
+// The ids and the times of the records, and the assignments of the users, are not in the policy.
+// example:
+//
+//	permissions:
+//	  - name: edit posts
+//	    description: edit the posts of all the authors
+//	roles:
+//	  - name: editor
+//	    permissions:
+//	      - edit posts
+//	      - name: publish posts
+//	        expires_at: 2030-01-01T00:00:00Z
+//	    denied_permissions: [delete posts]
+//	    children: [viewer]
+type Policy struct {
+	Permissions []PolicyPermission `json:"permissions" yaml:"permissions"`
+	Roles       []PolicyRole       `json:"roles" yaml:"roles"`
+}
+
+// PolicyPermission is a permission of the policy.
+type PolicyPermission struct {
+	Name string `json:"name" yaml:"name"`
+	// GuardName is set if it is not the guard name of the name. (see Options.Guard)
+	GuardName   string `json:"guard_name,omitempty" yaml:"guard_name,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// PolicyRole is a role of the policy.
+type PolicyRole struct {
+	Name string `json:"name" yaml:"name"`
+	// GuardName is set if it is not the guard name of the name. (see Options.Guard)
+	GuardName   string `json:"guard_name,omitempty" yaml:"guard_name,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Permissions and DeniedPermissions are the permissions and the denied permissions of the role, Children the names of its child roles.
+	Permissions       []PolicyLink `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	DeniedPermissions []PolicyLink `json:"denied_permissions,omitempty" yaml:"denied_permissions,omitempty"`
+	Children          []string     `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// PolicyLink is a permission or a denied permission of a role of the policy, valid between StartsAt and ExpiresAt. (see Permify.Between)
+// It is written as its name if it has no window.
+type PolicyLink struct {
+	Name      string     `json:"name" yaml:"name"`
+	StartsAt  *time.Time `json:"starts_at,omitempty" yaml:"starts_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+}
+
+// policyLink is PolicyLink without its methods, to encode and decode its fields.
+type policyLink PolicyLink
+
+// policyLinkFields are the fields of the links that are written with their windows.
+var policyLinkFields = []string{"name", "starts_at", "expires_at"}
+
+// MarshalJSON writes the link as its name if it has no window.
+// @return []byte, error
+func (l PolicyLink) MarshalJSON() ([]byte, error) {
+	if l.StartsAt == nil && l.ExpiresAt == nil {
+		return json.Marshal(l.Name)
+	}
+	return json.Marshal(policyLink(l))
+}
+
+// UnmarshalJSON reads the link from its name or from its fields, the unknown fields are errors.
+// @param []byte
+// @return error
+func (l *PolicyLink) UnmarshalJSON(data []byte) error {
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '"' {
+		*l = PolicyLink{}
+		return json.Unmarshal(data, &l.Name)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var link policyLink
+	if err := decoder.Decode(&link); err != nil {
+		return err
+	}
+	*l = PolicyLink(link)
+	return nil
+}
+
+// MarshalYAML writes the link as its name if it has no window.
+// @return interface{}, error
+func (l PolicyLink) MarshalYAML() (interface{}, error) {
+	if l.StartsAt == nil && l.ExpiresAt == nil {
+		return l.Name, nil
+	}
+	return policyLink(l), nil
+}
+
+// UnmarshalYAML reads the link from its name or from its fields, the unknown fields are errors.
+// @param *yaml.Node
+// @return error
+func (l *PolicyLink) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = PolicyLink{}
+		return value.Decode(&l.Name)
+	}
+
+	if value.Kind == yaml.MappingNode {
+		for i := 0; i < len(value.Content); i += 2 {
+			if !helpers.InArray(value.Content[i].Value, policyLinkFields) {
+				return fmt.Errorf("line %d: field %s not found in type %T", value.Content[i].Line, value.Content[i].Value, *l)
+			}
+		}
+	}
+	var link policyLink
+	if err := value.Decode(&link); err != nil {
+		return err
+	}
+	*l = PolicyLink(link)
+	return nil
+}
+
+// window returns the window of the link.
+// @return repositories_scopes.Window
+func (l PolicyLink) window() scopes.Window {
+	return scopes.Window{StartsAt: l.StartsAt, ExpiresAt: l.ExpiresAt}
+}
+
+// ImportPolicy reads the yaml or json policy and adds it, in one transaction: the missing permissions and roles are created,
+// the names and the descriptions of the existing ones are updated, and the permissions, the denied permissions and the child roles
+// of the policy are added to the roles, with their windows. It is Reconcile without the prune option, nothing is removed.
+// @param io.Reader
+// @return error
+func (s *Permify) ImportPolicy(reader io.Reader) (err error) {
+	return s.ImportPolicyCtx(context.Background(), reader)
+}
+
+// ImportPolicyCtx is the context-aware variant of ImportPolicy.
+// @param context.Context
+// @param io.Reader
+// @return error
+func (s *Permify) ImportPolicyCtx(ctx context.Context, reader io.Reader) (err error) {
+	defer wrapError(&err, "ImportPolicy", nil)
+
+	var policy Policy
+	if policy, err = ReadPolicy(reader); err != nil {
+		return err
+	}
+	_, err = s.reconcile(ctx, policy, options.ReconcileOption{})
+	return err
+}
+
+// AddPolicy adds the policy like ImportPolicy.
+// @param Policy
+// @return error
+func (s *Permify) AddPolicy(policy Policy) (err error) {
+	return s.AddPolicyCtx(context.Background(), policy)
+}
+
+// AddPolicyCtx is the context-aware variant of AddPolicy.
+// @param context.Context
+// @param Policy
+// @return error
+func (s *Permify) AddPolicyCtx(ctx context.Context, policy Policy) (err error) {
+	defer wrapError(&err, "AddPolicy", nil)
+
+	_, err = s.reconcile(ctx, policy, options.ReconcileOption{})
+	return err
+}
+
+// ExportPolicy writes the policy of all the permissions and roles in the format. The permissions, the roles and their references are sorted by name.
+// The permissions and the denied permissions of the roles are all written with their windows, also the ones that are not valid now.
+// @param io.Writer
+// @param PolicyFormat
+// @return error
+func (s *Permify) ExportPolicy(writer io.Writer, format PolicyFormat) (err error) {
+	return s.ExportPolicyCtx(context.Background(), writer, format)
+}
+
+// ExportPolicyCtx is the context-aware variant of ExportPolicy.
+// @param context.Context
+// @param io.Writer
+// @param PolicyFormat
+// @return error
+func (s *Permify) ExportPolicyCtx(ctx context.Context, writer io.Writer, format PolicyFormat) (err error) {
+	defer wrapError(&err, "ExportPolicy", nil)
+
+	var policy Policy
+	if policy, err = s.GetPolicyCtx(ctx); err != nil {
+		return err
+	}
+	return WritePolicy(writer, policy, format)
+}
+
+// GetPolicy returns the policy of all the permissions and roles, like ExportPolicy.
+// @return Policy, error
+func (s *Permify) GetPolicy() (policy Policy, err error) {
+	return s.GetPolicyCtx(context.Background())
+}
+
+// GetPolicyCtx is the context-aware variant of GetPolicy.
+// @param context.Context
+// @return Policy, error
+func (s *Permify) GetPolicyCtx(ctx context.Context) (policy Policy, err error) {
+	defer wrapError(&err, "GetPolicy", nil)
+
+	var state policyState
+	if state, err = s.loadPolicyState(ctx); err != nil {
+		return
+	}
+
+	policy.Permissions = []PolicyPermission{}
+	for _, permission := range state.permissions {
+		policy.Permissions = append(policy.Permissions, PolicyPermission{
+			Name:        permission.Name,
+			GuardName:   s.policyExportedGuardName(permission.Name, permission.GuardName),
+			Description: permission.Description,
+		})
+	}
+
+	permissionLinks := func(object PlanObject, role string, guardNames []string) (links []PolicyLink) {
+		for _, guardName := range guardNames {
+			window := state.windows[policyLinkKey{object: object, role: role, guardName: guardName}]
+			links = append(links, PolicyLink{Name: state.permissions[guardName].Name, StartsAt: utc(window.StartsAt), ExpiresAt: utc(window.ExpiresAt)})
+		}
+		sort.Slice(links, func(i, j int) bool { return links[i].Name < links[j].Name })
+		return
+	}
+	policy.Roles = []PolicyRole{}
+	for guardName, role := range state.roles {
+		r := PolicyRole{
+			Name:              role.Name,
+			GuardName:         s.policyExportedGuardName(role.Name, role.GuardName),
+			Description:       role.Description,
+			Permissions:       permissionLinks(PlanRolePermission, guardName, state.rolePermissions[guardName]),
+			DeniedPermissions: permissionLinks(PlanRoleDeniedPermission, guardName, state.deniedPermissions[guardName]),
+		}
+		for _, child := range state.children[guardName] {
+			r.Children = append(r.Children, state.roles[child].Name)
+		}
+		sort.Strings(r.Children)
+		policy.Roles = append(policy.Roles, r)
+	}
+
+	sort.Slice(policy.Permissions, func(i, j int) bool { return policy.Permissions[i].Name < policy.Permissions[j].Name })
+	sort.Slice(policy.Roles, func(i, j int) bool { return policy.Roles[i].Name < policy.Roles[j].Name })
+	return
+}
+
+// policyState is the policy in the database, the permissions and the roles by their guard names,
+// the guard names of the permissions, the denied permissions and the child roles of the roles, and the windows of the permissions and the denied permissions.
+type policyState struct {
+	permissions       map[string]models.Permission
+	roles             map[string]models.Role
+	rolePermissions   map[string][]string
+	deniedPermissions map[string][]string
+	children          map[string][]string
+	windows           map[policyLinkKey]scopes.Window
+}
+
+// policyLinkKey is the key of the window of a permission or a denied permission of a role, by their guard names.
+type policyLinkKey struct {
+	object    PlanObject
+	role      string
+	guardName string
+}
+
+// loadPolicyState loads the policy in the database. The permissions and the denied permissions of the roles that are not valid now are loaded too.
+// @param context.Context
+// @return policyState, error
+func (s *Permify) loadPolicyState(ctx context.Context) (state policyState, err error) {
+	state = policyState{
+		permissions:       map[string]models.Permission{},
+		roles:             map[string]models.Role{},
+		rolePermissions:   map[string][]string{},
+		deniedPermissions: map[string][]string{},
+		children:          map[string][]string{},
+		windows:           map[policyLinkKey]scopes.Window{},
+	}
+
+	var permissionIDs []uint
+	if permissionIDs, _, err = s.PermissionRepository.GetPermissionIDs(ctx, nil); err != nil {
+		return
+	}
+	var permissions collections.Permission
+	if len(permissionIDs) > 0 {
+		if permissions, err = s.PermissionRepository.GetPermissions(ctx, permissionIDs); err != nil {
+			return
+		}
+	}
+	permissionGuardNames := make(map[uint]string, len(permissions))
+	for _, permission := range permissions {
+		state.permissions[permission.GuardName] = permission
+		permissionGuardNames[permission.ID] = permission.GuardName
+	}
+
+	var roleIDs []uint
+	if roleIDs, _, err = s.RoleRepository.GetRoleIDs(ctx, nil); err != nil {
+		return
+	}
+	if len(roleIDs) == 0 {
+		return
+	}
+	var roles collections.Role
+	if roles, err = s.RoleRepository.GetRoles(ctx, roleIDs); err != nil {
+		return
+	}
+	roleGuardNames := make(map[uint]string, len(roles))
+	for _, role := range roles {
+		state.roles[role.GuardName] = role
+		roleGuardNames[role.ID] = role.GuardName
+	}
+
+	link := func(object PlanObject, links map[string][]string, roleID uint, permissionID uint, window scopes.Window) {
+		role, guardName := roleGuardNames[roleID], permissionGuardNames[permissionID]
+		links[role] = append(links[role], guardName)
+		state.windows[policyLinkKey{object: object, role: role, guardName: guardName}] = window
+	}
+
+	var rolePermissions []pivot.RolePermissions
+	if rolePermissions, err = s.RoleRepository.GetPermissionWindows(ctx, roleIDs); err != nil {
+		return
+	}
+	for _, rolePermission := range rolePermissions {
+		link(PlanRolePermission, state.rolePermissions, rolePermission.RoleID, rolePermission.PermissionID, scopes.Window{StartsAt: rolePermission.StartsAt, ExpiresAt: rolePermission.ExpiresAt})
+	}
+
+	var roleDeniedPermissions []pivot.RoleDeniedPermissions
+	if roleDeniedPermissions, err = s.RoleRepository.GetDeniedPermissionWindows(ctx, roleIDs); err != nil {
+		return
+	}
+	for _, roleDeniedPermission := range roleDeniedPermissions {
+		link(PlanRoleDeniedPermission, state.deniedPermissions, roleDeniedPermission.RoleID, roleDeniedPermission.PermissionID, scopes.Window{StartsAt: roleDeniedPermission.StartsAt, ExpiresAt: roleDeniedPermission.ExpiresAt})
+	}
+
+	for _, role := range roles {
+		var childRoleIDs []uint
+		if childRoleIDs, err = s.RoleRepository.GetChildRoleIDs(ctx, []uint{role.ID}); err != nil {
+			return
+		}
+		for _, ID := range childRoleIDs {
+			state.children[role.GuardName] = append(state.children[role.GuardName], roleGuardNames[ID])
+		}
+	}
+	return
+}
+
+// ReadPolicy reads the yaml or json policy. The json policies start with {, the unknown fields are errors in both formats.
+// @param io.Reader
+// @return Policy, error
+func ReadPolicy(reader io.Reader) (policy Policy, err error) {
+	var data []byte
+	if data, err = io.ReadAll(reader); err != nil {
+		return
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return Policy{}, nil
+	}
+
+	if data[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&policy)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&policy)
+	}
+	if err != nil {
+		return Policy{}, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	return
+}
+
+// WritePolicy writes the policy in the format.
+// @param io.Writer
+// @param Policy
+// @param PolicyFormat
+// @return error
+func WritePolicy(writer io.Writer, policy Policy, format PolicyFormat) error {
+	if format == PolicyJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(policy)
+	}
+
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(policy); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// validatePolicy returns ErrInvalidPolicy if a permission or a role of the policy has no name, or has the guard name of another one.
+// @param Policy
+// @return error
+func (s *Permify) validatePolicy(policy Policy) error {
+	permissions := map[string]bool{}
+	for _, p := range policy.Permissions {
+		guardName := s.policyGuardName(p.Name, p.GuardName)
+		if p.Name == "" || guardName == "" {
+			return fmt.Errorf("%w: a permission has no name", ErrInvalidPolicy)
+		}
+		if permissions[guardName] {
+			return fmt.Errorf("%w: the permission %s is duplicated", ErrInvalidPolicy, guardName)
+		}
+		permissions[guardName] = true
+	}
+
+	roles := map[string]bool{}
+	for _, r := range policy.Roles {
+		guardName := s.policyGuardName(r.Name, r.GuardName)
+		if r.Name == "" || guardName == "" {
+			return fmt.Errorf("%w: a role has no name", ErrInvalidPolicy)
+		}
+		if roles[guardName] {
+			return fmt.Errorf("%w: the role %s is duplicated", ErrInvalidPolicy, guardName)
+		}
+		roles[guardName] = true
+	}
+	return nil
+}
+
+// policyGuardName returns the guard name of a permission or a role of the policy, the guard name of the name if it has none.
+// @param string
+// @param string
+// @return string
+func (s *Permify) policyGuardName(name string, guardName string) string {
+	if guardName != "" {
+		return guardName
+	}
+	return s.guardName(name)
+}
+
+// policyExportedGuardName returns the guard name of a permission or a role in the exported policy, empty if it is the guard name of the name.
+// @param string
+// @param string
+// @return string
+func (s *Permify) policyExportedGuardName(name string, guardName string) string {
+	if guardName == s.guardName(name) {
+		return ""
+	}
+	return guardName
+}
+
+// utc returns the time in UTC, nil if it is nil.
+// @param *time.Time
+// @return *time.Time
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
@@ -0,0 +1,578 @@
+package permify_gorm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Permify/go-role/helpers"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/options"
+	"github.com/Permify/go-role/repositories/scopes"
+)
+
+// PlanAction is the action of a change of the plan.
+type PlanAction string
+
+const (
+	// PlanCreate creates a permission or a role.
+	PlanCreate PlanAction = "create"
+	// PlanUpdate updates the name or the description of a permission or a role, or the window of a permission or a denied permission of a role.
+	PlanUpdate PlanAction = "update"
+	// PlanDelete deletes a permission or a role.
+	PlanDelete PlanAction = "delete"
+	// PlanAdd adds a permission, a denied permission or a child role to a role.
+	PlanAdd PlanAction = "add"
+	// PlanRemove removes a permission, a denied permission or a child role from a role.
+	PlanRemove PlanAction = "remove"
+)
+
+// PlanObject is what a change of the plan changes.
+type PlanObject string
+
+const (
+	// PlanPermission is a permission.
+	PlanPermission PlanObject = "permission"
+	// PlanRole is a role.
+	PlanRole PlanObject = "role"
+	// PlanRolePermission is a permission of a role.
+	PlanRolePermission PlanObject = "role permission"
+	// PlanRoleDeniedPermission is a denied permission of a role.
+	PlanRoleDeniedPermission PlanObject = "role denied permission"
+	// PlanChildRole is a child role of a role.
+	PlanChildRole PlanObject = "child role"
+)
+
+// PlanChange is a change of the plan.
+type PlanChange struct {
+	Action PlanAction
+	Object PlanObject
+	// GuardName is the guard name of the permission or the role, or of the permission or the child role that is added to or removed from the Role.
+	GuardName string
+	// Name and Description are the name and the description of the created or updated permission or role.
+	Name        string
+	Description string
+	// PreviousName and PreviousDescription are the name and the description of the updated permission or role before the update.
+	PreviousName        string
+	PreviousDescription string
+	// Role is the guard name of the role of the links.
+	Role string
+	// StartsAt and ExpiresAt are the window of the added or updated permission or denied permission of the Role,
+	// PreviousStartsAt and PreviousExpiresAt its window before the update.
+	StartsAt          *time.Time
+	ExpiresAt         *time.Time
+	PreviousStartsAt  *time.Time
+	PreviousExpiresAt *time.Time
+}
+
+// String returns the change. example: + add permission edit-posts to role editor
+// @return string
+func (c PlanChange) String() string {
+	switch c.Action {
+	case PlanCreate:
+		return fmt.Sprintf("+ create %s %s", c.Object, c.GuardName)
+	case PlanUpdate:
+		if c.Role != "" {
+			return fmt.Sprintf("~ update %s %s of role %s: window %q -> %q", c.linked(), c.GuardName, c.Role, windowString(c.PreviousStartsAt, c.PreviousExpiresAt), windowString(c.StartsAt, c.ExpiresAt))
+		}
+		var updates []string
+		if c.Name != c.PreviousName {
+			updates = append(updates, fmt.Sprintf("name %q -> %q", c.PreviousName, c.Name))
+		}
+		if c.Description != c.PreviousDescription {
+			updates = append(updates, fmt.Sprintf("description %q -> %q", c.PreviousDescription, c.Description))
+		}
+		return fmt.Sprintf("~ update %s %s: %s", c.Object, c.GuardName, strings.Join(updates, ", "))
+	case PlanDelete:
+		return fmt.Sprintf("- delete %s %s", c.Object, c.GuardName)
+	case PlanAdd:
+		if window := windowString(c.StartsAt, c.ExpiresAt); window != "" {
+			return fmt.Sprintf("+ add %s %s to role %s %s", c.linked(), c.GuardName, c.Role, window)
+		}
+		return fmt.Sprintf("+ add %s %s to role %s", c.linked(), c.GuardName, c.Role)
+	case PlanRemove:
+		return fmt.Sprintf("- remove %s %s from role %s", c.linked(), c.GuardName, c.Role)
+	}
+	return string(c.Action)
+}
+
+// linked returns what is added to or removed from the role.
+// @return string
+func (c PlanChange) linked() string {
+	switch c.Object {
+	case PlanRoleDeniedPermission:
+		return "denied permission"
+	case PlanChildRole:
+		return "child role"
+	}
+	return "permission"
+}
+
+// windowString returns the window in the plan, empty if it has no bounds. example: from 2030-01-01T00:00:00Z until 2030-02-01T00:00:00Z
+// @param *time.Time
+// @param *time.Time
+// @return string
+func windowString(startsAt *time.Time, expiresAt *time.Time) string {
+	var bounds []string
+	if startsAt != nil {
+		bounds = append(bounds, "from "+startsAt.UTC().Format(time.RFC3339Nano))
+	}
+	if expiresAt != nil {
+		bounds = append(bounds, "until "+expiresAt.UTC().Format(time.RFC3339Nano))
+	}
+	return strings.Join(bounds, " ")
+}
+
+// sameTime are the times both nil or the same instant?
+// @param *time.Time
+// @param *time.Time
+// @return bool
+func sameTime(t *time.Time, other *time.Time) bool {
+	if t == nil || other == nil {
+		return t == other
+	}
+	return t.Equal(*other)
+}
+
+// Plan is the changes that converge the database to a policy, in the order they are applied:
+// the creations, the updates, the removed links, the added links and the deletions.
+type Plan struct {
+	Changes []PlanChange
+}
+
+// Empty returns true if the database is converged.
+// @return bool
+func (p Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String returns the changes, one per line.
+// @return string
+func (p Plan) String() string {
+	if p.Empty() {
+		return "no changes\n"
+	}
+
+	var builder strings.Builder
+	for _, change := range p.Changes {
+		builder.WriteString(change.String())
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// PlanPolicy returns the plan that Reconcile would apply, without applying it.
+// @param Policy
+// @param bool
+// @return Plan, error
+func (s *Permify) PlanPolicy(policy Policy, prune bool) (plan Plan, err error) {
+	return s.PlanPolicyCtx(context.Background(), policy, prune)
+}
+
+// PlanPolicyCtx is the context-aware variant of PlanPolicy.
+// @param context.Context
+// @param Policy
+// @param bool
+// @return Plan, error
+func (s *Permify) PlanPolicyCtx(ctx context.Context, policy Policy, prune bool) (plan Plan, err error) {
+	defer wrapError(&err, "PlanPolicy", nil)
+
+	if err = s.validatePolicy(policy); err != nil {
+		return
+	}
+	var state policyState
+	if state, err = s.loadPolicyState(ctx); err != nil {
+		return
+	}
+	return s.planPolicy(policy, state, prune)
+}
+
+// Reconcile converges the database to the policy, in one transaction: the permissions and the roles of the policy are created or updated,
+// and the permissions, the denied permissions and the child roles of the policy are added to the roles, or their windows are updated.
+// With the prune option, the permissions and the roles that are not in the policy are deleted, and the roles of the policy lose the permissions,
+// the denied permissions and the child roles that the policy does not give them; the roles can only refer to the permissions and roles of the policy.
+// Without it, nothing is deleted or removed, and the roles can refer to the permissions and roles of the policy or of the database.
+// The plan is written to the output of the option before it is applied, and it is not applied with the dry run option.
+// @param Policy
+// @param options.ReconcileOption
+// @return Plan, error
+func (s *Permify) Reconcile(policy Policy, option options.ReconcileOption) (plan Plan, err error) {
+	return s.ReconcileCtx(context.Background(), policy, option)
+}
+
+// ReconcileCtx is the context-aware variant of Reconcile.
+// @param context.Context
+// @param Policy
+// @param options.ReconcileOption
+// @return Plan, error
+func (s *Permify) ReconcileCtx(ctx context.Context, policy Policy, option options.ReconcileOption) (plan Plan, err error) {
+	defer wrapError(&err, "Reconcile", nil)
+
+	return s.reconcile(ctx, policy, option)
+}
+
+// reconcile converges the database to the policy, like Reconcile.
+// @param context.Context
+// @param Policy
+// @param options.ReconcileOption
+// @return Plan, error
+func (s *Permify) reconcile(ctx context.Context, policy Policy, option options.ReconcileOption) (plan Plan, err error) {
+	if err = s.validatePolicy(policy); err != nil {
+		return
+	}
+
+	err = s.transaction(ctx, func(ctx context.Context) (err error) {
+		var state policyState
+		if state, err = s.loadPolicyState(ctx); err != nil {
+			return
+		}
+		if plan, err = s.planPolicy(policy, state, option.Prune); err != nil {
+			return
+		}
+
+		if option.Output != nil {
+			if _, err = io.WriteString(option.Output, plan.String()); err != nil {
+				return
+			}
+		}
+		if option.DryRun {
+			return nil
+		}
+
+		for _, change := range plan.Changes {
+			if err = s.applyPlanChange(ctx, change); err != nil {
+				return fmt.Errorf("%s: %w", strings.TrimLeft(change.String(), "+~- "), err)
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// planPolicy returns the changes that converge the state to the policy.
+// It returns ErrPermissionNotFound or ErrRoleNotFound if a role refers to a permission or a role that is neither in the policy nor kept in the database,
+// and *CircularInheritanceError if the roles would inherit themselves once the changes are applied.
+// @param Policy
+// @param policyState
+// @param bool
+// @return Plan, error
+func (s *Permify) planPolicy(policy Policy, state policyState, prune bool) (plan Plan, err error) {
+	// the guard names of the names of the policy, the other names refer to the records of the database.
+	permissionGuardNames, keptPermissions := map[string]string{}, map[string]bool{}
+	for _, p := range policy.Permissions {
+		permissionGuardNames[p.Name] = s.policyGuardName(p.Name, p.GuardName)
+		keptPermissions[permissionGuardNames[p.Name]] = true
+	}
+	roleGuardNames, keptRoles := map[string]string{}, map[string]bool{}
+	for _, r := range policy.Roles {
+		roleGuardNames[r.Name] = s.policyGuardName(r.Name, r.GuardName)
+		keptRoles[roleGuardNames[r.Name]] = true
+	}
+
+	// resolve returns the guard names of the names and the windows of the links by their guard names, a link of a name given twice keeps its last window.
+	resolve := func(links []PolicyLink, guardNames map[string]string, exists func(guardName string) bool, notFound error) (resolved []string, windows map[string]scopes.Window, err error) {
+		windows = map[string]scopes.Window{}
+		for _, link := range links {
+			guardName, ok := guardNames[link.Name]
+			if !ok {
+				guardName = s.guardName(link.Name)
+				if prune || !exists(guardName) {
+					return nil, nil, fmt.Errorf("%w: %s", notFound, link.Name)
+				}
+			}
+			if !helpers.InArray(guardName, resolved) {
+				resolved = append(resolved, guardName)
+			}
+			windows[guardName] = link.window()
+		}
+		sort.Strings(resolved)
+		return
+	}
+	permissionExists := func(guardName string) bool {
+		_, ok := state.permissions[guardName]
+		return ok
+	}
+	roleExists := func(guardName string) bool {
+		_, ok := state.roles[guardName]
+		return ok
+	}
+
+	var creates, updates, adds, removes, deletes []PlanChange
+
+	permissions := append([]PolicyPermission{}, policy.Permissions...)
+	sort.Slice(permissions, func(i, j int) bool {
+		return permissionGuardNames[permissions[i].Name] < permissionGuardNames[permissions[j].Name]
+	})
+	for _, p := range permissions {
+		guardName := permissionGuardNames[p.Name]
+		current, ok := state.permissions[guardName]
+		change := PlanChange{Object: PlanPermission, GuardName: guardName, Name: p.Name, Description: p.Description}
+		if !ok {
+			change.Action = PlanCreate
+			creates = append(creates, change)
+		} else if current.Name != p.Name || current.Description != p.Description {
+			change.Action, change.PreviousName, change.PreviousDescription = PlanUpdate, current.Name, current.Description
+			updates = append(updates, change)
+		}
+	}
+
+	roles := append([]PolicyRole{}, policy.Roles...)
+	sort.Slice(roles, func(i, j int) bool {
+		return roleGuardNames[roles[i].Name] < roleGuardNames[roles[j].Name]
+	})
+	for _, r := range roles {
+		guardName := roleGuardNames[r.Name]
+		current, ok := state.roles[guardName]
+		change := PlanChange{Object: PlanRole, GuardName: guardName, Name: r.Name, Description: r.Description}
+		if !ok {
+			change.Action = PlanCreate
+			creates = append(creates, change)
+		} else if current.Name != r.Name || current.Description != r.Description {
+			change.Action, change.PreviousName, change.PreviousDescription = PlanUpdate, current.Name, current.Description
+			updates = append(updates, change)
+		}
+
+		links := []struct {
+			object  PlanObject
+			desired []string
+			windows map[string]scopes.Window
+			current []string
+		}{
+			{object: PlanRolePermission, current: state.rolePermissions[guardName]},
+			{object: PlanRoleDeniedPermission, current: state.deniedPermissions[guardName]},
+			{object: PlanChildRole, current: state.children[guardName]},
+		}
+		if links[0].desired, links[0].windows, err = resolve(r.Permissions, permissionGuardNames, permissionExists, ErrPermissionNotFound); err != nil {
+			return Plan{}, err
+		}
+		if links[1].desired, links[1].windows, err = resolve(r.DeniedPermissions, permissionGuardNames, permissionExists, ErrPermissionNotFound); err != nil {
+			return Plan{}, err
+		}
+		children := make([]PolicyLink, 0, len(r.Children))
+		for _, child := range r.Children {
+			children = append(children, PolicyLink{Name: child})
+		}
+		if links[2].desired, links[2].windows, err = resolve(children, roleGuardNames, roleExists, ErrRoleNotFound); err != nil {
+			return Plan{}, err
+		}
+
+		for _, link := range links {
+			for _, linked := range link.desired {
+				window := link.windows[linked]
+				change := PlanChange{Object: link.object, GuardName: linked, Role: guardName, StartsAt: window.StartsAt, ExpiresAt: window.ExpiresAt}
+				if !helpers.InArray(linked, link.current) {
+					change.Action = PlanAdd
+					adds = append(adds, change)
+					continue
+				}
+				// the child roles have no windows.
+				current := state.windows[policyLinkKey{object: link.object, role: guardName, guardName: linked}]
+				if !sameTime(current.StartsAt, window.StartsAt) || !sameTime(current.ExpiresAt, window.ExpiresAt) {
+					change.Action, change.PreviousStartsAt, change.PreviousExpiresAt = PlanUpdate, current.StartsAt, current.ExpiresAt
+					updates = append(updates, change)
+				}
+			}
+			if !prune {
+				continue
+			}
+			current := append([]string{}, link.current...)
+			sort.Strings(current)
+			for _, linked := range current {
+				// the links of the deleted permissions and roles are deleted with them.
+				kept := keptPermissions[linked]
+				if link.object == PlanChildRole {
+					kept = keptRoles[linked]
+				}
+				if kept && !helpers.InArray(linked, link.desired) {
+					removes = append(removes, PlanChange{Action: PlanRemove, Object: link.object, GuardName: linked, Role: guardName})
+				}
+			}
+		}
+	}
+
+	if prune {
+		for guardName := range state.roles {
+			if !keptRoles[guardName] {
+				deletes = append(deletes, PlanChange{Action: PlanDelete, Object: PlanRole, GuardName: guardName})
+			}
+		}
+		for guardName := range state.permissions {
+			if !keptPermissions[guardName] {
+				deletes = append(deletes, PlanChange{Action: PlanDelete, Object: PlanPermission, GuardName: guardName})
+			}
+		}
+		sort.Slice(deletes, func(i, j int) bool {
+			if deletes[i].Object != deletes[j].Object {
+				return deletes[i].Object == PlanRole
+			}
+			return deletes[i].GuardName < deletes[j].GuardName
+		})
+	}
+
+	if err = checkPlannedInheritance(state, adds, removes, deletes); err != nil {
+		return Plan{}, err
+	}
+
+	// the links are removed before they are added, so that an inverted inheritance or a permission moved to the denied permissions are applied.
+	for _, changes := range [][]PlanChange{creates, updates, removes, adds, deletes} {
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	return plan, nil
+}
+
+// checkPlannedInheritance returns *CircularInheritanceError if a child role added by the plan would make a role inherit itself once the plan is applied.
+// @param policyState
+// @param []PlanChange
+// @param []PlanChange
+// @param []PlanChange
+// @return error
+func checkPlannedInheritance(state policyState, adds []PlanChange, removes []PlanChange, deletes []PlanChange) error {
+	deleted := map[string]bool{}
+	for _, change := range deletes {
+		if change.Object == PlanRole {
+			deleted[change.GuardName] = true
+		}
+	}
+
+	children := map[string]map[string]bool{}
+	link := func(role string, child string, linked bool) {
+		if children[role] == nil {
+			children[role] = map[string]bool{}
+		}
+		children[role][child] = linked
+	}
+	for role, current := range state.children {
+		for _, child := range current {
+			link(role, child, !deleted[role] && !deleted[child])
+		}
+	}
+	for _, change := range removes {
+		if change.Object == PlanChildRole {
+			link(change.Role, change.GuardName, false)
+		}
+	}
+	for _, change := range adds {
+		if change.Object == PlanChildRole {
+			link(change.Role, change.GuardName, true)
+		}
+	}
+
+	// inherits returns true if the role inherits the other role, transitively.
+	inherits := func(role string, other string) bool {
+		visited, queue := map[string]bool{role: true}, []string{role}
+		for len(queue) > 0 {
+			for child, linked := range children[queue[0]] {
+				if !linked {
+					continue
+				}
+				if child == other {
+					return true
+				}
+				if !visited[child] {
+					visited[child] = true
+					queue = append(queue, child)
+				}
+			}
+			queue = queue[1:]
+		}
+		return false
+	}
+
+	for _, change := range adds {
+		if change.Object == PlanChildRole && (change.GuardName == change.Role || inherits(change.GuardName, change.Role)) {
+			return &CircularInheritanceError{Role: change.Role, Child: change.GuardName}
+		}
+	}
+	return nil
+}
+
+// applyPlanChange applies the change with the methods of Permify, so that the changes are audited and the cache is invalidated.
+// @param context.Context
+// @param PlanChange
+// @return error
+func (s *Permify) applyPlanChange(ctx context.Context, change PlanChange) (err error) {
+	switch change.Object {
+	case PlanPermission:
+		switch change.Action {
+		case PlanCreate:
+			return s.createPermission(ctx, &models.Permission{Name: change.Name, GuardName: change.GuardName, Description: change.Description})
+		case PlanUpdate:
+			var permission models.Permission
+			if permission, err = s.PermissionRepository.GetPermissionByGuardName(ctx, change.GuardName); err != nil {
+				return err
+			}
+			return s.audited(ctx, models.AuditEntry{Operation: "UpdatePermission", Kind: models.AuditPermission}, s.permissionNames(change.GuardName), func(ctx context.Context) error {
+				return s.PermissionRepository.Updates(ctx, &permission, map[string]interface{}{"name": change.Name, "description": change.Description})
+			})
+		case PlanDelete:
+			var permission models.Permission
+			if permission, err = s.PermissionRepository.GetPermissionByGuardName(ctx, change.GuardName); err != nil {
+				return err
+			}
+			return s.DeletePermissionCtx(ctx, permission.ID)
+		}
+	case PlanRole:
+		switch change.Action {
+		case PlanCreate:
+			return s.createRole(ctx, &models.Role{Name: change.Name, GuardName: change.GuardName, Description: change.Description})
+		case PlanUpdate:
+			var role models.Role
+			if role, err = s.RoleRepository.GetRoleByGuardName(ctx, change.GuardName); err != nil {
+				return err
+			}
+			return s.audited(ctx, s.roleAuditEntry("UpdateRole", role, models.AuditRole), s.roleNames(change.GuardName), func(ctx context.Context) error {
+				return s.RoleRepository.Updates(ctx, &role, map[string]interface{}{"name": change.Name, "description": change.Description})
+			})
+		case PlanDelete:
+			var role models.Role
+			if role, err = s.RoleRepository.GetRoleByGuardName(ctx, change.GuardName); err != nil {
+				return err
+			}
+			return s.DeleteRoleCtx(ctx, role.ID)
+		}
+	default:
+		var role models.Role
+		if role, err = s.RoleRepository.GetRoleByGuardName(ctx, change.Role); err != nil {
+			return err
+		}
+
+		if change.Object == PlanChildRole {
+			var child models.Role
+			if child, err = s.RoleRepository.GetRoleByGuardName(ctx, change.GuardName); err != nil {
+				return err
+			}
+			if change.Action == PlanAdd {
+				return s.AddChildRolesToRoleCtx(ctx, role.ID, child.ID)
+			}
+			return s.RemoveChildRolesFromRoleCtx(ctx, role.ID, child.ID)
+		}
+
+		var permission models.Permission
+		if permission, err = s.PermissionRepository.GetPermissionByGuardName(ctx, change.GuardName); err != nil {
+			return err
+		}
+		// the window of an updated permission is replaced by removing the permission and adding it again,
+		// since adding a permission again without a window keeps its window.
+		if change.Action == PlanRemove || change.Action == PlanUpdate {
+			if change.Object == PlanRolePermission {
+				err = s.RemovePermissionsFromRoleCtx(ctx, role.ID, permission.ID)
+			} else {
+				err = s.RemoveDeniedPermissionsFromRoleCtx(ctx, role.ID, permission.ID)
+			}
+			if err != nil || change.Action == PlanRemove {
+				return err
+			}
+		}
+
+		windowed := *s
+		windowed.window = scopes.Window{StartsAt: change.StartsAt, ExpiresAt: change.ExpiresAt}
+		if change.Object == PlanRolePermission {
+			return windowed.AddPermissionsToRoleCtx(ctx, role.ID, permission.ID)
+		}
+		return windowed.DenyPermissionsToRoleCtx(ctx, role.ID, permission.ID)
+	}
+	return nil
+}
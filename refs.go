@@ -0,0 +1,114 @@
+package permify_gorm
+
+import (
+	"fmt"
+)
+
+// ref refers to records by their ids or by their names.
+// A ref of one id or name refers to a record that must exist, the missing records of a ref of many are skipped.
+type ref struct {
+	ids    []uint
+	names  []string
+	byName bool
+	one    bool
+	err    error
+}
+
+// RoleRef refers to roles by their ids or by their names. Build it with RoleByID or RoleByName.
+type RoleRef struct {
+	ref
+}
+
+// PermissionRef refers to permissions by their ids or by their names. Build it with PermissionByID or PermissionByName.
+type PermissionRef struct {
+	ref
+}
+
+// RoleByID refers to roles by their ids.
+// example: RoleByID(1), RoleByID(1, 2)
+// @param ...uint
+// @return RoleRef
+func RoleByID(IDs ...uint) RoleRef {
+	return RoleRef{ref{ids: IDs, one: len(IDs) == 1}}
+}
+
+// RoleByName refers to roles by their names. The names are converted to guard names.
+// example: RoleByName("admin"), RoleByName("admin", "editor")
+// @param ...string
+// @return RoleRef
+func RoleByName(names ...string) RoleRef {
+	return RoleRef{ref{names: names, byName: true, one: len(names) == 1}}
+}
+
+// PermissionByID refers to permissions by their ids.
+// example: PermissionByID(1), PermissionByID(1, 2)
+// @param ...uint
+// @return PermissionRef
+func PermissionByID(IDs ...uint) PermissionRef {
+	return PermissionRef{ref{ids: IDs, one: len(IDs) == 1}}
+}
+
+// PermissionByName refers to permissions by their names. The names are converted to guard names.
+// example: PermissionByName("edit user details"), PermissionByName("edit user details", "delete user")
+// @param ...string
+// @return PermissionRef
+func PermissionByName(names ...string) PermissionRef {
+	return PermissionRef{ref{names: names, byName: true, one: len(names) == 1}}
+}
+
+// String returns the ids or the names.
+// @return string
+func (r ref) String() string {
+	if r.byName {
+		if r.one {
+			return r.names[0]
+		}
+		return fmt.Sprint(r.names)
+	}
+	if r.one {
+		return fmt.Sprint(r.ids[0])
+	}
+	return fmt.Sprint(r.ids)
+}
+
+// roleRefOf converts the role identifiers of the untyped methods to ref. They can be a name, an id or an array of them, or a RoleRef.
+// @param interface{}
+// @return ref
+func roleRefOf(v interface{}) ref {
+	if r, ok := v.(RoleRef); ok {
+		return r.ref
+	}
+	return refOf(v)
+}
+
+// permissionRefOf converts the permission identifiers of the untyped methods to ref. They can be a name, an id or an array of them, or a PermissionRef.
+// @param interface{}
+// @return ref
+func permissionRefOf(v interface{}) ref {
+	if p, ok := v.(PermissionRef); ok {
+		return p.ref
+	}
+	return refOf(v)
+}
+
+// refOf converts a name, an id or an array of them to ref. The negative ids are not supported.
+// @param interface{}
+// @return ref
+func refOf(v interface{}) ref {
+	switch value := v.(type) {
+	case string:
+		return ref{names: []string{value}, byName: true, one: true}
+	case []string:
+		return ref{names: value, byName: true}
+	case int:
+		if value < 0 {
+			break
+		}
+		return ref{ids: []uint{uint(value)}, one: true}
+	case uint:
+		return ref{ids: []uint{value}, one: true}
+	case []uint:
+		return ref{ids: value}
+	}
+	return ref{err: ErrUnsupportedIdentifier}
+}
@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/repositories/scopes"
+)
+
+// IAuditRepository its data access layer abstraction of the audit log.
+type IAuditRepository interface {
+	Migratable
+
+	// actions
+
+	Record(ctx context.Context, entry *models.AuditEntry) (err error)
+
+	// fetch options
+
+	GetAuditEntries(ctx context.Context, filter AuditFilter, pagination scopes.GormPager) (entries []models.AuditEntry, totalCount int64, err error)
+	GetChainHead(ctx context.Context) (hash string, err error)
+}
+
+// AuditFilter selects the audit entries, the zero value selects all of them.
+type AuditFilter struct {
+	// Subject selects the changes of the assignments of the user.
+	Subject *models.Subject
+	// Role selects the creation, the deletion and the changes of the role, and the changes that give or take the role. (guard name)
+	Role string
+	// Actor selects the changes made by the actor.
+	Actor string
+	// Since and Until select the changes made in the time range, Until is excluded. The zero times are unbounded.
+	Since time.Time
+	Until time.Time
+}
+
+// AuditRepository its data access layer of the audit log.
+type AuditRepository struct {
+	Database *gorm.DB
+}
+
+// auditChainHeadID is the id of the single row of the audit_chain_heads table.
+const auditChainHeadID = 1
+
+// Migrate generate the audit_entries and audit_chain_heads tables in the database if they don't exist, and create the head of the chain.
+// @return error
+func (repository *AuditRepository) Migrate() (err error) {
+	if err = repository.Database.AutoMigrate(models.AuditEntry{}, models.AuditChainHead{}); err != nil {
+		return err
+	}
+	return repository.createHead(context.Background())
+}
+
+// ACTIONS
+
+// Record chains the entry to the last entry and creates it. The creation time is set if it is zero.
+// It should be given the context of the transaction of the change (see Transactor), so that the entry is created with the change.
+// @param context.Context
+// @param *models.AuditEntry
+// @return error
+func (repository *AuditRepository) Record(ctx context.Context, entry *models.AuditEntry) error {
+	// the head of the chain is locked until the transaction ends, so that the entries of the concurrent changes are chained one after another.
+	// the head is a row that always exists, unlike the last entry, so the first entries are chained one after another too.
+	var head models.AuditChainHead
+	err := repository.lockHead(ctx, &head)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// the head is created if the tables are not migrated by Migrate.
+		if err = repository.createHead(ctx); err != nil {
+			return err
+		}
+		err = repository.lockHead(ctx, &head)
+	}
+	if err != nil {
+		return err
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().Truncate(time.Millisecond)
+	}
+	entry.PreviousHash = head.Hash
+	entry.Hash = entry.ComputeHash()
+	if err = database(ctx, repository.Database).Create(entry).Error; err != nil {
+		return err
+	}
+	return database(ctx, repository.Database).Model(&head).Update("hash", entry.Hash).Error
+}
+
+// lockHead gets the head of the chain and locks it until the transaction ends.
+// @param context.Context
+// @param *models.AuditChainHead
+// @return error
+func (repository *AuditRepository) lockHead(ctx context.Context, head *models.AuditChainHead) error {
+	return database(ctx, repository.Database).Clauses(clause.Locking{Strength: "UPDATE"}).Where("audit_chain_heads.id = ?", auditChainHeadID).Take(head).Error
+}
+
+// createHead creates the head of the chain with the hash of the last entry, if it doesn't exist.
+// @param context.Context
+// @return error
+func (repository *AuditRepository) createHead(ctx context.Context) error {
+	var last models.AuditEntry
+	if err := database(ctx, repository.Database).Select("audit_entries.hash").Order("audit_entries.id desc").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+	return database(ctx, repository.Database).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.AuditChainHead{ID: auditChainHeadID, Hash: last.Hash}).Error
+}
+
+// FETCH OPTIONS
+
+// GetAuditEntries get the audit entries that are selected by the filter, in the order they are recorded. (with pagination)
+// @param context.Context
+// @param AuditFilter
+// @param repositories_scopes.GormPager
+// @return []models.AuditEntry, int64, error
+func (repository *AuditRepository) GetAuditEntries(ctx context.Context, filter AuditFilter, pagination scopes.GormPager) (entries []models.AuditEntry, totalCount int64, err error) {
+	err = database(ctx, repository.Database).Model(&models.AuditEntry{}).Scopes(filter.toAuditEntries).Count(&totalCount).Scopes(repository.paginate(pagination)).Order("audit_entries.id").Find(&entries).Error
+	return
+}
+
+// GetChainHead get the hash of the last recorded entry, which is kept apart from the entries, so that the deletion of the last entries can be detected.
+// It is empty if no entry is recorded.
+// @param context.Context
+// @return string, error
+func (repository *AuditRepository) GetChainHead(ctx context.Context) (hash string, err error) {
+	var heads []models.AuditChainHead
+	if err = database(ctx, repository.Database).Where("audit_chain_heads.id = ?", auditChainHeadID).Limit(1).Find(&heads).Error; err != nil || len(heads) == 0 {
+		return "", err
+	}
+	return heads[0].Hash, nil
+}
+
+// toAuditEntries adds the conditions of the filter to your gorm queries.
+// @param *gorm.DB
+// @return *gorm.DB
+func (filter AuditFilter) toAuditEntries(db *gorm.DB) *gorm.DB {
+	if filter.Subject != nil {
+		db = db.Where("audit_entries.subject_type = ?", filter.Subject.Type).Where("audit_entries.subject_id = ?", filter.Subject.ID.String())
+	}
+	if filter.Role != "" {
+		// the names are json arrays, the role is matched with its quotes, or its opening quote and its window, so that it does not match the names that contain it. (see models.AuditName)
+		escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(filter.Role)
+		name, windowed := `%"`+escaped+`"%`, `%"`+escaped+` (%`
+		db = db.Where("audit_entries.role = ? OR (audit_entries.kind IN (?) AND (audit_entries.before_names LIKE ? ESCAPE '!' OR audit_entries.after_names LIKE ? ESCAPE '!' OR audit_entries.before_names LIKE ? ESCAPE '!' OR audit_entries.after_names LIKE ? ESCAPE '!'))",
+			filter.Role, []models.AuditKind{models.AuditRoles, models.AuditChildRoles}, name, name, windowed, windowed)
+	}
+	if filter.Actor != "" {
+		db = db.Where("audit_entries.actor = ?", filter.Actor)
+	}
+	if !filter.Since.IsZero() {
+		db = db.Where("audit_entries.created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		db = db.Where("audit_entries.created_at < ?", filter.Until)
+	}
+	return db
+}
+
+// paginate pagging if pagination option is true.
+// @param repositories_scopes.GormPager
+// @return func(db *gorm.DB) *gorm.DB
+func (repository *AuditRepository) paginate(pagination scopes.GormPager) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if pagination != nil {
+			db.Scopes(pagination.ToPaginate())
+		}
+
+		return db
+	}
+}
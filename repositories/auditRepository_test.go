@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/Permify/go-role/models"
+)
+
+var _ = Describe("Audit Repository", func() {
+	var repository *AuditRepository
+	var mock sqlmock.Sqlmock
+
+	BeforeEach(func() {
+		var db *sql.DB
+		var err error
+
+		db, mock, err = sqlmock.New()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var gormDb *gorm.DB
+		dialector := postgres.New(postgres.Config{
+			DSN:                  "sqlmock_db_0",
+			DriverName:           "postgres",
+			Conn:                 db,
+			PreferSimpleProtocol: true,
+		})
+		gormDb, err = gorm.Open(dialector, &gorm.Config{})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repository = &AuditRepository{Database: gormDb}
+	})
+
+	AfterEach(func() {
+		err := mock.ExpectationsWereMet()
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	Context("Record", func() {
+		const sqlLockHead = `SELECT * FROM "audit_chain_heads" WHERE audit_chain_heads.id = $1 LIMIT 1 FOR UPDATE`
+		const sqlUpdateHead = `UPDATE "audit_chain_heads" SET "hash"=$1 WHERE "id" = $2`
+
+		It("chains to the last entry", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(sqlLockHead)).
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}).AddRow(1, "previous"))
+
+			entry := models.AuditEntry{
+				Actor:     "user:5",
+				Operation: "AddPermissionsToRole",
+				Role:      "admin",
+				Kind:      models.AuditPermissions,
+				After:     models.AuditNames{"edit"},
+				CreatedAt: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+			}
+			expected := entry
+			expected.PreviousHash = "previous"
+			expected.Hash = expected.ComputeHash()
+
+			const sqlInsert = `INSERT INTO "audit_entries" ("actor","operation","subject_type","subject_id","role","tenant_id","resource_type","resource_id","kind","before_names","after_names","previous_hash","hash","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14) RETURNING "id"`
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(sqlInsert)).
+				WithArgs("user:5", "AddPermissionsToRole", "", "", "admin", "", "", "", models.AuditPermissions, "[]", `["edit"]`, "previous", expected.Hash, entry.CreatedAt).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+			mock.ExpectCommit()
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(sqlUpdateHead)).
+				WithArgs(expected.Hash, 1).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			err := repository.Record(context.Background(), &entry)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(entry.ID).Should(Equal(uint(8)))
+			Expect(entry.PreviousHash).Should(Equal("previous"))
+			Expect(entry.Hash).Should(Equal(expected.Hash))
+		})
+
+		It("is the first entry", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(sqlLockHead)).WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}).AddRow(1, ""))
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO "audit_entries"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(sqlUpdateHead)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			entry := models.AuditEntry{Operation: "CreateRole", Role: "admin", Kind: models.AuditRole}
+			err := repository.Record(context.Background(), &entry)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(entry.PreviousHash).Should(BeEmpty())
+			Expect(entry.CreatedAt.IsZero()).Should(BeFalse())
+		})
+
+		It("creates the missing head with the hash of the last entry", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(sqlLockHead)).WillReturnRows(sqlmock.NewRows(nil))
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT audit_entries.hash FROM "audit_entries" ORDER BY audit_entries.id desc LIMIT 1`)).
+				WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("previous"))
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_chain_heads" ("hash","id") VALUES ($1,$2) ON CONFLICT DO NOTHING RETURNING "id"`)).
+				WithArgs("previous", 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectCommit()
+			mock.ExpectQuery(regexp.QuoteMeta(sqlLockHead)).WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}).AddRow(1, "previous"))
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO "audit_entries"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(sqlUpdateHead)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			entry := models.AuditEntry{Operation: "CreateRole", Role: "admin", Kind: models.AuditRole}
+			err := repository.Record(context.Background(), &entry)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(entry.PreviousHash).Should(Equal("previous"))
+		})
+	})
+
+	Context("Get Audit Entries", func() {
+		It("filters by user and role", func() {
+			subject := models.UserSubject(models.UserIDFromInt(1))
+
+			const sqlCount = `SELECT count(*) FROM "audit_entries" WHERE audit_entries.subject_type = $1 AND audit_entries.subject_id = $2 AND (audit_entries.role = $3 OR (audit_entries.kind IN ($4,$5) AND (audit_entries.before_names LIKE $6 ESCAPE '!' OR audit_entries.after_names LIKE $7 ESCAPE '!' OR audit_entries.before_names LIKE $8 ESCAPE '!' OR audit_entries.after_names LIKE $9 ESCAPE '!')))`
+			const sqlSelect = `SELECT * FROM "audit_entries" WHERE audit_entries.subject_type = $1 AND audit_entries.subject_id = $2 AND (audit_entries.role = $3 OR (audit_entries.kind IN ($4,$5) AND (audit_entries.before_names LIKE $6 ESCAPE '!' OR audit_entries.after_names LIKE $7 ESCAPE '!' OR audit_entries.before_names LIKE $8 ESCAPE '!' OR audit_entries.after_names LIKE $9 ESCAPE '!'))) ORDER BY audit_entries.id`
+
+			mock.ExpectQuery(regexp.QuoteMeta(sqlCount)).
+				WithArgs("user", "1", "order_admin", models.AuditRoles, models.AuditChildRoles, `%"order!_admin"%`, `%"order!_admin"%`, `%"order!_admin (%`, `%"order!_admin (%`).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+				WithArgs("user", "1", "order_admin", models.AuditRoles, models.AuditChildRoles, `%"order!_admin"%`, `%"order!_admin"%`, `%"order!_admin (%`, `%"order!_admin (%`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "operation", "kind", "before_names", "after_names"}).AddRow(3, "AddRolesToUser", "roles", "[]", `["order_admin"]`))
+
+			entries, totalCount, err := repository.GetAuditEntries(context.Background(), AuditFilter{Subject: &subject, Role: "order_admin"}, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(totalCount).Should(Equal(int64(1)))
+			Expect(entries).Should(Equal([]models.AuditEntry{{
+				ID:        3,
+				Operation: "AddRolesToUser",
+				Kind:      models.AuditRoles,
+				Before:    models.AuditNames{},
+				After:     models.AuditNames{"order_admin"},
+			}}))
+		})
+
+		It("filters by time range", func() {
+			since := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+			until := since.AddDate(0, 1, 0)
+
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "audit_entries" WHERE audit_entries.created_at >= $1 AND audit_entries.created_at < $2`)).
+				WithArgs(since, until).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "audit_entries" WHERE audit_entries.created_at >= $1 AND audit_entries.created_at < $2 ORDER BY audit_entries.id`)).
+				WithArgs(since, until).
+				WillReturnRows(sqlmock.NewRows(nil))
+
+			entries, totalCount, err := repository.GetAuditEntries(context.Background(), AuditFilter{Since: since, Until: until}, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(totalCount).Should(Equal(int64(0)))
+			Expect(entries).Should(BeEmpty())
+		})
+	})
+})
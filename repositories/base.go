@@ -1,5 +1,16 @@
 package repositories
 
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Permify/go-role/helpers"
+	"github.com/Permify/go-role/models"
+)
+
 // Seedable gives models the ability to seed.
 type Seedable interface {
 	Seed() error
@@ -25,3 +36,125 @@ func Migrates(repos ...Migratable) (err error) {
 	}
 	return
 }
+
+// migrateUserPivot generate the table of a user pivot. (user_roles, user_permissions or user_denied_permissions)
+// AutoMigrate adds the new columns to the tables created before, but keeps their primary key. The tables whose primary key is not
+// the one of the model, e.g. (user_id, role_id) before the subject type, the tenant and the resource joined it, are rebuilt with it.
+func migrateUserPivot(db *gorm.DB, model interface{}) error {
+	if err := db.AutoMigrate(model); err != nil {
+		return err
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	primaryKey, err := primaryKeyColumns(db, model, stmt.Table)
+	if err != nil {
+		return err
+	}
+	if sameColumns(primaryKey, stmt.Schema.PrimaryFieldDBNames) {
+		return nil
+	}
+
+	// the rows are copied to a new table with the primary key of the model, which replaces the old one.
+	// the old rows are unique by the new primary key, since the old primary key is a part of it.
+	return db.Transaction(func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for name := range stmt.Schema.ParseIndexes() {
+			if migrator.HasIndex(model, name) {
+				if err := migrator.DropIndex(model, name); err != nil {
+					return err
+				}
+			}
+		}
+
+		rebuilt := stmt.Table + "_rebuilt"
+		if err := tx.Table(rebuilt).Migrator().CreateTable(model); err != nil {
+			return err
+		}
+		columns := make([]string, 0, len(stmt.Schema.DBNames))
+		for _, name := range stmt.Schema.DBNames {
+			columns = append(columns, tx.Statement.Quote(name))
+		}
+		list := strings.Join(columns, ",")
+		if err := tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", tx.Statement.Quote(rebuilt), list, list, tx.Statement.Quote(stmt.Table))).Error; err != nil {
+			return err
+		}
+		if err := migrator.DropTable(stmt.Table); err != nil {
+			return err
+		}
+		return migrator.RenameTable(rebuilt, stmt.Table)
+	})
+}
+
+// primaryKeyColumns returns the columns of the primary key of the table in the database.
+func primaryKeyColumns(db *gorm.DB, model interface{}, table string) (columns []string, err error) {
+	// the sqlite migrator finds the primary key by parsing the ddl of the table, which misses the columns of the composite keys.
+	if db.Dialector.Name() == "sqlite" {
+		err = db.Raw("SELECT name FROM pragma_table_info(?) WHERE pk > 0", table).Scan(&columns).Error
+		return
+	}
+
+	columnTypes, err := db.Migrator().ColumnTypes(model)
+	if err != nil {
+		return nil, err
+	}
+	for _, columnType := range columnTypes {
+		if primary, ok := columnType.PrimaryKey(); ok && primary {
+			columns = append(columns, columnType.Name())
+		}
+	}
+	return
+}
+
+// sameColumns do the lists have the same columns, in any order?
+func sameColumns(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, column := range b {
+		if !helpers.InArray(column, a) {
+			return false
+		}
+	}
+	return true
+}
+
+// purgeExpired deletes the rows of the model that expired before the given time, batch by batch.
+// Each batch deletes the expired rows of at most batchSize owners. (users or roles)
+func purgeExpired(db *gorm.DB, model interface{}, table string, owner string, before time.Time, batchSize int) (deleted int64, err error) {
+	for {
+		// models.UserID scans any id, so it holds the role ids too.
+		var ownerIDs []models.UserID
+		if err = db.Table(table).Distinct(table+"."+owner).Where(table+".expires_at <= ?", before).Limit(batchSize).Pluck(table+"."+owner, &ownerIDs).Error; err != nil || len(ownerIDs) == 0 {
+			return
+		}
+
+		result := db.Where(table+"."+owner+" IN (?)", ownerIDs).Where(table+".expires_at <= ?", before).Delete(model)
+		if err = result.Error; err != nil {
+			return
+		}
+		deleted += result.RowsAffected
+
+		if len(ownerIDs) < batchSize {
+			return
+		}
+	}
+}
+
+// nextWindowBound returns the earliest starts_at or expires_at after the given time of the rows of the tables selected by their scopes, nil if there is none.
+func nextWindowBound(db *gorm.DB, tables []string, scope func(table string) func(db *gorm.DB) *gorm.DB, after time.Time) (bound *time.Time, err error) {
+	for _, table := range tables {
+		for _, column := range []string{table + ".starts_at", table + ".expires_at"} {
+			var bounds []time.Time
+			if err = db.Table(table).Scopes(scope(table)).Where(column+" > ?", after).Order(column).Limit(1).Pluck(column, &bounds).Error; err != nil {
+				return nil, err
+			}
+			if len(bounds) > 0 && (bound == nil || bounds[0].Before(*bound)) {
+				bound = &bounds[0]
+			}
+		}
+	}
+	return bound, nil
+}
@@ -0,0 +1,1215 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/repositories"
+	"github.com/Permify/go-role/repositories/scopes"
+	"github.com/Permify/go-role/utils"
+)
+
+// Repositories are the implementations under test. They must share the same storage, which must be empty.
+type Repositories struct {
+	Role       repositories.IRoleRepository
+	Permission repositories.IPermissionRepository
+	User       repositories.IUserRepository
+	Audit      repositories.IAuditRepository
+	Transactor repositories.ITransactor
+
+	// UserIDType is the type of the user ids that the specs use, the gorm repositories must migrate their user_id columns with it.
+	UserIDType models.UserIDType
+
+	// Close releases the storage after each spec, it can be nil.
+	Close func() error
+}
+
+// Describe registers the conformance specs of the repositories to the ginkgo suite.
+// newRepositories is called before each spec, so that the specs do not see the data of each other.
+// The role permissions and the role children of a deleted role must be deleted by the storage, e.g. with foreign keys. (sqlite needs _foreign_keys=on)
+// example: var _ = conformance.Describe("memory", func() conformance.Repositories { ... })
+// @param string
+// @param func() Repositories
+// @return bool
+func Describe(name string, newRepositories func() Repositories) bool {
+	return ginkgo.Describe(name+" conformance", func() {
+		var (
+			ctx  context.Context
+			repo Repositories
+		)
+
+		ginkgo.BeforeEach(func() {
+			ctx = context.Background()
+			repo = newRepositories()
+			Expect(repositories.Migrates(repo.Role, repo.Permission, repo.Audit)).ShouldNot(HaveOccurred())
+		})
+
+		ginkgo.AfterEach(func() {
+			if repo.Close != nil {
+				Expect(repo.Close()).ShouldNot(HaveOccurred())
+			}
+		})
+
+		subject := func(subjectType string, n int) models.Subject {
+			var ID models.UserID
+			var ok bool
+			switch repo.UserIDType {
+			case models.UserIDString:
+				ID, ok = repo.UserIDType.Parse(fmt.Sprintf("user-%d", n))
+			case models.UserIDUUID:
+				ID, ok = repo.UserIDType.Parse(fmt.Sprintf("00000000-0000-4000-8000-%012d", n))
+			default:
+				ID, ok = repo.UserIDType.Parse(n)
+			}
+			Expect(ok).Should(BeTrue())
+			return models.Subject{Type: subjectType, ID: ID}
+		}
+
+		user := func(n int) models.Subject {
+			return subject(models.DefaultSubjectType, n)
+		}
+
+		createRole := func(guardName string) models.Role {
+			role := models.Role{Name: guardName, GuardName: guardName}
+			Expect(repo.Role.FirstOrCreate(ctx, &role)).ShouldNot(HaveOccurred())
+			return role
+		}
+
+		createPermission := func(guardName string) models.Permission {
+			permission := models.Permission{Name: guardName, GuardName: guardName}
+			Expect(repo.Permission.FirstOrCreate(ctx, &permission)).ShouldNot(HaveOccurred())
+			return permission
+		}
+
+		past := time.Now().Add(-time.Hour)
+		future := time.Now().Add(time.Hour)
+
+		ginkgo.Context("Roles", func() {
+			ginkgo.It("creates once", func() {
+				role := createRole("admin")
+				Expect(role.ID).ShouldNot(BeZero())
+
+				again := models.Role{Name: "other name", GuardName: "admin"}
+				Expect(repo.Role.FirstOrCreate(ctx, &again)).ShouldNot(HaveOccurred())
+				Expect(again.ID).Should(Equal(role.ID))
+				Expect(again.Name).Should(Equal("admin"))
+
+				roleIDs, totalCount, err := repo.Role.GetRoleIDs(ctx, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(Equal([]uint{role.ID}))
+				Expect(totalCount).Should(Equal(int64(1)))
+			})
+
+			ginkgo.It("fetches", func() {
+				admin := createRole("admin")
+				editor := createRole("editor")
+
+				role, err := repo.Role.GetRoleByID(ctx, admin.ID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(role.GuardName).Should(Equal("admin"))
+
+				role, err = repo.Role.GetRoleByGuardName(ctx, "editor")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(role.ID).Should(Equal(editor.ID))
+
+				roles, err := repo.Role.GetRoles(ctx, []uint{admin.ID, editor.ID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roles.IDs()).Should(ConsistOf(admin.ID, editor.ID))
+
+				roles, err = repo.Role.GetRolesByGuardNames(ctx, []string{"editor", "viewer"})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roles.IDs()).Should(Equal([]uint{editor.ID}))
+			})
+
+			ginkgo.It("returns record not found", func() {
+				_, err := repo.Role.GetRoleByID(ctx, 1)
+				Expect(err).Should(MatchError(gorm.ErrRecordNotFound))
+
+				_, err = repo.Role.GetRoleByGuardName(ctx, "admin")
+				Expect(err).Should(MatchError(gorm.ErrRecordNotFound))
+			})
+
+			ginkgo.It("updates", func() {
+				role := createRole("admin")
+				Expect(repo.Role.Updates(ctx, &role, map[string]interface{}{"description": "all access"})).ShouldNot(HaveOccurred())
+
+				role, err := repo.Role.GetRoleByID(ctx, role.ID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(role.Description).Should(Equal("all access"))
+			})
+		})
+
+		ginkgo.Context("Permissions", func() {
+			ginkgo.It("creates once", func() {
+				permission := createPermission("edit")
+				Expect(permission.ID).ShouldNot(BeZero())
+
+				again := models.Permission{Name: "other name", GuardName: "edit"}
+				Expect(repo.Permission.FirstOrCreate(ctx, &again)).ShouldNot(HaveOccurred())
+				Expect(again.ID).Should(Equal(permission.ID))
+
+				permissionIDs, totalCount, err := repo.Permission.GetPermissionIDs(ctx, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{permission.ID}))
+				Expect(totalCount).Should(Equal(int64(1)))
+			})
+
+			ginkgo.It("fetches", func() {
+				edit := createPermission("edit")
+				view := createPermission("view")
+
+				permission, err := repo.Permission.GetPermissionByID(ctx, edit.ID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permission.GuardName).Should(Equal("edit"))
+
+				permission, err = repo.Permission.GetPermissionByGuardName(ctx, "view")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permission.ID).Should(Equal(view.ID))
+
+				permissions, err := repo.Permission.GetPermissions(ctx, []uint{edit.ID, view.ID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissions.IDs()).Should(ConsistOf(edit.ID, view.ID))
+
+				permissions, err = repo.Permission.GetPermissionsByGuardNames(ctx, []string{"view", "delete"})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissions.IDs()).Should(Equal([]uint{view.ID}))
+			})
+
+			ginkgo.It("returns record not found", func() {
+				_, err := repo.Permission.GetPermissionByID(ctx, 1)
+				Expect(err).Should(MatchError(gorm.ErrRecordNotFound))
+
+				_, err = repo.Permission.GetPermissionByGuardName(ctx, "edit")
+				Expect(err).Should(MatchError(gorm.ErrRecordNotFound))
+			})
+		})
+
+		ginkgo.Context("Role Permissions", func() {
+			ginkgo.It("adds and removes", func() {
+				role := createRole("admin")
+				edit := createPermission("edit")
+				view := createPermission("view")
+
+				Expect(repo.Role.AddPermissions(ctx, &role, collections.Permission{edit, view}, scopes.Window{})).ShouldNot(HaveOccurred())
+
+				permissionIDs, totalCount, err := repo.Permission.GetPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(ConsistOf(edit.ID, view.ID))
+				Expect(totalCount).Should(Equal(int64(2)))
+
+				roleIDs, _, err := repo.Role.GetRoleIDsOfPermission(ctx, edit.ID, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(Equal([]uint{role.ID}))
+
+				withPermissions, err := repo.Role.GetRoleByIDWithPermissions(ctx, role.ID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(collections.Permission(withPermissions.Permissions).IDs()).Should(ConsistOf(edit.ID, view.ID))
+
+				Expect(repo.Role.RemovePermissions(ctx, &role, collections.Permission{edit})).ShouldNot(HaveOccurred())
+
+				permissionIDs, _, err = repo.Permission.GetPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{view.ID}))
+			})
+
+			ginkgo.It("replaces and clears", func() {
+				role := createRole("admin")
+				edit := createPermission("edit")
+				view := createPermission("view")
+
+				Expect(repo.Role.AddPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.ReplacePermissions(ctx, &role, collections.Permission{view}, scopes.Window{})).ShouldNot(HaveOccurred())
+
+				permissionIDs, _, err := repo.Permission.GetPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{view.ID}))
+
+				Expect(repo.Role.ClearPermissions(ctx, &role)).ShouldNot(HaveOccurred())
+
+				permissionIDs, _, err = repo.Permission.GetPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+			})
+
+			ginkgo.It("controls", func() {
+				admin := createRole("admin")
+				editor := createRole("editor")
+				edit := createPermission("edit")
+				view := createPermission("view")
+
+				Expect(repo.Role.AddPermissions(ctx, &admin, collections.Permission{edit, view}, scopes.Window{})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddPermissions(ctx, &editor, collections.Permission{edit}, scopes.Window{})).ShouldNot(HaveOccurred())
+
+				Expect(repo.Role.HasPermission(ctx, collections.Role{editor}, edit)).Should(BeTrue())
+				Expect(repo.Role.HasPermission(ctx, collections.Role{editor}, view)).Should(BeFalse())
+				Expect(repo.Role.HasAllPermissions(ctx, collections.Role{admin}, collections.Permission{edit, view})).Should(BeTrue())
+				Expect(repo.Role.HasAllPermissions(ctx, collections.Role{admin, editor}, collections.Permission{edit, view})).Should(BeFalse())
+				Expect(repo.Role.HasAnyPermissions(ctx, collections.Role{editor}, collections.Permission{edit, view})).Should(BeTrue())
+			})
+
+			ginkgo.It("ignores the assignments outside their window", func() {
+				role := createRole("admin")
+				expired := createPermission("expired")
+				pending := createPermission("pending")
+				current := createPermission("current")
+
+				Expect(repo.Role.AddPermissions(ctx, &role, collections.Permission{expired}, scopes.Window{ExpiresAt: &past})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddPermissions(ctx, &role, collections.Permission{pending}, scopes.Window{StartsAt: &future})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddPermissions(ctx, &role, collections.Permission{current}, scopes.Window{StartsAt: &past, ExpiresAt: &future})).ShouldNot(HaveOccurred())
+
+				permissionIDs, _, err := repo.Permission.GetPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{current.ID}))
+
+				Expect(repo.Role.HasPermission(ctx, collections.Role{role}, expired)).Should(BeFalse())
+
+				withPermissions, err := repo.Role.GetRoleByGuardNameWithPermissions(ctx, "admin")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(collections.Permission(withPermissions.Permissions).IDs()).Should(Equal([]uint{current.ID}))
+			})
+
+			ginkgo.It("denies", func() {
+				role := createRole("admin")
+				edit := createPermission("edit")
+
+				Expect(repo.Role.DenyPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{})).ShouldNot(HaveOccurred())
+
+				permissionIDs, _, err := repo.Permission.GetDeniedPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{edit.ID}))
+
+				Expect(repo.Role.RemoveDeniedPermissions(ctx, &role, collections.Permission{edit})).ShouldNot(HaveOccurred())
+
+				permissionIDs, _, err = repo.Permission.GetDeniedPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+			})
+		})
+
+		ginkgo.Context("Role Hierarchy", func() {
+			ginkgo.It("adds and removes children", func() {
+				admin := createRole("admin")
+				editor := createRole("editor")
+				viewer := createRole("viewer")
+
+				Expect(repo.Role.AddChildren(ctx, &admin, collections.Role{editor})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddChildren(ctx, &editor, collections.Role{viewer})).ShouldNot(HaveOccurred())
+
+				childRoleIDs, err := repo.Role.GetChildRoleIDs(ctx, []uint{admin.ID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(childRoleIDs).Should(Equal([]uint{editor.ID}))
+
+				childRoleIDs, err = repo.Role.GetChildRoleIDs(ctx, []uint{admin.ID, editor.ID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(childRoleIDs).Should(ConsistOf(editor.ID, viewer.ID))
+
+				parentRoleIDs, err := repo.Role.GetParentRoleIDs(ctx, []uint{viewer.ID, editor.ID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(parentRoleIDs).Should(ConsistOf(admin.ID, editor.ID))
+
+				Expect(repo.Role.RemoveChildren(ctx, &admin, collections.Role{editor})).ShouldNot(HaveOccurred())
+
+				childRoleIDs, err = repo.Role.GetChildRoleIDs(ctx, []uint{admin.ID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(childRoleIDs).Should(BeEmpty())
+			})
+		})
+
+		ginkgo.Context("User Roles", func() {
+			ginkgo.It("adds and removes", func() {
+				admin := createRole("admin")
+				editor := createRole("editor")
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{admin, editor})).ShouldNot(HaveOccurred())
+
+				roleIDs, totalCount, err := repo.Role.GetRoleIDsOfUser(ctx, user(1), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(ConsistOf(admin.ID, editor.ID))
+				Expect(totalCount).Should(Equal(int64(2)))
+
+				Expect(repo.User.HasRole(ctx, user(1), scopes.Assignment{}, admin)).Should(BeTrue())
+				Expect(repo.User.HasAllRoles(ctx, user(1), scopes.Assignment{}, collections.Role{admin, editor})).Should(BeTrue())
+				Expect(repo.User.HasRole(ctx, user(2), scopes.Assignment{}, admin)).Should(BeFalse())
+
+				Expect(repo.User.RemoveRoles(ctx, user(1), scopes.Assignment{}, collections.Role{admin})).ShouldNot(HaveOccurred())
+
+				Expect(repo.User.HasRole(ctx, user(1), scopes.Assignment{}, admin)).Should(BeFalse())
+				Expect(repo.User.HasAnyRoles(ctx, user(1), scopes.Assignment{}, collections.Role{admin, editor})).Should(BeTrue())
+			})
+
+			ginkgo.It("replaces and clears in the exact scope", func() {
+				admin := createRole("admin")
+				editor := createRole("editor")
+				tenant := scopes.Assignment{TenantID: "org-a"}
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(1), tenant, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.ReplaceRoles(ctx, user(1), tenant, collections.Role{editor})).ShouldNot(HaveOccurred())
+
+				roleIDs, _, err := repo.Role.GetRoleIDsOfUser(ctx, user(1), tenant, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(ConsistOf(admin.ID, editor.ID))
+
+				Expect(repo.User.ClearRoles(ctx, user(1), scopes.Assignment{})).ShouldNot(HaveOccurred())
+
+				roleIDs, _, err = repo.Role.GetRoleIDsOfUser(ctx, user(1), tenant, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(Equal([]uint{editor.ID}))
+			})
+
+			ginkgo.It("applies the global assignments in the tenants and on the resources", func() {
+				admin := createRole("admin")
+				editor := createRole("editor")
+				viewer := createRole("viewer")
+				project := scopes.Assignment{ResourceType: "project", ResourceID: "42"}
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{viewer})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{TenantID: "org-a"}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(1), project, collections.Role{editor})).ShouldNot(HaveOccurred())
+
+				roleIDs, _, err := repo.Role.GetRoleIDsOfUser(ctx, user(1), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(Equal([]uint{viewer.ID}))
+
+				roleIDs, _, err = repo.Role.GetRoleIDsOfUser(ctx, user(1), scopes.Assignment{TenantID: "org-a"}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(ConsistOf(admin.ID, viewer.ID))
+
+				roleIDs, _, err = repo.Role.GetRoleIDsOfUser(ctx, user(1), scopes.Assignment{TenantID: "org-b"}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(Equal([]uint{viewer.ID}))
+
+				roleIDs, _, err = repo.Role.GetRoleIDsOfUser(ctx, user(1), project, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(ConsistOf(editor.ID, viewer.ID))
+
+				Expect(repo.User.HasRole(ctx, user(1), scopes.Assignment{ResourceType: "project", ResourceID: "43"}, editor)).Should(BeFalse())
+			})
+
+			ginkgo.It("ignores the assignments outside their window", func() {
+				admin := createRole("admin")
+				editor := createRole("editor")
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{Window: scopes.Window{ExpiresAt: &past}}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{Window: scopes.Window{StartsAt: &past}}, collections.Role{editor})).ShouldNot(HaveOccurred())
+
+				roleIDs, _, err := repo.Role.GetRoleIDsOfUser(ctx, user(1), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(Equal([]uint{editor.ID}))
+
+				Expect(repo.User.HasRole(ctx, user(1), scopes.Assignment{}, admin)).Should(BeFalse())
+			})
+		})
+
+		ginkgo.Context("User Permissions", func() {
+			ginkgo.It("adds and removes", func() {
+				edit := createPermission("edit")
+				view := createPermission("view")
+
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit, view})).ShouldNot(HaveOccurred())
+
+				permissionIDs, totalCount, err := repo.Permission.GetDirectPermissionIDsOfUserByID(ctx, user(1), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(ConsistOf(edit.ID, view.ID))
+				Expect(totalCount).Should(Equal(int64(2)))
+
+				Expect(repo.User.HasDirectPermission(ctx, user(1), scopes.Assignment{}, edit)).Should(BeTrue())
+				Expect(repo.User.HasAllDirectPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit, view})).Should(BeTrue())
+
+				Expect(repo.User.RemovePermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+
+				Expect(repo.User.HasDirectPermission(ctx, user(1), scopes.Assignment{}, edit)).Should(BeFalse())
+				Expect(repo.User.HasAnyDirectPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit, view})).Should(BeTrue())
+			})
+
+			ginkgo.It("replaces and clears in the exact scope", func() {
+				edit := createPermission("edit")
+				view := createPermission("view")
+				project := scopes.Assignment{ResourceType: "project", ResourceID: "42"}
+
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(1), project, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.ReplacePermissions(ctx, user(1), project, collections.Permission{view})).ShouldNot(HaveOccurred())
+
+				permissionIDs, _, err := repo.Permission.GetDirectPermissionIDsOfUserByID(ctx, user(1), project, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(ConsistOf(edit.ID, view.ID))
+
+				Expect(repo.User.ClearPermissions(ctx, user(1), project)).ShouldNot(HaveOccurred())
+
+				permissionIDs, _, err = repo.Permission.GetDirectPermissionIDsOfUserByID(ctx, user(1), project, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{edit.ID}))
+			})
+
+			ginkgo.It("denies", func() {
+				edit := createPermission("edit")
+				tenant := scopes.Assignment{TenantID: "org-a"}
+
+				Expect(repo.User.DenyPermissions(ctx, user(1), tenant, collections.Permission{edit})).ShouldNot(HaveOccurred())
+
+				permissionIDs, _, err := repo.Permission.GetDeniedPermissionIDsOfUserByID(ctx, user(1), tenant, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{edit.ID}))
+
+				permissionIDs, _, err = repo.Permission.GetDeniedPermissionIDsOfUserByID(ctx, user(1), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+
+				Expect(repo.User.RemoveDeniedPermissions(ctx, user(1), tenant, collections.Permission{edit})).ShouldNot(HaveOccurred())
+
+				permissionIDs, _, err = repo.Permission.GetDeniedPermissionIDsOfUserByID(ctx, user(1), tenant, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+			})
+		})
+
+		ginkgo.Context("Subjects", func() {
+			ginkgo.It("keeps the assignments of the subjects of the same id apart", func() {
+				admin := createRole("admin")
+				edit := createPermission("edit")
+				device := subject("device", 1)
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, device, scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.DenyPermissions(ctx, device, scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+
+				Expect(repo.User.HasRole(ctx, device, scopes.Assignment{}, admin)).Should(BeFalse())
+				Expect(repo.User.HasDirectPermission(ctx, user(1), scopes.Assignment{}, edit)).Should(BeFalse())
+				Expect(repo.User.HasDirectPermission(ctx, device, scopes.Assignment{}, edit)).Should(BeTrue())
+
+				roleIDs, _, err := repo.Role.GetRoleIDsOfUser(ctx, device, scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(BeEmpty())
+
+				permissionIDs, _, err := repo.Permission.GetDeniedPermissionIDsOfUserByID(ctx, user(1), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+			})
+
+			ginkgo.It("replaces and clears the assignments of one subject", func() {
+				admin := createRole("admin")
+				editor := createRole("editor")
+				device := subject("device", 1)
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, device, scopes.Assignment{}, collections.Role{admin})).ShouldNot(HaveOccurred())
+
+				Expect(repo.User.ReplaceRoles(ctx, device, scopes.Assignment{}, collections.Role{editor})).ShouldNot(HaveOccurred())
+				Expect(repo.User.HasRole(ctx, user(1), scopes.Assignment{}, admin)).Should(BeTrue())
+				Expect(repo.User.HasRole(ctx, device, scopes.Assignment{}, admin)).Should(BeFalse())
+
+				Expect(repo.User.ClearRoles(ctx, user(1), scopes.Assignment{})).ShouldNot(HaveOccurred())
+				Expect(repo.User.HasRole(ctx, device, scopes.Assignment{}, editor)).Should(BeTrue())
+
+				Expect(repo.User.RemoveRoles(ctx, device, scopes.Assignment{}, collections.Role{editor})).ShouldNot(HaveOccurred())
+				Expect(repo.User.HasRole(ctx, device, scopes.Assignment{}, editor)).Should(BeFalse())
+			})
+		})
+
+		ginkgo.Context("Effective Permissions", func() {
+			ginkgo.It("collects the permissions of the user and of the inherited roles", func() {
+				view := createPermission("view")
+				edit := createPermission("edit")
+				publish := createPermission("publish")
+				share := createPermission("share")
+				unused := createPermission("unused")
+				admin := createRole("admin")
+				editor := createRole("editor")
+				viewer := createRole("viewer")
+				tenant := scopes.Assignment{TenantID: "org-a"}
+
+				Expect(repo.Role.AddChildren(ctx, &admin, collections.Role{editor})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddChildren(ctx, &editor, collections.Role{viewer})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddPermissions(ctx, &viewer, collections.Permission{view}, scopes.Window{})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddPermissions(ctx, &admin, collections.Permission{publish}, scopes.Window{ExpiresAt: &past})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.DenyPermissions(ctx, &editor, collections.Permission{share}, scopes.Window{})).ShouldNot(HaveOccurred())
+
+				Expect(repo.User.AddRoles(ctx, user(1), tenant, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit, unused})).ShouldNot(HaveOccurred())
+				Expect(repo.User.DenyPermissions(ctx, user(1), tenant, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, subject("device", 1), scopes.Assignment{}, collections.Permission{view})).ShouldNot(HaveOccurred())
+
+				permissionIDs := []uint{view.ID, edit.ID, publish.ID, share.ID}
+
+				effective, err := repo.Permission.GetEffectivePermissionIDsOfUser(ctx, user(1), tenant, permissionIDs)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(effective.DirectPermissionIDs).Should(Equal([]uint{edit.ID}))
+				Expect(effective.RolePermissionIDs).Should(Equal([]uint{view.ID}))
+				Expect(effective.DirectDeniedPermissionIDs).Should(Equal([]uint{edit.ID}))
+				Expect(effective.RoleDeniedPermissionIDs).Should(Equal([]uint{share.ID}))
+
+				effective, err = repo.Permission.GetEffectivePermissionIDsOfUser(ctx, user(1), scopes.Assignment{TenantID: "org-b"}, permissionIDs)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(effective).Should(Equal(repositories.EffectivePermissionIDs{DirectPermissionIDs: []uint{edit.ID}}))
+
+				effective, err = repo.Permission.GetEffectivePermissionIDsOfUser(ctx, user(2), tenant, permissionIDs)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(effective).Should(Equal(repositories.EffectivePermissionIDs{}))
+			})
+		})
+
+		ginkgo.Context("Reverse Lookups", func() {
+			ginkgo.It("lists the users of a role", func() {
+				admin := createRole("admin")
+				tenant := scopes.Assignment{TenantID: "org-a"}
+
+				Expect(repo.User.AddRoles(ctx, user(2), scopes.Assignment{}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, subject("device", 1), scopes.Assignment{}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(3), tenant, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(1), tenant, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(4), scopes.Assignment{Window: scopes.Window{ExpiresAt: &past}}, collections.Role{admin})).ShouldNot(HaveOccurred())
+
+				subjects, totalCount, err := repo.User.GetUserIDsOfRole(ctx, admin.ID, scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(subjects).Should(Equal([]models.Subject{subject("device", 1), user(1), user(2)}))
+				Expect(totalCount).Should(Equal(int64(3)))
+
+				subjects, totalCount, err = repo.User.GetUserIDsOfRole(ctx, admin.ID, tenant, &scopes.GormPagination{Pagination: &utils.Pagination{Page: 2, Limit: 2}})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(subjects).Should(Equal([]models.Subject{user(2), user(3)}))
+				Expect(totalCount).Should(Equal(int64(4)))
+			})
+
+			ginkgo.It("lists the users of a direct permission", func() {
+				edit := createPermission("edit")
+				project := scopes.Assignment{ResourceType: "project", ResourceID: "42"}
+
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(2), project, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(3), scopes.Assignment{Window: scopes.Window{StartsAt: &future}}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+
+				subjects, totalCount, err := repo.User.GetUserIDsWithDirectPermission(ctx, edit.ID, scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(subjects).Should(Equal([]models.Subject{user(1)}))
+				Expect(totalCount).Should(Equal(int64(1)))
+
+				subjects, _, err = repo.User.GetUserIDsWithDirectPermission(ctx, edit.ID, project, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(subjects).Should(Equal([]models.Subject{user(1), user(2)}))
+			})
+
+			ginkgo.It("lists the users of a permission given directly or via roles, without the denied ones", func() {
+				edit := createPermission("edit")
+				admin := createRole("admin")
+				blocked := createRole("blocked")
+
+				Expect(repo.Role.DenyPermissions(ctx, &blocked, collections.Permission{edit}, scopes.Window{})).ShouldNot(HaveOccurred())
+
+				roleIDs, _, err := repo.Role.GetRoleIDsOfDeniedPermission(ctx, edit.ID, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(Equal([]uint{blocked.ID}))
+
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(2), scopes.Assignment{}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(3), scopes.Assignment{}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.DenyPermissions(ctx, user(3), scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(4), scopes.Assignment{}, collections.Role{admin, blocked})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(5), scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(5), scopes.Assignment{}, collections.Role{admin, blocked})).ShouldNot(HaveOccurred())
+
+				holders := repositories.PermissionHolders{PermissionIDs: []uint{edit.ID}, RoleIDs: []uint{admin.ID}}
+				subjects, totalCount, err := repo.User.GetUserIDsWithPermission(ctx, holders, scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(subjects).Should(Equal([]models.Subject{user(1), user(2), user(3), user(4), user(5)}))
+				Expect(totalCount).Should(Equal(int64(5)))
+
+				holders.DeniedPermissionIDs = []uint{edit.ID}
+				holders.DeniedRoleIDs = []uint{blocked.ID}
+				subjects, totalCount, err = repo.User.GetUserIDsWithPermission(ctx, holders, scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(subjects).Should(Equal([]models.Subject{user(1), user(2)}))
+				Expect(totalCount).Should(Equal(int64(2)))
+
+				holders.DirectOverridesRoles = true
+				subjects, totalCount, err = repo.User.GetUserIDsWithPermission(ctx, holders, scopes.Assignment{}, &scopes.GormPagination{Pagination: &utils.Pagination{Page: 2, Limit: 2}})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(subjects).Should(Equal([]models.Subject{user(5)}))
+				Expect(totalCount).Should(Equal(int64(3)))
+
+				holders.Subjects = []models.Subject{user(2), user(3), user(5), subject("device", 2)}
+				subjects, totalCount, err = repo.User.GetUserIDsWithPermission(ctx, holders, scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(subjects).Should(Equal([]models.Subject{user(2), user(5)}))
+				Expect(totalCount).Should(Equal(int64(2)))
+			})
+		})
+
+		ginkgo.Context("Conflicts", func() {
+			ginkgo.It("replaces the window of a role permission added again", func() {
+				role := createRole("admin")
+				edit := createPermission("edit")
+
+				Expect(repo.Role.AddPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{ExpiresAt: &past})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.HasPermission(ctx, collections.Role{role}, edit)).Should(BeFalse())
+
+				Expect(repo.Role.AddPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{ExpiresAt: &future})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.HasPermission(ctx, collections.Role{role}, edit)).Should(BeTrue())
+
+				Expect(repo.Role.DenyPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{ExpiresAt: &past})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.DenyPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{ExpiresAt: &future})).ShouldNot(HaveOccurred())
+
+				permissionIDs, totalCount, err := repo.Permission.GetDeniedPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{edit.ID}))
+				Expect(totalCount).Should(Equal(int64(1)))
+			})
+
+			ginkgo.It("keeps the window of a role permission added again without a window", func() {
+				role := createRole("admin")
+				edit := createPermission("edit")
+
+				Expect(repo.Role.AddPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{ExpiresAt: &past})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.DenyPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{ExpiresAt: &past})).ShouldNot(HaveOccurred())
+
+				Expect(repo.Role.AddPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.DenyPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{})).ShouldNot(HaveOccurred())
+
+				Expect(repo.Role.HasPermission(ctx, collections.Role{role}, edit)).Should(BeFalse())
+
+				permissionIDs, _, err := repo.Permission.GetDeniedPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+			})
+
+			ginkgo.It("replaces the window of a user assignment added again in the same scope with a window", func() {
+				role := createRole("admin")
+				edit := createPermission("edit")
+				tenant := scopes.Assignment{TenantID: "org-a", Window: scopes.Window{ExpiresAt: &past}}
+
+				Expect(repo.User.AddRoles(ctx, user(1), tenant, collections.Role{role})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(1), tenant, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.DenyPermissions(ctx, user(1), tenant, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.HasRole(ctx, user(1), tenant, role)).Should(BeFalse())
+
+				tenant.Window = scopes.Window{ExpiresAt: &future}
+				Expect(repo.User.AddRoles(ctx, user(1), tenant, collections.Role{role})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(1), tenant, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.DenyPermissions(ctx, user(1), tenant, collections.Permission{edit})).ShouldNot(HaveOccurred())
+
+				Expect(repo.User.HasRole(ctx, user(1), tenant, role)).Should(BeTrue())
+				Expect(repo.User.HasDirectPermission(ctx, user(1), tenant, edit)).Should(BeTrue())
+
+				permissionIDs, _, err := repo.Permission.GetDeniedPermissionIDsOfUserByID(ctx, user(1), tenant, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{edit.ID}))
+			})
+
+			ginkgo.It("keeps the window of a user assignment added again without a window", func() {
+				role := createRole("admin")
+				edit := createPermission("edit")
+				expired := scopes.Assignment{Window: scopes.Window{ExpiresAt: &past}}
+
+				Expect(repo.User.AddRoles(ctx, user(1), expired, collections.Role{role})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(1), expired, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.DenyPermissions(ctx, user(1), expired, collections.Permission{edit})).ShouldNot(HaveOccurred())
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{role})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.DenyPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+
+				Expect(repo.User.HasRole(ctx, user(1), scopes.Assignment{}, role)).Should(BeFalse())
+				Expect(repo.User.HasDirectPermission(ctx, user(1), scopes.Assignment{}, edit)).Should(BeFalse())
+
+				permissionIDs, _, err := repo.Permission.GetDeniedPermissionIDsOfUserByID(ctx, user(1), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+			})
+
+			ginkgo.It("keeps one assignment per scope", func() {
+				role := createRole("admin")
+				child := createRole("editor")
+				edit := createPermission("edit")
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{role})).ShouldNot(HaveOccurred())
+				Expect(repo.User.ReplaceRoles(ctx, user(1), scopes.Assignment{}, collections.Role{role, role})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{TenantID: "org-a"}, collections.Role{role})).ShouldNot(HaveOccurred())
+				Expect(repo.User.ReplacePermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit, edit})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.ReplacePermissions(ctx, &role, collections.Permission{edit, edit}, scopes.Window{})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddChildren(ctx, &role, collections.Role{child})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddChildren(ctx, &role, collections.Role{child})).ShouldNot(HaveOccurred())
+
+				roleIDs, totalCount, err := repo.Role.GetRoleIDsOfUser(ctx, user(1), scopes.Assignment{TenantID: "org-a"}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(Equal([]uint{role.ID}))
+				Expect(totalCount).Should(Equal(int64(1)))
+
+				permissionIDs, totalCount, err := repo.Permission.GetDirectPermissionIDsOfUserByID(ctx, user(1), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{edit.ID}))
+				Expect(totalCount).Should(Equal(int64(1)))
+
+				permissionIDs, totalCount, err = repo.Permission.GetPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{edit.ID}))
+				Expect(totalCount).Should(Equal(int64(1)))
+
+				childRoleIDs, err := repo.Role.GetChildRoleIDs(ctx, []uint{role.ID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(childRoleIDs).Should(Equal([]uint{child.ID}))
+			})
+		})
+
+		ginkgo.Context("Cascades", func() {
+			ginkgo.It("deletes the assignments of a deleted role", func() {
+				admin := createRole("admin")
+				editor := createRole("editor")
+				viewer := createRole("viewer")
+				edit := createPermission("edit")
+				view := createPermission("view")
+
+				Expect(repo.Role.AddPermissions(ctx, &editor, collections.Permission{edit}, scopes.Window{})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.DenyPermissions(ctx, &editor, collections.Permission{view}, scopes.Window{})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddChildren(ctx, &admin, collections.Role{editor})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddChildren(ctx, &editor, collections.Role{viewer})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{editor, viewer})).ShouldNot(HaveOccurred())
+
+				Expect(repo.Role.Delete(ctx, &editor)).ShouldNot(HaveOccurred())
+
+				_, err := repo.Role.GetRoleByID(ctx, editor.ID)
+				Expect(err).Should(MatchError(gorm.ErrRecordNotFound))
+
+				roleIDs, _, err := repo.Role.GetRoleIDsOfUser(ctx, user(1), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(Equal([]uint{viewer.ID}))
+
+				roleIDs, _, err = repo.Role.GetRoleIDsOfPermission(ctx, edit.ID, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(BeEmpty())
+
+				permissionIDs, _, err := repo.Permission.GetDeniedPermissionIDsOfRolesByIDs(ctx, []uint{editor.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+
+				childRoleIDs, err := repo.Role.GetChildRoleIDs(ctx, []uint{admin.ID, editor.ID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(childRoleIDs).Should(BeEmpty())
+			})
+
+			ginkgo.It("deletes the assignments of a deleted permission", func() {
+				role := createRole("admin")
+				edit := createPermission("edit")
+				view := createPermission("view")
+
+				Expect(repo.Role.AddPermissions(ctx, &role, collections.Permission{edit, view}, scopes.Window{})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.DenyPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit, view})).ShouldNot(HaveOccurred())
+				Expect(repo.User.DenyPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+
+				Expect(repo.Permission.Delete(ctx, &edit)).ShouldNot(HaveOccurred())
+
+				_, err := repo.Permission.GetPermissionByID(ctx, edit.ID)
+				Expect(err).Should(MatchError(gorm.ErrRecordNotFound))
+
+				permissionIDs, _, err := repo.Permission.GetPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{view.ID}))
+
+				permissionIDs, _, err = repo.Permission.GetDeniedPermissionIDsOfRolesByIDs(ctx, []uint{role.ID}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+
+				permissionIDs, _, err = repo.Permission.GetDirectPermissionIDsOfUserByID(ctx, user(1), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(Equal([]uint{view.ID}))
+
+				permissionIDs, _, err = repo.Permission.GetDeniedPermissionIDsOfUserByID(ctx, user(1), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+			})
+
+			ginkgo.It("does not restore the assignments of a recreated role", func() {
+				role := createRole("admin")
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{role})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.Delete(ctx, &role)).ShouldNot(HaveOccurred())
+
+				role = createRole("admin")
+				Expect(repo.User.HasRole(ctx, user(1), scopes.Assignment{}, role)).Should(BeFalse())
+			})
+		})
+
+		ginkgo.Context("Pagination", func() {
+			page := func(page int, limit int) scopes.GormPager {
+				return &scopes.GormPagination{Pagination: &utils.Pagination{Page: page, Limit: limit}}
+			}
+
+			ginkgo.It("counts all the records", func() {
+				var roles collections.Role
+				var permissions collections.Permission
+				for _, guardName := range []string{"a", "b", "c", "d", "e"} {
+					roles = append(roles, createRole(guardName))
+					permissions = append(permissions, createPermission(guardName))
+				}
+
+				roleIDs, totalCount, err := repo.Role.GetRoleIDs(ctx, page(2, 2))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(HaveLen(2))
+				Expect(totalCount).Should(Equal(int64(5)))
+
+				permissionIDs, totalCount, err := repo.Permission.GetPermissionIDs(ctx, page(3, 2))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(HaveLen(1))
+				Expect(totalCount).Should(Equal(int64(5)))
+
+				permissionIDs, totalCount, err = repo.Permission.GetPermissionIDs(ctx, page(4, 2))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+				Expect(totalCount).Should(Equal(int64(5)))
+
+				Expect(repo.Role.AddPermissions(ctx, &roles[0], permissions, scopes.Window{})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddPermissions(ctx, &roles[1], permissions[:2], scopes.Window{ExpiresAt: &past})).ShouldNot(HaveOccurred())
+
+				permissionIDs, totalCount, err = repo.Permission.GetPermissionIDsOfRolesByIDs(ctx, []uint{roles[0].ID, roles[1].ID}, page(1, 3))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(HaveLen(3))
+				Expect(totalCount).Should(Equal(int64(5)))
+
+				roleIDs, totalCount, err = repo.Role.GetRoleIDsOfPermission(ctx, permissions[0].ID, page(1, 3))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(Equal([]uint{roles[0].ID}))
+				Expect(totalCount).Should(Equal(int64(1)))
+			})
+
+			ginkgo.It("counts the distinct roles of a user", func() {
+				var roles collections.Role
+				for _, guardName := range []string{"a", "b", "c"} {
+					roles = append(roles, createRole(guardName))
+				}
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, roles)).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{TenantID: "org-a"}, roles[:2])).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{TenantID: "org-a"}, collections.Permission{createPermission("edit")})).ShouldNot(HaveOccurred())
+
+				roleIDs, totalCount, err := repo.Role.GetRoleIDsOfUser(ctx, user(1), scopes.Assignment{TenantID: "org-a"}, page(1, 2))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(HaveLen(2))
+				Expect(totalCount).Should(Equal(int64(3)))
+
+				permissionIDs, totalCount, err := repo.Permission.GetDirectPermissionIDsOfUserByID(ctx, user(1), scopes.Assignment{}, page(1, 2))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(permissionIDs).Should(BeEmpty())
+				Expect(totalCount).Should(Equal(int64(0)))
+			})
+		})
+
+		ginkgo.Context("Maintenance", func() {
+			ginkgo.It("purges the expired assignments", func() {
+				role := createRole("admin")
+				edit := createPermission("edit")
+				view := createPermission("view")
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{Window: scopes.Window{ExpiresAt: &past}}, collections.Role{role})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(2), scopes.Assignment{Window: scopes.Window{ExpiresAt: &future}}, collections.Role{role})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{Window: scopes.Window{ExpiresAt: &past}}, collections.Permission{edit, view})).ShouldNot(HaveOccurred())
+				Expect(repo.User.DenyPermissions(ctx, user(1), scopes.Assignment{Window: scopes.Window{ExpiresAt: &past}}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddPermissions(ctx, &role, collections.Permission{edit}, scopes.Window{ExpiresAt: &past})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.DenyPermissions(ctx, &role, collections.Permission{view}, scopes.Window{ExpiresAt: &past})).ShouldNot(HaveOccurred())
+
+				Expect(repo.User.PurgeExpiredRoles(ctx, time.Now(), 1)).Should(Equal(int64(1)))
+				Expect(repo.User.PurgeExpiredPermissions(ctx, time.Now(), 1)).Should(Equal(int64(2)))
+				Expect(repo.User.PurgeExpiredDeniedPermissions(ctx, time.Now(), 1)).Should(Equal(int64(1)))
+				Expect(repo.Role.PurgeExpiredPermissions(ctx, time.Now(), 1)).Should(Equal(int64(1)))
+				Expect(repo.Role.PurgeExpiredDeniedPermissions(ctx, time.Now(), 1)).Should(Equal(int64(1)))
+
+				roleIDs, _, err := repo.Role.GetRoleIDsOfUser(ctx, user(2), scopes.Assignment{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleIDs).Should(Equal([]uint{role.ID}))
+			})
+		})
+
+		ginkgo.Context("Windows", func() {
+			ginkgo.It("returns the next window bound", func() {
+				admin := createRole("admin")
+				viewer := createRole("viewer")
+				edit := createPermission("edit")
+				view := createPermission("view")
+
+				soon := time.Now().Add(time.Minute)
+				later := time.Now().Add(2 * time.Minute)
+
+				bound, err := repo.User.GetNextWindowBound(ctx, user(1), scopes.Assignment{}, time.Now())
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(bound).Should(BeNil())
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{Window: scopes.Window{StartsAt: &past, ExpiresAt: &later}}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.DenyPermissions(ctx, user(1), scopes.Assignment{Window: scopes.Window{StartsAt: &soon}}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				// the assignments in other tenants and of other users are not bounds of the user in the global scope.
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{TenantID: "org-a", Window: scopes.Window{ExpiresAt: &past}}, collections.Permission{view})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(2), scopes.Assignment{Window: scopes.Window{ExpiresAt: &past}}, collections.Permission{view})).ShouldNot(HaveOccurred())
+
+				bound, err = repo.User.GetNextWindowBound(ctx, user(1), scopes.Assignment{}, time.Now())
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(bound).ShouldNot(BeNil())
+				Expect(*bound).Should(BeTemporally("~", soon, time.Millisecond))
+
+				bound, err = repo.User.GetNextWindowBound(ctx, user(1), scopes.Assignment{}, soon)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(bound).ShouldNot(BeNil())
+				Expect(*bound).Should(BeTemporally("~", later, time.Millisecond))
+
+				bound, err = repo.Role.GetNextWindowBound(ctx, []uint{admin.ID, viewer.ID}, time.Now())
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(bound).Should(BeNil())
+
+				Expect(repo.Role.AddPermissions(ctx, &admin, collections.Permission{edit}, scopes.Window{ExpiresAt: &later})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.DenyPermissions(ctx, &viewer, collections.Permission{view}, scopes.Window{StartsAt: &soon})).ShouldNot(HaveOccurred())
+
+				bound, err = repo.Role.GetNextWindowBound(ctx, []uint{admin.ID, viewer.ID}, time.Now())
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(bound).ShouldNot(BeNil())
+				Expect(*bound).Should(BeTemporally("~", soon, time.Millisecond))
+
+				bound, err = repo.Role.GetNextWindowBound(ctx, []uint{admin.ID}, time.Now())
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(bound).ShouldNot(BeNil())
+				Expect(*bound).Should(BeTemporally("~", later, time.Millisecond))
+			})
+
+			ginkgo.It("returns the windows of the role assignments, also the ones that are not valid now", func() {
+				admin := createRole("admin")
+				viewer := createRole("viewer")
+				edit := createPermission("edit")
+				view := createPermission("view")
+
+				Expect(repo.Role.AddPermissions(ctx, &admin, collections.Permission{view}, scopes.Window{})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddPermissions(ctx, &admin, collections.Permission{edit}, scopes.Window{ExpiresAt: &past})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.AddPermissions(ctx, &viewer, collections.Permission{view}, scopes.Window{StartsAt: &future})).ShouldNot(HaveOccurred())
+				Expect(repo.Role.DenyPermissions(ctx, &viewer, collections.Permission{edit}, scopes.Window{StartsAt: &past, ExpiresAt: &future})).ShouldNot(HaveOccurred())
+
+				rolePermissions, err := repo.Role.GetPermissionWindows(ctx, []uint{admin.ID, viewer.ID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(rolePermissions).Should(HaveLen(3))
+				Expect(rolePermissions[0].RoleID).Should(Equal(admin.ID))
+				Expect(rolePermissions[0].PermissionID).Should(Equal(edit.ID))
+				Expect(rolePermissions[0].StartsAt).Should(BeNil())
+				Expect(*rolePermissions[0].ExpiresAt).Should(BeTemporally("~", past, time.Millisecond))
+				Expect(rolePermissions[1].PermissionID).Should(Equal(view.ID))
+				Expect(rolePermissions[1].StartsAt).Should(BeNil())
+				Expect(rolePermissions[1].ExpiresAt).Should(BeNil())
+				Expect(rolePermissions[2].RoleID).Should(Equal(viewer.ID))
+				Expect(*rolePermissions[2].StartsAt).Should(BeTemporally("~", future, time.Millisecond))
+
+				roleDeniedPermissions, err := repo.Role.GetDeniedPermissionWindows(ctx, []uint{admin.ID, viewer.ID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(roleDeniedPermissions).Should(HaveLen(1))
+				Expect(roleDeniedPermissions[0].RoleID).Should(Equal(viewer.ID))
+				Expect(*roleDeniedPermissions[0].StartsAt).Should(BeTemporally("~", past, time.Millisecond))
+				Expect(*roleDeniedPermissions[0].ExpiresAt).Should(BeTemporally("~", future, time.Millisecond))
+
+				rolePermissions, err = repo.Role.GetPermissionWindows(ctx, []uint{viewer.ID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(rolePermissions).Should(HaveLen(1))
+			})
+
+			ginkgo.It("returns the windows of the user assignments made exactly in the scope, also the ones that are not valid now", func() {
+				admin := createRole("admin")
+				viewer := createRole("viewer")
+				tenant := scopes.Assignment{TenantID: "org-a"}
+
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{TenantID: "org-a", Window: scopes.Window{StartsAt: &future}}, collections.Role{viewer})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(1), scopes.Assignment{TenantID: "org-a", Window: scopes.Window{ExpiresAt: &past}}, collections.Role{admin})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddRoles(ctx, user(2), tenant, collections.Role{admin})).ShouldNot(HaveOccurred())
+
+				userRoles, err := repo.User.GetRoleWindows(ctx, user(1), tenant)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(userRoles).Should(HaveLen(2))
+				Expect(userRoles[0].RoleID).Should(Equal(admin.ID))
+				Expect(userRoles[0].TenantID).Should(Equal("org-a"))
+				Expect(*userRoles[0].ExpiresAt).Should(BeTemporally("~", past, time.Millisecond))
+				Expect(userRoles[1].RoleID).Should(Equal(viewer.ID))
+				Expect(*userRoles[1].StartsAt).Should(BeTemporally("~", future, time.Millisecond))
+
+				userRoles, err = repo.User.GetRoleWindows(ctx, user(1), scopes.Assignment{})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(userRoles).Should(HaveLen(1))
+				Expect(userRoles[0].ExpiresAt).Should(BeNil())
+			})
+
+			ginkgo.It("returns the windows of the user permissions and denied permissions made exactly in the scope", func() {
+				edit := createPermission("edit")
+				view := createPermission("view")
+				tenant := scopes.Assignment{TenantID: "org-a"}
+
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{}, collections.Permission{edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.AddPermissions(ctx, user(1), scopes.Assignment{TenantID: "org-a", Window: scopes.Window{StartsAt: &future}}, collections.Permission{view, edit})).ShouldNot(HaveOccurred())
+				Expect(repo.User.DenyPermissions(ctx, user(1), scopes.Assignment{TenantID: "org-a", Window: scopes.Window{ExpiresAt: &past}}, collections.Permission{view})).ShouldNot(HaveOccurred())
+
+				userPermissions, err := repo.User.GetPermissionWindows(ctx, user(1), tenant)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(userPermissions).Should(HaveLen(2))
+				Expect(userPermissions[0].PermissionID).Should(Equal(edit.ID))
+				Expect(*userPermissions[0].StartsAt).Should(BeTemporally("~", future, time.Millisecond))
+				Expect(userPermissions[1].PermissionID).Should(Equal(view.ID))
+
+				userDeniedPermissions, err := repo.User.GetDeniedPermissionWindows(ctx, user(1), tenant)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(userDeniedPermissions).Should(HaveLen(1))
+				Expect(*userDeniedPermissions[0].ExpiresAt).Should(BeTemporally("~", past, time.Millisecond))
+
+				userDeniedPermissions, err = repo.User.GetDeniedPermissionWindows(ctx, user(1), scopes.Assignment{})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(userDeniedPermissions).Should(BeEmpty())
+			})
+		})
+
+		ginkgo.Context("Audit Log", func() {
+			record := func(entry models.AuditEntry) models.AuditEntry {
+				Expect(repo.Audit.Record(ctx, &entry)).ShouldNot(HaveOccurred())
+				return entry
+			}
+
+			ginkgo.It("chains the entries", func() {
+				first := record(models.AuditEntry{Operation: "CreateRole", Role: "admin", Kind: models.AuditRole, After: models.AuditNames{"admin"}})
+				second := record(models.AuditEntry{Operation: "DeleteRole", Role: "admin", Kind: models.AuditRole, Before: models.AuditNames{"admin"}})
+
+				Expect(first.PreviousHash).Should(BeEmpty())
+				Expect(second.PreviousHash).Should(Equal(first.Hash))
+				Expect(second.ID).Should(BeNumerically(">", first.ID))
+
+				entries, totalCount, err := repo.Audit.GetAuditEntries(ctx, repositories.AuditFilter{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(totalCount).Should(Equal(int64(2)))
+				Expect(entries).Should(HaveLen(2))
+				for i, entry := range entries {
+					Expect(entry.Hash).Should(Equal(entry.ComputeHash()))
+					Expect(entry.ID).Should(Equal([]uint{first.ID, second.ID}[i]))
+				}
+				Expect(entries[0].After).Should(Equal(models.AuditNames{"admin"}))
+				Expect(entries[1].After).Should(BeEmpty())
+			})
+
+			ginkgo.It("returns the hash of the last entry as the head of the chain", func() {
+				head, err := repo.Audit.GetChainHead(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(head).Should(BeEmpty())
+
+				record(models.AuditEntry{Operation: "CreateRole", Role: "admin", Kind: models.AuditRole, After: models.AuditNames{"admin"}})
+				last := record(models.AuditEntry{Operation: "DeleteRole", Role: "admin", Kind: models.AuditRole, Before: models.AuditNames{"admin"}})
+
+				head, err = repo.Audit.GetChainHead(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(head).Should(Equal(last.Hash))
+			})
+
+			ginkgo.It("filters the entries", func() {
+				u1, u2 := user(1), user(2)
+				record(models.AuditEntry{Actor: "user:9", Operation: "AddRolesToUser", SubjectType: u1.Type, SubjectID: u1.ID.String(), Kind: models.AuditRoles, After: models.AuditNames{"admin"}, CreatedAt: past})
+				record(models.AuditEntry{Operation: "AddPermissionsToUser", SubjectType: u2.Type, SubjectID: u2.ID.String(), Kind: models.AuditPermissions, After: models.AuditNames{"admin"}})
+				record(models.AuditEntry{Operation: "AddPermissionsToRole", Role: "admin", Kind: models.AuditPermissions, After: models.AuditNames{"edit"}})
+				record(models.AuditEntry{Operation: "AddRolesToUser", SubjectType: u2.Type, SubjectID: u2.ID.String(), Kind: models.AuditRoles, After: models.AuditNames{"admin_2"}, CreatedAt: future})
+				record(models.AuditEntry{Operation: "AddRolesToUser", SubjectType: u2.Type, SubjectID: u2.ID.String(), TenantID: "org-a", Kind: models.AuditRoles, After: models.AuditNames{models.AuditName("admin", nil, &future)}, CreatedAt: future})
+
+				operations := func(filter repositories.AuditFilter) (operations []string) {
+					entries, totalCount, err := repo.Audit.GetAuditEntries(ctx, filter, nil)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(totalCount).Should(Equal(int64(len(entries))))
+					for _, entry := range entries {
+						operations = append(operations, entry.Operation+" "+entry.SubjectID+entry.Role)
+					}
+					return
+				}
+
+				Expect(operations(repositories.AuditFilter{Subject: &u1})).Should(Equal([]string{"AddRolesToUser " + u1.ID.String()}))
+				Expect(operations(repositories.AuditFilter{Role: "admin"})).Should(Equal([]string{"AddRolesToUser " + u1.ID.String(), "AddPermissionsToRole admin", "AddRolesToUser " + u2.ID.String()}))
+				Expect(operations(repositories.AuditFilter{Role: "admin%"})).Should(BeEmpty())
+				Expect(operations(repositories.AuditFilter{Actor: "user:9"})).Should(Equal([]string{"AddRolesToUser " + u1.ID.String()}))
+				Expect(operations(repositories.AuditFilter{Since: past.Add(time.Minute), Until: future})).Should(Equal([]string{"AddPermissionsToUser " + u2.ID.String(), "AddPermissionsToRole admin"}))
+
+				entries, totalCount, err := repo.Audit.GetAuditEntries(ctx, repositories.AuditFilter{Subject: &u2}, &scopes.GormPagination{Pagination: &utils.Pagination{Page: 2, Limit: 1}})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(totalCount).Should(Equal(int64(3)))
+				Expect(entries).Should(HaveLen(1))
+				Expect(entries[0].After).Should(Equal(models.AuditNames{"admin_2"}))
+			})
+		})
+
+		ginkgo.Context("Transactions", func() {
+			ginkgo.It("commits the changes", func() {
+				Expect(repo.Transactor.Transaction(ctx, func(ctx context.Context) error {
+					role := models.Role{Name: "admin", GuardName: "admin"}
+					if err := repo.Role.FirstOrCreate(ctx, &role); err != nil {
+						return err
+					}
+					return repo.Audit.Record(ctx, &models.AuditEntry{Operation: "CreateRole", Role: "admin", Kind: models.AuditRole})
+				})).ShouldNot(HaveOccurred())
+
+				_, err := repo.Role.GetRoleByGuardName(ctx, "admin")
+				Expect(err).ShouldNot(HaveOccurred())
+				_, totalCount, err := repo.Audit.GetAuditEntries(ctx, repositories.AuditFilter{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(totalCount).Should(Equal(int64(1)))
+			})
+
+			ginkgo.It("rolls back the changes", func() {
+				editor := createRole("editor")
+				failure := fmt.Errorf("failure")
+
+				Expect(repo.Transactor.Transaction(ctx, func(ctx context.Context) error {
+					role := models.Role{Name: "admin", GuardName: "admin"}
+					if err := repo.Role.FirstOrCreate(ctx, &role); err != nil {
+						return err
+					}
+					if err := repo.User.AddRoles(ctx, user(1), scopes.Assignment{}, collections.Role{role, editor}); err != nil {
+						return err
+					}
+					// the changes are seen in the transaction.
+					if has, err := repo.User.HasRole(ctx, user(1), scopes.Assignment{}, role); err != nil || !has {
+						return fmt.Errorf("err role not added: %v", err)
+					}
+					if err := repo.Audit.Record(ctx, &models.AuditEntry{Operation: "AddRolesToUser", Kind: models.AuditRoles}); err != nil {
+						return err
+					}
+					return failure
+				})).Should(MatchError(failure))
+
+				_, err := repo.Role.GetRoleByGuardName(ctx, "admin")
+				Expect(err).Should(MatchError(gorm.ErrRecordNotFound))
+				Expect(repo.User.HasRole(ctx, user(1), scopes.Assignment{}, editor)).Should(BeFalse())
+				_, totalCount, err := repo.Audit.GetAuditEntries(ctx, repositories.AuditFilter{}, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(totalCount).Should(Equal(int64(0)))
+
+				// the chain is rolled back too, the next entry is the first one.
+				entry := models.AuditEntry{Operation: "CreateRole", Role: "admin", Kind: models.AuditRole}
+				Expect(repo.Audit.Record(ctx, &entry)).ShouldNot(HaveOccurred())
+				Expect(entry.PreviousHash).Should(BeEmpty())
+			})
+
+			ginkgo.It("rolls back only the changes of a nested transaction", func() {
+				failure := fmt.Errorf("failure")
+
+				Expect(repo.Transactor.Transaction(ctx, func(ctx context.Context) error {
+					admin := models.Role{Name: "admin", GuardName: "admin"}
+					if err := repo.Role.FirstOrCreate(ctx, &admin); err != nil {
+						return err
+					}
+					err := repo.Transactor.Transaction(ctx, func(ctx context.Context) error {
+						editor := models.Role{Name: "editor", GuardName: "editor"}
+						if err := repo.Role.FirstOrCreate(ctx, &editor); err != nil {
+							return err
+						}
+						return failure
+					})
+					if err != failure {
+						return fmt.Errorf("err nested transaction: %v", err)
+					}
+					return nil
+				})).ShouldNot(HaveOccurred())
+
+				_, err := repo.Role.GetRoleByGuardName(ctx, "admin")
+				Expect(err).ShouldNot(HaveOccurred())
+				_, err = repo.Role.GetRoleByGuardName(ctx, "editor")
+				Expect(err).Should(MatchError(gorm.ErrRecordNotFound))
+			})
+		})
+	})
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/Permify/go-role/helpers"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/repositories"
+	"github.com/Permify/go-role/repositories/scopes"
+)
+
+// AuditRepository its in-memory data access layer of the audit log.
+type AuditRepository struct {
+	Database *Database
+}
+
+// Migrate does nothing, the in-memory database has no tables.
+// @return error
+func (repository *AuditRepository) Migrate() (err error) {
+	return nil
+}
+
+// ACTIONS
+
+// Record chains the entry to the last entry and creates it. The creation time is set if it is zero.
+// @param context.Context
+// @param *models.AuditEntry
+// @return error
+func (repository *AuditRepository) Record(ctx context.Context, entry *models.AuditEntry) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	entries := repository.Database.auditEntries
+	if len(entries) > 0 {
+		entry.PreviousHash = entries[len(entries)-1].Hash
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().Truncate(time.Millisecond)
+	}
+	entry.ID = uint(len(entries)) + 1
+	entry.Hash = entry.ComputeHash()
+
+	repository.Database.journaled(func() { repository.Database.auditEntries = repository.Database.auditEntries[:len(entries)] })
+	repository.Database.auditEntries = append(entries, *entry)
+	return nil
+}
+
+// FETCH OPTIONS
+
+// GetAuditEntries get the audit entries that are selected by the filter, in the order they are recorded. (with pagination)
+// @param context.Context
+// @param repositories.AuditFilter
+// @param repositories_scopes.GormPager
+// @return []models.AuditEntry, int64, error
+func (repository *AuditRepository) GetAuditEntries(ctx context.Context, filter repositories.AuditFilter, pagination scopes.GormPager) (entries []models.AuditEntry, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for _, entry := range repository.Database.auditEntries {
+		if selected(entry, filter) {
+			entries = append(entries, entry)
+		}
+	}
+
+	offset, end := bounds(len(entries), pagination)
+	return entries[offset:end], int64(len(entries)), nil
+}
+
+// GetChainHead get the hash of the last recorded entry. It is empty if no entry is recorded.
+// @param context.Context
+// @return string, error
+func (repository *AuditRepository) GetChainHead(ctx context.Context) (hash string, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	if entries := repository.Database.auditEntries; len(entries) > 0 {
+		hash = entries[len(entries)-1].Hash
+	}
+	return
+}
+
+// selected is the entry selected by the filter? (see repositories.AuditFilter)
+// @param models.AuditEntry
+// @param repositories.AuditFilter
+// @return bool
+func selected(entry models.AuditEntry, filter repositories.AuditFilter) bool {
+	if filter.Subject != nil && (entry.SubjectType != filter.Subject.Type || entry.SubjectID != filter.Subject.ID.String()) {
+		return false
+	}
+	if filter.Role != "" && entry.Role != filter.Role {
+		if entry.Kind != models.AuditRoles && entry.Kind != models.AuditChildRoles {
+			return false
+		}
+		if !helpers.InArray(filter.Role, entry.Before.GuardNames()) && !helpers.InArray(filter.Role, entry.After.GuardNames()) {
+			return false
+		}
+	}
+	if filter.Actor != "" && entry.Actor != filter.Actor {
+		return false
+	}
+	if !filter.Since.IsZero() && entry.CreatedAt.Before(filter.Since) {
+		return false
+	}
+	return filter.Until.IsZero() || entry.CreatedAt.Before(filter.Until)
+}
@@ -0,0 +1,334 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Permify/go-role/helpers"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/models/pivot"
+	"github.com/Permify/go-role/repositories/scopes"
+)
+
+// Database stores the roles, the permissions and their assignments in memory. It is safe for concurrent use.
+// The repositories that share a Database see the changes of each other, like the gorm repositories that share a *gorm.DB.
+type Database struct {
+	mu sync.RWMutex
+
+	roles            map[uint]models.Role
+	permissions      map[uint]models.Permission
+	lastRoleID       uint
+	lastPermissionID uint
+
+	rolePermissions       map[rolePair]pivot.RolePermissions
+	roleDeniedPermissions map[rolePair]pivot.RoleDeniedPermissions
+	roleChildren          map[rolePair]struct{}
+
+	userRoles             map[userAssignment]pivot.UserRoles
+	userPermissions       map[userAssignment]pivot.UserPermissions
+	userDeniedPermissions map[userAssignment]pivot.UserDeniedPermissions
+
+	auditEntries []models.AuditEntry
+
+	// journal undoes the changes of the running transaction, in reverse order. It is nil when no transaction is running. (see Transactor)
+	journal []func()
+}
+
+// rolePair is the key of the assignments of a role. (permission or child role)
+type rolePair struct {
+	roleID uint
+	ID     uint
+}
+
+// userAssignment is the key of the assignments of a user. (role or permission)
+type userAssignment struct {
+	subject      models.Subject
+	ID           uint
+	tenantID     string
+	resourceType string
+	resourceID   string
+}
+
+// NewDatabase initializer for Database
+// @return *Database
+func NewDatabase() *Database {
+	return &Database{
+		roles:                 make(map[uint]models.Role),
+		permissions:           make(map[uint]models.Permission),
+		rolePermissions:       make(map[rolePair]pivot.RolePermissions),
+		roleDeniedPermissions: make(map[rolePair]pivot.RoleDeniedPermissions),
+		roleChildren:          make(map[rolePair]struct{}),
+		userRoles:             make(map[userAssignment]pivot.UserRoles),
+		userPermissions:       make(map[userAssignment]pivot.UserPermissions),
+		userDeniedPermissions: make(map[userAssignment]pivot.UserDeniedPermissions),
+	}
+}
+
+// rlock locks the database for reading, unless the context is done.
+// The context of a transaction does not lock it again, the transaction holds the lock.
+// @param context.Context
+// @return error
+func (d *Database) rlock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !d.transacting(ctx) {
+		d.mu.RLock()
+	}
+	return nil
+}
+
+// runlock unlocks the database locked by rlock.
+// @param context.Context
+func (d *Database) runlock(ctx context.Context) {
+	if !d.transacting(ctx) {
+		d.mu.RUnlock()
+	}
+}
+
+// lock locks the database for writing, unless the context is done.
+// The context of a transaction does not lock it again, the transaction holds the lock.
+// @param context.Context
+// @return error
+func (d *Database) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !d.transacting(ctx) {
+		d.mu.Lock()
+	}
+	return nil
+}
+
+// unlock unlocks the database locked by lock.
+// @param context.Context
+func (d *Database) unlock(ctx context.Context) {
+	if !d.transacting(ctx) {
+		d.mu.Unlock()
+	}
+}
+
+// put sets the value of the key in the map of the database, the previous value is journaled.
+// The database must be locked by the caller.
+// @param interface{}
+// @param interface{}
+// @param interface{}
+func (d *Database) put(m interface{}, key interface{}, value interface{}) {
+	d.journalKey(m, key)
+	reflect.ValueOf(m).SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(value))
+}
+
+// add puts the assignment of a user or a role, an assignment that already exists keeps its window unless a window is given.
+// The database must be locked by the caller.
+// @param interface{}
+// @param interface{}
+// @param interface{}
+// @param repositories_scopes.Window
+func (d *Database) add(m interface{}, key interface{}, value interface{}, window scopes.Window) {
+	if window.IsZero() && reflect.ValueOf(m).MapIndex(reflect.ValueOf(key)).IsValid() {
+		return
+	}
+	d.put(m, key, value)
+}
+
+// remove deletes the key from the map of the database, the previous value is journaled.
+// The database must be locked by the caller.
+// @param interface{}
+// @param interface{}
+func (d *Database) remove(m interface{}, key interface{}) {
+	d.journalKey(m, key)
+	reflect.ValueOf(m).SetMapIndex(reflect.ValueOf(key), reflect.Value{})
+}
+
+// journalKey journals the restoration of the key of the map to its current value, or its deletion if it has none.
+// @param interface{}
+// @param interface{}
+func (d *Database) journalKey(m interface{}, key interface{}) {
+	if d.journal == nil {
+		return
+	}
+	mapValue, keyValue := reflect.ValueOf(m), reflect.ValueOf(key)
+	previous := mapValue.MapIndex(keyValue)
+	d.journaled(func() { mapValue.SetMapIndex(keyValue, previous) })
+}
+
+// journaled appends the undo of a change to the journal, if a transaction is running.
+// @param func()
+func (d *Database) journaled(undo func()) {
+	if d.journal != nil {
+		d.journal = append(d.journal, undo)
+	}
+}
+
+// newUserAssignment returns the key of the assignment of the user in the scope.
+// @param models.Subject
+// @param uint
+// @param repositories_scopes.Assignment
+// @return userAssignment
+func newUserAssignment(subject models.Subject, ID uint, assignment scopes.Assignment) userAssignment {
+	return userAssignment{
+		subject:      subject,
+		ID:           ID,
+		tenantID:     assignment.TenantID,
+		resourceType: assignment.ResourceType,
+		resourceID:   assignment.ResourceID,
+	}
+}
+
+// applicable does the assignment of the user apply in the scope? (see scopes.Assignment.ToApplicable)
+// @param userAssignment
+// @param repositories_scopes.Assignment
+// @return bool
+func (a userAssignment) applicable(assignment scopes.Assignment) bool {
+	if a.tenantID != "" && a.tenantID != assignment.TenantID {
+		return false
+	}
+	if a.resourceType == "" {
+		return true
+	}
+	return assignment.ResourceType != "" && a.resourceType == assignment.ResourceType && a.resourceID == assignment.ResourceID
+}
+
+// exact is the assignment of the user made exactly in the scope? (see scopes.Assignment.ToExact)
+// @param userAssignment
+// @param repositories_scopes.Assignment
+// @return bool
+func (a userAssignment) exact(assignment scopes.Assignment) bool {
+	return a.tenantID == assignment.TenantID && a.resourceType == assignment.ResourceType && a.resourceID == assignment.ResourceID
+}
+
+// withInheritedRoleIDs returns the role ids together with the ids of all the roles they inherit, transitively.
+// The database must be locked by the caller.
+// @param []uint
+// @return []uint
+func (d *Database) withInheritedRoleIDs(roleIDs []uint) []uint {
+	allRoleIDs := helpers.RemoveDuplicateValues(roleIDs)
+	for i := 0; i < len(allRoleIDs); i++ {
+		for key := range d.roleChildren {
+			if key.roleID == allRoleIDs[i] && !helpers.InArray(key.ID, allRoleIDs) {
+				allRoleIDs = append(allRoleIDs, key.ID)
+			}
+		}
+	}
+	return allRoleIDs
+}
+
+// active is the window valid at the given time? (see scopes.ToActive)
+// @param *time.Time
+// @param *time.Time
+// @param time.Time
+// @return bool
+func active(startsAt *time.Time, expiresAt *time.Time, now time.Time) bool {
+	return (startsAt == nil || !startsAt.After(now)) && (expiresAt == nil || expiresAt.After(now))
+}
+
+// nextBound returns the earliest of the bound and the bounds of the window that are after the given time. (see repositories.nextWindowBound)
+// @param *time.Time
+// @param *time.Time
+// @param *time.Time
+// @param time.Time
+// @return *time.Time
+func nextBound(bound *time.Time, startsAt *time.Time, expiresAt *time.Time, after time.Time) *time.Time {
+	for _, windowBound := range []*time.Time{startsAt, expiresAt} {
+		if windowBound != nil && windowBound.After(after) && (bound == nil || windowBound.Before(*bound)) {
+			bound = windowBound
+		}
+	}
+	return bound
+}
+
+// expired has the window expired before the given time? (see repositories.purgeExpired)
+// @param *time.Time
+// @param time.Time
+// @return bool
+func expired(expiresAt *time.Time, before time.Time) bool {
+	return expiresAt != nil && !expiresAt.After(before)
+}
+
+// sortedIDs returns the ids without duplicates in ascending order.
+// @param []uint
+// @return []uint
+func sortedIDs(IDs []uint) []uint {
+	IDs = helpers.RemoveDuplicateValues(IDs)
+	sort.Slice(IDs, func(i, j int) bool { return IDs[i] < IDs[j] })
+	return IDs
+}
+
+// paginate returns the page of the ids and the total count. Only *scopes.GormPagination is applied, the other pagers are ignored.
+// @param []uint
+// @param repositories_scopes.GormPager
+// @return []uint, int64
+func paginate(IDs []uint, pagination scopes.GormPager) (page []uint, totalCount int64) {
+	offset, end := bounds(len(IDs), pagination)
+	return IDs[offset:end], int64(len(IDs))
+}
+
+// sortedSubjects returns the subjects ordered by type and id, the integer ids are compared as numbers.
+// @param map[models.Subject]struct{}
+// @return []models.Subject
+func sortedSubjects(set map[models.Subject]struct{}) []models.Subject {
+	subjects := make([]models.Subject, 0, len(set))
+	for subject := range set {
+		subjects = append(subjects, subject)
+	}
+	sort.Slice(subjects, func(i, j int) bool {
+		if subjects[i].Type != subjects[j].Type {
+			return subjects[i].Type < subjects[j].Type
+		}
+		a, aIsInt := subjects[i].ID.Int64()
+		b, bIsInt := subjects[j].ID.Int64()
+		if aIsInt && bIsInt {
+			return a < b
+		}
+		return subjects[i].ID.String() < subjects[j].ID.String()
+	})
+	return subjects
+}
+
+// containsSubject is the subject one of the subjects?
+// @param []models.Subject
+// @param models.Subject
+// @return bool
+func containsSubject(subjects []models.Subject, subject models.Subject) bool {
+	for _, s := range subjects {
+		if s == subject {
+			return true
+		}
+	}
+	return false
+}
+
+// paginateSubjects returns the page of the subjects and the total count. (see paginate)
+// @param []models.Subject
+// @param repositories_scopes.GormPager
+// @return []models.Subject, int64
+func paginateSubjects(subjects []models.Subject, pagination scopes.GormPager) (page []models.Subject, totalCount int64) {
+	offset, end := bounds(len(subjects), pagination)
+	return subjects[offset:end], int64(len(subjects))
+}
+
+// bounds returns the bounds of the page in a list of the given length. Only *scopes.GormPagination is applied, the other pagers are ignored.
+// @param int
+// @param repositories_scopes.GormPager
+// @return int, int
+func bounds(length int, pagination scopes.GormPager) (offset int, end int) {
+	gormPagination, ok := pagination.(*scopes.GormPagination)
+	if !ok || gormPagination == nil || gormPagination.Pagination == nil {
+		return 0, length
+	}
+
+	offset = helpers.OffsetCal(gormPagination.GetPage(), gormPagination.GetLimit())
+	if offset >= length {
+		return length, length
+	}
+
+	end = offset + gormPagination.GetLimit()
+	if end > length {
+		end = length
+	}
+
+	return offset, end
+}
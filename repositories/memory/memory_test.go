@@ -0,0 +1,75 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/repositories/conformance"
+	"github.com/Permify/go-role/repositories/memory"
+)
+
+func TestMemory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "memory suite")
+}
+
+var _ = conformance.Describe("memory", newMemoryRepositories(models.UserIDUint))
+
+var _ = conformance.Describe("memory with int64 user ids", newMemoryRepositories(models.UserIDInt64))
+
+var _ = conformance.Describe("memory with uuid user ids", newMemoryRepositories(models.UserIDUUID))
+
+// newMemoryRepositories returns the repositories on a new in-memory database for each spec.
+func newMemoryRepositories(userIDType models.UserIDType) func() conformance.Repositories {
+	return func() conformance.Repositories {
+		database := memory.NewDatabase()
+		return conformance.Repositories{
+			Role:       &memory.RoleRepository{Database: database},
+			Permission: &memory.PermissionRepository{Database: database},
+			User:       &memory.UserRepository{Database: database},
+			Audit:      &memory.AuditRepository{Database: database},
+			Transactor: &memory.Transactor{Database: database},
+			UserIDType: userIDType,
+		}
+	}
+}
+
+var _ = Describe("memory transactions", func() {
+	It("does not undo the changes of the other goroutines", func() {
+		ctx := context.Background()
+		database := memory.NewDatabase()
+		roles := &memory.RoleRepository{Database: database}
+		transactor := &memory.Transactor{Database: database}
+		failure := errors.New("failure")
+
+		started, done := make(chan struct{}), make(chan error, 1)
+		go func() {
+			<-started
+			done <- roles.FirstOrCreate(ctx, &models.Role{Name: "viewer", GuardName: "viewer"})
+		}()
+
+		Expect(transactor.Transaction(ctx, func(ctx context.Context) error {
+			if err := roles.FirstOrCreate(ctx, &models.Role{Name: "admin", GuardName: "admin"}); err != nil {
+				return err
+			}
+			close(started)
+			// the other goroutine waits for the end of the transaction.
+			Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+			return failure
+		})).Should(MatchError(failure))
+		Eventually(done).Should(Receive(BeNil()))
+
+		_, err := roles.GetRoleByGuardName(ctx, "admin")
+		Expect(err).Should(MatchError(gorm.ErrRecordNotFound))
+		viewer, err := roles.GetRoleByGuardName(ctx, "viewer")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(viewer.ID).Should(Equal(uint(1)))
+	})
+})
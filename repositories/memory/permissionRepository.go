@@ -0,0 +1,380 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/helpers"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/repositories"
+	"github.com/Permify/go-role/repositories/scopes"
+)
+
+// PermissionRepository its in-memory data access layer of permission.
+type PermissionRepository struct {
+	Database *Database
+}
+
+// Migrate does nothing, the in-memory database has no tables.
+// @return error
+func (repository *PermissionRepository) Migrate() (err error) {
+	return nil
+}
+
+// GetPermissionByID get permission by id.
+// @param context.Context
+// @param uint
+// @return models.Permission, error
+func (repository *PermissionRepository) GetPermissionByID(ctx context.Context, ID uint) (permission models.Permission, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	permission, ok := repository.Database.permissions[ID]
+	if !ok {
+		return models.Permission{}, gorm.ErrRecordNotFound
+	}
+	return permission, nil
+}
+
+// GetPermissionByGuardName get permission by guard name.
+// @param context.Context
+// @param string
+// @return models.Permission, error
+func (repository *PermissionRepository) GetPermissionByGuardName(ctx context.Context, guardName string) (permission models.Permission, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return repository.firstByGuardName(guardName)
+}
+
+// MULTIPLE FETCH OPTIONS
+
+// GetPermissions get permissions by ids.
+// @param context.Context
+// @param []uint
+// @return collections.Permission, error
+func (repository *PermissionRepository) GetPermissions(ctx context.Context, IDs []uint) (permissions collections.Permission, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return repository.find(func(permission models.Permission) bool { return helpers.InArray(permission.ID, IDs) }), nil
+}
+
+// GetPermissionsByGuardNames get permissions by guard names.
+// @param context.Context
+// @param []string
+// @return collections.Permission, error
+func (repository *PermissionRepository) GetPermissionsByGuardNames(ctx context.Context, guardNames []string) (permissions collections.Permission, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return repository.find(func(permission models.Permission) bool { return helpers.InArray(permission.GuardName, guardNames) }), nil
+}
+
+// ID FETCH OPTIONS
+
+// GetPermissionIDs get permission ids. (with pagination)
+// @param context.Context
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *PermissionRepository) GetPermissionIDs(ctx context.Context, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for ID := range repository.Database.permissions {
+		permissionIDs = append(permissionIDs, ID)
+	}
+
+	permissionIDs, totalCount = paginate(sortedIDs(permissionIDs), pagination)
+	return
+}
+
+// GetDirectPermissionIDsOfUserByID get direct permission ids of user that apply in the assignment scope. (with pagination)
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *PermissionRepository) GetDirectPermissionIDsOfUserByID(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	now := time.Now()
+	for key, userPermission := range repository.Database.userPermissions {
+		if key.subject == subject && key.applicable(assignment) && active(userPermission.StartsAt, userPermission.ExpiresAt, now) {
+			permissionIDs = append(permissionIDs, key.ID)
+		}
+	}
+
+	permissionIDs, totalCount = paginate(sortedIDs(permissionIDs), pagination)
+	return
+}
+
+// GetPermissionIDsOfRolesByIDs get permission ids of roles that are valid now. (with pagination)
+// @param context.Context
+// @param []uint
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *PermissionRepository) GetPermissionIDsOfRolesByIDs(ctx context.Context, roleIDs []uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	now := time.Now()
+	for key, rolePermission := range repository.Database.rolePermissions {
+		if helpers.InArray(key.roleID, roleIDs) && active(rolePermission.StartsAt, rolePermission.ExpiresAt, now) {
+			permissionIDs = append(permissionIDs, key.ID)
+		}
+	}
+
+	permissionIDs, totalCount = paginate(sortedIDs(permissionIDs), pagination)
+	return
+}
+
+// GetDeniedPermissionIDsOfUserByID get denied permission ids of user that apply in the assignment scope. (with pagination)
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *PermissionRepository) GetDeniedPermissionIDsOfUserByID(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	now := time.Now()
+	for key, userDeniedPermission := range repository.Database.userDeniedPermissions {
+		if key.subject == subject && key.applicable(assignment) && active(userDeniedPermission.StartsAt, userDeniedPermission.ExpiresAt, now) {
+			permissionIDs = append(permissionIDs, key.ID)
+		}
+	}
+
+	permissionIDs, totalCount = paginate(sortedIDs(permissionIDs), pagination)
+	return
+}
+
+// GetDeniedPermissionIDsOfRolesByIDs get denied permission ids of roles that are valid now. (with pagination)
+// @param context.Context
+// @param []uint
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *PermissionRepository) GetDeniedPermissionIDsOfRolesByIDs(ctx context.Context, roleIDs []uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	now := time.Now()
+	for key, roleDeniedPermission := range repository.Database.roleDeniedPermissions {
+		if helpers.InArray(key.roleID, roleIDs) && active(roleDeniedPermission.StartsAt, roleDeniedPermission.ExpiresAt, now) {
+			permissionIDs = append(permissionIDs, key.ID)
+		}
+	}
+
+	permissionIDs, totalCount = paginate(sortedIDs(permissionIDs), pagination)
+	return
+}
+
+// GetEffectivePermissionIDsOfUser get which of the permission ids are allowed and denied to the user in the assignment scope,
+// directly or via the roles of the user and the roles they inherit, that are valid now.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param []uint
+// @return repositories.EffectivePermissionIDs, error
+func (repository *PermissionRepository) GetEffectivePermissionIDsOfUser(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissionIDs []uint) (effective repositories.EffectivePermissionIDs, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	now := time.Now()
+	var roleIDs []uint
+	for key, userRole := range repository.Database.userRoles {
+		if key.subject == subject && key.applicable(assignment) && active(userRole.StartsAt, userRole.ExpiresAt, now) {
+			roleIDs = append(roleIDs, key.ID)
+		}
+	}
+	roleIDs = repository.Database.withInheritedRoleIDs(roleIDs)
+
+	for key, userPermission := range repository.Database.userPermissions {
+		if key.subject == subject && key.applicable(assignment) && active(userPermission.StartsAt, userPermission.ExpiresAt, now) && helpers.InArray(key.ID, permissionIDs) {
+			effective.DirectPermissionIDs = append(effective.DirectPermissionIDs, key.ID)
+		}
+	}
+
+	for key, rolePermission := range repository.Database.rolePermissions {
+		if helpers.InArray(key.roleID, roleIDs) && active(rolePermission.StartsAt, rolePermission.ExpiresAt, now) && helpers.InArray(key.ID, permissionIDs) {
+			effective.RolePermissionIDs = append(effective.RolePermissionIDs, key.ID)
+		}
+	}
+
+	for key, userDeniedPermission := range repository.Database.userDeniedPermissions {
+		if key.subject == subject && key.applicable(assignment) && active(userDeniedPermission.StartsAt, userDeniedPermission.ExpiresAt, now) && helpers.InArray(key.ID, permissionIDs) {
+			effective.DirectDeniedPermissionIDs = append(effective.DirectDeniedPermissionIDs, key.ID)
+		}
+	}
+
+	for key, roleDeniedPermission := range repository.Database.roleDeniedPermissions {
+		if helpers.InArray(key.roleID, roleIDs) && active(roleDeniedPermission.StartsAt, roleDeniedPermission.ExpiresAt, now) && helpers.InArray(key.ID, permissionIDs) {
+			effective.RoleDeniedPermissionIDs = append(effective.RoleDeniedPermissionIDs, key.ID)
+		}
+	}
+
+	return
+}
+
+// FirstOrCreate & Updates & Delete
+
+// FirstOrCreate create new permission if name not exist.
+// If the permission exists, it is loaded into the given permission.
+// @param context.Context
+// @param *models.Permission
+// @return error
+func (repository *PermissionRepository) FirstOrCreate(ctx context.Context, permission *models.Permission) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	if existing, err := repository.firstByGuardName(permission.GuardName); err == nil {
+		*permission = existing
+		return nil
+	}
+
+	if permission.ID == 0 {
+		permission.ID = repository.Database.lastPermissionID + 1
+	}
+	if lastPermissionID := repository.Database.lastPermissionID; permission.ID > lastPermissionID {
+		repository.Database.journaled(func() { repository.Database.lastPermissionID = lastPermissionID })
+		repository.Database.lastPermissionID = permission.ID
+	}
+
+	now := time.Now()
+	permission.CreatedAt, permission.UpdatedAt = now, now
+
+	repository.Database.put(repository.Database.permissions, permission.ID, *permission)
+	return nil
+}
+
+// Updates update permission. The keys of the updates are the column names. (name, guard_name, description)
+// @param context.Context
+// @param *models.Permission
+// @param map[string]interface{}
+// @return error
+func (repository *PermissionRepository) Updates(ctx context.Context, permission *models.Permission, updates map[string]interface{}) (err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	stored, ok := repository.Database.permissions[permission.ID]
+	if !ok {
+		return nil
+	}
+
+	for column, value := range updates {
+		var s string
+		if s, ok = value.(string); !ok {
+			return fmt.Errorf("memory: unsupported value of column %s", column)
+		}
+		switch column {
+		case "name":
+			stored.Name, permission.Name = s, s
+		case "guard_name":
+			stored.GuardName, permission.GuardName = s, s
+		case "description":
+			stored.Description, permission.Description = s, s
+		default:
+			return fmt.Errorf("memory: unknown column %s", column)
+		}
+	}
+
+	stored.UpdatedAt = time.Now()
+	permission.UpdatedAt = stored.UpdatedAt
+	repository.Database.put(repository.Database.permissions, permission.ID, stored)
+	return nil
+}
+
+// Delete delete permission.
+// Its user, role and deny relations are deleted too.
+// @param context.Context
+// @param *models.Permission
+// @return error
+func (repository *PermissionRepository) Delete(ctx context.Context, permission *models.Permission) (err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key := range repository.Database.userPermissions {
+		if key.ID == permission.ID {
+			repository.Database.remove(repository.Database.userPermissions, key)
+		}
+	}
+	for key := range repository.Database.userDeniedPermissions {
+		if key.ID == permission.ID {
+			repository.Database.remove(repository.Database.userDeniedPermissions, key)
+		}
+	}
+	for key := range repository.Database.rolePermissions {
+		if key.ID == permission.ID {
+			repository.Database.remove(repository.Database.rolePermissions, key)
+		}
+	}
+	for key := range repository.Database.roleDeniedPermissions {
+		if key.ID == permission.ID {
+			repository.Database.remove(repository.Database.roleDeniedPermissions, key)
+		}
+	}
+
+	repository.Database.remove(repository.Database.permissions, permission.ID)
+	return nil
+}
+
+// firstByGuardName returns the permission with the lowest id that has the guard name, the caller must hold the lock.
+// @param string
+// @return models.Permission, error
+func (repository *PermissionRepository) firstByGuardName(guardName string) (permission models.Permission, err error) {
+	permissions := repository.find(func(permission models.Permission) bool { return permission.GuardName == guardName })
+	if permissions.Len() == 0 {
+		return models.Permission{}, gorm.ErrRecordNotFound
+	}
+	return permissions[0], nil
+}
+
+// find returns the permissions that match in ascending id order, the caller must hold the lock.
+// @param func(permission models.Permission) bool
+// @return collections.Permission
+func (repository *PermissionRepository) find(match func(permission models.Permission) bool) (permissions collections.Permission) {
+	var IDs []uint
+	for ID, permission := range repository.Database.permissions {
+		if match(permission) {
+			IDs = append(IDs, ID)
+		}
+	}
+
+	permissions = collections.Permission{}
+	for _, ID := range sortedIDs(IDs) {
+		permissions = append(permissions, repository.Database.permissions[ID])
+	}
+	return
+}
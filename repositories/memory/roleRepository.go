@@ -0,0 +1,791 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/helpers"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/models/pivot"
+	"github.com/Permify/go-role/repositories/scopes"
+)
+
+// RoleRepository its in-memory data access layer of role.
+type RoleRepository struct {
+	Database *Database
+}
+
+// Migrate does nothing, the in-memory database has no tables.
+// @return error
+func (repository *RoleRepository) Migrate() (err error) {
+	return nil
+}
+
+// SINGLE FETCH OPTIONS
+
+// GetRoleByID get role by id.
+// @param context.Context
+// @param uint
+// @return models.Role, error
+func (repository *RoleRepository) GetRoleByID(ctx context.Context, ID uint) (role models.Role, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	role, ok := repository.Database.roles[ID]
+	if !ok {
+		return models.Role{}, gorm.ErrRecordNotFound
+	}
+	return role, nil
+}
+
+// GetRoleByIDWithPermissions get role by id with its permissions. (only the permissions valid now)
+// @param context.Context
+// @param uint
+// @return models.Role, error
+func (repository *RoleRepository) GetRoleByIDWithPermissions(ctx context.Context, ID uint) (role models.Role, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	role, ok := repository.Database.roles[ID]
+	if !ok {
+		return models.Role{}, gorm.ErrRecordNotFound
+	}
+	return repository.withActivePermissions(role), nil
+}
+
+// GetRoleByGuardName get role by guard name.
+// @param context.Context
+// @param string
+// @return models.Role, error
+func (repository *RoleRepository) GetRoleByGuardName(ctx context.Context, guardName string) (role models.Role, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return repository.firstByGuardName(guardName)
+}
+
+// GetRoleByGuardNameWithPermissions get role by guard name with its permissions. (only the permissions valid now)
+// @param context.Context
+// @param string
+// @return models.Role, error
+func (repository *RoleRepository) GetRoleByGuardNameWithPermissions(ctx context.Context, guardName string) (role models.Role, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	role, err = repository.firstByGuardName(guardName)
+	if err != nil {
+		return
+	}
+	return repository.withActivePermissions(role), nil
+}
+
+// MULTIPLE FETCH OPTIONS
+
+// GetRoles get roles by ids.
+// @param context.Context
+// @param []uint
+// @return collections.Role, error
+func (repository *RoleRepository) GetRoles(ctx context.Context, IDs []uint) (roles collections.Role, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return repository.find(func(role models.Role) bool { return helpers.InArray(role.ID, IDs) }, false), nil
+}
+
+// GetRolesWithPermissions get roles by ids with its permissions. (only the permissions valid now)
+// @param context.Context
+// @param []uint
+// @return collections.Role, error
+func (repository *RoleRepository) GetRolesWithPermissions(ctx context.Context, IDs []uint) (roles collections.Role, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return repository.find(func(role models.Role) bool { return helpers.InArray(role.ID, IDs) }, true), nil
+}
+
+// GetRolesByGuardNames get roles by guard names.
+// @param context.Context
+// @param []string
+// @return collections.Role, error
+func (repository *RoleRepository) GetRolesByGuardNames(ctx context.Context, guardNames []string) (roles collections.Role, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return repository.find(func(role models.Role) bool { return helpers.InArray(role.GuardName, guardNames) }, false), nil
+}
+
+// GetRolesByGuardNamesWithPermissions get roles by guard names with its permissions. (only the permissions valid now)
+// @param context.Context
+// @param []string
+// @return collections.Role, error
+func (repository *RoleRepository) GetRolesByGuardNamesWithPermissions(ctx context.Context, guardNames []string) (roles collections.Role, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return repository.find(func(role models.Role) bool { return helpers.InArray(role.GuardName, guardNames) }, true), nil
+}
+
+// ID FETCH OPTIONS
+
+// GetRoleIDs get role ids. (with pagination)
+// @param context.Context
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *RoleRepository) GetRoleIDs(ctx context.Context, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for ID := range repository.Database.roles {
+		roleIDs = append(roleIDs, ID)
+	}
+
+	roleIDs, totalCount = paginate(sortedIDs(roleIDs), pagination)
+	return
+}
+
+// GetRoleIDsOfUser get role ids of user that apply in the assignment scope. (with pagination)
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *RoleRepository) GetRoleIDsOfUser(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	now := time.Now()
+	for key, userRole := range repository.Database.userRoles {
+		if key.subject == subject && key.applicable(assignment) && active(userRole.StartsAt, userRole.ExpiresAt, now) {
+			roleIDs = append(roleIDs, key.ID)
+		}
+	}
+
+	roleIDs, totalCount = paginate(sortedIDs(roleIDs), pagination)
+	return
+}
+
+// GetRoleIDsOfPermission get role ids of permission that are valid now. (with pagination)
+// @param context.Context
+// @param uint
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *RoleRepository) GetRoleIDsOfPermission(ctx context.Context, permissionID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	now := time.Now()
+	for key, rolePermission := range repository.Database.rolePermissions {
+		if key.ID == permissionID && active(rolePermission.StartsAt, rolePermission.ExpiresAt, now) {
+			roleIDs = append(roleIDs, key.roleID)
+		}
+	}
+
+	roleIDs, totalCount = paginate(sortedIDs(roleIDs), pagination)
+	return
+}
+
+// GetRoleIDsOfDeniedPermission get the ids of the roles that deny the permission and are valid now. (with pagination)
+// @param context.Context
+// @param uint
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *RoleRepository) GetRoleIDsOfDeniedPermission(ctx context.Context, permissionID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	now := time.Now()
+	for key, roleDeniedPermission := range repository.Database.roleDeniedPermissions {
+		if key.ID == permissionID && active(roleDeniedPermission.StartsAt, roleDeniedPermission.ExpiresAt, now) {
+			roleIDs = append(roleIDs, key.roleID)
+		}
+	}
+
+	roleIDs, totalCount = paginate(sortedIDs(roleIDs), pagination)
+	return
+}
+
+// FirstOrCreate & Updates & Delete
+
+// FirstOrCreate create new role if name not exist.
+// If the role exists, it is loaded into the given role.
+// @param context.Context
+// @param *models.Role
+// @return error
+func (repository *RoleRepository) FirstOrCreate(ctx context.Context, role *models.Role) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	if existing, err := repository.firstByGuardName(role.GuardName); err == nil {
+		*role = existing
+		return nil
+	}
+
+	if role.ID == 0 {
+		role.ID = repository.Database.lastRoleID + 1
+	}
+	if lastRoleID := repository.Database.lastRoleID; role.ID > lastRoleID {
+		repository.Database.journaled(func() { repository.Database.lastRoleID = lastRoleID })
+		repository.Database.lastRoleID = role.ID
+	}
+
+	now := time.Now()
+	role.CreatedAt, role.UpdatedAt = now, now
+
+	stored := *role
+	stored.Permissions, stored.Children = nil, nil
+	repository.Database.put(repository.Database.roles, role.ID, stored)
+	return nil
+}
+
+// Updates update role. The keys of the updates are the column names. (name, guard_name, description)
+// @param context.Context
+// @param *models.Role
+// @param map[string]interface{}
+// @return error
+func (repository *RoleRepository) Updates(ctx context.Context, role *models.Role, updates map[string]interface{}) (err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	stored, ok := repository.Database.roles[role.ID]
+	if !ok {
+		return nil
+	}
+
+	for column, value := range updates {
+		var s string
+		if s, ok = value.(string); !ok {
+			return fmt.Errorf("memory: unsupported value of column %s", column)
+		}
+		switch column {
+		case "name":
+			stored.Name, role.Name = s, s
+		case "guard_name":
+			stored.GuardName, role.GuardName = s, s
+		case "description":
+			stored.Description, role.Description = s, s
+		default:
+			return fmt.Errorf("memory: unknown column %s", column)
+		}
+	}
+
+	stored.UpdatedAt = time.Now()
+	role.UpdatedAt = stored.UpdatedAt
+	repository.Database.put(repository.Database.roles, role.ID, stored)
+	return nil
+}
+
+// Delete delete role.
+// Its user, permission, deny and child relations are deleted too.
+// @param context.Context
+// @param *models.Role
+// @return error
+func (repository *RoleRepository) Delete(ctx context.Context, role *models.Role) (err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key := range repository.Database.userRoles {
+		if key.ID == role.ID {
+			repository.Database.remove(repository.Database.userRoles, key)
+		}
+	}
+	for key := range repository.Database.rolePermissions {
+		if key.roleID == role.ID {
+			repository.Database.remove(repository.Database.rolePermissions, key)
+		}
+	}
+	for key := range repository.Database.roleDeniedPermissions {
+		if key.roleID == role.ID {
+			repository.Database.remove(repository.Database.roleDeniedPermissions, key)
+		}
+	}
+	for key := range repository.Database.roleChildren {
+		if key.roleID == role.ID || key.ID == role.ID {
+			repository.Database.remove(repository.Database.roleChildren, key)
+		}
+	}
+
+	repository.Database.remove(repository.Database.roles, role.ID)
+	return nil
+}
+
+// ACTIONS
+
+// AddPermissions add permissions to role, valid in the window.
+// If a permission has been added before, its window is replaced by the given window, or kept if no window is given.
+// @param context.Context
+// @param *models.Role
+// @param collections.Permission
+// @param repositories_scopes.Window
+// @return error
+func (repository *RoleRepository) AddPermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, permission := range permissions.Origin() {
+		repository.Database.add(repository.Database.rolePermissions, rolePair{roleID: role.ID, ID: permission.ID}, pivot.RolePermissions{
+			RoleID:       role.ID,
+			PermissionID: permission.ID,
+			StartsAt:     window.StartsAt,
+			ExpiresAt:    window.ExpiresAt,
+		}, window)
+	}
+	return nil
+}
+
+// ReplacePermissions replace permissions of role, the new permissions are valid in the window.
+// @param context.Context
+// @param *models.Role
+// @param collections.Permission
+// @param repositories_scopes.Window
+// @return error
+func (repository *RoleRepository) ReplacePermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key := range repository.Database.rolePermissions {
+		if key.roleID == role.ID {
+			repository.Database.remove(repository.Database.rolePermissions, key)
+		}
+	}
+
+	for _, permission := range permissions.Origin() {
+		key := rolePair{roleID: role.ID, ID: permission.ID}
+		if _, ok := repository.Database.rolePermissions[key]; ok {
+			continue
+		}
+		repository.Database.put(repository.Database.rolePermissions, key, pivot.RolePermissions{
+			RoleID:       role.ID,
+			PermissionID: permission.ID,
+			StartsAt:     window.StartsAt,
+			ExpiresAt:    window.ExpiresAt,
+		})
+	}
+	return nil
+}
+
+// RemovePermissions remove permissions of role.
+// @param context.Context
+// @param *models.Role
+// @param collections.Permission
+// @return error
+func (repository *RoleRepository) RemovePermissions(ctx context.Context, role *models.Role, permissions collections.Permission) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, permission := range permissions.Origin() {
+		repository.Database.remove(repository.Database.rolePermissions, rolePair{roleID: role.ID, ID: permission.ID})
+	}
+	return nil
+}
+
+// ClearPermissions remove all permissions of role.
+// @param context.Context
+// @param *models.Role
+// @return error
+func (repository *RoleRepository) ClearPermissions(ctx context.Context, role *models.Role) (err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key := range repository.Database.rolePermissions {
+		if key.roleID == role.ID {
+			repository.Database.remove(repository.Database.rolePermissions, key)
+		}
+	}
+	return nil
+}
+
+// DenyPermissions deny permissions to role, valid in the window. The denied permissions block the permissions for the users of the role.
+// If a permission has been denied before, its window is replaced by the given window, or kept if no window is given.
+// @param context.Context
+// @param *models.Role
+// @param collections.Permission
+// @param repositories_scopes.Window
+// @return error
+func (repository *RoleRepository) DenyPermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, permission := range permissions.Origin() {
+		repository.Database.add(repository.Database.roleDeniedPermissions, rolePair{roleID: role.ID, ID: permission.ID}, pivot.RoleDeniedPermissions{
+			RoleID:       role.ID,
+			PermissionID: permission.ID,
+			StartsAt:     window.StartsAt,
+			ExpiresAt:    window.ExpiresAt,
+		}, window)
+	}
+	return nil
+}
+
+// RemoveDeniedPermissions remove denied permissions of role.
+// @param context.Context
+// @param *models.Role
+// @param collections.Permission
+// @return error
+func (repository *RoleRepository) RemoveDeniedPermissions(ctx context.Context, role *models.Role, permissions collections.Permission) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, permission := range permissions.Origin() {
+		repository.Database.remove(repository.Database.roleDeniedPermissions, rolePair{roleID: role.ID, ID: permission.ID})
+	}
+	return nil
+}
+
+// HIERARCHY
+
+// GetChildRoleIDs get the ids of the direct child roles of the roles.
+// @param context.Context
+// @param []uint
+// @return []uint, error
+func (repository *RoleRepository) GetChildRoleIDs(ctx context.Context, roleIDs []uint) (childRoleIDs []uint, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for key := range repository.Database.roleChildren {
+		if helpers.InArray(key.roleID, roleIDs) {
+			childRoleIDs = append(childRoleIDs, key.ID)
+		}
+	}
+	return sortedIDs(childRoleIDs), nil
+}
+
+// GetParentRoleIDs get the ids of the roles that directly inherit the roles.
+// @param context.Context
+// @param []uint
+// @return []uint, error
+func (repository *RoleRepository) GetParentRoleIDs(ctx context.Context, roleIDs []uint) (parentRoleIDs []uint, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for key := range repository.Database.roleChildren {
+		if helpers.InArray(key.ID, roleIDs) {
+			parentRoleIDs = append(parentRoleIDs, key.roleID)
+		}
+	}
+	return sortedIDs(parentRoleIDs), nil
+}
+
+// AddChildren add child roles to role.
+// @param context.Context
+// @param *models.Role
+// @param collections.Role
+// @return error
+func (repository *RoleRepository) AddChildren(ctx context.Context, role *models.Role, children collections.Role) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, child := range children.Origin() {
+		repository.Database.put(repository.Database.roleChildren, rolePair{roleID: role.ID, ID: child.ID}, struct{}{})
+	}
+	return nil
+}
+
+// RemoveChildren remove child roles of role.
+// @param context.Context
+// @param *models.Role
+// @param collections.Role
+// @return error
+func (repository *RoleRepository) RemoveChildren(ctx context.Context, role *models.Role, children collections.Role) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, child := range children.Origin() {
+		repository.Database.remove(repository.Database.roleChildren, rolePair{roleID: role.ID, ID: child.ID})
+	}
+	return nil
+}
+
+// Controls
+
+// HasPermission does the role or any of the roles have given permission? (valid now)
+// @param context.Context
+// @param collections.Role
+// @param models.Permission
+// @return bool, error
+func (repository *RoleRepository) HasPermission(ctx context.Context, roles collections.Role, permission models.Permission) (b bool, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return repository.countPermissions(roles, []uint{permission.ID}) > 0, nil
+}
+
+// HasAllPermissions does the role or roles have all the given permissions? (valid now)
+// @param context.Context
+// @param collections.Role
+// @param collections.Permission
+// @return bool, error
+func (repository *RoleRepository) HasAllPermissions(ctx context.Context, roles collections.Role, permissions collections.Permission) (b bool, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return roles.Len()*permissions.Len() == repository.countPermissions(roles, permissions.IDs()), nil
+}
+
+// HasAnyPermissions does the role or roles have any of the given permissions? (valid now)
+// @param context.Context
+// @param collections.Role
+// @param collections.Permission
+// @return bool, error
+func (repository *RoleRepository) HasAnyPermissions(ctx context.Context, roles collections.Role, permissions collections.Permission) (b bool, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return repository.countPermissions(roles, permissions.IDs()) > 0, nil
+}
+
+// MAINTENANCE
+
+// PurgeExpiredPermissions delete the permission assignments of roles that expired before the given time.
+// The batch size is not used, the in-memory database is not locked for long.
+// @param context.Context
+// @param time.Time
+// @param int
+// @return int64, error
+func (repository *RoleRepository) PurgeExpiredPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key, rolePermission := range repository.Database.rolePermissions {
+		if expired(rolePermission.ExpiresAt, before) {
+			repository.Database.remove(repository.Database.rolePermissions, key)
+			deleted++
+		}
+	}
+	return
+}
+
+// PurgeExpiredDeniedPermissions delete the denied permissions of roles that expired before the given time.
+// The batch size is not used, the in-memory database is not locked for long.
+// @param context.Context
+// @param time.Time
+// @param int
+// @return int64, error
+func (repository *RoleRepository) PurgeExpiredDeniedPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key, roleDeniedPermission := range repository.Database.roleDeniedPermissions {
+		if expired(roleDeniedPermission.ExpiresAt, before) {
+			repository.Database.remove(repository.Database.roleDeniedPermissions, key)
+			deleted++
+		}
+	}
+	return
+}
+
+// WINDOWS
+
+// GetNextWindowBound get the earliest time after the given time at which one of the permissions or denied permissions of the roles starts or expires.
+// It is nil if none of them starts or expires after the given time.
+// @param context.Context
+// @param []uint
+// @param time.Time
+// @return *time.Time, error
+func (repository *RoleRepository) GetNextWindowBound(ctx context.Context, roleIDs []uint, after time.Time) (bound *time.Time, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for key, rolePermission := range repository.Database.rolePermissions {
+		if helpers.InArray(key.roleID, roleIDs) {
+			bound = nextBound(bound, rolePermission.StartsAt, rolePermission.ExpiresAt, after)
+		}
+	}
+	for key, roleDeniedPermission := range repository.Database.roleDeniedPermissions {
+		if helpers.InArray(key.roleID, roleIDs) {
+			bound = nextBound(bound, roleDeniedPermission.StartsAt, roleDeniedPermission.ExpiresAt, after)
+		}
+	}
+	return
+}
+
+// GetPermissionWindows get the permissions of the roles with their windows, also the ones that are not valid now.
+// @param context.Context
+// @param []uint
+// @return []pivot.RolePermissions, error
+func (repository *RoleRepository) GetPermissionWindows(ctx context.Context, roleIDs []uint) (rolePermissions []pivot.RolePermissions, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for key, rolePermission := range repository.Database.rolePermissions {
+		if helpers.InArray(key.roleID, roleIDs) {
+			rolePermissions = append(rolePermissions, rolePermission)
+		}
+	}
+	sort.Slice(rolePermissions, func(i, j int) bool {
+		return rolePairLess(rolePermissions[i].RoleID, rolePermissions[i].PermissionID, rolePermissions[j].RoleID, rolePermissions[j].PermissionID)
+	})
+	return
+}
+
+// GetDeniedPermissionWindows get the denied permissions of the roles with their windows, also the ones that are not valid now.
+// @param context.Context
+// @param []uint
+// @return []pivot.RoleDeniedPermissions, error
+func (repository *RoleRepository) GetDeniedPermissionWindows(ctx context.Context, roleIDs []uint) (roleDeniedPermissions []pivot.RoleDeniedPermissions, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for key, roleDeniedPermission := range repository.Database.roleDeniedPermissions {
+		if helpers.InArray(key.roleID, roleIDs) {
+			roleDeniedPermissions = append(roleDeniedPermissions, roleDeniedPermission)
+		}
+	}
+	sort.Slice(roleDeniedPermissions, func(i, j int) bool {
+		return rolePairLess(roleDeniedPermissions[i].RoleID, roleDeniedPermissions[i].PermissionID, roleDeniedPermissions[j].RoleID, roleDeniedPermissions[j].PermissionID)
+	})
+	return
+}
+
+// rolePairLess orders the assignments of the roles by the role id, then by the id of the assigned record.
+// @param uint
+// @param uint
+// @param uint
+// @param uint
+// @return bool
+func rolePairLess(roleID uint, ID uint, otherRoleID uint, otherID uint) bool {
+	if roleID != otherRoleID {
+		return roleID < otherRoleID
+	}
+	return ID < otherID
+}
+
+// firstByGuardName returns the role with the lowest id that has the guard name, the caller must hold the lock.
+// @param string
+// @return models.Role, error
+func (repository *RoleRepository) firstByGuardName(guardName string) (role models.Role, err error) {
+	roles := repository.find(func(role models.Role) bool { return role.GuardName == guardName }, false)
+	if roles.Len() == 0 {
+		return models.Role{}, gorm.ErrRecordNotFound
+	}
+	return roles[0], nil
+}
+
+// find returns the roles that match in ascending id order, the caller must hold the lock.
+// @param func(role models.Role) bool
+// @param bool
+// @return collections.Role
+func (repository *RoleRepository) find(match func(role models.Role) bool, withPermissions bool) (roles collections.Role) {
+	var IDs []uint
+	for ID, role := range repository.Database.roles {
+		if match(role) {
+			IDs = append(IDs, ID)
+		}
+	}
+
+	roles = collections.Role{}
+	for _, ID := range sortedIDs(IDs) {
+		role := repository.Database.roles[ID]
+		if withPermissions {
+			role = repository.withActivePermissions(role)
+		}
+		roles = append(roles, role)
+	}
+	return
+}
+
+// withActivePermissions returns the role with its permissions that are valid now, the caller must hold the lock.
+// @param models.Role
+// @return models.Role
+func (repository *RoleRepository) withActivePermissions(role models.Role) models.Role {
+	now := time.Now()
+
+	var permissionIDs []uint
+	for key, rolePermission := range repository.Database.rolePermissions {
+		if key.roleID == role.ID && active(rolePermission.StartsAt, rolePermission.ExpiresAt, now) {
+			permissionIDs = append(permissionIDs, key.ID)
+		}
+	}
+
+	role.Permissions = nil
+	for _, permissionID := range sortedIDs(permissionIDs) {
+		if permission, ok := repository.Database.permissions[permissionID]; ok {
+			role.Permissions = append(role.Permissions, permission)
+		}
+	}
+	return role
+}
+
+// countPermissions counts the permission assignments of the roles that are valid now, the caller must hold the lock.
+// @param collections.Role
+// @param []uint
+// @return int64
+func (repository *RoleRepository) countPermissions(roles collections.Role, permissionIDs []uint) (count int64) {
+	now := time.Now()
+	for key, rolePermission := range repository.Database.rolePermissions {
+		if helpers.InArray(key.roleID, roles.IDs()) && helpers.InArray(key.ID, permissionIDs) && active(rolePermission.StartsAt, rolePermission.ExpiresAt, now) {
+			count++
+		}
+	}
+	return
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+)
+
+// transactionKey is the context key of the database whose transaction is running.
+type transactionKey struct{}
+
+// Transactor runs the in-memory repositories in a transaction of the database.
+type Transactor struct {
+	Database *Database
+}
+
+// Transaction runs fc with the database locked for writing, the changes of fc are undone if it returns an error or panics.
+// The repository calls that are given the context of fc do not lock the database again; the others wait for the end of the transaction,
+// so fc must give its context to the repositories. The transactions started in fc are nested, they undo only their own changes. (save points)
+// @param context.Context
+// @param func(ctx context.Context) error
+// @return error
+func (transactor *Transactor) Transaction(ctx context.Context, fc func(ctx context.Context) error) (err error) {
+	d := transactor.Database
+	if d.transacting(ctx) {
+		return d.savepoint(ctx, fc)
+	}
+
+	if err = d.lock(ctx); err != nil {
+		return
+	}
+	d.journal = []func(){}
+	defer func() {
+		d.journal = nil
+		d.mu.Unlock()
+	}()
+
+	return d.savepoint(context.WithValue(ctx, transactionKey{}, d), fc)
+}
+
+// transacting is the context the one of a running transaction of the database?
+// @param context.Context
+// @return bool
+func (d *Database) transacting(ctx context.Context) bool {
+	database, ok := ctx.Value(transactionKey{}).(*Database)
+	return ok && database == d
+}
+
+// savepoint runs fc in the running transaction, the changes of fc are undone if it returns an error or panics.
+// @param context.Context
+// @param func(ctx context.Context) error
+// @return error
+func (d *Database) savepoint(ctx context.Context, fc func(ctx context.Context) error) (err error) {
+	mark := len(d.journal)
+	defer func() {
+		if r := recover(); r != nil {
+			d.undo(mark)
+			panic(r)
+		}
+		if err != nil {
+			d.undo(mark)
+		}
+	}()
+
+	return fc(ctx)
+}
+
+// undo undoes the changes journaled after the mark, the latest first.
+// @param int
+func (d *Database) undo(mark int) {
+	for i := len(d.journal) - 1; i >= mark; i-- {
+		d.journal[i]()
+	}
+	d.journal = d.journal[:mark]
+}
@@ -0,0 +1,682 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/helpers"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/models/pivot"
+	"github.com/Permify/go-role/repositories"
+	"github.com/Permify/go-role/repositories/scopes"
+)
+
+// UserRepository its in-memory data access layer of user.
+type UserRepository struct {
+	Database *Database
+}
+
+// ACTIONS
+
+// AddPermissions add direct permissions to user.
+// If a permission has been added before in the scope, its window is replaced by the given window, or kept if no window is given.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Permission
+// @return error
+func (repository *UserRepository) AddPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, permission := range permissions.Origin() {
+		repository.Database.add(repository.Database.userPermissions, newUserAssignment(subject, permission.ID, assignment), pivot.UserPermissions{
+			SubjectType:  subject.Type,
+			UserID:       subject.ID,
+			PermissionID: permission.ID,
+			TenantID:     assignment.TenantID,
+			ResourceType: assignment.ResourceType,
+			ResourceID:   assignment.ResourceID,
+			StartsAt:     assignment.StartsAt,
+			ExpiresAt:    assignment.ExpiresAt,
+		}, assignment.Window)
+	}
+	return nil
+}
+
+// ReplacePermissions replace direct permissions of user.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Permission
+// @return error
+func (repository *UserRepository) ReplacePermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key := range repository.Database.userPermissions {
+		if key.subject == subject && key.exact(assignment) {
+			repository.Database.remove(repository.Database.userPermissions, key)
+		}
+	}
+
+	for _, permission := range permissions.Origin() {
+		key := newUserAssignment(subject, permission.ID, assignment)
+		if _, ok := repository.Database.userPermissions[key]; ok {
+			continue
+		}
+		repository.Database.put(repository.Database.userPermissions, key, pivot.UserPermissions{
+			SubjectType:  subject.Type,
+			UserID:       subject.ID,
+			PermissionID: permission.ID,
+			TenantID:     assignment.TenantID,
+			ResourceType: assignment.ResourceType,
+			ResourceID:   assignment.ResourceID,
+			StartsAt:     assignment.StartsAt,
+			ExpiresAt:    assignment.ExpiresAt,
+		})
+	}
+	return nil
+}
+
+// RemovePermissions remove direct permissions of user.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Permission
+// @return error
+func (repository *UserRepository) RemovePermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, permission := range permissions.Origin() {
+		repository.Database.remove(repository.Database.userPermissions, newUserAssignment(subject, permission.ID, assignment))
+	}
+	return nil
+}
+
+// ClearPermissions remove all direct permissions of user.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @return error
+func (repository *UserRepository) ClearPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key := range repository.Database.userPermissions {
+		if key.subject == subject && key.exact(assignment) {
+			repository.Database.remove(repository.Database.userPermissions, key)
+		}
+	}
+	return nil
+}
+
+// AddRoles add roles to user.
+// If a role has been added before in the scope, its window is replaced by the given window, or kept if no window is given.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Role
+// @return error
+func (repository *UserRepository) AddRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, role := range roles.Origin() {
+		repository.Database.add(repository.Database.userRoles, newUserAssignment(subject, role.ID, assignment), pivot.UserRoles{
+			SubjectType:  subject.Type,
+			UserID:       subject.ID,
+			RoleID:       role.ID,
+			TenantID:     assignment.TenantID,
+			ResourceType: assignment.ResourceType,
+			ResourceID:   assignment.ResourceID,
+			StartsAt:     assignment.StartsAt,
+			ExpiresAt:    assignment.ExpiresAt,
+		}, assignment.Window)
+	}
+	return nil
+}
+
+// ReplaceRoles replace roles of user.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Role
+// @return error
+func (repository *UserRepository) ReplaceRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key := range repository.Database.userRoles {
+		if key.subject == subject && key.exact(assignment) {
+			repository.Database.remove(repository.Database.userRoles, key)
+		}
+	}
+
+	for _, role := range roles.Origin() {
+		key := newUserAssignment(subject, role.ID, assignment)
+		if _, ok := repository.Database.userRoles[key]; ok {
+			continue
+		}
+		repository.Database.put(repository.Database.userRoles, key, pivot.UserRoles{
+			SubjectType:  subject.Type,
+			UserID:       subject.ID,
+			RoleID:       role.ID,
+			TenantID:     assignment.TenantID,
+			ResourceType: assignment.ResourceType,
+			ResourceID:   assignment.ResourceID,
+			StartsAt:     assignment.StartsAt,
+			ExpiresAt:    assignment.ExpiresAt,
+		})
+	}
+	return nil
+}
+
+// RemoveRoles remove roles of user.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Role
+// @return error
+func (repository *UserRepository) RemoveRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, role := range roles.Origin() {
+		repository.Database.remove(repository.Database.userRoles, newUserAssignment(subject, role.ID, assignment))
+	}
+	return nil
+}
+
+// ClearRoles remove all roles of user.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @return error
+func (repository *UserRepository) ClearRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key := range repository.Database.userRoles {
+		if key.subject == subject && key.exact(assignment) {
+			repository.Database.remove(repository.Database.userRoles, key)
+		}
+	}
+	return nil
+}
+
+// DenyPermissions deny permissions to user, the denied permissions block the permissions given to the user.
+// If a permission has been denied before in the scope, its window is replaced by the given window, or kept if no window is given.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Permission
+// @return error
+func (repository *UserRepository) DenyPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, permission := range permissions.Origin() {
+		repository.Database.add(repository.Database.userDeniedPermissions, newUserAssignment(subject, permission.ID, assignment), pivot.UserDeniedPermissions{
+			SubjectType:  subject.Type,
+			UserID:       subject.ID,
+			PermissionID: permission.ID,
+			TenantID:     assignment.TenantID,
+			ResourceType: assignment.ResourceType,
+			ResourceID:   assignment.ResourceID,
+			StartsAt:     assignment.StartsAt,
+			ExpiresAt:    assignment.ExpiresAt,
+		}, assignment.Window)
+	}
+	return nil
+}
+
+// RemoveDeniedPermissions remove denied permissions of user.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Permission
+// @return error
+func (repository *UserRepository) RemoveDeniedPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	if err := repository.Database.lock(ctx); err != nil {
+		return err
+	}
+	defer repository.Database.unlock(ctx)
+
+	for _, permission := range permissions.Origin() {
+		repository.Database.remove(repository.Database.userDeniedPermissions, newUserAssignment(subject, permission.ID, assignment))
+	}
+	return nil
+}
+
+// CONTROLS
+
+// HasRole does the user have the given role?
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param models.Role
+// @return bool, error
+func (repository *UserRepository) HasRole(ctx context.Context, subject models.Subject, assignment scopes.Assignment, role models.Role) (b bool, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return len(repository.roleIDs(subject, assignment, []uint{role.ID})) > 0, nil
+}
+
+// HasAllRoles does the user have all the given roles?
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Role
+// @return bool, error
+func (repository *UserRepository) HasAllRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) (b bool, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return roles.Len() == int64(len(repository.roleIDs(subject, assignment, roles.IDs()))), nil
+}
+
+// HasAnyRoles does the user have any of the given roles?
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Role
+// @return bool, error
+func (repository *UserRepository) HasAnyRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) (b bool, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return len(repository.roleIDs(subject, assignment, roles.IDs())) > 0, nil
+}
+
+// HasDirectPermission does the user have the given permission? (not including the permissions of the roles)
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param models.Permission
+// @return bool, error
+func (repository *UserRepository) HasDirectPermission(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permission models.Permission) (b bool, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return len(repository.permissionIDs(subject, assignment, []uint{permission.ID})) > 0, nil
+}
+
+// HasAllDirectPermissions does the user have all the given permissions? (not including the permissions of the roles)
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Permission
+// @return bool, error
+func (repository *UserRepository) HasAllDirectPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (b bool, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return permissions.Len() == int64(len(repository.permissionIDs(subject, assignment, permissions.IDs()))), nil
+}
+
+// HasAnyDirectPermissions does the user have any of the given permissions? (not including the permissions of the roles)
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Permission
+// @return bool, error
+func (repository *UserRepository) HasAnyDirectPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (b bool, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	return len(repository.permissionIDs(subject, assignment, permissions.IDs())) > 0, nil
+}
+
+// ID FETCH OPTIONS
+
+// GetUserIDsOfRole get the users that have the role in the scope and are valid now, ordered by subject type and id. (with pagination)
+// @param context.Context
+// @param uint
+// @param repositories_scopes.Assignment
+// @param repositories_scopes.GormPager
+// @return []models.Subject, int64, error
+func (repository *UserRepository) GetUserIDsOfRole(ctx context.Context, roleID uint, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	subjects, totalCount = paginateSubjects(sortedSubjects(repository.subjectsWithRoles(assignment, []uint{roleID})), pagination)
+	return
+}
+
+// GetUserIDsWithDirectPermission get the users that have the permission directly in the scope and are valid now, ordered by subject type and id. (with pagination)
+// @param context.Context
+// @param uint
+// @param repositories_scopes.Assignment
+// @param repositories_scopes.GormPager
+// @return []models.Subject, int64, error
+func (repository *UserRepository) GetUserIDsWithDirectPermission(ctx context.Context, permissionID uint, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	subjects, totalCount = paginateSubjects(sortedSubjects(repository.subjectsWithPermissions(assignment, []uint{permissionID})), pagination)
+	return
+}
+
+// GetUserIDsWithPermission get the users that are selected by the holders in the scope, ordered by subject type and id. (with pagination)
+// @param context.Context
+// @param repositories.PermissionHolders
+// @param repositories_scopes.Assignment
+// @param repositories_scopes.GormPager
+// @return []models.Subject, int64, error
+func (repository *UserRepository) GetUserIDsWithPermission(ctx context.Context, holders repositories.PermissionHolders, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	allowedDirectly := repository.subjectsWithPermissions(assignment, holders.PermissionIDs)
+	allowedViaRoles := repository.subjectsWithRoles(assignment, holders.RoleIDs)
+	deniedDirectly := repository.subjectsWithDeniedPermissions(assignment, holders.DeniedPermissionIDs)
+	deniedViaRoles := repository.subjectsWithRoles(assignment, holders.DeniedRoleIDs)
+
+	allowed := make(map[models.Subject]struct{})
+	for subject := range allowedDirectly {
+		allowed[subject] = struct{}{}
+	}
+	for subject := range allowedViaRoles {
+		allowed[subject] = struct{}{}
+	}
+
+	for subject := range allowed {
+		if len(holders.Subjects) > 0 && !containsSubject(holders.Subjects, subject) {
+			delete(allowed, subject)
+			continue
+		}
+
+		_, isDeniedDirectly := deniedDirectly[subject]
+		_, isDeniedViaRoles := deniedViaRoles[subject]
+		_, isAllowedDirectly := allowedDirectly[subject]
+		if isDeniedDirectly || (isDeniedViaRoles && !(holders.DirectOverridesRoles && isAllowedDirectly)) {
+			delete(allowed, subject)
+		}
+	}
+
+	subjects, totalCount = paginateSubjects(sortedSubjects(allowed), pagination)
+	return
+}
+
+// MAINTENANCE
+
+// PurgeExpiredRoles delete the role assignments of users that expired before the given time.
+// The batch size is not used, the in-memory database is not locked for long.
+// @param context.Context
+// @param time.Time
+// @param int
+// @return int64, error
+func (repository *UserRepository) PurgeExpiredRoles(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key, userRole := range repository.Database.userRoles {
+		if expired(userRole.ExpiresAt, before) {
+			repository.Database.remove(repository.Database.userRoles, key)
+			deleted++
+		}
+	}
+	return
+}
+
+// PurgeExpiredPermissions delete the direct permission assignments of users that expired before the given time.
+// The batch size is not used, the in-memory database is not locked for long.
+// @param context.Context
+// @param time.Time
+// @param int
+// @return int64, error
+func (repository *UserRepository) PurgeExpiredPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key, userPermission := range repository.Database.userPermissions {
+		if expired(userPermission.ExpiresAt, before) {
+			repository.Database.remove(repository.Database.userPermissions, key)
+			deleted++
+		}
+	}
+	return
+}
+
+// PurgeExpiredDeniedPermissions delete the denied permissions of users that expired before the given time.
+// The batch size is not used, the in-memory database is not locked for long.
+// @param context.Context
+// @param time.Time
+// @param int
+// @return int64, error
+func (repository *UserRepository) PurgeExpiredDeniedPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	if err = repository.Database.lock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.unlock(ctx)
+
+	for key, userDeniedPermission := range repository.Database.userDeniedPermissions {
+		if expired(userDeniedPermission.ExpiresAt, before) {
+			repository.Database.remove(repository.Database.userDeniedPermissions, key)
+			deleted++
+		}
+	}
+	return
+}
+
+// WINDOWS
+
+// GetNextWindowBound get the earliest time after the given time at which one of the roles, permissions or denied permissions of the user that apply in the scope starts or expires.
+// It is nil if none of them starts or expires after the given time.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param time.Time
+// @return *time.Time, error
+func (repository *UserRepository) GetNextWindowBound(ctx context.Context, subject models.Subject, assignment scopes.Assignment, after time.Time) (bound *time.Time, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for key, userRole := range repository.Database.userRoles {
+		if key.subject == subject && key.applicable(assignment) {
+			bound = nextBound(bound, userRole.StartsAt, userRole.ExpiresAt, after)
+		}
+	}
+	for key, userPermission := range repository.Database.userPermissions {
+		if key.subject == subject && key.applicable(assignment) {
+			bound = nextBound(bound, userPermission.StartsAt, userPermission.ExpiresAt, after)
+		}
+	}
+	for key, userDeniedPermission := range repository.Database.userDeniedPermissions {
+		if key.subject == subject && key.applicable(assignment) {
+			bound = nextBound(bound, userDeniedPermission.StartsAt, userDeniedPermission.ExpiresAt, after)
+		}
+	}
+	return
+}
+
+// GetRoleWindows get the roles of the user made exactly in the scope with their windows, also the ones that are not valid now.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @return []pivot.UserRoles, error
+func (repository *UserRepository) GetRoleWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userRoles []pivot.UserRoles, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for key, userRole := range repository.Database.userRoles {
+		if key.subject == subject && key.exact(assignment) {
+			userRoles = append(userRoles, userRole)
+		}
+	}
+	sort.Slice(userRoles, func(i, j int) bool {
+		return userRoles[i].RoleID < userRoles[j].RoleID
+	})
+	return
+}
+
+// GetPermissionWindows get the permissions of the user made exactly in the scope with their windows, also the ones that are not valid now.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @return []pivot.UserPermissions, error
+func (repository *UserRepository) GetPermissionWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userPermissions []pivot.UserPermissions, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for key, userPermission := range repository.Database.userPermissions {
+		if key.subject == subject && key.exact(assignment) {
+			userPermissions = append(userPermissions, userPermission)
+		}
+	}
+	sort.Slice(userPermissions, func(i, j int) bool {
+		return userPermissions[i].PermissionID < userPermissions[j].PermissionID
+	})
+	return
+}
+
+// GetDeniedPermissionWindows get the denied permissions of the user made exactly in the scope with their windows, also the ones that are not valid now.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @return []pivot.UserDeniedPermissions, error
+func (repository *UserRepository) GetDeniedPermissionWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userDeniedPermissions []pivot.UserDeniedPermissions, err error) {
+	if err = repository.Database.rlock(ctx); err != nil {
+		return
+	}
+	defer repository.Database.runlock(ctx)
+
+	for key, userDeniedPermission := range repository.Database.userDeniedPermissions {
+		if key.subject == subject && key.exact(assignment) {
+			userDeniedPermissions = append(userDeniedPermissions, userDeniedPermission)
+		}
+	}
+	sort.Slice(userDeniedPermissions, func(i, j int) bool {
+		return userDeniedPermissions[i].PermissionID < userDeniedPermissions[j].PermissionID
+	})
+	return
+}
+
+// roleIDs returns the distinct ids of the given roles that the user has in the scope, the caller must hold the lock.
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param []uint
+// @return []uint
+func (repository *UserRepository) roleIDs(subject models.Subject, assignment scopes.Assignment, roleIDs []uint) (IDs []uint) {
+	now := time.Now()
+	for key, userRole := range repository.Database.userRoles {
+		if key.subject == subject && helpers.InArray(key.ID, roleIDs) && key.applicable(assignment) && active(userRole.StartsAt, userRole.ExpiresAt, now) {
+			IDs = append(IDs, key.ID)
+		}
+	}
+	return helpers.RemoveDuplicateValues(IDs)
+}
+
+// permissionIDs returns the distinct ids of the given permissions that the user has directly in the scope, the caller must hold the lock.
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param []uint
+// @return []uint
+func (repository *UserRepository) permissionIDs(subject models.Subject, assignment scopes.Assignment, permissionIDs []uint) (IDs []uint) {
+	now := time.Now()
+	for key, userPermission := range repository.Database.userPermissions {
+		if key.subject == subject && helpers.InArray(key.ID, permissionIDs) && key.applicable(assignment) && active(userPermission.StartsAt, userPermission.ExpiresAt, now) {
+			IDs = append(IDs, key.ID)
+		}
+	}
+	return helpers.RemoveDuplicateValues(IDs)
+}
+
+// subjectsWithRoles returns the users that have any of the roles in the scope and are valid now.
+// The caller must hold the lock of the database.
+// @param repositories_scopes.Assignment
+// @param []uint
+// @return map[models.Subject]struct{}
+func (repository *UserRepository) subjectsWithRoles(assignment scopes.Assignment, roleIDs []uint) map[models.Subject]struct{} {
+	now := time.Now()
+	subjects := make(map[models.Subject]struct{})
+	for key, userRole := range repository.Database.userRoles {
+		if helpers.InArray(key.ID, roleIDs) && key.applicable(assignment) && active(userRole.StartsAt, userRole.ExpiresAt, now) {
+			subjects[key.subject] = struct{}{}
+		}
+	}
+	return subjects
+}
+
+// subjectsWithPermissions returns the users that have any of the permissions directly in the scope and are valid now.
+// The caller must hold the lock of the database.
+// @param repositories_scopes.Assignment
+// @param []uint
+// @return map[models.Subject]struct{}
+func (repository *UserRepository) subjectsWithPermissions(assignment scopes.Assignment, permissionIDs []uint) map[models.Subject]struct{} {
+	now := time.Now()
+	subjects := make(map[models.Subject]struct{})
+	for key, userPermission := range repository.Database.userPermissions {
+		if helpers.InArray(key.ID, permissionIDs) && key.applicable(assignment) && active(userPermission.StartsAt, userPermission.ExpiresAt, now) {
+			subjects[key.subject] = struct{}{}
+		}
+	}
+	return subjects
+}
+
+// subjectsWithDeniedPermissions returns the users that are denied any of the permissions in the scope and are valid now.
+// The caller must hold the lock of the database.
+// @param repositories_scopes.Assignment
+// @param []uint
+// @return map[models.Subject]struct{}
+func (repository *UserRepository) subjectsWithDeniedPermissions(assignment scopes.Assignment, permissionIDs []uint) map[models.Subject]struct{} {
+	now := time.Now()
+	subjects := make(map[models.Subject]struct{})
+	for key, userDeniedPermission := range repository.Database.userDeniedPermissions {
+		if helpers.InArray(key.ID, permissionIDs) && key.applicable(assignment) && active(userDeniedPermission.StartsAt, userDeniedPermission.ExpiresAt, now) {
+			subjects[key.subject] = struct{}{}
+		}
+	}
+	return subjects
+}
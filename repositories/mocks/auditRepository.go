@@ -0,0 +1,93 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/repositories"
+	"github.com/Permify/go-role/repositories/scopes"
+)
+
+// AuditRepository is an autogenerated mock type for the AuditRepository type
+type AuditRepository struct {
+	mock.Mock
+}
+
+// Migrate provides a mock function.
+func (_m *AuditRepository) Migrate() (err error) {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Record provides a mock function with given fields: ctx, entry
+func (_m *AuditRepository) Record(ctx context.Context, entry *models.AuditEntry) error {
+	ret := _m.Called(ctx, entry)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.AuditEntry) error); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAuditEntries provides a mock function with given fields: ctx, filter, pagination
+func (_m *AuditRepository) GetAuditEntries(ctx context.Context, filter repositories.AuditFilter, pagination scopes.GormPager) (entries []models.AuditEntry, totalCount int64, err error) {
+	ret := _m.Called(ctx, filter, pagination)
+
+	var r0 []models.AuditEntry
+	if rf, ok := ret.Get(0).(func(context.Context, repositories.AuditFilter, scopes.GormPager) []models.AuditEntry); ok {
+		r0 = rf(ctx, filter, pagination)
+	} else {
+		r0 = ret.Get(0).([]models.AuditEntry)
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, repositories.AuditFilter, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, filter, pagination)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, repositories.AuditFilter, scopes.GormPager) error); ok {
+		r2 = rf(ctx, filter, pagination)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetChainHead provides a mock function with given fields: ctx
+func (_m *AuditRepository) GetChainHead(ctx context.Context) (hash string, err error) {
+	ret := _m.Called(ctx)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -1,10 +1,13 @@
 package mocks
 
 import (
+	"context"
+
 	"github.com/stretchr/testify/mock"
 
 	"github.com/Permify/go-role/collections"
 	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/repositories"
 	"github.com/Permify/go-role/repositories/scopes"
 )
 
@@ -27,20 +30,20 @@ func (_m *PermissionRepository) Migrate() (err error) {
 	return r0
 }
 
-// GetPermissionByID provides a mock function with given fields: ID
-func (_m *PermissionRepository) GetPermissionByID(ID uint) (permission models.Permission, err error) {
-	ret := _m.Called(ID)
+// GetPermissionByID provides a mock function with given fields: ctx, ID
+func (_m *PermissionRepository) GetPermissionByID(ctx context.Context, ID uint) (permission models.Permission, err error) {
+	ret := _m.Called(ctx, ID)
 
 	var r0 models.Permission
-	if rf, ok := ret.Get(0).(func(uint) models.Permission); ok {
-		r0 = rf(ID)
+	if rf, ok := ret.Get(0).(func(context.Context, uint) models.Permission); ok {
+		r0 = rf(ctx, ID)
 	} else {
 		r0 = ret.Get(0).(models.Permission)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint) error); ok {
-		r1 = rf(ID)
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, ID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -48,20 +51,20 @@ func (_m *PermissionRepository) GetPermissionByID(ID uint) (permission models.Pe
 	return r0, r1
 }
 
-// GetPermissionByGuardName provides a mock function with given fields: guardName
-func (_m *PermissionRepository) GetPermissionByGuardName(guardName string) (permission models.Permission, err error) {
-	ret := _m.Called(guardName)
+// GetPermissionByGuardName provides a mock function with given fields: ctx, guardName
+func (_m *PermissionRepository) GetPermissionByGuardName(ctx context.Context, guardName string) (permission models.Permission, err error) {
+	ret := _m.Called(ctx, guardName)
 
 	var r0 models.Permission
-	if rf, ok := ret.Get(0).(func(string) models.Permission); ok {
-		r0 = rf(guardName)
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Permission); ok {
+		r0 = rf(ctx, guardName)
 	} else {
 		r0 = ret.Get(0).(models.Permission)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(guardName)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, guardName)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -69,20 +72,20 @@ func (_m *PermissionRepository) GetPermissionByGuardName(guardName string) (perm
 	return r0, r1
 }
 
-// GetPermissions provides a mock function with given fields: IDs
-func (_m *PermissionRepository) GetPermissions(IDs []uint) (permissions collections.Permission, err error) {
-	ret := _m.Called(IDs)
+// GetPermissions provides a mock function with given fields: ctx, IDs
+func (_m *PermissionRepository) GetPermissions(ctx context.Context, IDs []uint) (permissions collections.Permission, err error) {
+	ret := _m.Called(ctx, IDs)
 
 	var r0 collections.Permission
-	if rf, ok := ret.Get(0).(func([]uint) collections.Permission); ok {
-		r0 = rf(IDs)
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) collections.Permission); ok {
+		r0 = rf(ctx, IDs)
 	} else {
 		r0 = ret.Get(0).(collections.Permission)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]uint) error); ok {
-		r1 = rf(IDs)
+	if rf, ok := ret.Get(1).(func(context.Context, []uint) error); ok {
+		r1 = rf(ctx, IDs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,20 +93,20 @@ func (_m *PermissionRepository) GetPermissions(IDs []uint) (permissions collecti
 	return r0, r1
 }
 
-// GetPermissionsByGuardNames provides a mock function with given fields: guardNames
-func (_m *PermissionRepository) GetPermissionsByGuardNames(guardNames []string) (permissions collections.Permission, err error) {
-	ret := _m.Called(guardNames)
+// GetPermissionsByGuardNames provides a mock function with given fields: ctx, guardNames
+func (_m *PermissionRepository) GetPermissionsByGuardNames(ctx context.Context, guardNames []string) (permissions collections.Permission, err error) {
+	ret := _m.Called(ctx, guardNames)
 
 	var r0 collections.Permission
-	if rf, ok := ret.Get(0).(func([]string) collections.Permission); ok {
-		r0 = rf(guardNames)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) collections.Permission); ok {
+		r0 = rf(ctx, guardNames)
 	} else {
 		r0 = ret.Get(0).(collections.Permission)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]string) error); ok {
-		r1 = rf(guardNames)
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, guardNames)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -111,27 +114,55 @@ func (_m *PermissionRepository) GetPermissionsByGuardNames(guardNames []string)
 	return r0, r1
 }
 
-// GetPermissionIDs provides a mock function with given fields: pagination
-func (_m *PermissionRepository) GetPermissionIDs(pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
-	ret := _m.Called(pagination)
+// GetPermissionIDs provides a mock function with given fields: ctx, pagination
+func (_m *PermissionRepository) GetPermissionIDs(ctx context.Context, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	ret := _m.Called(ctx, pagination)
+
+	var r0 []uint
+	if rf, ok := ret.Get(0).(func(context.Context, scopes.GormPager) []uint); ok {
+		r0 = rf(ctx, pagination)
+	} else {
+		r0 = ret.Get(0).([]uint)
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, pagination)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, scopes.GormPager) error); ok {
+		r2 = rf(ctx, pagination)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetDirectPermissionIDsOfUserByID provides a mock function with given fields: ctx, subject, assignment,  pagination
+func (_m *PermissionRepository) GetDirectPermissionIDsOfUserByID(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	ret := _m.Called(ctx, subject, assignment, pagination)
 
 	var r0 []uint
-	if rf, ok := ret.Get(0).(func(scopes.GormPager) []uint); ok {
-		r0 = rf(pagination)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, scopes.GormPager) []uint); ok {
+		r0 = rf(ctx, subject, assignment, pagination)
 	} else {
 		r0 = ret.Get(0).([]uint)
 	}
 
 	var r1 int64
-	if rf, ok := ret.Get(1).(func(scopes.GormPager) int64); ok {
-		r1 = rf(pagination)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, subject, assignment, pagination)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(scopes.GormPager) error); ok {
-		r2 = rf(pagination)
+	if rf, ok := ret.Get(2).(func(context.Context, models.Subject, scopes.Assignment, scopes.GormPager) error); ok {
+		r2 = rf(ctx, subject, assignment, pagination)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -139,27 +170,27 @@ func (_m *PermissionRepository) GetPermissionIDs(pagination scopes.GormPager) (p
 	return r0, r1, r2
 }
 
-// GetDirectPermissionIDsOfUserByID provides a mock function with given fields: userID,  pagination
-func (_m *PermissionRepository) GetDirectPermissionIDsOfUserByID(userID uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
-	ret := _m.Called(userID, pagination)
+// GetPermissionIDsOfRolesByIDs provides a mock function with given fields: ctx, roleIDs,  pagination
+func (_m *PermissionRepository) GetPermissionIDsOfRolesByIDs(ctx context.Context, roleIDs []uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	ret := _m.Called(ctx, roleIDs, pagination)
 
 	var r0 []uint
-	if rf, ok := ret.Get(0).(func(uint, scopes.GormPager) []uint); ok {
-		r0 = rf(userID, pagination)
+	if rf, ok := ret.Get(0).(func(context.Context, []uint, scopes.GormPager) []uint); ok {
+		r0 = rf(ctx, roleIDs, pagination)
 	} else {
 		r0 = ret.Get(0).([]uint)
 	}
 
 	var r1 int64
-	if rf, ok := ret.Get(1).(func(uint, scopes.GormPager) int64); ok {
-		r1 = rf(userID, pagination)
+	if rf, ok := ret.Get(1).(func(context.Context, []uint, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, roleIDs, pagination)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(uint, scopes.GormPager) error); ok {
-		r2 = rf(userID, pagination)
+	if rf, ok := ret.Get(2).(func(context.Context, []uint, scopes.GormPager) error); ok {
+		r2 = rf(ctx, roleIDs, pagination)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -167,27 +198,27 @@ func (_m *PermissionRepository) GetDirectPermissionIDsOfUserByID(userID uint, pa
 	return r0, r1, r2
 }
 
-// GetPermissionIDsOfRolesByIDs provides a mock function with given fields: roleIDs,  pagination
-func (_m *PermissionRepository) GetPermissionIDsOfRolesByIDs(roleIDs []uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
-	ret := _m.Called(roleIDs, pagination)
+// GetDeniedPermissionIDsOfUserByID provides a mock function with given fields: ctx, subject, assignment, pagination
+func (_m *PermissionRepository) GetDeniedPermissionIDsOfUserByID(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	ret := _m.Called(ctx, subject, assignment, pagination)
 
 	var r0 []uint
-	if rf, ok := ret.Get(0).(func([]uint, scopes.GormPager) []uint); ok {
-		r0 = rf(roleIDs, pagination)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, scopes.GormPager) []uint); ok {
+		r0 = rf(ctx, subject, assignment, pagination)
 	} else {
 		r0 = ret.Get(0).([]uint)
 	}
 
 	var r1 int64
-	if rf, ok := ret.Get(1).(func([]uint, scopes.GormPager) int64); ok {
-		r1 = rf(roleIDs, pagination)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, subject, assignment, pagination)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func([]uint, scopes.GormPager) error); ok {
-		r2 = rf(roleIDs, pagination)
+	if rf, ok := ret.Get(2).(func(context.Context, models.Subject, scopes.Assignment, scopes.GormPager) error); ok {
+		r2 = rf(ctx, subject, assignment, pagination)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -195,13 +226,62 @@ func (_m *PermissionRepository) GetPermissionIDsOfRolesByIDs(roleIDs []uint, pag
 	return r0, r1, r2
 }
 
-// FirstOrCreate provides a mock function with given fields: permission
-func (_m *PermissionRepository) FirstOrCreate(permission *models.Permission) error {
-	ret := _m.Called(permission)
+// GetDeniedPermissionIDsOfRolesByIDs provides a mock function with given fields: ctx, roleIDs, pagination
+func (_m *PermissionRepository) GetDeniedPermissionIDsOfRolesByIDs(ctx context.Context, roleIDs []uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	ret := _m.Called(ctx, roleIDs, pagination)
+
+	var r0 []uint
+	if rf, ok := ret.Get(0).(func(context.Context, []uint, scopes.GormPager) []uint); ok {
+		r0 = rf(ctx, roleIDs, pagination)
+	} else {
+		r0 = ret.Get(0).([]uint)
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, []uint, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, roleIDs, pagination)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, []uint, scopes.GormPager) error); ok {
+		r2 = rf(ctx, roleIDs, pagination)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetEffectivePermissionIDsOfUser provides a mock function with given fields: ctx, subject, assignment, permissionIDs
+func (_m *PermissionRepository) GetEffectivePermissionIDsOfUser(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissionIDs []uint) (effective repositories.EffectivePermissionIDs, err error) {
+	ret := _m.Called(ctx, subject, assignment, permissionIDs)
+
+	var r0 repositories.EffectivePermissionIDs
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, []uint) repositories.EffectivePermissionIDs); ok {
+		r0 = rf(ctx, subject, assignment, permissionIDs)
+	} else {
+		r0 = ret.Get(0).(repositories.EffectivePermissionIDs)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment, []uint) error); ok {
+		r1 = rf(ctx, subject, assignment, permissionIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FirstOrCreate provides a mock function with given fields: ctx, permission
+func (_m *PermissionRepository) FirstOrCreate(ctx context.Context, permission *models.Permission) error {
+	ret := _m.Called(ctx, permission)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Permission) error); ok {
-		r0 = rf(permission)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Permission) error); ok {
+		r0 = rf(ctx, permission)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -209,13 +289,13 @@ func (_m *PermissionRepository) FirstOrCreate(permission *models.Permission) err
 	return r0
 }
 
-// Updates provides a mock function with given fields: permission, updates
-func (_m *PermissionRepository) Updates(permission *models.Permission, updates map[string]interface{}) (err error) {
-	ret := _m.Called(permission, updates)
+// Updates provides a mock function with given fields: ctx, permission, updates
+func (_m *PermissionRepository) Updates(ctx context.Context, permission *models.Permission, updates map[string]interface{}) (err error) {
+	ret := _m.Called(ctx, permission, updates)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Permission, map[string]interface{}) error); ok {
-		r0 = rf(permission, updates)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Permission, map[string]interface{}) error); ok {
+		r0 = rf(ctx, permission, updates)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -223,13 +303,13 @@ func (_m *PermissionRepository) Updates(permission *models.Permission, updates m
 	return r0
 }
 
-// Delete provides a mock function with given fields: permission
-func (_m *PermissionRepository) Delete(permission *models.Permission) (err error) {
-	ret := _m.Called(permission)
+// Delete provides a mock function with given fields: ctx, permission
+func (_m *PermissionRepository) Delete(ctx context.Context, permission *models.Permission) (err error) {
+	ret := _m.Called(ctx, permission)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Permission) error); ok {
-		r0 = rf(permission)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Permission) error); ok {
+		r0 = rf(ctx, permission)
 	} else {
 		r0 = ret.Error(0)
 	}
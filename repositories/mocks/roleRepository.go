@@ -1,10 +1,14 @@
 package mocks
 
 import (
+	"context"
+	"time"
+
 	"github.com/stretchr/testify/mock"
 
 	"github.com/Permify/go-role/collections"
 	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/models/pivot"
 	"github.com/Permify/go-role/repositories/scopes"
 )
 
@@ -27,20 +31,20 @@ func (_m *RoleRepository) Migrate() (err error) {
 	return r0
 }
 
-// GetRoleByID provides a mock function with given fields: ID
-func (_m *RoleRepository) GetRoleByID(ID uint) (role models.Role, err error) {
-	ret := _m.Called(ID)
+// GetRoleByID provides a mock function with given fields: ctx, ID
+func (_m *RoleRepository) GetRoleByID(ctx context.Context, ID uint) (role models.Role, err error) {
+	ret := _m.Called(ctx, ID)
 
 	var r0 models.Role
-	if rf, ok := ret.Get(0).(func(uint) models.Role); ok {
-		r0 = rf(ID)
+	if rf, ok := ret.Get(0).(func(context.Context, uint) models.Role); ok {
+		r0 = rf(ctx, ID)
 	} else {
 		r0 = ret.Get(0).(models.Role)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint) error); ok {
-		r1 = rf(ID)
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, ID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -48,20 +52,20 @@ func (_m *RoleRepository) GetRoleByID(ID uint) (role models.Role, err error) {
 	return r0, r1
 }
 
-// GetRoleByIDWithPermissions provides a mock function with given fields: ID
-func (_m *RoleRepository) GetRoleByIDWithPermissions(ID uint) (role models.Role, err error) {
-	ret := _m.Called(ID)
+// GetRoleByIDWithPermissions provides a mock function with given fields: ctx, ID
+func (_m *RoleRepository) GetRoleByIDWithPermissions(ctx context.Context, ID uint) (role models.Role, err error) {
+	ret := _m.Called(ctx, ID)
 
 	var r0 models.Role
-	if rf, ok := ret.Get(0).(func(uint) models.Role); ok {
-		r0 = rf(ID)
+	if rf, ok := ret.Get(0).(func(context.Context, uint) models.Role); ok {
+		r0 = rf(ctx, ID)
 	} else {
 		r0 = ret.Get(0).(models.Role)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint) error); ok {
-		r1 = rf(ID)
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, ID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -69,20 +73,20 @@ func (_m *RoleRepository) GetRoleByIDWithPermissions(ID uint) (role models.Role,
 	return r0, r1
 }
 
-// GetRoleByGuardName provides a mock function with given fields: guardName
-func (_m *RoleRepository) GetRoleByGuardName(guardName string) (role models.Role, err error) {
-	ret := _m.Called(guardName)
+// GetRoleByGuardName provides a mock function with given fields: ctx, guardName
+func (_m *RoleRepository) GetRoleByGuardName(ctx context.Context, guardName string) (role models.Role, err error) {
+	ret := _m.Called(ctx, guardName)
 
 	var r0 models.Role
-	if rf, ok := ret.Get(0).(func(string) models.Role); ok {
-		r0 = rf(guardName)
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Role); ok {
+		r0 = rf(ctx, guardName)
 	} else {
 		r0 = ret.Get(0).(models.Role)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(guardName)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, guardName)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,20 +94,20 @@ func (_m *RoleRepository) GetRoleByGuardName(guardName string) (role models.Role
 	return r0, r1
 }
 
-// GetRoleByGuardNameWithPermissions provides a mock function with given fields: guardName
-func (_m *RoleRepository) GetRoleByGuardNameWithPermissions(guardName string) (role models.Role, err error) {
-	ret := _m.Called(guardName)
+// GetRoleByGuardNameWithPermissions provides a mock function with given fields: ctx, guardName
+func (_m *RoleRepository) GetRoleByGuardNameWithPermissions(ctx context.Context, guardName string) (role models.Role, err error) {
+	ret := _m.Called(ctx, guardName)
 
 	var r0 models.Role
-	if rf, ok := ret.Get(0).(func(string) models.Role); ok {
-		r0 = rf(guardName)
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Role); ok {
+		r0 = rf(ctx, guardName)
 	} else {
 		r0 = ret.Get(0).(models.Role)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(guardName)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, guardName)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -111,20 +115,20 @@ func (_m *RoleRepository) GetRoleByGuardNameWithPermissions(guardName string) (r
 	return r0, r1
 }
 
-// GetRoles provides a mock function with given fields: IDs
-func (_m *RoleRepository) GetRoles(IDs []uint) (roles collections.Role, err error) {
-	ret := _m.Called(IDs)
+// GetRoles provides a mock function with given fields: ctx, IDs
+func (_m *RoleRepository) GetRoles(ctx context.Context, IDs []uint) (roles collections.Role, err error) {
+	ret := _m.Called(ctx, IDs)
 
 	var r0 collections.Role
-	if rf, ok := ret.Get(0).(func([]uint) collections.Role); ok {
-		r0 = rf(IDs)
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) collections.Role); ok {
+		r0 = rf(ctx, IDs)
 	} else {
 		r0 = ret.Get(0).(collections.Role)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]uint) error); ok {
-		r1 = rf(IDs)
+	if rf, ok := ret.Get(1).(func(context.Context, []uint) error); ok {
+		r1 = rf(ctx, IDs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -132,20 +136,20 @@ func (_m *RoleRepository) GetRoles(IDs []uint) (roles collections.Role, err erro
 	return r0, r1
 }
 
-// GetRolesWithPermissions provides a mock function with given fields: IDs
-func (_m *RoleRepository) GetRolesWithPermissions(IDs []uint) (roles collections.Role, err error) {
-	ret := _m.Called(IDs)
+// GetRolesWithPermissions provides a mock function with given fields: ctx, IDs
+func (_m *RoleRepository) GetRolesWithPermissions(ctx context.Context, IDs []uint) (roles collections.Role, err error) {
+	ret := _m.Called(ctx, IDs)
 
 	var r0 collections.Role
-	if rf, ok := ret.Get(0).(func([]uint) collections.Role); ok {
-		r0 = rf(IDs)
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) collections.Role); ok {
+		r0 = rf(ctx, IDs)
 	} else {
 		r0 = ret.Get(0).(collections.Role)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]uint) error); ok {
-		r1 = rf(IDs)
+	if rf, ok := ret.Get(1).(func(context.Context, []uint) error); ok {
+		r1 = rf(ctx, IDs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -153,20 +157,20 @@ func (_m *RoleRepository) GetRolesWithPermissions(IDs []uint) (roles collections
 	return r0, r1
 }
 
-// GetRolesByGuardNames provides a mock function with given fields: guardNames
-func (_m *RoleRepository) GetRolesByGuardNames(guardNames []string) (roles collections.Role, err error) {
-	ret := _m.Called(guardNames)
+// GetRolesByGuardNames provides a mock function with given fields: ctx, guardNames
+func (_m *RoleRepository) GetRolesByGuardNames(ctx context.Context, guardNames []string) (roles collections.Role, err error) {
+	ret := _m.Called(ctx, guardNames)
 
 	var r0 collections.Role
-	if rf, ok := ret.Get(0).(func([]string) collections.Role); ok {
-		r0 = rf(guardNames)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) collections.Role); ok {
+		r0 = rf(ctx, guardNames)
 	} else {
 		r0 = ret.Get(0).(collections.Role)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]string) error); ok {
-		r1 = rf(guardNames)
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, guardNames)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -174,20 +178,20 @@ func (_m *RoleRepository) GetRolesByGuardNames(guardNames []string) (roles colle
 	return r0, r1
 }
 
-// GetRolesByGuardNamesWithPermissions provides a mock function with given fields: guardNames
-func (_m *RoleRepository) GetRolesByGuardNamesWithPermissions(guardNames []string) (roles collections.Role, err error) {
-	ret := _m.Called(guardNames)
+// GetRolesByGuardNamesWithPermissions provides a mock function with given fields: ctx, guardNames
+func (_m *RoleRepository) GetRolesByGuardNamesWithPermissions(ctx context.Context, guardNames []string) (roles collections.Role, err error) {
+	ret := _m.Called(ctx, guardNames)
 
 	var r0 collections.Role
-	if rf, ok := ret.Get(0).(func([]string) collections.Role); ok {
-		r0 = rf(guardNames)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) collections.Role); ok {
+		r0 = rf(ctx, guardNames)
 	} else {
 		r0 = ret.Get(0).(collections.Role)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func([]string) error); ok {
-		r1 = rf(guardNames)
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, guardNames)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -195,27 +199,55 @@ func (_m *RoleRepository) GetRolesByGuardNamesWithPermissions(guardNames []strin
 	return r0, r1
 }
 
-// GetRoleIDs provides a mock function with given fields: pagination
-func (_m *RoleRepository) GetRoleIDs(pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
-	ret := _m.Called(pagination)
+// GetRoleIDs provides a mock function with given fields: ctx, pagination
+func (_m *RoleRepository) GetRoleIDs(ctx context.Context, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	ret := _m.Called(ctx, pagination)
+
+	var r0 []uint
+	if rf, ok := ret.Get(0).(func(context.Context, scopes.GormPager) []uint); ok {
+		r0 = rf(ctx, pagination)
+	} else {
+		r0 = ret.Get(0).([]uint)
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, pagination)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, scopes.GormPager) error); ok {
+		r2 = rf(ctx, pagination)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetRoleIDsOfUser provides a mock function with given fields: ctx, subject, assignment, pagination
+func (_m *RoleRepository) GetRoleIDsOfUser(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	ret := _m.Called(ctx, subject, assignment, pagination)
 
 	var r0 []uint
-	if rf, ok := ret.Get(0).(func(scopes.GormPager) []uint); ok {
-		r0 = rf(pagination)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, scopes.GormPager) []uint); ok {
+		r0 = rf(ctx, subject, assignment, pagination)
 	} else {
 		r0 = ret.Get(0).([]uint)
 	}
 
 	var r1 int64
-	if rf, ok := ret.Get(1).(func(scopes.GormPager) int64); ok {
-		r1 = rf(pagination)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, subject, assignment, pagination)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(scopes.GormPager) error); ok {
-		r2 = rf(pagination)
+	if rf, ok := ret.Get(2).(func(context.Context, models.Subject, scopes.Assignment, scopes.GormPager) error); ok {
+		r2 = rf(ctx, subject, assignment, pagination)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -223,27 +255,27 @@ func (_m *RoleRepository) GetRoleIDs(pagination scopes.GormPager) (roleIDs []uin
 	return r0, r1, r2
 }
 
-// GetRoleIDsOfUser provides a mock function with given fields: userID, pagination
-func (_m *RoleRepository) GetRoleIDsOfUser(userID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
-	ret := _m.Called(userID, pagination)
+// GetRoleIDsOfPermission provides a mock function with given fields: ctx, userID, pagination
+func (_m *RoleRepository) GetRoleIDsOfPermission(ctx context.Context, permissionID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	ret := _m.Called(ctx, permissionID, pagination)
 
 	var r0 []uint
-	if rf, ok := ret.Get(0).(func(uint, scopes.GormPager) []uint); ok {
-		r0 = rf(userID, pagination)
+	if rf, ok := ret.Get(0).(func(context.Context, uint, scopes.GormPager) []uint); ok {
+		r0 = rf(ctx, permissionID, pagination)
 	} else {
 		r0 = ret.Get(0).([]uint)
 	}
 
 	var r1 int64
-	if rf, ok := ret.Get(1).(func(uint, scopes.GormPager) int64); ok {
-		r1 = rf(userID, pagination)
+	if rf, ok := ret.Get(1).(func(context.Context, uint, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, permissionID, pagination)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(uint, scopes.GormPager) error); ok {
-		r2 = rf(userID, pagination)
+	if rf, ok := ret.Get(2).(func(context.Context, uint, scopes.GormPager) error); ok {
+		r2 = rf(ctx, permissionID, pagination)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -251,27 +283,27 @@ func (_m *RoleRepository) GetRoleIDsOfUser(userID uint, pagination scopes.GormPa
 	return r0, r1, r2
 }
 
-// GetRoleIDsOfPermission provides a mock function with given fields: userID, pagination
-func (_m *RoleRepository) GetRoleIDsOfPermission(permissionID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
-	ret := _m.Called(permissionID, pagination)
+// GetRoleIDsOfDeniedPermission provides a mock function with given fields: ctx, permissionID, pagination
+func (_m *RoleRepository) GetRoleIDsOfDeniedPermission(ctx context.Context, permissionID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	ret := _m.Called(ctx, permissionID, pagination)
 
 	var r0 []uint
-	if rf, ok := ret.Get(0).(func(uint, scopes.GormPager) []uint); ok {
-		r0 = rf(permissionID, pagination)
+	if rf, ok := ret.Get(0).(func(context.Context, uint, scopes.GormPager) []uint); ok {
+		r0 = rf(ctx, permissionID, pagination)
 	} else {
 		r0 = ret.Get(0).([]uint)
 	}
 
 	var r1 int64
-	if rf, ok := ret.Get(1).(func(uint, scopes.GormPager) int64); ok {
-		r1 = rf(permissionID, pagination)
+	if rf, ok := ret.Get(1).(func(context.Context, uint, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, permissionID, pagination)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(uint, scopes.GormPager) error); ok {
-		r2 = rf(permissionID, pagination)
+	if rf, ok := ret.Get(2).(func(context.Context, uint, scopes.GormPager) error); ok {
+		r2 = rf(ctx, permissionID, pagination)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -279,13 +311,69 @@ func (_m *RoleRepository) GetRoleIDsOfPermission(permissionID uint, pagination s
 	return r0, r1, r2
 }
 
-// FirstOrCreate provides a mock function with given fields: permission
-func (_m *RoleRepository) FirstOrCreate(role *models.Role) error {
-	ret := _m.Called(role)
+// FirstOrCreate provides a mock function with given fields: ctx, permission
+func (_m *RoleRepository) FirstOrCreate(ctx context.Context, role *models.Role) error {
+	ret := _m.Called(ctx, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role) error); ok {
+		r0 = rf(ctx, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Updates provides a mock function with given fields: ctx, permission, updates
+func (_m *RoleRepository) Updates(ctx context.Context, role *models.Role, updates map[string]interface{}) (err error) {
+	ret := _m.Called(ctx, role, updates)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role, map[string]interface{}) error); ok {
+		r0 = rf(ctx, role, updates)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: ctx, permission
+func (_m *RoleRepository) Delete(ctx context.Context, role *models.Role) (err error) {
+	ret := _m.Called(ctx, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role) error); ok {
+		r0 = rf(ctx, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddPermissions provides a mock function with given fields: ctx, role, permissions, window
+func (_m *RoleRepository) AddPermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) error {
+	ret := _m.Called(ctx, role, permissions, window)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role, collections.Permission, scopes.Window) error); ok {
+		r0 = rf(ctx, role, permissions, window)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplacePermissions provides a mock function with given fields: ctx, role, permissions, window
+func (_m *RoleRepository) ReplacePermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) error {
+	ret := _m.Called(ctx, role, permissions, window)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Role) error); ok {
-		r0 = rf(role)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role, collections.Permission, scopes.Window) error); ok {
+		r0 = rf(ctx, role, permissions, window)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -293,13 +381,13 @@ func (_m *RoleRepository) FirstOrCreate(role *models.Role) error {
 	return r0
 }
 
-// Updates provides a mock function with given fields: permission, updates
-func (_m *RoleRepository) Updates(role *models.Role, updates map[string]interface{}) (err error) {
-	ret := _m.Called(role, updates)
+// RemovePermissions provides a mock function with given fields: ctx, role, permissions
+func (_m *RoleRepository) RemovePermissions(ctx context.Context, role *models.Role, permissions collections.Permission) error {
+	ret := _m.Called(ctx, role, permissions)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Role, map[string]interface{}) error); ok {
-		r0 = rf(role, updates)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role, collections.Permission) error); ok {
+		r0 = rf(ctx, role, permissions)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -307,13 +395,13 @@ func (_m *RoleRepository) Updates(role *models.Role, updates map[string]interfac
 	return r0
 }
 
-// Delete provides a mock function with given fields: permission
-func (_m *RoleRepository) Delete(role *models.Role) (err error) {
-	ret := _m.Called(role)
+// ClearPermissions provides a mock function with given fields: ctx, role
+func (_m *RoleRepository) ClearPermissions(ctx context.Context, role *models.Role) error {
+	ret := _m.Called(ctx, role)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Role) error); ok {
-		r0 = rf(role)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role) error); ok {
+		r0 = rf(ctx, role)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -321,13 +409,13 @@ func (_m *RoleRepository) Delete(role *models.Role) (err error) {
 	return r0
 }
 
-// AddPermissions provides a mock function with given fields: role, permissions
-func (_m *RoleRepository) AddPermissions(role *models.Role, permissions collections.Permission) error {
-	ret := _m.Called(role, permissions)
+// DenyPermissions provides a mock function with given fields: ctx, role, permissions, window
+func (_m *RoleRepository) DenyPermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) error {
+	ret := _m.Called(ctx, role, permissions, window)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Role, collections.Permission) error); ok {
-		r0 = rf(role, permissions)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role, collections.Permission, scopes.Window) error); ok {
+		r0 = rf(ctx, role, permissions, window)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -335,13 +423,13 @@ func (_m *RoleRepository) AddPermissions(role *models.Role, permissions collecti
 	return r0
 }
 
-// ReplacePermissions provides a mock function with given fields: role, permissions
-func (_m *RoleRepository) ReplacePermissions(role *models.Role, permissions collections.Permission) error {
-	ret := _m.Called(role, permissions)
+// RemoveDeniedPermissions provides a mock function with given fields: ctx, role, permissions
+func (_m *RoleRepository) RemoveDeniedPermissions(ctx context.Context, role *models.Role, permissions collections.Permission) error {
+	ret := _m.Called(ctx, role, permissions)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Role, collections.Permission) error); ok {
-		r0 = rf(role, permissions)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role, collections.Permission) error); ok {
+		r0 = rf(ctx, role, permissions)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -349,13 +437,55 @@ func (_m *RoleRepository) ReplacePermissions(role *models.Role, permissions coll
 	return r0
 }
 
-// RemovePermissions provides a mock function with given fields: role, permissions
-func (_m *RoleRepository) RemovePermissions(role *models.Role, permissions collections.Permission) error {
-	ret := _m.Called(role, permissions)
+// GetChildRoleIDs provides a mock function with given fields: ctx, roleIDs
+func (_m *RoleRepository) GetChildRoleIDs(ctx context.Context, roleIDs []uint) (childRoleIDs []uint, err error) {
+	ret := _m.Called(ctx, roleIDs)
+
+	var r0 []uint
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) []uint); ok {
+		r0 = rf(ctx, roleIDs)
+	} else {
+		r0 = ret.Get(0).([]uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint) error); ok {
+		r1 = rf(ctx, roleIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetParentRoleIDs provides a mock function with given fields: ctx, roleIDs
+func (_m *RoleRepository) GetParentRoleIDs(ctx context.Context, roleIDs []uint) (parentRoleIDs []uint, err error) {
+	ret := _m.Called(ctx, roleIDs)
+
+	var r0 []uint
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) []uint); ok {
+		r0 = rf(ctx, roleIDs)
+	} else {
+		r0 = ret.Get(0).([]uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint) error); ok {
+		r1 = rf(ctx, roleIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddChildren provides a mock function with given fields: ctx, role, children
+func (_m *RoleRepository) AddChildren(ctx context.Context, role *models.Role, children collections.Role) error {
+	ret := _m.Called(ctx, role, children)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Role, collections.Permission) error); ok {
-		r0 = rf(role, permissions)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role, collections.Role) error); ok {
+		r0 = rf(ctx, role, children)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -363,13 +493,13 @@ func (_m *RoleRepository) RemovePermissions(role *models.Role, permissions colle
 	return r0
 }
 
-// ClearPermissions provides a mock function with given fields: role
-func (_m *RoleRepository) ClearPermissions(role *models.Role) error {
-	ret := _m.Called(role)
+// RemoveChildren provides a mock function with given fields: ctx, role, children
+func (_m *RoleRepository) RemoveChildren(ctx context.Context, role *models.Role, children collections.Role) error {
+	ret := _m.Called(ctx, role, children)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Role) error); ok {
-		r0 = rf(role)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Role, collections.Role) error); ok {
+		r0 = rf(ctx, role, children)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -377,20 +507,20 @@ func (_m *RoleRepository) ClearPermissions(role *models.Role) error {
 	return r0
 }
 
-// HasPermission provides a mock function with given fields: role, permissions
-func (_m *RoleRepository) HasPermission(roles collections.Role, permission models.Permission) (b bool, err error) {
-	ret := _m.Called(roles, permission)
+// HasPermission provides a mock function with given fields: ctx, role, permissions
+func (_m *RoleRepository) HasPermission(ctx context.Context, roles collections.Role, permission models.Permission) (b bool, err error) {
+	ret := _m.Called(ctx, roles, permission)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(collections.Role, models.Permission) bool); ok {
-		r0 = rf(roles, permission)
+	if rf, ok := ret.Get(0).(func(context.Context, collections.Role, models.Permission) bool); ok {
+		r0 = rf(ctx, roles, permission)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(collections.Role, models.Permission) error); ok {
-		r1 = rf(roles, permission)
+	if rf, ok := ret.Get(1).(func(context.Context, collections.Role, models.Permission) error); ok {
+		r1 = rf(ctx, roles, permission)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -398,20 +528,20 @@ func (_m *RoleRepository) HasPermission(roles collections.Role, permission model
 	return r0, r1
 }
 
-// HasAllPermissions provides a mock function with given fields: roles, permissions
-func (_m *RoleRepository) HasAllPermissions(roles collections.Role, permissions collections.Permission) (b bool, err error) {
-	ret := _m.Called(roles, permissions)
+// HasAllPermissions provides a mock function with given fields: ctx, roles, permissions
+func (_m *RoleRepository) HasAllPermissions(ctx context.Context, roles collections.Role, permissions collections.Permission) (b bool, err error) {
+	ret := _m.Called(ctx, roles, permissions)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(collections.Role, collections.Permission) bool); ok {
-		r0 = rf(roles, permissions)
+	if rf, ok := ret.Get(0).(func(context.Context, collections.Role, collections.Permission) bool); ok {
+		r0 = rf(ctx, roles, permissions)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(collections.Role, collections.Permission) error); ok {
-		r1 = rf(roles, permissions)
+	if rf, ok := ret.Get(1).(func(context.Context, collections.Role, collections.Permission) error); ok {
+		r1 = rf(ctx, roles, permissions)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -419,20 +549,131 @@ func (_m *RoleRepository) HasAllPermissions(roles collections.Role, permissions
 	return r0, r1
 }
 
-// HasAnyPermissions provides a mock function with given fields: roles, permissions
-func (_m *RoleRepository) HasAnyPermissions(roles collections.Role, permissions collections.Permission) (b bool, err error) {
-	ret := _m.Called(roles, permissions)
+// HasAnyPermissions provides a mock function with given fields: ctx, roles, permissions
+func (_m *RoleRepository) HasAnyPermissions(ctx context.Context, roles collections.Role, permissions collections.Permission) (b bool, err error) {
+	ret := _m.Called(ctx, roles, permissions)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(collections.Role, collections.Permission) bool); ok {
-		r0 = rf(roles, permissions)
+	if rf, ok := ret.Get(0).(func(context.Context, collections.Role, collections.Permission) bool); ok {
+		r0 = rf(ctx, roles, permissions)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(collections.Role, collections.Permission) error); ok {
-		r1 = rf(roles, permissions)
+	if rf, ok := ret.Get(1).(func(context.Context, collections.Role, collections.Permission) error); ok {
+		r1 = rf(ctx, roles, permissions)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeExpiredPermissions provides a mock function with given fields: ctx, before, batchSize
+func (_m *RoleRepository) PurgeExpiredPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	ret := _m.Called(ctx, before, batchSize)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) int64); ok {
+		r0 = rf(ctx, before, batchSize)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, before, batchSize)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeExpiredDeniedPermissions provides a mock function with given fields: ctx, before, batchSize
+func (_m *RoleRepository) PurgeExpiredDeniedPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	ret := _m.Called(ctx, before, batchSize)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) int64); ok {
+		r0 = rf(ctx, before, batchSize)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, before, batchSize)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNextWindowBound provides a mock function with given fields: ctx, roleIDs, after
+func (_m *RoleRepository) GetNextWindowBound(ctx context.Context, roleIDs []uint, after time.Time) (bound *time.Time, err error) {
+	ret := _m.Called(ctx, roleIDs, after)
+
+	var r0 *time.Time
+	if rf, ok := ret.Get(0).(func(context.Context, []uint, time.Time) *time.Time); ok {
+		r0 = rf(ctx, roleIDs, after)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Time)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint, time.Time) error); ok {
+		r1 = rf(ctx, roleIDs, after)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPermissionWindows provides a mock function with given fields: ctx, roleIDs
+func (_m *RoleRepository) GetPermissionWindows(ctx context.Context, roleIDs []uint) (rolePermissions []pivot.RolePermissions, err error) {
+	ret := _m.Called(ctx, roleIDs)
+
+	var r0 []pivot.RolePermissions
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) []pivot.RolePermissions); ok {
+		r0 = rf(ctx, roleIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]pivot.RolePermissions)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint) error); ok {
+		r1 = rf(ctx, roleIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeniedPermissionWindows provides a mock function with given fields: ctx, roleIDs
+func (_m *RoleRepository) GetDeniedPermissionWindows(ctx context.Context, roleIDs []uint) (roleDeniedPermissions []pivot.RoleDeniedPermissions, err error) {
+	ret := _m.Called(ctx, roleIDs)
+
+	var r0 []pivot.RoleDeniedPermissions
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) []pivot.RoleDeniedPermissions); ok {
+		r0 = rf(ctx, roleIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]pivot.RoleDeniedPermissions)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint) error); ok {
+		r1 = rf(ctx, roleIDs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// Transactor is an autogenerated mock type for the Transactor type
+type Transactor struct {
+	mock.Mock
+}
+
+// Transaction provides a mock function with given fields: ctx, fc
+func (_m *Transactor) Transaction(ctx context.Context, fc func(ctx context.Context) error) error {
+	ret := _m.Called(ctx, fc)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(ctx context.Context) error) error); ok {
+		r0 = rf(ctx, fc)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -1,10 +1,16 @@
 package mocks
 
 import (
+	"context"
+	"time"
+
 	"github.com/stretchr/testify/mock"
 
 	"github.com/Permify/go-role/collections"
 	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/models/pivot"
+	"github.com/Permify/go-role/repositories"
+	"github.com/Permify/go-role/repositories/scopes"
 )
 
 // UserRepository is an autogenerated mock type for the UserRepository type
@@ -12,13 +18,27 @@ type UserRepository struct {
 	mock.Mock
 }
 
-// AddPermissions provides a mock function with given fields: userID, permissions
-func (_m *UserRepository) AddPermissions(userID uint, permissions collections.Permission) error {
-	ret := _m.Called(userID, permissions)
+// AddPermissions provides a mock function with given fields: ctx, subject, assignment, permissions
+func (_m *UserRepository) AddPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	ret := _m.Called(ctx, subject, assignment, permissions)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Permission) error); ok {
+		r0 = rf(ctx, subject, assignment, permissions)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplacePermissions provides a mock function with given fields: ctx, subject, assignment, permissions
+func (_m *UserRepository) ReplacePermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	ret := _m.Called(ctx, subject, assignment, permissions)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint, collections.Permission) error); ok {
-		r0 = rf(userID, permissions)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Permission) error); ok {
+		r0 = rf(ctx, subject, assignment, permissions)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -26,13 +46,13 @@ func (_m *UserRepository) AddPermissions(userID uint, permissions collections.Pe
 	return r0
 }
 
-// ReplacePermissions provides a mock function with given fields: userID, permissions
-func (_m *UserRepository) ReplacePermissions(userID uint, permissions collections.Permission) error {
-	ret := _m.Called(userID, permissions)
+// RemovePermissions provides a mock function with given fields: ctx, subject, assignment, permissions
+func (_m *UserRepository) RemovePermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	ret := _m.Called(ctx, subject, assignment, permissions)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint, collections.Permission) error); ok {
-		r0 = rf(userID, permissions)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Permission) error); ok {
+		r0 = rf(ctx, subject, assignment, permissions)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -40,13 +60,13 @@ func (_m *UserRepository) ReplacePermissions(userID uint, permissions collection
 	return r0
 }
 
-// RemovePermissions provides a mock function with given fields: userID, permissions
-func (_m *UserRepository) RemovePermissions(userID uint, permissions collections.Permission) error {
-	ret := _m.Called(userID, permissions)
+// ClearPermissions provides a mock function with given fields: ctx, subject, assignment
+func (_m *UserRepository) ClearPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (err error) {
+	ret := _m.Called(ctx, subject, assignment)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint, collections.Permission) error); ok {
-		r0 = rf(userID, permissions)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment) error); ok {
+		r0 = rf(ctx, subject, assignment)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -54,13 +74,13 @@ func (_m *UserRepository) RemovePermissions(userID uint, permissions collections
 	return r0
 }
 
-// ClearPermissions provides a mock function with given fields: userID
-func (_m *UserRepository) ClearPermissions(userID uint) (err error) {
-	ret := _m.Called(userID)
+// AddRoles provides a mock function with given fields: ctx, subject, assignment, roles
+func (_m *UserRepository) AddRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) error {
+	ret := _m.Called(ctx, subject, assignment, roles)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint) error); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Role) error); ok {
+		r0 = rf(ctx, subject, assignment, roles)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -68,13 +88,13 @@ func (_m *UserRepository) ClearPermissions(userID uint) (err error) {
 	return r0
 }
 
-// AddRoles provides a mock function with given fields: userID, roles
-func (_m *UserRepository) AddRoles(userID uint, roles collections.Role) error {
-	ret := _m.Called(userID, roles)
+// ReplaceRoles provides a mock function with given fields: ctx, subject, assignment, roles
+func (_m *UserRepository) ReplaceRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) error {
+	ret := _m.Called(ctx, subject, assignment, roles)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint, collections.Role) error); ok {
-		r0 = rf(userID, roles)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Role) error); ok {
+		r0 = rf(ctx, subject, assignment, roles)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -82,13 +102,13 @@ func (_m *UserRepository) AddRoles(userID uint, roles collections.Role) error {
 	return r0
 }
 
-// ReplaceRoles provides a mock function with given fields: userID, roles
-func (_m *UserRepository) ReplaceRoles(userID uint, roles collections.Role) error {
-	ret := _m.Called(userID, roles)
+// RemoveRoles provides a mock function with given fields: ctx, subject, assignment, roles
+func (_m *UserRepository) RemoveRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) error {
+	ret := _m.Called(ctx, subject, assignment, roles)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint, collections.Role) error); ok {
-		r0 = rf(userID, roles)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Role) error); ok {
+		r0 = rf(ctx, subject, assignment, roles)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -96,13 +116,13 @@ func (_m *UserRepository) ReplaceRoles(userID uint, roles collections.Role) erro
 	return r0
 }
 
-// RemoveRoles provides a mock function with given fields: userID, roles
-func (_m *UserRepository) RemoveRoles(userID uint, roles collections.Role) error {
-	ret := _m.Called(userID, roles)
+// ClearRoles provides a mock function with given fields: ctx, subject, assignment
+func (_m *UserRepository) ClearRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (err error) {
+	ret := _m.Called(ctx, subject, assignment)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint, collections.Role) error); ok {
-		r0 = rf(userID, roles)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment) error); ok {
+		r0 = rf(ctx, subject, assignment)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -110,13 +130,13 @@ func (_m *UserRepository) RemoveRoles(userID uint, roles collections.Role) error
 	return r0
 }
 
-// ClearRoles provides a mock function with given fields: userID
-func (_m *UserRepository) ClearRoles(userID uint) (err error) {
-	ret := _m.Called(userID)
+// DenyPermissions provides a mock function with given fields: ctx, subject, assignment, permissions
+func (_m *UserRepository) DenyPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	ret := _m.Called(ctx, subject, assignment, permissions)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint) error); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Permission) error); ok {
+		r0 = rf(ctx, subject, assignment, permissions)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -124,20 +144,34 @@ func (_m *UserRepository) ClearRoles(userID uint) (err error) {
 	return r0
 }
 
-// HasRole provides a mock function with given fields: userID, role
-func (_m *UserRepository) HasRole(userID uint, role models.Role) (b bool, err error) {
-	ret := _m.Called(userID, role)
+// RemoveDeniedPermissions provides a mock function with given fields: ctx, subject, assignment, permissions
+func (_m *UserRepository) RemoveDeniedPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	ret := _m.Called(ctx, subject, assignment, permissions)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Permission) error); ok {
+		r0 = rf(ctx, subject, assignment, permissions)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// HasRole provides a mock function with given fields: ctx, subject, assignment, role
+func (_m *UserRepository) HasRole(ctx context.Context, subject models.Subject, assignment scopes.Assignment, role models.Role) (b bool, err error) {
+	ret := _m.Called(ctx, subject, assignment, role)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(uint, models.Role) bool); ok {
-		r0 = rf(userID, role)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, models.Role) bool); ok {
+		r0 = rf(ctx, subject, assignment, role)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint, models.Role) error); ok {
-		r1 = rf(userID, role)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment, models.Role) error); ok {
+		r1 = rf(ctx, subject, assignment, role)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -145,20 +179,20 @@ func (_m *UserRepository) HasRole(userID uint, role models.Role) (b bool, err er
 	return r0, r1
 }
 
-// HasAllRoles provides a mock function with given fields: userID, roles
-func (_m *UserRepository) HasAllRoles(userID uint, roles collections.Role) (b bool, err error) {
-	ret := _m.Called(userID, roles)
+// HasAllRoles provides a mock function with given fields: ctx, subject, assignment, roles
+func (_m *UserRepository) HasAllRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) (b bool, err error) {
+	ret := _m.Called(ctx, subject, assignment, roles)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(uint, collections.Role) bool); ok {
-		r0 = rf(userID, roles)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Role) bool); ok {
+		r0 = rf(ctx, subject, assignment, roles)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint, collections.Role) error); ok {
-		r1 = rf(userID, roles)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment, collections.Role) error); ok {
+		r1 = rf(ctx, subject, assignment, roles)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -166,20 +200,20 @@ func (_m *UserRepository) HasAllRoles(userID uint, roles collections.Role) (b bo
 	return r0, r1
 }
 
-// HasAnyRoles provides a mock function with given fields: userID, roles
-func (_m *UserRepository) HasAnyRoles(userID uint, roles collections.Role) (b bool, err error) {
-	ret := _m.Called(userID, roles)
+// HasAnyRoles provides a mock function with given fields: ctx, subject, assignment, roles
+func (_m *UserRepository) HasAnyRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) (b bool, err error) {
+	ret := _m.Called(ctx, subject, assignment, roles)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(uint, collections.Role) bool); ok {
-		r0 = rf(userID, roles)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Role) bool); ok {
+		r0 = rf(ctx, subject, assignment, roles)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint, collections.Role) error); ok {
-		r1 = rf(userID, roles)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment, collections.Role) error); ok {
+		r1 = rf(ctx, subject, assignment, roles)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -187,20 +221,20 @@ func (_m *UserRepository) HasAnyRoles(userID uint, roles collections.Role) (b bo
 	return r0, r1
 }
 
-// HasDirectPermission provides a mock function with given fields: userID, permission
-func (_m *UserRepository) HasDirectPermission(userID uint, permission models.Permission) (b bool, err error) {
-	ret := _m.Called(userID, permission)
+// HasDirectPermission provides a mock function with given fields: ctx, subject, assignment, permission
+func (_m *UserRepository) HasDirectPermission(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permission models.Permission) (b bool, err error) {
+	ret := _m.Called(ctx, subject, assignment, permission)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(uint, models.Permission) bool); ok {
-		r0 = rf(userID, permission)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, models.Permission) bool); ok {
+		r0 = rf(ctx, subject, assignment, permission)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint, models.Permission) error); ok {
-		r1 = rf(userID, permission)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment, models.Permission) error); ok {
+		r1 = rf(ctx, subject, assignment, permission)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -208,20 +242,20 @@ func (_m *UserRepository) HasDirectPermission(userID uint, permission models.Per
 	return r0, r1
 }
 
-// HasAllDirectPermissions provides a mock function with given fields: userID, permissions
-func (_m *UserRepository) HasAllDirectPermissions(userID uint, permissions collections.Permission) (b bool, err error) {
-	ret := _m.Called(userID, permissions)
+// HasAllDirectPermissions provides a mock function with given fields: ctx, subject, assignment, permissions
+func (_m *UserRepository) HasAllDirectPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (b bool, err error) {
+	ret := _m.Called(ctx, subject, assignment, permissions)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(uint, collections.Permission) bool); ok {
-		r0 = rf(userID, permissions)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Permission) bool); ok {
+		r0 = rf(ctx, subject, assignment, permissions)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint, collections.Permission) error); ok {
-		r1 = rf(userID, permissions)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment, collections.Permission) error); ok {
+		r1 = rf(ctx, subject, assignment, permissions)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -229,20 +263,259 @@ func (_m *UserRepository) HasAllDirectPermissions(userID uint, permissions colle
 	return r0, r1
 }
 
-// HasAnyDirectPermissions provides a mock function with given fields: userID, permissions
-func (_m *UserRepository) HasAnyDirectPermissions(userID uint, permissions collections.Permission) (b bool, err error) {
-	ret := _m.Called(userID, permissions)
+// HasAnyDirectPermissions provides a mock function with given fields: ctx, subject, assignment, permissions
+func (_m *UserRepository) HasAnyDirectPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (b bool, err error) {
+	ret := _m.Called(ctx, subject, assignment, permissions)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(uint, collections.Permission) bool); ok {
-		r0 = rf(userID, permissions)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, collections.Permission) bool); ok {
+		r0 = rf(ctx, subject, assignment, permissions)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint, collections.Permission) error); ok {
-		r1 = rf(userID, permissions)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment, collections.Permission) error); ok {
+		r1 = rf(ctx, subject, assignment, permissions)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserIDsOfRole provides a mock function with given fields: ctx, roleID, assignment, pagination
+func (_m *UserRepository) GetUserIDsOfRole(ctx context.Context, roleID uint, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error) {
+	ret := _m.Called(ctx, roleID, assignment, pagination)
+
+	var r0 []models.Subject
+	if rf, ok := ret.Get(0).(func(context.Context, uint, scopes.Assignment, scopes.GormPager) []models.Subject); ok {
+		r0 = rf(ctx, roleID, assignment, pagination)
+	} else {
+		r0 = ret.Get(0).([]models.Subject)
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, uint, scopes.Assignment, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, roleID, assignment, pagination)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uint, scopes.Assignment, scopes.GormPager) error); ok {
+		r2 = rf(ctx, roleID, assignment, pagination)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetUserIDsWithDirectPermission provides a mock function with given fields: ctx, permissionID, assignment, pagination
+func (_m *UserRepository) GetUserIDsWithDirectPermission(ctx context.Context, permissionID uint, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error) {
+	ret := _m.Called(ctx, permissionID, assignment, pagination)
+
+	var r0 []models.Subject
+	if rf, ok := ret.Get(0).(func(context.Context, uint, scopes.Assignment, scopes.GormPager) []models.Subject); ok {
+		r0 = rf(ctx, permissionID, assignment, pagination)
+	} else {
+		r0 = ret.Get(0).([]models.Subject)
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, uint, scopes.Assignment, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, permissionID, assignment, pagination)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uint, scopes.Assignment, scopes.GormPager) error); ok {
+		r2 = rf(ctx, permissionID, assignment, pagination)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetUserIDsWithPermission provides a mock function with given fields: ctx, holders, assignment, pagination
+func (_m *UserRepository) GetUserIDsWithPermission(ctx context.Context, holders repositories.PermissionHolders, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error) {
+	ret := _m.Called(ctx, holders, assignment, pagination)
+
+	var r0 []models.Subject
+	if rf, ok := ret.Get(0).(func(context.Context, repositories.PermissionHolders, scopes.Assignment, scopes.GormPager) []models.Subject); ok {
+		r0 = rf(ctx, holders, assignment, pagination)
+	} else {
+		r0 = ret.Get(0).([]models.Subject)
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, repositories.PermissionHolders, scopes.Assignment, scopes.GormPager) int64); ok {
+		r1 = rf(ctx, holders, assignment, pagination)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, repositories.PermissionHolders, scopes.Assignment, scopes.GormPager) error); ok {
+		r2 = rf(ctx, holders, assignment, pagination)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// PurgeExpiredRoles provides a mock function with given fields: ctx, before, batchSize
+func (_m *UserRepository) PurgeExpiredRoles(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	ret := _m.Called(ctx, before, batchSize)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) int64); ok {
+		r0 = rf(ctx, before, batchSize)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, before, batchSize)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeExpiredPermissions provides a mock function with given fields: ctx, before, batchSize
+func (_m *UserRepository) PurgeExpiredPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	ret := _m.Called(ctx, before, batchSize)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) int64); ok {
+		r0 = rf(ctx, before, batchSize)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, before, batchSize)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeExpiredDeniedPermissions provides a mock function with given fields: ctx, before, batchSize
+func (_m *UserRepository) PurgeExpiredDeniedPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	ret := _m.Called(ctx, before, batchSize)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) int64); ok {
+		r0 = rf(ctx, before, batchSize)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, before, batchSize)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNextWindowBound provides a mock function with given fields: ctx, subject, assignment, after
+func (_m *UserRepository) GetNextWindowBound(ctx context.Context, subject models.Subject, assignment scopes.Assignment, after time.Time) (bound *time.Time, err error) {
+	ret := _m.Called(ctx, subject, assignment, after)
+
+	var r0 *time.Time
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment, time.Time) *time.Time); ok {
+		r0 = rf(ctx, subject, assignment, after)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Time)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment, time.Time) error); ok {
+		r1 = rf(ctx, subject, assignment, after)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRoleWindows provides a mock function with given fields: ctx, subject, assignment
+func (_m *UserRepository) GetRoleWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userRoles []pivot.UserRoles, err error) {
+	ret := _m.Called(ctx, subject, assignment)
+
+	var r0 []pivot.UserRoles
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment) []pivot.UserRoles); ok {
+		r0 = rf(ctx, subject, assignment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]pivot.UserRoles)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment) error); ok {
+		r1 = rf(ctx, subject, assignment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPermissionWindows provides a mock function with given fields: ctx, subject, assignment
+func (_m *UserRepository) GetPermissionWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userPermissions []pivot.UserPermissions, err error) {
+	ret := _m.Called(ctx, subject, assignment)
+
+	var r0 []pivot.UserPermissions
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment) []pivot.UserPermissions); ok {
+		r0 = rf(ctx, subject, assignment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]pivot.UserPermissions)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment) error); ok {
+		r1 = rf(ctx, subject, assignment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeniedPermissionWindows provides a mock function with given fields: ctx, subject, assignment
+func (_m *UserRepository) GetDeniedPermissionWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userDeniedPermissions []pivot.UserDeniedPermissions, err error) {
+	ret := _m.Called(ctx, subject, assignment)
+
+	var r0 []pivot.UserDeniedPermissions
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subject, scopes.Assignment) []pivot.UserDeniedPermissions); ok {
+		r0 = rf(ctx, subject, assignment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]pivot.UserDeniedPermissions)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subject, scopes.Assignment) error); ok {
+		r1 = rf(ctx, subject, assignment)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1,6 +1,9 @@
 package repositories
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
 
 	"github.com/Permify/go-role/collections"
@@ -15,128 +18,229 @@ type IPermissionRepository interface {
 
 	// single fetch options
 
-	GetPermissionByID(ID uint) (permission models.Permission, err error)
-	GetPermissionByGuardName(guardName string) (permission models.Permission, err error)
+	GetPermissionByID(ctx context.Context, ID uint) (permission models.Permission, err error)
+	GetPermissionByGuardName(ctx context.Context, guardName string) (permission models.Permission, err error)
 
 	// Multiple fetch options
 
-	GetPermissions(IDs []uint) (permissions collections.Permission, err error)
-	GetPermissionsByGuardNames(guardNames []string) (permissions collections.Permission, err error)
+	GetPermissions(ctx context.Context, IDs []uint) (permissions collections.Permission, err error)
+	GetPermissionsByGuardNames(ctx context.Context, guardNames []string) (permissions collections.Permission, err error)
 
 	// ID fetch options
 
-	GetPermissionIDs(pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error)
-	GetDirectPermissionIDsOfUserByID(userID uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error)
-	GetPermissionIDsOfRolesByIDs(roleIDs []uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error)
+	GetPermissionIDs(ctx context.Context, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error)
+	GetDirectPermissionIDsOfUserByID(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error)
+	GetPermissionIDsOfRolesByIDs(ctx context.Context, roleIDs []uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error)
+	GetDeniedPermissionIDsOfUserByID(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error)
+	GetDeniedPermissionIDsOfRolesByIDs(ctx context.Context, roleIDs []uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error)
+	GetEffectivePermissionIDsOfUser(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissionIDs []uint) (effective EffectivePermissionIDs, err error)
 
 	// FirstOrCreate & Updates & Delete
 
-	FirstOrCreate(permission *models.Permission) (err error)
-	Updates(permission *models.Permission, updates map[string]interface{}) (err error)
-	Delete(permission *models.Permission) (err error)
+	FirstOrCreate(ctx context.Context, permission *models.Permission) (err error)
+	Updates(ctx context.Context, permission *models.Permission, updates map[string]interface{}) (err error)
+	Delete(ctx context.Context, permission *models.Permission) (err error)
+}
+
+// EffectivePermissionIDs represents which of the given permission ids are allowed and denied to a user,
+// directly or via the roles of the user and the roles they inherit.
+type EffectivePermissionIDs struct {
+	DirectPermissionIDs       []uint
+	RolePermissionIDs         []uint
+	DirectDeniedPermissionIDs []uint
+	RoleDeniedPermissionIDs   []uint
 }
 
 // PermissionRepository its data access layer of permission.
 type PermissionRepository struct {
 	Database *gorm.DB
+	// UserIDType is the type of the user_id columns created by Migrate. (default models.UserIDUint)
+	UserIDType models.UserIDType
 }
 
 // Migrate generate tables from the database.
+// The user_permissions and user_denied_permissions tables created before are rebuilt if their primary key is not the one of their pivot.
 // @return error
 func (repository *PermissionRepository) Migrate() (err error) {
-	err = repository.Database.AutoMigrate(models.Permission{})
-	err = repository.Database.AutoMigrate(pivot.UserPermissions{})
-	return
+	if err = repository.Database.AutoMigrate(models.Permission{}); err != nil {
+		return
+	}
+	if err = migrateUserPivot(repository.Database.Set(models.UserIDTypeKey, repository.UserIDType), pivot.UserPermissions{}); err != nil {
+		return
+	}
+	return migrateUserPivot(repository.Database.Set(models.UserIDTypeKey, repository.UserIDType), pivot.UserDeniedPermissions{})
 }
 
 // GetPermissionByID get permission by id.
+// @param context.Context
 // @param uint
 // @return models.Permission, error
-func (repository *PermissionRepository) GetPermissionByID(ID uint) (permission models.Permission, err error) {
-	err = repository.Database.First(&permission, "permissions.id = ?", ID).Error
+func (repository *PermissionRepository) GetPermissionByID(ctx context.Context, ID uint) (permission models.Permission, err error) {
+	err = database(ctx, repository.Database).First(&permission, "permissions.id = ?", ID).Error
 	return
 }
 
 // GetPermissionByGuardName get permission by guard name.
+// @param context.Context
 // @param string
 // @return models.Permission, error
-func (repository *PermissionRepository) GetPermissionByGuardName(guardName string) (permission models.Permission, err error) {
-	err = repository.Database.Where("permissions.guard_name = ?", guardName).First(&permission).Error
+func (repository *PermissionRepository) GetPermissionByGuardName(ctx context.Context, guardName string) (permission models.Permission, err error) {
+	err = database(ctx, repository.Database).Where("permissions.guard_name = ?", guardName).First(&permission).Error
 	return
 }
 
 // MULTIPLE FETCH OPTIONS
 
 // GetPermissions get permissions by ids.
+// @param context.Context
 // @param []uint
 // @return collections.Role, error
-func (repository *PermissionRepository) GetPermissions(IDs []uint) (permissions collections.Permission, err error) {
-	err = repository.Database.Where("permissions.id IN (?)", IDs).Find(&permissions).Error
+func (repository *PermissionRepository) GetPermissions(ctx context.Context, IDs []uint) (permissions collections.Permission, err error) {
+	err = database(ctx, repository.Database).Where("permissions.id IN (?)", IDs).Find(&permissions).Error
 	return
 }
 
 // GetPermissionsByGuardNames get permissions by guard names.
+// @param context.Context
 // @param []string
 // @return collections.Permission, error
-func (repository *PermissionRepository) GetPermissionsByGuardNames(guardNames []string) (permissions collections.Permission, err error) {
-	err = repository.Database.Where("permissions.guard_name IN (?)", guardNames).Find(&permissions).Error
+func (repository *PermissionRepository) GetPermissionsByGuardNames(ctx context.Context, guardNames []string) (permissions collections.Permission, err error) {
+	err = database(ctx, repository.Database).Where("permissions.guard_name IN (?)", guardNames).Find(&permissions).Error
 	return
 }
 
 // ID FETCH OPTIONS
 
 // GetPermissionIDs get permission ids. (with pagination)
+// @param context.Context
 // @param repositories_scopes.GormPager
 // @return []uint, int64, error
-func (repository *PermissionRepository) GetPermissionIDs(pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
-	err = repository.Database.Model(&models.Permission{}).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("permissions.id", &permissionIDs).Error
+func (repository *PermissionRepository) GetPermissionIDs(ctx context.Context, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	err = database(ctx, repository.Database).Model(&models.Permission{}).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("permissions.id", &permissionIDs).Error
 	return
 }
 
-// GetDirectPermissionIDsOfUserByID get direct permission ids of user. (with pagination)
-// @param uint
+// GetDirectPermissionIDsOfUserByID get direct permission ids of user that apply in the assignment scope. (with pagination)
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *PermissionRepository) GetDirectPermissionIDsOfUserByID(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	err = database(ctx, repository.Database).Table("user_permissions").Distinct("user_permissions.permission_id").Where("user_permissions.subject_type = ?", subject.Type).Where("user_permissions.user_id = ?", subject.ID).Scopes(assignment.ToApplicable("user_permissions")).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("user_permissions.permission_id", &permissionIDs).Error
+	return
+}
+
+// GetPermissionIDsOfRolesByIDs get permission ids of roles that are valid now. (with pagination)
+// @param context.Context
+// @param []uint
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *PermissionRepository) GetPermissionIDsOfRolesByIDs(ctx context.Context, roleIDs []uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	err = database(ctx, repository.Database).Table("role_permissions").Distinct("role_permissions.permission_id").Where("role_permissions.role_id IN (?)", roleIDs).Scopes(scopes.ToActive("role_permissions", time.Now())).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("role_permissions.permission_id", &permissionIDs).Error
+	return
+}
+
+// GetDeniedPermissionIDsOfUserByID get denied permission ids of user that apply in the assignment scope. (with pagination)
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param repositories_scopes.GormPager
 // @return []uint, int64, error
-func (repository *PermissionRepository) GetDirectPermissionIDsOfUserByID(userID uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
-	err = repository.Database.Table("user_permissions").Where("user_permissions.user_id = ?", userID).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("user_permissions.permission_id", &permissionIDs).Error
+func (repository *PermissionRepository) GetDeniedPermissionIDsOfUserByID(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	err = database(ctx, repository.Database).Table("user_denied_permissions").Distinct("user_denied_permissions.permission_id").Where("user_denied_permissions.subject_type = ?", subject.Type).Where("user_denied_permissions.user_id = ?", subject.ID).Scopes(assignment.ToApplicable("user_denied_permissions")).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("user_denied_permissions.permission_id", &permissionIDs).Error
 	return
 }
 
-// GetPermissionIDsOfRolesByIDs get permission ids of roles. (with pagination)
+// GetDeniedPermissionIDsOfRolesByIDs get denied permission ids of roles that are valid now. (with pagination)
+// @param context.Context
 // @param []uint
 // @param repositories_scopes.GormPager
 // @return []uint, int64, error
-func (repository *PermissionRepository) GetPermissionIDsOfRolesByIDs(roleIDs []uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
-	err = repository.Database.Table("role_permissions").Distinct("role_permissions.permission_id").Where("role_permissions.role_id IN (?)", roleIDs).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("role_permissions.permission_id", &permissionIDs).Error
+func (repository *PermissionRepository) GetDeniedPermissionIDsOfRolesByIDs(ctx context.Context, roleIDs []uint, pagination scopes.GormPager) (permissionIDs []uint, totalCount int64, err error) {
+	err = database(ctx, repository.Database).Table("role_denied_permissions").Distinct("role_denied_permissions.permission_id").Where("role_denied_permissions.role_id IN (?)", roleIDs).Scopes(scopes.ToActive("role_denied_permissions", time.Now())).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("role_denied_permissions.permission_id", &permissionIDs).Error
+	return
+}
+
+// GetEffectivePermissionIDsOfUser get which of the permission ids are allowed and denied to the user in the assignment scope,
+// directly or via the roles of the user and the roles they inherit, that are valid now.
+// It is a single query: the inherited roles are collected with a recursive common table expression, the four kinds of assignments are combined with a union.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param []uint
+// @return EffectivePermissionIDs, error
+func (repository *PermissionRepository) GetEffectivePermissionIDsOfUser(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissionIDs []uint) (effective EffectivePermissionIDs, err error) {
+	db := database(ctx, repository.Database)
+	now := time.Now()
+
+	userRoles := db.Table("user_roles").Select("user_roles.role_id").Where("user_roles.subject_type = ?", subject.Type).Where("user_roles.user_id = ?", subject.ID).Scopes(assignment.ToApplicable("user_roles"))
+	direct := db.Table("user_permissions").Select("'direct' AS source", "user_permissions.permission_id").Where("user_permissions.subject_type = ?", subject.Type).Where("user_permissions.user_id = ?", subject.ID).Where("user_permissions.permission_id IN (?)", permissionIDs).Scopes(assignment.ToApplicable("user_permissions"))
+	viaRoles := db.Table("role_permissions").Select("'role' AS source", "role_permissions.permission_id").Where("role_permissions.role_id IN (SELECT effective_roles.role_id FROM effective_roles)").Where("role_permissions.permission_id IN (?)", permissionIDs).Scopes(scopes.ToActive("role_permissions", now))
+	directDenied := db.Table("user_denied_permissions").Select("'direct_denied' AS source", "user_denied_permissions.permission_id").Where("user_denied_permissions.subject_type = ?", subject.Type).Where("user_denied_permissions.user_id = ?", subject.ID).Where("user_denied_permissions.permission_id IN (?)", permissionIDs).Scopes(assignment.ToApplicable("user_denied_permissions"))
+	deniedViaRoles := db.Table("role_denied_permissions").Select("'role_denied' AS source", "role_denied_permissions.permission_id").Where("role_denied_permissions.role_id IN (SELECT effective_roles.role_id FROM effective_roles)").Where("role_denied_permissions.permission_id IN (?)", permissionIDs).Scopes(scopes.ToActive("role_denied_permissions", now))
+
+	var rows []struct {
+		Source       string
+		PermissionID uint
+	}
+	err = db.Raw("WITH RECURSIVE effective_roles(role_id) AS (? UNION SELECT role_children.child_id FROM role_children INNER JOIN effective_roles ON effective_roles.role_id = role_children.role_id) ? UNION ALL ? UNION ALL ? UNION ALL ?", userRoles, direct, viaRoles, directDenied, deniedViaRoles).Scan(&rows).Error
+	if err != nil {
+		return EffectivePermissionIDs{}, err
+	}
+
+	for _, row := range rows {
+		switch row.Source {
+		case "direct":
+			effective.DirectPermissionIDs = append(effective.DirectPermissionIDs, row.PermissionID)
+		case "role":
+			effective.RolePermissionIDs = append(effective.RolePermissionIDs, row.PermissionID)
+		case "direct_denied":
+			effective.DirectDeniedPermissionIDs = append(effective.DirectDeniedPermissionIDs, row.PermissionID)
+		case "role_denied":
+			effective.RoleDeniedPermissionIDs = append(effective.RoleDeniedPermissionIDs, row.PermissionID)
+		}
+	}
 	return
 }
 
 // FirstOrCreate & Updates & Delete
 
 // FirstOrCreate create new permission if name not exist.
+// @param context.Context
 // @param *models.Permission
 // @return error
-func (repository *PermissionRepository) FirstOrCreate(permission *models.Permission) error {
-	return repository.Database.Where(models.Role{GuardName: permission.GuardName}).FirstOrCreate(permission).Error
+func (repository *PermissionRepository) FirstOrCreate(ctx context.Context, permission *models.Permission) error {
+	return database(ctx, repository.Database).Where(models.Role{GuardName: permission.GuardName}).FirstOrCreate(permission).Error
 }
 
 // Updates update permission.
+// @param context.Context
 // @param *models.Permission
 // @param map[string]interface{}
 // @return error
-func (repository *PermissionRepository) Updates(permission *models.Permission, updates map[string]interface{}) (err error) {
-	return repository.Database.Model(permission).Updates(updates).Error
+func (repository *PermissionRepository) Updates(ctx context.Context, permission *models.Permission, updates map[string]interface{}) (err error) {
+	return database(ctx, repository.Database).Model(permission).Updates(updates).Error
 }
 
 // Delete delete permission.
+// @param context.Context
 // @param *models.Permission
 // @return error
-func (repository *PermissionRepository) Delete(permission *models.Permission) (err error) {
-	return repository.Database.Transaction(func(tx *gorm.DB) error {
+func (repository *PermissionRepository) Delete(ctx context.Context, permission *models.Permission) (err error) {
+	return database(ctx, repository.Database).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("user_permissions.permission_id = ?", permission.ID).Delete(&pivot.UserPermissions{}).Error; err != nil {
 			tx.Rollback()
 			return err
 		}
+		if err := tx.Where("user_denied_permissions.permission_id = ?", permission.ID).Delete(&pivot.UserDeniedPermissions{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Where("role_denied_permissions.permission_id = ?", permission.ID).Delete(&pivot.RoleDeniedPermissions{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
 		if err := tx.Delete(permission).Error; err != nil {
 			tx.Rollback()
 			return err
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"regexp"
 
@@ -11,6 +12,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/repositories/scopes"
 )
 
 var _ = Describe("Permission Repository", func() {
@@ -59,7 +61,7 @@ var _ = Describe("Permission Repository", func() {
 				WithArgs(permission.ID).
 				WillReturnRows(rows)
 
-			value, err := repository.GetPermissionByID(permission.ID)
+			value, err := repository.GetPermissionByID(context.Background(), permission.ID)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(permission))
 		})
@@ -67,7 +69,7 @@ var _ = Describe("Permission Repository", func() {
 		It("not found", func() {
 			// ignore sql match
 			mock.ExpectQuery(`.+`).WillReturnRows(sqlmock.NewRows(nil))
-			_, err := repository.GetPermissionByID(1)
+			_, err := repository.GetPermissionByID(context.Background(), 1)
 			Expect(err).Should(Equal(gorm.ErrRecordNotFound))
 		})
 	})
@@ -89,14 +91,14 @@ var _ = Describe("Permission Repository", func() {
 				WithArgs(permission.GuardName).
 				WillReturnRows(rows)
 
-			value, err := repository.GetPermissionByGuardName(permission.GuardName)
+			value, err := repository.GetPermissionByGuardName(context.Background(), permission.GuardName)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(permission))
 		})
 
 		It("not found", func() {
 			mock.ExpectQuery(`.+`).WillReturnRows(sqlmock.NewRows(nil))
-			_, err := repository.GetPermissionByGuardName("create-contact-permission")
+			_, err := repository.GetPermissionByGuardName(context.Background(), "create-contact-permission")
 			Expect(err).Should(Equal(gorm.ErrRecordNotFound))
 		})
 	})
@@ -120,7 +122,7 @@ var _ = Describe("Permission Repository", func() {
 				WithArgs(permissions[0].ID).
 				WillReturnRows(rows)
 
-			value, err := repository.GetPermissions([]uint{permissions[0].ID})
+			value, err := repository.GetPermissions(context.Background(), []uint{permissions[0].ID})
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value.Origin()).Should(Equal(permissions))
 		})
@@ -145,9 +147,43 @@ var _ = Describe("Permission Repository", func() {
 				WithArgs(permissions[0].GuardName).
 				WillReturnRows(rows)
 
-			value, err := repository.GetPermissionsByGuardNames([]string{permissions[0].GuardName})
+			value, err := repository.GetPermissionsByGuardNames(context.Background(), []string{permissions[0].GuardName})
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value.Origin()).Should(Equal(permissions))
 		})
 	})
+
+	Context("Get Effective Permission IDs Of User", func() {
+		It("found", func() {
+			rows := sqlmock.NewRows([]string{"source", "permission_id"}).
+				AddRow("direct", 1).
+				AddRow("role", 1).
+				AddRow("role", 2).
+				AddRow("role_denied", 2)
+
+			const sqlSelect = `WITH RECURSIVE effective_roles(role_id) AS (SELECT user_roles.role_id FROM "user_roles" WHERE user_roles.subject_type = $1 AND user_roles.user_id = $2 AND (user_roles.starts_at IS NULL OR user_roles.starts_at <= $3) AND (user_roles.expires_at IS NULL OR user_roles.expires_at > $4) AND user_roles.tenant_id IN ($5,$6) AND user_roles.resource_type = $7 UNION SELECT role_children.child_id FROM role_children INNER JOIN effective_roles ON effective_roles.role_id = role_children.role_id) ` +
+				`SELECT 'direct' AS source,user_permissions.permission_id FROM "user_permissions" WHERE user_permissions.subject_type = $8 AND user_permissions.user_id = $9 AND user_permissions.permission_id IN ($10,$11) AND (user_permissions.starts_at IS NULL OR user_permissions.starts_at <= $12) AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > $13) AND user_permissions.tenant_id IN ($14,$15) AND user_permissions.resource_type = $16 ` +
+				`UNION ALL SELECT 'role' AS source,role_permissions.permission_id FROM "role_permissions" WHERE role_permissions.role_id IN (SELECT effective_roles.role_id FROM effective_roles) AND role_permissions.permission_id IN ($17,$18) AND (role_permissions.starts_at IS NULL OR role_permissions.starts_at <= $19) AND (role_permissions.expires_at IS NULL OR role_permissions.expires_at > $20) ` +
+				`UNION ALL SELECT 'direct_denied' AS source,user_denied_permissions.permission_id FROM "user_denied_permissions" WHERE user_denied_permissions.subject_type = $21 AND user_denied_permissions.user_id = $22 AND user_denied_permissions.permission_id IN ($23,$24) AND (user_denied_permissions.starts_at IS NULL OR user_denied_permissions.starts_at <= $25) AND (user_denied_permissions.expires_at IS NULL OR user_denied_permissions.expires_at > $26) AND user_denied_permissions.tenant_id IN ($27,$28) AND user_denied_permissions.resource_type = $29 ` +
+				`UNION ALL SELECT 'role_denied' AS source,role_denied_permissions.permission_id FROM "role_denied_permissions" WHERE role_denied_permissions.role_id IN (SELECT effective_roles.role_id FROM effective_roles) AND role_denied_permissions.permission_id IN ($30,$31) AND (role_denied_permissions.starts_at IS NULL OR role_denied_permissions.starts_at <= $32) AND (role_denied_permissions.expires_at IS NULL OR role_denied_permissions.expires_at > $33)`
+
+			mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+				WithArgs(
+					"user", 1, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "org-a", "",
+					"user", 1, 1, 2, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "org-a", "",
+					1, 2, sqlmock.AnyArg(), sqlmock.AnyArg(),
+					"user", 1, 1, 2, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "org-a", "",
+					1, 2, sqlmock.AnyArg(), sqlmock.AnyArg(),
+				).
+				WillReturnRows(rows)
+
+			value, err := repository.GetEffectivePermissionIDsOfUser(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{TenantID: "org-a"}, []uint{1, 2})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(value).Should(Equal(EffectivePermissionIDs{
+				DirectPermissionIDs:     []uint{1},
+				RolePermissionIDs:       []uint{1, 2},
+				RoleDeniedPermissionIDs: []uint{2},
+			}))
+		})
+	})
 })
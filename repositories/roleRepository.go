@@ -1,7 +1,11 @@
 package repositories
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/Permify/go-role/collections"
 	"github.com/Permify/go-role/models"
@@ -15,181 +19,272 @@ type IRoleRepository interface {
 
 	// single fetch options
 
-	GetRoleByID(ID uint) (role models.Role, err error)
-	GetRoleByIDWithPermissions(ID uint) (role models.Role, err error)
+	GetRoleByID(ctx context.Context, ID uint) (role models.Role, err error)
+	GetRoleByIDWithPermissions(ctx context.Context, ID uint) (role models.Role, err error)
 
-	GetRoleByGuardName(guardName string) (role models.Role, err error)
-	GetRoleByGuardNameWithPermissions(guardName string) (role models.Role, err error)
+	GetRoleByGuardName(ctx context.Context, guardName string) (role models.Role, err error)
+	GetRoleByGuardNameWithPermissions(ctx context.Context, guardName string) (role models.Role, err error)
 
 	// Multiple fetch options
 
-	GetRoles(roleIDs []uint) (roles collections.Role, err error)
-	GetRolesWithPermissions(roleIDs []uint) (roles collections.Role, err error)
+	GetRoles(ctx context.Context, roleIDs []uint) (roles collections.Role, err error)
+	GetRolesWithPermissions(ctx context.Context, roleIDs []uint) (roles collections.Role, err error)
 
-	GetRolesByGuardNames(guardNames []string) (roles collections.Role, err error)
-	GetRolesByGuardNamesWithPermissions(guardNames []string) (roles collections.Role, err error)
+	GetRolesByGuardNames(ctx context.Context, guardNames []string) (roles collections.Role, err error)
+	GetRolesByGuardNamesWithPermissions(ctx context.Context, guardNames []string) (roles collections.Role, err error)
 
 	// ID fetch options
 
-	GetRoleIDs(pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error)
-	GetRoleIDsOfUser(userID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error)
-	GetRoleIDsOfPermission(permissionID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error)
+	GetRoleIDs(ctx context.Context, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error)
+	GetRoleIDsOfUser(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error)
+	GetRoleIDsOfPermission(ctx context.Context, permissionID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error)
+	GetRoleIDsOfDeniedPermission(ctx context.Context, permissionID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error)
 
 	// FirstOrCreate & Updates & Delete
 
-	FirstOrCreate(role *models.Role) (err error)
-	Updates(role *models.Role, updates map[string]interface{}) (err error)
-	Delete(role *models.Role) (err error)
+	FirstOrCreate(ctx context.Context, role *models.Role) (err error)
+	Updates(ctx context.Context, role *models.Role, updates map[string]interface{}) (err error)
+	Delete(ctx context.Context, role *models.Role) (err error)
 
 	// Actions
 
-	AddPermissions(role *models.Role, permissions collections.Permission) (err error)
-	ReplacePermissions(role *models.Role, permissions collections.Permission) (err error)
-	RemovePermissions(role *models.Role, permissions collections.Permission) (err error)
-	ClearPermissions(role *models.Role) (err error)
+	AddPermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) (err error)
+	ReplacePermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) (err error)
+	RemovePermissions(ctx context.Context, role *models.Role, permissions collections.Permission) (err error)
+	ClearPermissions(ctx context.Context, role *models.Role) (err error)
+
+	DenyPermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) (err error)
+	RemoveDeniedPermissions(ctx context.Context, role *models.Role, permissions collections.Permission) (err error)
+
+	// Hierarchy
+
+	GetChildRoleIDs(ctx context.Context, roleIDs []uint) (childRoleIDs []uint, err error)
+	GetParentRoleIDs(ctx context.Context, roleIDs []uint) (parentRoleIDs []uint, err error)
+	AddChildren(ctx context.Context, role *models.Role, children collections.Role) (err error)
+	RemoveChildren(ctx context.Context, role *models.Role, children collections.Role) (err error)
 
 	// Controls
 
-	HasPermission(roles collections.Role, permission models.Permission) (b bool, err error)
-	HasAllPermissions(roles collections.Role, permissions collections.Permission) (b bool, err error)
-	HasAnyPermissions(roles collections.Role, permissions collections.Permission) (b bool, err error)
+	HasPermission(ctx context.Context, roles collections.Role, permission models.Permission) (b bool, err error)
+	HasAllPermissions(ctx context.Context, roles collections.Role, permissions collections.Permission) (b bool, err error)
+	HasAnyPermissions(ctx context.Context, roles collections.Role, permissions collections.Permission) (b bool, err error)
+
+	// Maintenance
+
+	PurgeExpiredPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error)
+	PurgeExpiredDeniedPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error)
+
+	// Windows
+
+	GetNextWindowBound(ctx context.Context, roleIDs []uint, after time.Time) (bound *time.Time, err error)
+	GetPermissionWindows(ctx context.Context, roleIDs []uint) (rolePermissions []pivot.RolePermissions, err error)
+	GetDeniedPermissionWindows(ctx context.Context, roleIDs []uint) (roleDeniedPermissions []pivot.RoleDeniedPermissions, err error)
 }
 
 // RoleRepository its data access layer of role.
 type RoleRepository struct {
 	Database *gorm.DB
+	// UserIDType is the type of the user_id columns created by Migrate. (default models.UserIDUint)
+	UserIDType models.UserIDType
 }
 
 // Migrate generate tables from the database.
+// The user_roles table created before is rebuilt if its primary key is not the one of pivot.UserRoles.
 // @return error
 func (repository *RoleRepository) Migrate() (err error) {
-	err = repository.Database.AutoMigrate(models.Role{})
-	err = repository.Database.AutoMigrate(pivot.UserRoles{})
-	return
+	if err = repository.Database.AutoMigrate(models.Role{}); err != nil {
+		return
+	}
+	if err = repository.Database.AutoMigrate(pivot.RolePermissions{}); err != nil {
+		return
+	}
+	if err = repository.Database.AutoMigrate(pivot.RoleDeniedPermissions{}); err != nil {
+		return
+	}
+	return migrateUserPivot(repository.Database.Set(models.UserIDTypeKey, repository.UserIDType), pivot.UserRoles{})
 }
 
 // SINGLE FETCH OPTIONS
 
 // GetRoleByID get role by id.
+// @param context.Context
 // @param uint
 // @return models.Role, error
-func (repository *RoleRepository) GetRoleByID(ID uint) (role models.Role, err error) {
-	err = repository.Database.First(&role, "roles.id = ?", ID).Error
+func (repository *RoleRepository) GetRoleByID(ctx context.Context, ID uint) (role models.Role, err error) {
+	err = database(ctx, repository.Database).First(&role, "roles.id = ?", ID).Error
 	return
 }
 
-// GetRoleByIDWithPermissions get role by id with its permissions.
+// GetRoleByIDWithPermissions get role by id with its permissions. (only the permissions valid now)
+// @param context.Context
 // @param uint
 // @return models.Role, error
-func (repository *RoleRepository) GetRoleByIDWithPermissions(ID uint) (role models.Role, err error) {
-	err = repository.Database.Preload("Permissions").First(&role, "roles.id = ?", ID).Error
+func (repository *RoleRepository) GetRoleByIDWithPermissions(ctx context.Context, ID uint) (role models.Role, err error) {
+	err = database(ctx, repository.Database).Preload("Permissions").First(&role, "roles.id = ?", ID).Error
+	if err != nil {
+		return
+	}
+
+	err = repository.withActivePermissions(ctx, []*models.Role{&role})
 	return
 }
 
 // GetRoleByGuardName get role by guard name.
+// @param context.Context
 // @param string
 // @return models.Role, error
-func (repository *RoleRepository) GetRoleByGuardName(guardName string) (role models.Role, err error) {
-	err = repository.Database.Where("roles.guard_name = ?", guardName).First(&role).Error
+func (repository *RoleRepository) GetRoleByGuardName(ctx context.Context, guardName string) (role models.Role, err error) {
+	err = database(ctx, repository.Database).Where("roles.guard_name = ?", guardName).First(&role).Error
 	return
 }
 
-// GetRoleByGuardNameWithPermissions get role by guard name with its permissions.
+// GetRoleByGuardNameWithPermissions get role by guard name with its permissions. (only the permissions valid now)
+// @param context.Context
 // @param string
 // @return models.Role, error
-func (repository *RoleRepository) GetRoleByGuardNameWithPermissions(guardName string) (role models.Role, err error) {
-	err = repository.Database.Preload("Permissions").Where("roles.guard_name = ?", guardName).First(&role).Error
+func (repository *RoleRepository) GetRoleByGuardNameWithPermissions(ctx context.Context, guardName string) (role models.Role, err error) {
+	err = database(ctx, repository.Database).Preload("Permissions").Where("roles.guard_name = ?", guardName).First(&role).Error
+	if err != nil {
+		return
+	}
+
+	err = repository.withActivePermissions(ctx, []*models.Role{&role})
 	return
 }
 
 // MULTIPLE FETCH OPTIONS
 
 // GetRoles get roles by ids.
+// @param context.Context
 // @param []uint
 // @return collections.Role, error
-func (repository *RoleRepository) GetRoles(IDs []uint) (roles collections.Role, err error) {
-	err = repository.Database.Where("roles.id IN (?)", IDs).Find(&roles).Error
+func (repository *RoleRepository) GetRoles(ctx context.Context, IDs []uint) (roles collections.Role, err error) {
+	err = database(ctx, repository.Database).Where("roles.id IN (?)", IDs).Find(&roles).Error
 	return
 }
 
-// GetRolesWithPermissions get roles by ids with its permissions.
+// GetRolesWithPermissions get roles by ids with its permissions. (only the permissions valid now)
+// @param context.Context
 // @param []uint
 // @return collections.Role, error
-func (repository *RoleRepository) GetRolesWithPermissions(IDs []uint) (roles collections.Role, err error) {
-	err = repository.Database.Preload("Permissions").Where("roles.id IN (?)", IDs).Find(&roles).Error
+func (repository *RoleRepository) GetRolesWithPermissions(ctx context.Context, IDs []uint) (roles collections.Role, err error) {
+	err = database(ctx, repository.Database).Preload("Permissions").Where("roles.id IN (?)", IDs).Find(&roles).Error
+	if err != nil {
+		return
+	}
+
+	var rolePointers []*models.Role
+	for i := range roles {
+		rolePointers = append(rolePointers, &roles[i])
+	}
+
+	err = repository.withActivePermissions(ctx, rolePointers)
 	return
 }
 
 // GetRolesByGuardNames get roles by guard names.
+// @param context.Context
 // @param []string
 // @return collections.Role, error
-func (repository *RoleRepository) GetRolesByGuardNames(guardNames []string) (roles collections.Role, err error) {
-	err = repository.Database.Where("roles.guard_name IN (?)", guardNames).Find(&roles).Error
+func (repository *RoleRepository) GetRolesByGuardNames(ctx context.Context, guardNames []string) (roles collections.Role, err error) {
+	err = database(ctx, repository.Database).Where("roles.guard_name IN (?)", guardNames).Find(&roles).Error
 	return
 }
 
-// GetRolesByGuardNamesWithPermissions get roles by guard names.
+// GetRolesByGuardNamesWithPermissions get roles by guard names with its permissions. (only the permissions valid now)
+// @param context.Context
 // @param []string
 // @return collections.Role, error
-func (repository *RoleRepository) GetRolesByGuardNamesWithPermissions(guardNames []string) (roles collections.Role, err error) {
-	err = repository.Database.Preload("Permissions").Where("roles.guard_name IN (?)", guardNames).Find(&roles).Error
+func (repository *RoleRepository) GetRolesByGuardNamesWithPermissions(ctx context.Context, guardNames []string) (roles collections.Role, err error) {
+	err = database(ctx, repository.Database).Preload("Permissions").Where("roles.guard_name IN (?)", guardNames).Find(&roles).Error
+	if err != nil {
+		return
+	}
+
+	var rolePointers []*models.Role
+	for i := range roles {
+		rolePointers = append(rolePointers, &roles[i])
+	}
+
+	err = repository.withActivePermissions(ctx, rolePointers)
 	return
 }
 
 // ID FETCH OPTIONS
 
 // GetRoleIDs get role ids. (with pagination)
+// @param context.Context
 // @param repositories_scopes.GormPager
 // @return []uint, int64, error
-func (repository *RoleRepository) GetRoleIDs(pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
-	err = repository.Database.Model(&models.Role{}).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("roles.id", &roleIDs).Error
+func (repository *RoleRepository) GetRoleIDs(ctx context.Context, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	err = database(ctx, repository.Database).Model(&models.Role{}).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("roles.id", &roleIDs).Error
 	return
 }
 
-// GetRoleIDsOfUser get role ids of user. (with pagination)
+// GetRoleIDsOfUser get role ids of user that apply in the assignment scope. (with pagination)
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param repositories_scopes.GormPager
+// @return []uint, int64, error
+func (repository *RoleRepository) GetRoleIDsOfUser(ctx context.Context, subject models.Subject, assignment scopes.Assignment, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	err = database(ctx, repository.Database).Table("user_roles").Distinct("user_roles.role_id").Where("user_roles.subject_type = ?", subject.Type).Where("user_roles.user_id = ?", subject.ID).Scopes(assignment.ToApplicable("user_roles")).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("user_roles.role_id", &roleIDs).Error
+	return
+}
+
+// GetRoleIDsOfPermission get role ids of permission that are valid now. (with pagination)
+// @param context.Context
 // @param uint
 // @param repositories_scopes.GormPager
 // @return []uint, int64, error
-func (repository *RoleRepository) GetRoleIDsOfUser(userID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
-	err = repository.Database.Table("user_roles").Where("user_roles.user_id = ?", userID).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("user_roles.role_id", &roleIDs).Error
+func (repository *RoleRepository) GetRoleIDsOfPermission(ctx context.Context, permissionID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	err = database(ctx, repository.Database).Table("role_permissions").Where("role_permissions.permission_id = ?", permissionID).Scopes(scopes.ToActive("role_permissions", time.Now())).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("role_permissions.role_id", &roleIDs).Error
 	return
 }
 
-// GetRoleIDsOfPermission get role ids of permission. (with pagination)
+// GetRoleIDsOfDeniedPermission get the ids of the roles that deny the permission and are valid now. (with pagination)
+// @param context.Context
 // @param uint
 // @param repositories_scopes.GormPager
 // @return []uint, int64, error
-func (repository *RoleRepository) GetRoleIDsOfPermission(permissionID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
-	err = repository.Database.Table("role_permissions").Where("role_permissions.permission_id = ?", permissionID).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("role_permissions.role_id", &roleIDs).Error
+func (repository *RoleRepository) GetRoleIDsOfDeniedPermission(ctx context.Context, permissionID uint, pagination scopes.GormPager) (roleIDs []uint, totalCount int64, err error) {
+	err = database(ctx, repository.Database).Table("role_denied_permissions").Where("role_denied_permissions.permission_id = ?", permissionID).Scopes(scopes.ToActive("role_denied_permissions", time.Now())).Count(&totalCount).Scopes(repository.paginate(pagination)).Pluck("role_denied_permissions.role_id", &roleIDs).Error
 	return
 }
 
 // FirstOrCreate & Updates & Delete
 
 // FirstOrCreate create new role if name not exist.
+// @param context.Context
 // @param *models.Role
 // @return error
-func (repository *RoleRepository) FirstOrCreate(role *models.Role) error {
-	return repository.Database.Where(models.Role{GuardName: role.GuardName}).FirstOrCreate(role).Error
+func (repository *RoleRepository) FirstOrCreate(ctx context.Context, role *models.Role) error {
+	return database(ctx, repository.Database).Where(models.Role{GuardName: role.GuardName}).FirstOrCreate(role).Error
 }
 
 // Updates update role.
+// @param context.Context
 // @param *models.Role
 // @param map[string]interface{}
 // @return error
-func (repository *RoleRepository) Updates(role *models.Role, updates map[string]interface{}) (err error) {
-	return repository.Database.Model(role).Updates(updates).Error
+func (repository *RoleRepository) Updates(ctx context.Context, role *models.Role, updates map[string]interface{}) (err error) {
+	return database(ctx, repository.Database).Model(role).Updates(updates).Error
 }
 
 // Delete delete role.
+// @param context.Context
 // @param *models.Role
 // @return error
-func (repository *RoleRepository) Delete(role *models.Role) (err error) {
-	return repository.Database.Transaction(func(tx *gorm.DB) error {
+func (repository *RoleRepository) Delete(ctx context.Context, role *models.Role) (err error) {
+	return database(ctx, repository.Database).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("user_roles.role_id = ?", role.ID).Delete(&pivot.UserRoles{}).Error; err != nil {
 			tx.Rollback()
 			return err
 		}
+		if err := tx.Where("role_denied_permissions.role_id = ?", role.ID).Delete(&pivot.RoleDeniedPermissions{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
 		if err := tx.Delete(role).Error; err != nil {
 			tx.Rollback()
 			return err
@@ -200,69 +295,283 @@ func (repository *RoleRepository) Delete(role *models.Role) (err error) {
 
 // ACTIONS
 
-// AddPermissions add permissions to role.
+// AddPermissions add permissions to role, valid in the window.
+// If a permission has been added before, its window is replaced by the given window, or kept if no window is given.
+// @param context.Context
 // @param *models.Role
 // @param collections.Permission
+// @param repositories_scopes.Window
 // @return error
-func (repository *RoleRepository) AddPermissions(role *models.Role, permissions collections.Permission) error {
-	return repository.Database.Model(role).Association("Permissions").Append(permissions.Origin())
+func (repository *RoleRepository) AddPermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) error {
+	var rolePermissions []pivot.RolePermissions
+	for _, permission := range permissions.Origin() {
+		rolePermissions = append(rolePermissions, pivot.RolePermissions{
+			RoleID:       role.ID,
+			PermissionID: permission.ID,
+			StartsAt:     window.StartsAt,
+			ExpiresAt:    window.ExpiresAt,
+		})
+	}
+	return database(ctx, repository.Database).Clauses(repository.onConflictUpdateWindow(window)).Create(&rolePermissions).Error
 }
 
-// ReplacePermissions replace permissions of role.
+// ReplacePermissions replace permissions of role, the new permissions are valid in the window.
+// @param context.Context
 // @param *models.Role
 // @param collections.Permission
+// @param repositories_scopes.Window
 // @return error
-func (repository *RoleRepository) ReplacePermissions(role *models.Role, permissions collections.Permission) error {
-	return repository.Database.Model(role).Association("Permissions").Replace(permissions.Origin())
+func (repository *RoleRepository) ReplacePermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) error {
+	return database(ctx, repository.Database).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_permissions.role_id = ?", role.ID).Delete(&pivot.RolePermissions{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		var rolePermissions []pivot.RolePermissions
+		for _, permission := range permissions.Origin() {
+			rolePermissions = append(rolePermissions, pivot.RolePermissions{
+				RoleID:       role.ID,
+				PermissionID: permission.ID,
+				StartsAt:     window.StartsAt,
+				ExpiresAt:    window.ExpiresAt,
+			})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rolePermissions).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		return nil
+	})
 }
 
 // RemovePermissions remove permissions of role.
+// @param context.Context
 // @param *models.Role
 // @param collections.Permission
 // @return error
-func (repository *RoleRepository) RemovePermissions(role *models.Role, permissions collections.Permission) error {
-	return repository.Database.Model(role).Association("Permissions").Delete(permissions.Origin())
+func (repository *RoleRepository) RemovePermissions(ctx context.Context, role *models.Role, permissions collections.Permission) error {
+	return database(ctx, repository.Database).Model(role).Association("Permissions").Delete(permissions.Origin())
 }
 
 // ClearPermissions remove all permissions of role.
+// @param context.Context
 // @param *models.Role
 // @return error
-func (repository *RoleRepository) ClearPermissions(role *models.Role) (err error) {
-	return repository.Database.Model(role).Association("Permissions").Clear()
+func (repository *RoleRepository) ClearPermissions(ctx context.Context, role *models.Role) (err error) {
+	return database(ctx, repository.Database).Model(role).Association("Permissions").Clear()
+}
+
+// DenyPermissions deny permissions to role, valid in the window. The denied permissions block the permissions for the users of the role.
+// If a permission has been denied before, its window is replaced by the given window, or kept if no window is given.
+// @param context.Context
+// @param *models.Role
+// @param collections.Permission
+// @param repositories_scopes.Window
+// @return error
+func (repository *RoleRepository) DenyPermissions(ctx context.Context, role *models.Role, permissions collections.Permission, window scopes.Window) error {
+	var roleDeniedPermissions []pivot.RoleDeniedPermissions
+	for _, permission := range permissions.Origin() {
+		roleDeniedPermissions = append(roleDeniedPermissions, pivot.RoleDeniedPermissions{
+			RoleID:       role.ID,
+			PermissionID: permission.ID,
+			StartsAt:     window.StartsAt,
+			ExpiresAt:    window.ExpiresAt,
+		})
+	}
+	return database(ctx, repository.Database).Clauses(repository.onConflictUpdateWindow(window)).Create(&roleDeniedPermissions).Error
+}
+
+// RemoveDeniedPermissions remove denied permissions of role.
+// @param context.Context
+// @param *models.Role
+// @param collections.Permission
+// @return error
+func (repository *RoleRepository) RemoveDeniedPermissions(ctx context.Context, role *models.Role, permissions collections.Permission) error {
+	return database(ctx, repository.Database).Where("role_denied_permissions.role_id = ?", role.ID).Where("role_denied_permissions.permission_id IN (?)", permissions.IDs()).Delete(&pivot.RoleDeniedPermissions{}).Error
+}
+
+// HIERARCHY
+
+// GetChildRoleIDs get the ids of the direct child roles of the roles.
+// @param context.Context
+// @param []uint
+// @return []uint, error
+func (repository *RoleRepository) GetChildRoleIDs(ctx context.Context, roleIDs []uint) (childRoleIDs []uint, err error) {
+	err = database(ctx, repository.Database).Table("role_children").Distinct("role_children.child_id").Where("role_children.role_id IN (?)", roleIDs).Pluck("role_children.child_id", &childRoleIDs).Error
+	return
+}
+
+// GetParentRoleIDs get the ids of the roles that directly inherit the roles.
+// @param context.Context
+// @param []uint
+// @return []uint, error
+func (repository *RoleRepository) GetParentRoleIDs(ctx context.Context, roleIDs []uint) (parentRoleIDs []uint, err error) {
+	err = database(ctx, repository.Database).Table("role_children").Distinct("role_children.role_id").Where("role_children.child_id IN (?)", roleIDs).Pluck("role_children.role_id", &parentRoleIDs).Error
+	return
+}
+
+// AddChildren add child roles to role.
+// @param context.Context
+// @param *models.Role
+// @param collections.Role
+// @return error
+func (repository *RoleRepository) AddChildren(ctx context.Context, role *models.Role, children collections.Role) error {
+	return database(ctx, repository.Database).Model(role).Association("Children").Append(children.Origin())
+}
+
+// RemoveChildren remove child roles of role.
+// @param context.Context
+// @param *models.Role
+// @param collections.Role
+// @return error
+func (repository *RoleRepository) RemoveChildren(ctx context.Context, role *models.Role, children collections.Role) error {
+	return database(ctx, repository.Database).Model(role).Association("Children").Delete(children.Origin())
 }
 
 // Controls
 
-// HasPermission does the role or any of the roles have given permission?
+// HasPermission does the role or any of the roles have given permission? (valid now)
+// @param context.Context
 // @param collections.Role
 // @param models.Permission
 // @return bool, error
-func (repository *RoleRepository) HasPermission(roles collections.Role, permission models.Permission) (b bool, err error) {
+func (repository *RoleRepository) HasPermission(ctx context.Context, roles collections.Role, permission models.Permission) (b bool, err error) {
 	var count int64
-	err = repository.Database.Table("role_permissions").Where("role_permissions.role_id IN (?)", roles.IDs()).Where("role_permissions.permission_id = ?", permission.ID).Count(&count).Error
+	err = database(ctx, repository.Database).Table("role_permissions").Where("role_permissions.role_id IN (?)", roles.IDs()).Where("role_permissions.permission_id = ?", permission.ID).Scopes(scopes.ToActive("role_permissions", time.Now())).Count(&count).Error
 	return count > 0, err
 }
 
-// HasAllPermissions does the role or roles have all the given permissions?
+// HasAllPermissions does the role or roles have all the given permissions? (valid now)
+// @param context.Context
 // @param collections.Role
 // @param collections.Permission
 // @return bool, error
-func (repository *RoleRepository) HasAllPermissions(roles collections.Role, permissions collections.Permission) (b bool, err error) {
+func (repository *RoleRepository) HasAllPermissions(ctx context.Context, roles collections.Role, permissions collections.Permission) (b bool, err error) {
 	var count int64
-	err = repository.Database.Table("role_permissions").Where("role_permissions.role_id IN (?)", roles.IDs()).Where("role_permissions.permission_id IN (?)", permissions.IDs()).Count(&count).Error
+	err = database(ctx, repository.Database).Table("role_permissions").Where("role_permissions.role_id IN (?)", roles.IDs()).Where("role_permissions.permission_id IN (?)", permissions.IDs()).Scopes(scopes.ToActive("role_permissions", time.Now())).Count(&count).Error
 	return roles.Len()*permissions.Len() == count, err
 }
 
-// HasAnyPermissions does the role or roles have any of the given permissions?
+// HasAnyPermissions does the role or roles have any of the given permissions? (valid now)
+// @param context.Context
 // @param collections.Role
 // @param collections.Permission
 // @return bool, error
-func (repository *RoleRepository) HasAnyPermissions(roles collections.Role, permissions collections.Permission) (b bool, err error) {
+func (repository *RoleRepository) HasAnyPermissions(ctx context.Context, roles collections.Role, permissions collections.Permission) (b bool, err error) {
 	var count int64
-	err = repository.Database.Table("role_permissions").Where("role_permissions.role_id IN (?)", roles.IDs()).Where("role_permissions.permission_id IN (?)", permissions.IDs()).Count(&count).Error
+	err = database(ctx, repository.Database).Table("role_permissions").Where("role_permissions.role_id IN (?)", roles.IDs()).Where("role_permissions.permission_id IN (?)", permissions.IDs()).Scopes(scopes.ToActive("role_permissions", time.Now())).Count(&count).Error
 	return count > 0, err
 }
 
+// MAINTENANCE
+
+// PurgeExpiredPermissions delete the permission assignments of roles that expired before the given time, batch by batch.
+// Each batch deletes the expired assignments of at most batchSize roles.
+// @param context.Context
+// @param time.Time
+// @param int
+// @return int64, error
+func (repository *RoleRepository) PurgeExpiredPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	return purgeExpired(database(ctx, repository.Database), &pivot.RolePermissions{}, "role_permissions", "role_id", before, batchSize)
+}
+
+// PurgeExpiredDeniedPermissions delete the denied permissions of roles that expired before the given time, batch by batch.
+// Each batch deletes the expired denies of at most batchSize roles.
+// @param context.Context
+// @param time.Time
+// @param int
+// @return int64, error
+func (repository *RoleRepository) PurgeExpiredDeniedPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	return purgeExpired(database(ctx, repository.Database), &pivot.RoleDeniedPermissions{}, "role_denied_permissions", "role_id", before, batchSize)
+}
+
+// WINDOWS
+
+// GetNextWindowBound get the earliest time after the given time at which one of the permissions or denied permissions of the roles starts or expires.
+// It is nil if none of them starts or expires after the given time.
+// @param context.Context
+// @param []uint
+// @param time.Time
+// @return *time.Time, error
+func (repository *RoleRepository) GetNextWindowBound(ctx context.Context, roleIDs []uint, after time.Time) (bound *time.Time, err error) {
+	return nextWindowBound(database(ctx, repository.Database), []string{"role_permissions", "role_denied_permissions"}, func(table string) func(db *gorm.DB) *gorm.DB {
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where(table+".role_id IN (?)", roleIDs)
+		}
+	}, after)
+}
+
+// GetPermissionWindows get the permissions of the roles with their windows, also the ones that are not valid now.
+// @param context.Context
+// @param []uint
+// @return []pivot.RolePermissions, error
+func (repository *RoleRepository) GetPermissionWindows(ctx context.Context, roleIDs []uint) (rolePermissions []pivot.RolePermissions, err error) {
+	err = database(ctx, repository.Database).Where("role_permissions.role_id IN (?)", roleIDs).Order("role_permissions.role_id, role_permissions.permission_id").Find(&rolePermissions).Error
+	return
+}
+
+// GetDeniedPermissionWindows get the denied permissions of the roles with their windows, also the ones that are not valid now.
+// @param context.Context
+// @param []uint
+// @return []pivot.RoleDeniedPermissions, error
+func (repository *RoleRepository) GetDeniedPermissionWindows(ctx context.Context, roleIDs []uint) (roleDeniedPermissions []pivot.RoleDeniedPermissions, err error) {
+	err = database(ctx, repository.Database).Where("role_denied_permissions.role_id IN (?)", roleIDs).Order("role_denied_permissions.role_id, role_denied_permissions.permission_id").Find(&roleDeniedPermissions).Error
+	return
+}
+
+// withActivePermissions removes the preloaded permissions of the roles that are not valid now.
+// @param context.Context
+// @param []*models.Role
+// @return error
+func (repository *RoleRepository) withActivePermissions(ctx context.Context, roles []*models.Role) (err error) {
+	var roleIDs []uint
+	for _, role := range roles {
+		roleIDs = append(roleIDs, role.ID)
+	}
+
+	if len(roleIDs) == 0 {
+		return
+	}
+
+	var rolePermissions []pivot.RolePermissions
+	err = database(ctx, repository.Database).Where("role_permissions.role_id IN (?)", roleIDs).Scopes(scopes.ToActive("role_permissions", time.Now())).Find(&rolePermissions).Error
+	if err != nil {
+		return
+	}
+
+	active := make(map[[2]uint]bool)
+	for _, rolePermission := range rolePermissions {
+		active[[2]uint{rolePermission.RoleID, rolePermission.PermissionID}] = true
+	}
+
+	for _, role := range roles {
+		var permissions []models.Permission
+		for _, permission := range role.Permissions {
+			if active[[2]uint{role.ID, permission.ID}] {
+				permissions = append(permissions, permission)
+			}
+		}
+		role.Permissions = permissions
+	}
+
+	return
+}
+
+// onConflictUpdateWindow replaces the time window of the permissions of the role that already exist by the given window.
+// If no window is given, the permissions that already exist are kept as they are, so that adding them again does not make them permanent.
+// @param repositories_scopes.Window
+// @return clause.OnConflict
+func (repository *RoleRepository) onConflictUpdateWindow(window scopes.Window) clause.OnConflict {
+	columns := []clause.Column{{Name: "role_id"}, {Name: "permission_id"}}
+	if window.IsZero() {
+		return clause.OnConflict{Columns: columns, DoNothing: true}
+	}
+	return clause.OnConflict{
+		Columns:   columns,
+		DoUpdates: clause.AssignmentColumns([]string{"starts_at", "expires_at"}),
+	}
+}
+
 // paginate pagging if pagination option is true.
 // @param repositories_scopes.GormPager
 // @return func(db *gorm.DB) *gorm.DB
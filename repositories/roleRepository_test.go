@@ -1,8 +1,11 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"regexp"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	. "github.com/onsi/ginkgo"
@@ -12,6 +15,7 @@ import (
 
 	"github.com/Permify/go-role/collections"
 	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/models/pivot"
 )
 
 var _ = Describe("Role Repository", func() {
@@ -60,7 +64,7 @@ var _ = Describe("Role Repository", func() {
 				WithArgs(role.ID).
 				WillReturnRows(rows)
 
-			db, err := repository.GetRoleByID(role.ID)
+			db, err := repository.GetRoleByID(context.Background(), role.ID)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(db).Should(Equal(role))
 		})
@@ -68,9 +72,17 @@ var _ = Describe("Role Repository", func() {
 		It("not found", func() {
 			// ignore sql match
 			mock.ExpectQuery(`.+`).WillReturnRows(sqlmock.NewRows(nil))
-			_, err := repository.GetRoleByID(1)
+			_, err := repository.GetRoleByID(context.Background(), 1)
 			Expect(err).Should(Equal(gorm.ErrRecordNotFound))
 		})
+
+		It("cancelled context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := repository.GetRoleByID(ctx, 1)
+			Expect(errors.Is(err, context.Canceled)).Should(BeTrue())
+		})
 	})
 
 	Context("Get Role By Guard Name", func() {
@@ -90,14 +102,14 @@ var _ = Describe("Role Repository", func() {
 				WithArgs(role.GuardName).
 				WillReturnRows(rows)
 
-			db, err := repository.GetRoleByGuardName(role.GuardName)
+			db, err := repository.GetRoleByGuardName(context.Background(), role.GuardName)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(db).Should(Equal(role))
 		})
 
 		It("not found", func() {
 			mock.ExpectQuery(`.+`).WillReturnRows(sqlmock.NewRows(nil))
-			_, err := repository.GetRoleByGuardName("admin")
+			_, err := repository.GetRoleByGuardName(context.Background(), "admin")
 			Expect(err).Should(Equal(gorm.ErrRecordNotFound))
 		})
 	})
@@ -121,7 +133,7 @@ var _ = Describe("Role Repository", func() {
 				WithArgs(roles[0].ID).
 				WillReturnRows(rows)
 
-			value, err := repository.GetRoles([]uint{roles[0].ID})
+			value, err := repository.GetRoles(context.Background(), []uint{roles[0].ID})
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value.Origin()).Should(Equal(roles))
 		})
@@ -146,7 +158,7 @@ var _ = Describe("Role Repository", func() {
 				WithArgs(roles[0].GuardName).
 				WillReturnRows(rows)
 
-			value, err := repository.GetRolesByGuardNames([]string{roles[0].GuardName})
+			value, err := repository.GetRolesByGuardNames(context.Background(), []string{roles[0].GuardName})
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value.Origin()).Should(Equal(roles))
 		})
@@ -154,27 +166,27 @@ var _ = Describe("Role Repository", func() {
 
 	Context("Has Permission", func() {
 		It("found", func() {
-			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1) AND role_permissions.permission_id = $2`
+			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1) AND role_permissions.permission_id = $2 AND (role_permissions.starts_at IS NULL OR role_permissions.starts_at <= $3) AND (role_permissions.expires_at IS NULL OR role_permissions.expires_at > $4)`
 
 			mock.ExpectQuery(regexp.QuoteMeta(sqlSelectOne)).
-				WithArgs(1, 1).
+				WithArgs(1, 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(1))
 
-			db, err := repository.HasPermission(collections.Role([]models.Role{{ID: 1}}), models.Permission{ID: 1})
+			db, err := repository.HasPermission(context.Background(), collections.Role([]models.Role{{ID: 1}}), models.Permission{ID: 1})
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(db).Should(Equal(true))
 		})
 
 		It("not found", func() {
-			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1) AND role_permissions.permission_id = $2`
+			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1) AND role_permissions.permission_id = $2 AND (role_permissions.starts_at IS NULL OR role_permissions.starts_at <= $3) AND (role_permissions.expires_at IS NULL OR role_permissions.expires_at > $4)`
 
 			mock.ExpectQuery(regexp.QuoteMeta(sqlSelectOne)).
-				WithArgs(1, 1).
+				WithArgs(1, 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(0))
 
-			db, err := repository.HasPermission(collections.Role([]models.Role{{ID: 1}}), models.Permission{ID: 1})
+			db, err := repository.HasPermission(context.Background(), collections.Role([]models.Role{{ID: 1}}), models.Permission{ID: 1})
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(db).Should(Equal(false))
 		})
@@ -182,27 +194,27 @@ var _ = Describe("Role Repository", func() {
 
 	Context("Has All Permission", func() {
 		It("found", func() {
-			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1) AND role_permissions.permission_id IN ($2)`
+			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1) AND role_permissions.permission_id IN ($2) AND (role_permissions.starts_at IS NULL OR role_permissions.starts_at <= $3) AND (role_permissions.expires_at IS NULL OR role_permissions.expires_at > $4)`
 
 			mock.ExpectQuery(regexp.QuoteMeta(sqlSelectOne)).
-				WithArgs(1, 1).
+				WithArgs(1, 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(1))
 
-			db, err := repository.HasAllPermissions(collections.Role([]models.Role{{ID: 1}}), collections.Permission([]models.Permission{{ID: 1}}))
+			db, err := repository.HasAllPermissions(context.Background(), collections.Role([]models.Role{{ID: 1}}), collections.Permission([]models.Permission{{ID: 1}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(db).Should(Equal(true))
 		})
 
 		It("not found", func() {
-			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1,$2) AND role_permissions.permission_id IN ($3)`
+			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1,$2) AND role_permissions.permission_id IN ($3) AND (role_permissions.starts_at IS NULL OR role_permissions.starts_at <= $4) AND (role_permissions.expires_at IS NULL OR role_permissions.expires_at > $5)`
 
 			mock.ExpectQuery(regexp.QuoteMeta(sqlSelectOne)).
-				WithArgs(1, 2, 1).
+				WithArgs(1, 2, 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(1))
 
-			db, err := repository.HasAllPermissions(collections.Role([]models.Role{{ID: 1}, {ID: 2}}), collections.Permission([]models.Permission{{ID: 1}}))
+			db, err := repository.HasAllPermissions(context.Background(), collections.Role([]models.Role{{ID: 1}, {ID: 2}}), collections.Permission([]models.Permission{{ID: 1}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(db).Should(Equal(false))
 		})
@@ -210,29 +222,88 @@ var _ = Describe("Role Repository", func() {
 
 	Context("Has Any Permission", func() {
 		It("found", func() {
-			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1) AND role_permissions.permission_id IN ($2)`
+			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1) AND role_permissions.permission_id IN ($2) AND (role_permissions.starts_at IS NULL OR role_permissions.starts_at <= $3) AND (role_permissions.expires_at IS NULL OR role_permissions.expires_at > $4)`
 
 			mock.ExpectQuery(regexp.QuoteMeta(sqlSelectOne)).
-				WithArgs(1, 1).
+				WithArgs(1, 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(1))
 
-			db, err := repository.HasAnyPermissions(collections.Role([]models.Role{{ID: 1}}), collections.Permission([]models.Permission{{ID: 1}}))
+			db, err := repository.HasAnyPermissions(context.Background(), collections.Role([]models.Role{{ID: 1}}), collections.Permission([]models.Permission{{ID: 1}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(db).Should(Equal(true))
 		})
 
 		It("found", func() {
-			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1) AND role_permissions.permission_id IN ($2)`
+			const sqlSelectOne = `SELECT count(*) FROM "role_permissions" WHERE role_permissions.role_id IN ($1) AND role_permissions.permission_id IN ($2) AND (role_permissions.starts_at IS NULL OR role_permissions.starts_at <= $3) AND (role_permissions.expires_at IS NULL OR role_permissions.expires_at > $4)`
 
 			mock.ExpectQuery(regexp.QuoteMeta(sqlSelectOne)).
-				WithArgs(1, 1).
+				WithArgs(1, 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(0))
 
-			db, err := repository.HasAnyPermissions(collections.Role([]models.Role{{ID: 1}}), collections.Permission([]models.Permission{{ID: 1}}))
+			db, err := repository.HasAnyPermissions(context.Background(), collections.Role([]models.Role{{ID: 1}}), collections.Permission([]models.Permission{{ID: 1}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(db).Should(Equal(false))
 		})
 	})
+
+	Context("Get Child Role IDs", func() {
+		It("found", func() {
+			const sqlSelect = `SELECT DISTINCT role_children.child_id FROM "role_children" WHERE role_children.role_id IN ($1,$2)`
+
+			mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+				WithArgs(1, 2).
+				WillReturnRows(sqlmock.NewRows([]string{"child_id"}).
+					AddRow(3).
+					AddRow(4))
+
+			childRoleIDs, err := repository.GetChildRoleIDs(context.Background(), []uint{1, 2})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(childRoleIDs).Should(Equal([]uint{3, 4}))
+		})
+
+		It("not found", func() {
+			const sqlSelect = `SELECT DISTINCT role_children.child_id FROM "role_children" WHERE role_children.role_id IN ($1)`
+
+			mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"child_id"}))
+
+			childRoleIDs, err := repository.GetChildRoleIDs(context.Background(), []uint{1})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(childRoleIDs).Should(BeEmpty())
+		})
+	})
+
+	Context("Get Parent Role IDs", func() {
+		It("found", func() {
+			const sqlSelect = `SELECT DISTINCT role_children.role_id FROM "role_children" WHERE role_children.child_id IN ($1,$2)`
+
+			mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+				WithArgs(3, 4).
+				WillReturnRows(sqlmock.NewRows([]string{"role_id"}).
+					AddRow(1))
+
+			parentRoleIDs, err := repository.GetParentRoleIDs(context.Background(), []uint{3, 4})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(parentRoleIDs).Should(Equal([]uint{1}))
+		})
+	})
+
+	Context("Get Permission Windows", func() {
+		It("found", func() {
+			const sqlSelect = `SELECT * FROM "role_permissions" WHERE role_permissions.role_id IN ($1) ORDER BY role_permissions.role_id, role_permissions.permission_id`
+
+			expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+			mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"role_id", "permission_id", "starts_at", "expires_at"}).
+					AddRow(1, 2, nil, expiresAt))
+
+			rolePermissions, err := repository.GetPermissionWindows(context.Background(), []uint{1})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(rolePermissions).Should(Equal([]pivot.RolePermissions{{RoleID: 1, PermissionID: 2, ExpiresAt: &expiresAt}}))
+		})
+	})
 })
@@ -0,0 +1,49 @@
+package scopes
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Assignment represents the scope of the role and permission assignments of a user.
+// The zero value is the global scope. The window is only used when the assignments are created.
+type Assignment struct {
+	TenantID string
+
+	ResourceType string
+	ResourceID   string
+
+	Window
+}
+
+// ToApplicable adds the conditions of the assignments that apply in the scope to your gorm queries.
+// Global assignments apply in every tenant and on every resource, the assignments outside their time window never apply.
+func (a Assignment) ToApplicable(table string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return a.ToApplicableAnyTime(table)(ToActive(table, time.Now())(db))
+	}
+}
+
+// ToApplicableAnyTime adds the conditions of the assignments that apply in the scope to your gorm queries, whatever their time window.
+func (a Assignment) ToApplicableAnyTime(table string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if a.TenantID == "" {
+			db = db.Where(table+".tenant_id = ?", "")
+		} else {
+			db = db.Where(table+".tenant_id IN (?)", []string{"", a.TenantID})
+		}
+
+		if a.ResourceType == "" {
+			return db.Where(table+".resource_type = ?", "")
+		}
+		return db.Where(table+".resource_type = ? OR ("+table+".resource_type = ? AND "+table+".resource_id = ?)", "", a.ResourceType, a.ResourceID)
+	}
+}
+
+// ToExact adds the conditions of the assignments made exactly in the scope to your gorm queries.
+func (a Assignment) ToExact(table string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(table+".tenant_id = ?", a.TenantID).Where(table+".resource_type = ?", a.ResourceType).Where(table+".resource_id = ?", a.ResourceID)
+	}
+}
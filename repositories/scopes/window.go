@@ -0,0 +1,27 @@
+package scopes
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Window represents the time window in which an assignment is valid.
+// A nil StartsAt is valid since the beginning, a nil ExpiresAt never expires.
+type Window struct {
+	StartsAt  *time.Time
+	ExpiresAt *time.Time
+}
+
+// IsZero is the window unbounded? (no StartsAt and no ExpiresAt)
+// @return bool
+func (w Window) IsZero() bool {
+	return w.StartsAt == nil && w.ExpiresAt == nil
+}
+
+// ToActive adds the conditions of the assignments that are valid at the given time to your gorm queries.
+func ToActive(table string, now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(table+".starts_at IS NULL OR "+table+".starts_at <= ?", now).Where(table+".expires_at IS NULL OR "+table+".expires_at > ?", now)
+	}
+}
@@ -0,0 +1,104 @@
+package repositories_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/repositories"
+	"github.com/Permify/go-role/repositories/conformance"
+	"github.com/Permify/go-role/repositories/scopes"
+)
+
+var _ = conformance.Describe("sqlite", newSQLiteRepositories(models.UserIDUint))
+
+var _ = conformance.Describe("sqlite with int64 user ids", newSQLiteRepositories(models.UserIDInt64))
+
+var _ = conformance.Describe("sqlite with string user ids", newSQLiteRepositories(models.UserIDString))
+
+var _ = conformance.Describe("sqlite with uuid user ids", newSQLiteRepositories(models.UserIDUUID))
+
+// newSQLiteRepositories returns the gorm repositories on a new on-disk sqlite database for each spec.
+func newSQLiteRepositories(userIDType models.UserIDType) func() conformance.Repositories {
+	return func() conformance.Repositories {
+		dir, err := os.MkdirTemp("", "permify")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "permify.db")+"?_foreign_keys=on"), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		return conformance.Repositories{
+			Role:       &repositories.RoleRepository{Database: db, UserIDType: userIDType},
+			Permission: &repositories.PermissionRepository{Database: db, UserIDType: userIDType},
+			User:       &repositories.UserRepository{Database: db},
+			Audit:      &repositories.AuditRepository{Database: db},
+			Transactor: &repositories.Transactor{Database: db},
+			UserIDType: userIDType,
+			Close: func() error {
+				sqlDB, err := db.DB()
+				if err != nil {
+					return err
+				}
+				if err = sqlDB.Close(); err != nil {
+					return err
+				}
+				return os.RemoveAll(dir)
+			},
+		}
+	}
+}
+
+var _ = Describe("sqlite migration", func() {
+	It("rebuilds the primary key of the user pivots created by the first version", func() {
+		repo := newSQLiteRepositories(models.UserIDUint)()
+		defer repo.Close()
+		db := repo.Role.(*repositories.RoleRepository).Database
+
+		// the schema of the first version, the assignments are keyed by the user and the role or the permission only.
+		Expect(db.Exec("CREATE TABLE `user_roles` (`user_id` integer,`role_id` integer,PRIMARY KEY (`user_id`,`role_id`))").Error).ShouldNot(HaveOccurred())
+		Expect(db.Exec("CREATE TABLE `user_permissions` (`user_id` integer,`permission_id` integer,PRIMARY KEY (`user_id`,`permission_id`))").Error).ShouldNot(HaveOccurred())
+		Expect(db.Exec("INSERT INTO `user_roles` (`user_id`,`role_id`) VALUES (1,1)").Error).ShouldNot(HaveOccurred())
+		Expect(db.Exec("INSERT INTO `user_permissions` (`user_id`,`permission_id`) VALUES (1,1)").Error).ShouldNot(HaveOccurred())
+
+		Expect(repositories.Migrates(repo.Role, repo.Permission)).ShouldNot(HaveOccurred())
+		// migrating again keeps the rebuilt tables.
+		Expect(repositories.Migrates(repo.Role, repo.Permission)).ShouldNot(HaveOccurred())
+
+		for _, table := range []string{"user_roles", "user_permissions", "user_denied_permissions"} {
+			var count int64
+			Expect(db.Raw("SELECT COUNT(*) FROM pragma_table_info(?) WHERE pk > 0", table).Scan(&count).Error).ShouldNot(HaveOccurred())
+			Expect(count).Should(Equal(int64(6)), table)
+		}
+
+		ctx := context.Background()
+		user := models.UserSubject(models.UserIDFromInt(1))
+		tenant := scopes.Assignment{TenantID: "org-a"}
+
+		roleIDs, _, err := repo.Role.GetRoleIDsOfUser(ctx, user, scopes.Assignment{}, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(roleIDs).Should(Equal([]uint{1}))
+		permissionIDs, _, err := repo.Permission.GetDirectPermissionIDsOfUserByID(ctx, user, scopes.Assignment{}, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(permissionIDs).Should(Equal([]uint{1}))
+
+		// the role held globally is added in a tenant, and again globally.
+		Expect(repo.User.AddRoles(ctx, user, tenant, collections.Role{{ID: 1}})).ShouldNot(HaveOccurred())
+		Expect(repo.User.AddRoles(ctx, user, scopes.Assignment{}, collections.Role{{ID: 1}})).ShouldNot(HaveOccurred())
+		Expect(repo.User.AddPermissions(ctx, user, tenant, collections.Permission{{ID: 1}})).ShouldNot(HaveOccurred())
+		Expect(repo.User.HasRole(ctx, user, scopes.Assignment{TenantID: "org-b"}, models.Role{ID: 1})).Should(BeTrue())
+
+		var count int64
+		Expect(db.Table("user_roles").Count(&count).Error).ShouldNot(HaveOccurred())
+		Expect(count).Should(Equal(int64(2)))
+	})
+})
@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// ITransactor its abstraction of the transactions that span the repositories.
+type ITransactor interface {
+	// Transaction runs fc in a transaction, the repository calls that are given the context of fc are a part of it.
+	// The transaction is committed if fc returns nil, and rolled back otherwise.
+	Transaction(ctx context.Context, fc func(ctx context.Context) error) (err error)
+}
+
+// transactionKey is the context key of the gorm transaction.
+type transactionKey struct{}
+
+// Transactor runs the gorm repositories in a transaction of the database.
+type Transactor struct {
+	Database *gorm.DB
+}
+
+// Transaction runs fc in a gorm transaction, which is carried by the context of fc.
+// The transactions started in fc are nested. (save points)
+// @param context.Context
+// @param func(ctx context.Context) error
+// @return error
+func (transactor *Transactor) Transaction(ctx context.Context, fc func(ctx context.Context) error) error {
+	return database(ctx, transactor.Database).Transaction(func(tx *gorm.DB) error {
+		return fc(context.WithValue(ctx, transactionKey{}, tx))
+	})
+}
+
+// database returns the transaction of the context if there is one, otherwise the database, with the context.
+// @param context.Context
+// @param *gorm.DB
+// @return *gorm.DB
+func database(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
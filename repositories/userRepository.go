@@ -1,37 +1,82 @@
 package repositories
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
 	"github.com/Permify/go-role/collections"
 	"github.com/Permify/go-role/models"
 	"github.com/Permify/go-role/models/pivot"
+	"github.com/Permify/go-role/repositories/scopes"
 )
 
 // IUserRepository its data access layer abstraction of user.
 type IUserRepository interface {
 	// actions
 
-	AddPermissions(userID uint, permissions collections.Permission) (err error)
-	ReplacePermissions(userID uint, permissions collections.Permission) (err error)
-	RemovePermissions(userID uint, permissions collections.Permission) (err error)
-	ClearPermissions(userID uint) (err error)
+	AddPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (err error)
+	ReplacePermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (err error)
+	RemovePermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (err error)
+	ClearPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (err error)
+
+	AddRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) (err error)
+	ReplaceRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) (err error)
+	RemoveRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) (err error)
+	ClearRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (err error)
 
-	AddRoles(userID uint, roles collections.Role) (err error)
-	ReplaceRoles(userID uint, roles collections.Role) (err error)
-	RemoveRoles(userID uint, roles collections.Role) (err error)
-	ClearRoles(userID uint) (err error)
+	DenyPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (err error)
+	RemoveDeniedPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (err error)
 
 	// controls
 
-	HasRole(userID uint, role models.Role) (b bool, err error)
-	HasAllRoles(userID uint, roles collections.Role) (b bool, err error)
-	HasAnyRoles(userID uint, roles collections.Role) (b bool, err error)
+	HasRole(ctx context.Context, subject models.Subject, assignment scopes.Assignment, role models.Role) (b bool, err error)
+	HasAllRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) (b bool, err error)
+	HasAnyRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) (b bool, err error)
+
+	HasDirectPermission(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permission models.Permission) (b bool, err error)
+	HasAllDirectPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (b bool, err error)
+	HasAnyDirectPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (b bool, err error)
+
+	// ID fetch options
+
+	GetUserIDsOfRole(ctx context.Context, roleID uint, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error)
+	GetUserIDsWithDirectPermission(ctx context.Context, permissionID uint, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error)
+	GetUserIDsWithPermission(ctx context.Context, holders PermissionHolders, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error)
+
+	// maintenance
 
-	HasDirectPermission(userID uint, permission models.Permission) (b bool, err error)
-	HasAllDirectPermissions(userID uint, permissions collections.Permission) (b bool, err error)
-	HasAnyDirectPermissions(userID uint, permissions collections.Permission) (b bool, err error)
+	PurgeExpiredRoles(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error)
+	PurgeExpiredPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error)
+	PurgeExpiredDeniedPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error)
+
+	// windows
+
+	GetNextWindowBound(ctx context.Context, subject models.Subject, assignment scopes.Assignment, after time.Time) (bound *time.Time, err error)
+	GetRoleWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userRoles []pivot.UserRoles, err error)
+	GetPermissionWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userPermissions []pivot.UserPermissions, err error)
+	GetDeniedPermissionWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userDeniedPermissions []pivot.UserDeniedPermissions, err error)
+}
+
+// PermissionHolders selects the users that have a permission, by the permissions and the roles that allow or deny it.
+// A user is selected if one of the permissions is given to the user directly or one of the roles is given to the user,
+// unless one of the denied permissions is denied to the user directly or one of the denied roles is given to the user.
+type PermissionHolders struct {
+	// PermissionIDs allow the permission when they are given directly.
+	PermissionIDs []uint
+	// RoleIDs allow the permission, they must include the roles that inherit them.
+	RoleIDs []uint
+	// DeniedPermissionIDs deny the permission when they are denied directly.
+	DeniedPermissionIDs []uint
+	// DeniedRoleIDs deny the permission, they must include the roles that inherit them.
+	DeniedRoleIDs []uint
+	// DirectOverridesRoles makes the permissions given directly win over the denied roles.
+	DirectOverridesRoles bool
+	// Subjects limits the selected users to the given subjects, if it is not empty.
+	Subjects []models.Subject
 }
 
 // UserRepository its data access layer of user.
@@ -42,27 +87,38 @@ type UserRepository struct {
 // ACTIONS
 
 // AddPermissions add direct permissions to user.
-// @param uint
+// If a permission has been added before in the scope, its window is replaced by the given window, or kept if no window is given.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param collections.Permission
 // @return error
-func (repository *UserRepository) AddPermissions(userID uint, permissions collections.Permission) error {
+func (repository *UserRepository) AddPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
 	var userPermissions []pivot.UserPermissions
 	for _, permission := range permissions.Origin() {
 		userPermissions = append(userPermissions, pivot.UserPermissions{
-			UserID:       userID,
+			SubjectType:  subject.Type,
+			UserID:       subject.ID,
 			PermissionID: permission.ID,
+			TenantID:     assignment.TenantID,
+			ResourceType: assignment.ResourceType,
+			ResourceID:   assignment.ResourceID,
+			StartsAt:     assignment.StartsAt,
+			ExpiresAt:    assignment.ExpiresAt,
 		})
 	}
-	return repository.Database.Clauses(clause.OnConflict{DoNothing: true}).Create(&userPermissions).Error
+	return database(ctx, repository.Database).Clauses(repository.onConflictUpdateWindow("permission_id", assignment.Window)).Create(&userPermissions).Error
 }
 
 // ReplacePermissions replace direct permissions of user.
-// @param uint
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param collections.Permission
 // @return error
-func (repository *UserRepository) ReplacePermissions(userID uint, permissions collections.Permission) error {
-	return repository.Database.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("user_permissions.user_id = ?", userID).Delete(&pivot.UserPermissions{}).Error; err != nil {
+func (repository *UserRepository) ReplacePermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	return database(ctx, repository.Database).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_permissions.subject_type = ?", subject.Type).Where("user_permissions.user_id = ?", subject.ID).Scopes(assignment.ToExact("user_permissions")).Delete(&pivot.UserPermissions{}).Error; err != nil {
 			tx.Rollback()
 			return err
 		}
@@ -70,8 +126,14 @@ func (repository *UserRepository) ReplacePermissions(userID uint, permissions co
 		var userPermissions []pivot.UserPermissions
 		for _, permission := range permissions.Origin() {
 			userPermissions = append(userPermissions, pivot.UserPermissions{
-				UserID:       userID,
+				SubjectType:  subject.Type,
+				UserID:       subject.ID,
 				PermissionID: permission.ID,
+				TenantID:     assignment.TenantID,
+				ResourceType: assignment.ResourceType,
+				ResourceID:   assignment.ResourceID,
+				StartsAt:     assignment.StartsAt,
+				ExpiresAt:    assignment.ExpiresAt,
 			})
 		}
 
@@ -85,57 +147,71 @@ func (repository *UserRepository) ReplacePermissions(userID uint, permissions co
 }
 
 // RemovePermissions remove direct permissions of user.
-// @param uint
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param collections.Permission
 // @return error
-func (repository *UserRepository) RemovePermissions(userID uint, permissions collections.Permission) error {
-	var userPermissions []pivot.UserPermissions
-	for _, permission := range permissions.Origin() {
-		userPermissions = append(userPermissions, pivot.UserPermissions{
-			UserID:       userID,
-			PermissionID: permission.ID,
-		})
-	}
-	return repository.Database.Delete(&userPermissions).Error
+func (repository *UserRepository) RemovePermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	return database(ctx, repository.Database).Where("user_permissions.subject_type = ?", subject.Type).Where("user_permissions.user_id = ?", subject.ID).Where("user_permissions.permission_id IN (?)", permissions.IDs()).Scopes(assignment.ToExact("user_permissions")).Delete(&pivot.UserPermissions{}).Error
 }
 
 // ClearPermissions remove all direct permissions of user.
-// @param uint
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @return error
-func (repository *UserRepository) ClearPermissions(userID uint) (err error) {
-	return repository.Database.Where("user_permissions.user_id = ?", userID).Delete(&pivot.UserPermissions{}).Error
+func (repository *UserRepository) ClearPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (err error) {
+	return database(ctx, repository.Database).Where("user_permissions.subject_type = ?", subject.Type).Where("user_permissions.user_id = ?", subject.ID).Scopes(assignment.ToExact("user_permissions")).Delete(&pivot.UserPermissions{}).Error
 }
 
 // AddRoles add roles to user.
-// @param uint
+// If a role has been added before in the scope, its window is replaced by the given window, or kept if no window is given.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param collections.Role
 // @return error
-func (repository *UserRepository) AddRoles(userID uint, roles collections.Role) error {
+func (repository *UserRepository) AddRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) error {
 	var userRoles []pivot.UserRoles
 	for _, role := range roles.Origin() {
 		userRoles = append(userRoles, pivot.UserRoles{
-			UserID: userID,
-			RoleID: role.ID,
+			SubjectType:  subject.Type,
+			UserID:       subject.ID,
+			RoleID:       role.ID,
+			TenantID:     assignment.TenantID,
+			ResourceType: assignment.ResourceType,
+			ResourceID:   assignment.ResourceID,
+			StartsAt:     assignment.StartsAt,
+			ExpiresAt:    assignment.ExpiresAt,
 		})
 	}
-	return repository.Database.Clauses(clause.OnConflict{DoNothing: true}).Create(&userRoles).Error
+	return database(ctx, repository.Database).Clauses(repository.onConflictUpdateWindow("role_id", assignment.Window)).Create(&userRoles).Error
 }
 
 // ReplaceRoles replace roles of user.
-// @param uint
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param collections.Role
 // @return error
-func (repository *UserRepository) ReplaceRoles(userID uint, roles collections.Role) error {
-	return repository.Database.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("user_roles.user_id = ?", userID).Delete(&pivot.UserRoles{}).Error; err != nil {
+func (repository *UserRepository) ReplaceRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) error {
+	return database(ctx, repository.Database).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_roles.subject_type = ?", subject.Type).Where("user_roles.user_id = ?", subject.ID).Scopes(assignment.ToExact("user_roles")).Delete(&pivot.UserRoles{}).Error; err != nil {
 			tx.Rollback()
 			return err
 		}
 		var userRoles []pivot.UserRoles
 		for _, role := range roles.Origin() {
 			userRoles = append(userRoles, pivot.UserRoles{
-				UserID: userID,
-				RoleID: role.ID,
+				SubjectType:  subject.Type,
+				UserID:       subject.ID,
+				RoleID:       role.ID,
+				TenantID:     assignment.TenantID,
+				ResourceType: assignment.ResourceType,
+				ResourceID:   assignment.ResourceID,
+				StartsAt:     assignment.StartsAt,
+				ExpiresAt:    assignment.ExpiresAt,
 			})
 		}
 		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&userRoles).Error; err != nil {
@@ -147,85 +223,333 @@ func (repository *UserRepository) ReplaceRoles(userID uint, roles collections.Ro
 }
 
 // RemoveRoles remove roles of user.
-// @param uint
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param collections.Role
 // @return error
-func (repository *UserRepository) RemoveRoles(userID uint, roles collections.Role) error {
-	var userRoles []pivot.UserRoles
-	for _, role := range roles.Origin() {
-		userRoles = append(userRoles, pivot.UserRoles{
-			UserID: userID,
-			RoleID: role.ID,
+func (repository *UserRepository) RemoveRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) error {
+	return database(ctx, repository.Database).Where("user_roles.subject_type = ?", subject.Type).Where("user_roles.user_id = ?", subject.ID).Where("user_roles.role_id IN (?)", roles.IDs()).Scopes(assignment.ToExact("user_roles")).Delete(&pivot.UserRoles{}).Error
+}
+
+// ClearRoles remove all roles of user.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @return error
+func (repository *UserRepository) ClearRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (err error) {
+	return database(ctx, repository.Database).Where("user_roles.subject_type = ?", subject.Type).Where("user_roles.user_id = ?", subject.ID).Scopes(assignment.ToExact("user_roles")).Delete(&pivot.UserRoles{}).Error
+}
+
+// DenyPermissions deny permissions to user, the denied permissions block the permissions given to the user.
+// If a permission has been denied before in the scope, its window is replaced by the given window, or kept if no window is given.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Permission
+// @return error
+func (repository *UserRepository) DenyPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	var userDeniedPermissions []pivot.UserDeniedPermissions
+	for _, permission := range permissions.Origin() {
+		userDeniedPermissions = append(userDeniedPermissions, pivot.UserDeniedPermissions{
+			SubjectType:  subject.Type,
+			UserID:       subject.ID,
+			PermissionID: permission.ID,
+			TenantID:     assignment.TenantID,
+			ResourceType: assignment.ResourceType,
+			ResourceID:   assignment.ResourceID,
+			StartsAt:     assignment.StartsAt,
+			ExpiresAt:    assignment.ExpiresAt,
 		})
 	}
-	return repository.Database.Delete(&userRoles).Error
+	return database(ctx, repository.Database).Clauses(repository.onConflictUpdateWindow("permission_id", assignment.Window)).Create(&userDeniedPermissions).Error
 }
 
-// ClearRoles remove all roles of user.
-// @param uint
+// RemoveDeniedPermissions remove denied permissions of user.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param collections.Permission
 // @return error
-func (repository *UserRepository) ClearRoles(userID uint) (err error) {
-	return repository.Database.Where("user_roles.user_id = ?", userID).Delete(&pivot.UserRoles{}).Error
+func (repository *UserRepository) RemoveDeniedPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) error {
+	return database(ctx, repository.Database).Where("user_denied_permissions.subject_type = ?", subject.Type).Where("user_denied_permissions.user_id = ?", subject.ID).Where("user_denied_permissions.permission_id IN (?)", permissions.IDs()).Scopes(assignment.ToExact("user_denied_permissions")).Delete(&pivot.UserDeniedPermissions{}).Error
 }
 
 // CONTROLS
 
 // HasRole does the user have the given role?
-// @param uint
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param models.Role
 // @return bool, error
-func (repository *UserRepository) HasRole(userID uint, role models.Role) (b bool, err error) {
+func (repository *UserRepository) HasRole(ctx context.Context, subject models.Subject, assignment scopes.Assignment, role models.Role) (b bool, err error) {
 	var count int64
-	err = repository.Database.Table("user_roles").Where("user_roles.user_id = ?", userID).Where("user_roles.role_id = ?", role.ID).Count(&count).Error
+	err = database(ctx, repository.Database).Table("user_roles").Where("user_roles.subject_type = ?", subject.Type).Where("user_roles.user_id = ?", subject.ID).Where("user_roles.role_id = ?", role.ID).Scopes(assignment.ToApplicable("user_roles")).Count(&count).Error
 	return count > 0, err
 }
 
 // HasAllRoles does the user have all the given roles?
-// @param uint
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param collections.Role
 // @return bool, error
-func (repository *UserRepository) HasAllRoles(userID uint, roles collections.Role) (b bool, err error) {
+func (repository *UserRepository) HasAllRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) (b bool, err error) {
 	var count int64
-	err = repository.Database.Table("user_roles").Where("user_roles.user_id = ?", userID).Where("user_roles.role_id IN (?)", roles.IDs()).Count(&count).Error
+	err = database(ctx, repository.Database).Table("user_roles").Where("user_roles.subject_type = ?", subject.Type).Where("user_roles.user_id = ?", subject.ID).Where("user_roles.role_id IN (?)", roles.IDs()).Scopes(assignment.ToApplicable("user_roles")).Distinct("user_roles.role_id").Count(&count).Error
 	return roles.Len() == count, err
 }
 
 // HasAnyRoles does the user have any of the given roles?
-// @param uint
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param collections.Role
 // @return bool, error
-func (repository *UserRepository) HasAnyRoles(userID uint, roles collections.Role) (b bool, err error) {
+func (repository *UserRepository) HasAnyRoles(ctx context.Context, subject models.Subject, assignment scopes.Assignment, roles collections.Role) (b bool, err error) {
 	var count int64
-	err = repository.Database.Table("user_roles").Where("user_roles.user_id = ?", userID).Where("user_roles.role_id IN (?)", roles.IDs()).Count(&count).Error
+	err = database(ctx, repository.Database).Table("user_roles").Where("user_roles.subject_type = ?", subject.Type).Where("user_roles.user_id = ?", subject.ID).Where("user_roles.role_id IN (?)", roles.IDs()).Scopes(assignment.ToApplicable("user_roles")).Distinct("user_roles.role_id").Count(&count).Error
 	return count > 0, err
 }
 
 // HasDirectPermission does the user have the given permission? (not including the permissions of the roles)
-// @param uint
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param collections.Permission
 // @return bool, error
-func (repository *UserRepository) HasDirectPermission(userID uint, permission models.Permission) (b bool, err error) {
+func (repository *UserRepository) HasDirectPermission(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permission models.Permission) (b bool, err error) {
 	var count int64
-	err = repository.Database.Table("user_permissions").Where("user_permissions.user_id = ?", userID).Where("user_permissions.permission_id = ?", permission.ID).Count(&count).Error
+	err = database(ctx, repository.Database).Table("user_permissions").Where("user_permissions.subject_type = ?", subject.Type).Where("user_permissions.user_id = ?", subject.ID).Where("user_permissions.permission_id = ?", permission.ID).Scopes(assignment.ToApplicable("user_permissions")).Count(&count).Error
 	return count > 0, err
 }
 
 // HasAllDirectPermissions does the user have all the given permissions? (not including the permissions of the roles)
-// @param uint
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param collections.Permission
 // @return bool, error
-func (repository *UserRepository) HasAllDirectPermissions(userID uint, permissions collections.Permission) (b bool, err error) {
+func (repository *UserRepository) HasAllDirectPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (b bool, err error) {
 	var count int64
-	err = repository.Database.Table("user_permissions").Where("user_permissions.user_id = ?", userID).Where("user_permissions.permission_id IN (?)", permissions.IDs()).Count(&count).Error
+	err = database(ctx, repository.Database).Table("user_permissions").Where("user_permissions.subject_type = ?", subject.Type).Where("user_permissions.user_id = ?", subject.ID).Where("user_permissions.permission_id IN (?)", permissions.IDs()).Scopes(assignment.ToApplicable("user_permissions")).Distinct("user_permissions.permission_id").Count(&count).Error
 	return permissions.Len() == count, err
 }
 
 // HasAnyDirectPermissions does the user have any of the given permissions? (not including the permissions of the roles)
-// @param uint
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
 // @param collections.Permission
 // @return bool, error
-func (repository *UserRepository) HasAnyDirectPermissions(userID uint, permissions collections.Permission) (b bool, err error) {
+func (repository *UserRepository) HasAnyDirectPermissions(ctx context.Context, subject models.Subject, assignment scopes.Assignment, permissions collections.Permission) (b bool, err error) {
 	var count int64
-	err = repository.Database.Table("user_permissions").Where("user_permissions.user_id = ?", userID).Where("user_permissions.permission_id IN (?)", permissions.IDs()).Count(&count).Error
+	err = database(ctx, repository.Database).Table("user_permissions").Where("user_permissions.subject_type = ?", subject.Type).Where("user_permissions.user_id = ?", subject.ID).Where("user_permissions.permission_id IN (?)", permissions.IDs()).Scopes(assignment.ToApplicable("user_permissions")).Distinct("user_permissions.permission_id").Count(&count).Error
 	return count > 0, err
 }
+
+// ID FETCH OPTIONS
+
+// GetUserIDsOfRole get the users that have the role in the scope and are valid now, ordered by subject type and id. (with pagination)
+// @param context.Context
+// @param uint
+// @param repositories_scopes.Assignment
+// @param repositories_scopes.GormPager
+// @return []models.Subject, int64, error
+func (repository *UserRepository) GetUserIDsOfRole(ctx context.Context, roleID uint, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error) {
+	db := database(ctx, repository.Database)
+	query := db.Table("user_roles").Distinct("user_roles.subject_type", "user_roles.user_id").Where("user_roles.role_id = ?", roleID).Scopes(assignment.ToApplicable("user_roles"))
+	return repository.subjects(db, query, pagination)
+}
+
+// GetUserIDsWithDirectPermission get the users that have the permission directly in the scope and are valid now, ordered by subject type and id. (with pagination)
+// @param context.Context
+// @param uint
+// @param repositories_scopes.Assignment
+// @param repositories_scopes.GormPager
+// @return []models.Subject, int64, error
+func (repository *UserRepository) GetUserIDsWithDirectPermission(ctx context.Context, permissionID uint, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error) {
+	db := database(ctx, repository.Database)
+	query := db.Table("user_permissions").Distinct("user_permissions.subject_type", "user_permissions.user_id").Where("user_permissions.permission_id = ?", permissionID).Scopes(assignment.ToApplicable("user_permissions"))
+	return repository.subjects(db, query, pagination)
+}
+
+// GetUserIDsWithPermission get the users that are selected by the holders in the scope, ordered by subject type and id. (with pagination)
+// The allowed users are selected with a union, the denied ones are excluded with NOT EXISTS, so it is a single query.
+// @param context.Context
+// @param PermissionHolders
+// @param repositories_scopes.Assignment
+// @param repositories_scopes.GormPager
+// @return []models.Subject, int64, error
+func (repository *UserRepository) GetUserIDsWithPermission(ctx context.Context, holders PermissionHolders, assignment scopes.Assignment, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error) {
+	db := database(ctx, repository.Database)
+	direct := db.Table("user_permissions").Select("user_permissions.subject_type", "user_permissions.user_id").Where("user_permissions.permission_id IN (?)", holders.PermissionIDs).Scopes(assignment.ToApplicable("user_permissions"), toSubjects("user_permissions", holders.Subjects))
+	viaRoles := db.Table("user_roles").Select("user_roles.subject_type", "user_roles.user_id").Where("user_roles.role_id IN (?)", holders.RoleIDs).Scopes(assignment.ToApplicable("user_roles"), toSubjects("user_roles", holders.Subjects))
+	query := db.Table("(?) AS allowed", db.Raw("? UNION ?", direct, viaRoles)).Select("allowed.subject_type", "allowed.user_id")
+
+	if len(holders.DeniedPermissionIDs) > 0 {
+		query = query.Where("NOT EXISTS (?)", db.Table("user_denied_permissions").Select("1").Where("user_denied_permissions.subject_type = allowed.subject_type").Where("user_denied_permissions.user_id = allowed.user_id").Where("user_denied_permissions.permission_id IN (?)", holders.DeniedPermissionIDs).Scopes(assignment.ToApplicable("user_denied_permissions")))
+	}
+
+	if len(holders.DeniedRoleIDs) > 0 {
+		deniedViaRoles := db.Table("user_roles").Select("1").Where("user_roles.subject_type = allowed.subject_type").Where("user_roles.user_id = allowed.user_id").Where("user_roles.role_id IN (?)", holders.DeniedRoleIDs).Scopes(assignment.ToApplicable("user_roles"))
+		if holders.DirectOverridesRoles {
+			allowedDirectly := db.Table("user_permissions").Select("1").Where("user_permissions.subject_type = allowed.subject_type").Where("user_permissions.user_id = allowed.user_id").Where("user_permissions.permission_id IN (?)", holders.PermissionIDs).Scopes(assignment.ToApplicable("user_permissions"))
+			query = query.Where("NOT EXISTS (?) OR EXISTS (?)", deniedViaRoles, allowedDirectly)
+		} else {
+			query = query.Where("NOT EXISTS (?)", deniedViaRoles)
+		}
+	}
+
+	return repository.subjects(db, query, pagination)
+}
+
+// subjects get the users that are selected by the query, ordered by subject type and id. (with pagination)
+// The query must select distinct subject_type and user_id columns.
+// @param *gorm.DB
+// @param *gorm.DB
+// @param repositories_scopes.GormPager
+// @return []models.Subject, int64, error
+func (repository *UserRepository) subjects(db *gorm.DB, query *gorm.DB, pagination scopes.GormPager) (subjects []models.Subject, totalCount int64, err error) {
+	err = db.Table("(?) AS subjects", query).Count(&totalCount).Scopes(repository.paginate(pagination)).Order("subjects.subject_type, subjects.user_id").Select("subjects.subject_type", "subjects.user_id").Scan(&subjects).Error
+	return
+}
+
+// toSubjects adds the conditions of the given subjects to your gorm queries, the ids are grouped by subject type.
+// It adds no condition if there are no subjects.
+// @param string
+// @param []models.Subject
+// @return func(db *gorm.DB) *gorm.DB
+func toSubjects(table string, subjects []models.Subject) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(subjects) == 0 {
+			return db
+		}
+
+		var subjectTypes []string
+		IDs := make(map[string][]models.UserID)
+		for _, subject := range subjects {
+			if _, ok := IDs[subject.Type]; !ok {
+				subjectTypes = append(subjectTypes, subject.Type)
+			}
+			IDs[subject.Type] = append(IDs[subject.Type], subject.ID)
+		}
+
+		var conditions []string
+		var values []interface{}
+		for _, subjectType := range subjectTypes {
+			conditions = append(conditions, "("+table+".subject_type = ? AND "+table+".user_id IN (?))")
+			values = append(values, subjectType, IDs[subjectType])
+		}
+		return db.Where(strings.Join(conditions, " OR "), values...)
+	}
+}
+
+// MAINTENANCE
+
+// PurgeExpiredRoles delete the role assignments of users that expired before the given time, batch by batch.
+// Each batch deletes the expired assignments of at most batchSize users.
+// @param context.Context
+// @param time.Time
+// @param int
+// @return int64, error
+func (repository *UserRepository) PurgeExpiredRoles(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	return purgeExpired(database(ctx, repository.Database), &pivot.UserRoles{}, "user_roles", "user_id", before, batchSize)
+}
+
+// PurgeExpiredPermissions delete the direct permission assignments of users that expired before the given time, batch by batch.
+// Each batch deletes the expired assignments of at most batchSize users.
+// @param context.Context
+// @param time.Time
+// @param int
+// @return int64, error
+func (repository *UserRepository) PurgeExpiredPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	return purgeExpired(database(ctx, repository.Database), &pivot.UserPermissions{}, "user_permissions", "user_id", before, batchSize)
+}
+
+// PurgeExpiredDeniedPermissions delete the denied permissions of users that expired before the given time, batch by batch.
+// Each batch deletes the expired denies of at most batchSize users.
+// @param context.Context
+// @param time.Time
+// @param int
+// @return int64, error
+func (repository *UserRepository) PurgeExpiredDeniedPermissions(ctx context.Context, before time.Time, batchSize int) (deleted int64, err error) {
+	return purgeExpired(database(ctx, repository.Database), &pivot.UserDeniedPermissions{}, "user_denied_permissions", "user_id", before, batchSize)
+}
+
+// WINDOWS
+
+// GetNextWindowBound get the earliest time after the given time at which one of the roles, permissions or denied permissions of the user that apply in the scope starts or expires.
+// It is nil if none of them starts or expires after the given time.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @param time.Time
+// @return *time.Time, error
+func (repository *UserRepository) GetNextWindowBound(ctx context.Context, subject models.Subject, assignment scopes.Assignment, after time.Time) (bound *time.Time, err error) {
+	return nextWindowBound(database(ctx, repository.Database), []string{"user_roles", "user_permissions", "user_denied_permissions"}, func(table string) func(db *gorm.DB) *gorm.DB {
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where(table+".subject_type = ?", subject.Type).Where(table+".user_id = ?", subject.ID).Scopes(assignment.ToApplicableAnyTime(table))
+		}
+	}, after)
+}
+
+// GetRoleWindows get the roles of the user made exactly in the scope with their windows, also the ones that are not valid now.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @return []pivot.UserRoles, error
+func (repository *UserRepository) GetRoleWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userRoles []pivot.UserRoles, err error) {
+	err = database(ctx, repository.Database).Where("user_roles.subject_type = ?", subject.Type).Where("user_roles.user_id = ?", subject.ID).Scopes(assignment.ToExact("user_roles")).Order("user_roles.role_id").Find(&userRoles).Error
+	return
+}
+
+// GetPermissionWindows get the permissions of the user made exactly in the scope with their windows, also the ones that are not valid now.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @return []pivot.UserPermissions, error
+func (repository *UserRepository) GetPermissionWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userPermissions []pivot.UserPermissions, err error) {
+	err = database(ctx, repository.Database).Where("user_permissions.subject_type = ?", subject.Type).Where("user_permissions.user_id = ?", subject.ID).Scopes(assignment.ToExact("user_permissions")).Order("user_permissions.permission_id").Find(&userPermissions).Error
+	return
+}
+
+// GetDeniedPermissionWindows get the denied permissions of the user made exactly in the scope with their windows, also the ones that are not valid now.
+// @param context.Context
+// @param models.Subject
+// @param repositories_scopes.Assignment
+// @return []pivot.UserDeniedPermissions, error
+func (repository *UserRepository) GetDeniedPermissionWindows(ctx context.Context, subject models.Subject, assignment scopes.Assignment) (userDeniedPermissions []pivot.UserDeniedPermissions, err error) {
+	err = database(ctx, repository.Database).Where("user_denied_permissions.subject_type = ?", subject.Type).Where("user_denied_permissions.user_id = ?", subject.ID).Scopes(assignment.ToExact("user_denied_permissions")).Order("user_denied_permissions.permission_id").Find(&userDeniedPermissions).Error
+	return
+}
+
+// onConflictUpdateWindow replaces the time window of the assignments that already exist by the given window.
+// If no window is given, the assignments that already exist are kept as they are, so that adding them again does not make them permanent or change their window.
+// @param string
+// @param repositories_scopes.Window
+// @return clause.OnConflict
+func (repository *UserRepository) onConflictUpdateWindow(column string, window scopes.Window) clause.OnConflict {
+	columns := []clause.Column{{Name: "subject_type"}, {Name: "user_id"}, {Name: column}, {Name: "tenant_id"}, {Name: "resource_type"}, {Name: "resource_id"}}
+	if window.IsZero() {
+		return clause.OnConflict{Columns: columns, DoNothing: true}
+	}
+	return clause.OnConflict{
+		Columns:   columns,
+		DoUpdates: clause.AssignmentColumns([]string{"starts_at", "expires_at"}),
+	}
+}
+
+// paginate pagging if pagination option is true.
+// @param repositories_scopes.GormPager
+// @return func(db *gorm.DB) *gorm.DB
+func (repository *UserRepository) paginate(pagination scopes.GormPager) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if pagination != nil {
+			db.Scopes(pagination.ToPaginate())
+		}
+
+		return db
+	}
+}
@@ -1,8 +1,10 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"regexp"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	. "github.com/onsi/ginkgo"
@@ -13,6 +15,8 @@ import (
 	"github.com/Permify/go-role/collections"
 	"github.com/Permify/go-role/models"
 	"github.com/Permify/go-role/models/pivot"
+	"github.com/Permify/go-role/repositories/scopes"
+	"github.com/Permify/go-role/utils"
 )
 
 var _ = Describe("User Repository", func() {
@@ -47,79 +51,105 @@ var _ = Describe("User Repository", func() {
 	Context("Has Role", func() {
 		It("found", func() {
 			userRoles := pivot.UserRoles{
-				UserID: 1,
+				UserID: models.UserIDFromInt(1),
 				RoleID: 1,
 			}
 
-			const query = `SELECT count(*) FROM "user_roles" WHERE user_roles.user_id = $1 AND user_roles.role_id = $2`
+			const query = `SELECT count(*) FROM "user_roles" WHERE user_roles.subject_type = $1 AND user_roles.user_id = $2 AND user_roles.role_id = $3 AND (user_roles.starts_at IS NULL OR user_roles.starts_at <= $4) AND (user_roles.expires_at IS NULL OR user_roles.expires_at > $5) AND user_roles.tenant_id = $6 AND user_roles.resource_type = $7`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(userRoles.UserID, userRoles.RoleID).
+				WithArgs("user", userRoles.UserID, userRoles.RoleID, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(1))
 
-			value, err := repository.HasRole(1, models.Role{ID: 1})
+			value, err := repository.HasRole(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, models.Role{ID: 1})
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(true))
 		})
 
 		It("not found", func() {
-			const query = `SELECT count(*) FROM "user_roles" WHERE user_roles.user_id = $1 AND user_roles.role_id = $2`
+			const query = `SELECT count(*) FROM "user_roles" WHERE user_roles.subject_type = $1 AND user_roles.user_id = $2 AND user_roles.role_id = $3 AND (user_roles.starts_at IS NULL OR user_roles.starts_at <= $4) AND (user_roles.expires_at IS NULL OR user_roles.expires_at > $5) AND user_roles.tenant_id = $6 AND user_roles.resource_type = $7`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(1, 1).
+				WithArgs("user", 1, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(0))
 
-			value, err := repository.HasRole(1, models.Role{ID: 1})
+			value, err := repository.HasRole(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, models.Role{ID: 1})
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(false))
 		})
+
+		It("found in tenant", func() {
+			const query = `SELECT count(*) FROM "user_roles" WHERE user_roles.subject_type = $1 AND user_roles.user_id = $2 AND user_roles.role_id = $3 AND (user_roles.starts_at IS NULL OR user_roles.starts_at <= $4) AND (user_roles.expires_at IS NULL OR user_roles.expires_at > $5) AND user_roles.tenant_id IN ($6,$7) AND user_roles.resource_type = $8`
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).
+				WithArgs("user", 1, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "org-a", "").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).
+					AddRow(1))
+
+			value, err := repository.HasRole(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{TenantID: "org-a"}, models.Role{ID: 1})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(value).Should(Equal(true))
+		})
+
+		It("found on resource", func() {
+			const query = `SELECT count(*) FROM "user_roles" WHERE user_roles.subject_type = $1 AND user_roles.user_id = $2 AND user_roles.role_id = $3 AND (user_roles.starts_at IS NULL OR user_roles.starts_at <= $4) AND (user_roles.expires_at IS NULL OR user_roles.expires_at > $5) AND user_roles.tenant_id = $6 AND (user_roles.resource_type = $7 OR (user_roles.resource_type = $8 AND user_roles.resource_id = $9))`
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).
+				WithArgs("user", 7, 2, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "", "project", "42").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).
+					AddRow(1))
+
+			value, err := repository.HasRole(context.Background(), models.UserSubject(models.UserIDFromInt(7)), scopes.Assignment{ResourceType: "project", ResourceID: "42"}, models.Role{ID: 2})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(value).Should(Equal(true))
+		})
 	})
 
 	Context("Has All Roles", func() {
 		It("found", func() {
 			userRoles1 := pivot.UserRoles{
-				UserID: 1,
+				UserID: models.UserIDFromInt(1),
 				RoleID: 1,
 			}
 
 			userRoles2 := pivot.UserRoles{
-				UserID: 1,
+				UserID: models.UserIDFromInt(1),
 				RoleID: 2,
 			}
 
-			const query = `SELECT count(*) FROM "user_roles" WHERE user_roles.user_id = $1 AND user_roles.role_id IN ($2,$3)`
+			const query = `SELECT COUNT(DISTINCT("user_roles"."role_id")) FROM "user_roles" WHERE user_roles.subject_type = $1 AND user_roles.user_id = $2 AND user_roles.role_id IN ($3,$4) AND (user_roles.starts_at IS NULL OR user_roles.starts_at <= $5) AND (user_roles.expires_at IS NULL OR user_roles.expires_at > $6) AND user_roles.tenant_id = $7 AND user_roles.resource_type = $8`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(userRoles1.UserID, userRoles1.RoleID, userRoles2.RoleID).
+				WithArgs("user", userRoles1.UserID, userRoles1.RoleID, userRoles2.RoleID, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(2))
 
-			value, err := repository.HasAllRoles(uint(1), collections.Role([]models.Role{{ID: 1}, {ID: 2}}))
+			value, err := repository.HasAllRoles(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role([]models.Role{{ID: 1}, {ID: 2}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(true))
 		})
 
 		It("not found", func() {
 			userRoles1 := pivot.UserRoles{
-				UserID: 1,
+				UserID: models.UserIDFromInt(1),
 				RoleID: 1,
 			}
 
 			userRoles2 := pivot.UserRoles{
-				UserID: 1,
+				UserID: models.UserIDFromInt(1),
 				RoleID: 2,
 			}
 
-			const query = `SELECT count(*) FROM "user_roles" WHERE user_roles.user_id = $1 AND user_roles.role_id IN ($2,$3)`
+			const query = `SELECT COUNT(DISTINCT("user_roles"."role_id")) FROM "user_roles" WHERE user_roles.subject_type = $1 AND user_roles.user_id = $2 AND user_roles.role_id IN ($3,$4) AND (user_roles.starts_at IS NULL OR user_roles.starts_at <= $5) AND (user_roles.expires_at IS NULL OR user_roles.expires_at > $6) AND user_roles.tenant_id = $7 AND user_roles.resource_type = $8`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(userRoles1.UserID, userRoles1.RoleID, userRoles2.RoleID).
+				WithArgs("user", userRoles1.UserID, userRoles1.RoleID, userRoles2.RoleID, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(1))
 
-			value, err := repository.HasAllRoles(uint(1), collections.Role([]models.Role{{ID: 1}, {ID: 2}}))
+			value, err := repository.HasAllRoles(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role([]models.Role{{ID: 1}, {ID: 2}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(false))
 		})
@@ -128,31 +158,31 @@ var _ = Describe("User Repository", func() {
 	Context("Has Any Roles", func() {
 		It("found", func() {
 			userRoles1 := pivot.UserRoles{
-				UserID: 1,
+				UserID: models.UserIDFromInt(1),
 				RoleID: 1,
 			}
 
-			const query = `SELECT count(*) FROM "user_roles" WHERE user_roles.user_id = $1 AND user_roles.role_id IN ($2,$3)`
+			const query = `SELECT COUNT(DISTINCT("user_roles"."role_id")) FROM "user_roles" WHERE user_roles.subject_type = $1 AND user_roles.user_id = $2 AND user_roles.role_id IN ($3,$4) AND (user_roles.starts_at IS NULL OR user_roles.starts_at <= $5) AND (user_roles.expires_at IS NULL OR user_roles.expires_at > $6) AND user_roles.tenant_id = $7 AND user_roles.resource_type = $8`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(userRoles1.UserID, userRoles1.RoleID, 2).
+				WithArgs("user", userRoles1.UserID, userRoles1.RoleID, 2, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(1))
 
-			value, err := repository.HasAnyRoles(uint(1), collections.Role([]models.Role{{ID: 1}, {ID: 2}}))
+			value, err := repository.HasAnyRoles(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role([]models.Role{{ID: 1}, {ID: 2}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(true))
 		})
 
 		It("not found", func() {
-			const query = `SELECT count(*) FROM "user_roles" WHERE user_roles.user_id = $1 AND user_roles.role_id IN ($2,$3)`
+			const query = `SELECT COUNT(DISTINCT("user_roles"."role_id")) FROM "user_roles" WHERE user_roles.subject_type = $1 AND user_roles.user_id = $2 AND user_roles.role_id IN ($3,$4) AND (user_roles.starts_at IS NULL OR user_roles.starts_at <= $5) AND (user_roles.expires_at IS NULL OR user_roles.expires_at > $6) AND user_roles.tenant_id = $7 AND user_roles.resource_type = $8`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(1, 1, 2).
+				WithArgs("user", 1, 1, 2, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(0))
 
-			value, err := repository.HasAllRoles(uint(1), collections.Role([]models.Role{{ID: 1}, {ID: 2}}))
+			value, err := repository.HasAllRoles(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role([]models.Role{{ID: 1}, {ID: 2}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(false))
 		})
@@ -161,31 +191,31 @@ var _ = Describe("User Repository", func() {
 	Context("Has Direct Permission", func() {
 		It("found", func() {
 			userPermissions := pivot.UserPermissions{
-				UserID:       1,
+				UserID:       models.UserIDFromInt(1),
 				PermissionID: 1,
 			}
 
-			const query = `SELECT count(*) FROM "user_permissions" WHERE user_permissions.user_id = $1 AND user_permissions.permission_id = $2`
+			const query = `SELECT count(*) FROM "user_permissions" WHERE user_permissions.subject_type = $1 AND user_permissions.user_id = $2 AND user_permissions.permission_id = $3 AND (user_permissions.starts_at IS NULL OR user_permissions.starts_at <= $4) AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > $5) AND user_permissions.tenant_id = $6 AND user_permissions.resource_type = $7`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(userPermissions.UserID, userPermissions.PermissionID).
+				WithArgs("user", userPermissions.UserID, userPermissions.PermissionID, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(1))
 
-			value, err := repository.HasDirectPermission(1, models.Permission{ID: 1})
+			value, err := repository.HasDirectPermission(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, models.Permission{ID: 1})
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(true))
 		})
 
 		It("not found", func() {
-			const query = `SELECT count(*) FROM "user_permissions" WHERE user_permissions.user_id = $1 AND user_permissions.permission_id = $2`
+			const query = `SELECT count(*) FROM "user_permissions" WHERE user_permissions.subject_type = $1 AND user_permissions.user_id = $2 AND user_permissions.permission_id = $3 AND (user_permissions.starts_at IS NULL OR user_permissions.starts_at <= $4) AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > $5) AND user_permissions.tenant_id = $6 AND user_permissions.resource_type = $7`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(1, 1).
+				WithArgs("user", 1, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(0))
 
-			value, err := repository.HasDirectPermission(1, models.Permission{ID: 1})
+			value, err := repository.HasDirectPermission(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, models.Permission{ID: 1})
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(false))
 		})
@@ -194,46 +224,46 @@ var _ = Describe("User Repository", func() {
 	Context("Has All Direct Permissions", func() {
 		It("found", func() {
 			userPermissions1 := pivot.UserPermissions{
-				UserID:       1,
+				UserID:       models.UserIDFromInt(1),
 				PermissionID: 1,
 			}
 
 			userPermissions2 := pivot.UserPermissions{
-				UserID:       1,
+				UserID:       models.UserIDFromInt(1),
 				PermissionID: 2,
 			}
 
-			const query = `SELECT count(*) FROM "user_permissions" WHERE user_permissions.user_id = $1 AND user_permissions.permission_id IN ($2,$3)`
+			const query = `SELECT COUNT(DISTINCT("user_permissions"."permission_id")) FROM "user_permissions" WHERE user_permissions.subject_type = $1 AND user_permissions.user_id = $2 AND user_permissions.permission_id IN ($3,$4) AND (user_permissions.starts_at IS NULL OR user_permissions.starts_at <= $5) AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > $6) AND user_permissions.tenant_id = $7 AND user_permissions.resource_type = $8`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(userPermissions1.UserID, userPermissions1.PermissionID, userPermissions2.PermissionID).
+				WithArgs("user", userPermissions1.UserID, userPermissions1.PermissionID, userPermissions2.PermissionID, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(2))
 
-			value, err := repository.HasAllDirectPermissions(uint(1), collections.Permission([]models.Permission{{ID: 1}, {ID: 2}}))
+			value, err := repository.HasAllDirectPermissions(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission([]models.Permission{{ID: 1}, {ID: 2}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(true))
 		})
 
 		It("not found", func() {
 			userPermissions1 := pivot.UserPermissions{
-				UserID:       1,
+				UserID:       models.UserIDFromInt(1),
 				PermissionID: 1,
 			}
 
 			userPermissions2 := pivot.UserPermissions{
-				UserID:       1,
+				UserID:       models.UserIDFromInt(1),
 				PermissionID: 2,
 			}
 
-			const query = `SELECT count(*) FROM "user_permissions" WHERE user_permissions.user_id = $1 AND user_permissions.permission_id IN ($2,$3)`
+			const query = `SELECT COUNT(DISTINCT("user_permissions"."permission_id")) FROM "user_permissions" WHERE user_permissions.subject_type = $1 AND user_permissions.user_id = $2 AND user_permissions.permission_id IN ($3,$4) AND (user_permissions.starts_at IS NULL OR user_permissions.starts_at <= $5) AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > $6) AND user_permissions.tenant_id = $7 AND user_permissions.resource_type = $8`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(userPermissions1.UserID, userPermissions1.PermissionID, userPermissions2.PermissionID).
+				WithArgs("user", userPermissions1.UserID, userPermissions1.PermissionID, userPermissions2.PermissionID, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(1))
 
-			value, err := repository.HasAllDirectPermissions(uint(1), collections.Permission([]models.Permission{{ID: 1}, {ID: 2}}))
+			value, err := repository.HasAllDirectPermissions(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission([]models.Permission{{ID: 1}, {ID: 2}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(false))
 		})
@@ -242,33 +272,128 @@ var _ = Describe("User Repository", func() {
 	Context("Has Any Direct Permissions", func() {
 		It("found", func() {
 			userPermissions := pivot.UserPermissions{
-				UserID:       1,
+				UserID:       models.UserIDFromInt(1),
 				PermissionID: 1,
 			}
 
-			const query = `SELECT count(*) FROM "user_permissions" WHERE user_permissions.user_id = $1 AND user_permissions.permission_id IN ($2,$3)`
+			const query = `SELECT COUNT(DISTINCT("user_permissions"."permission_id")) FROM "user_permissions" WHERE user_permissions.subject_type = $1 AND user_permissions.user_id = $2 AND user_permissions.permission_id IN ($3,$4) AND (user_permissions.starts_at IS NULL OR user_permissions.starts_at <= $5) AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > $6) AND user_permissions.tenant_id = $7 AND user_permissions.resource_type = $8`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(userPermissions.UserID, userPermissions.PermissionID, 2).
+				WithArgs("user", userPermissions.UserID, userPermissions.PermissionID, 2, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(1))
 
-			value, err := repository.HasAnyDirectPermissions(uint(1), collections.Permission([]models.Permission{{ID: 1}, {ID: 2}}))
+			value, err := repository.HasAnyDirectPermissions(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission([]models.Permission{{ID: 1}, {ID: 2}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(true))
 		})
 
 		It("not found", func() {
-			const query = `SELECT count(*) FROM "user_permissions" WHERE user_permissions.user_id = $1 AND user_permissions.permission_id IN ($2,$3)`
+			const query = `SELECT COUNT(DISTINCT("user_permissions"."permission_id")) FROM "user_permissions" WHERE user_permissions.subject_type = $1 AND user_permissions.user_id = $2 AND user_permissions.permission_id IN ($3,$4) AND (user_permissions.starts_at IS NULL OR user_permissions.starts_at <= $5) AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > $6) AND user_permissions.tenant_id = $7 AND user_permissions.resource_type = $8`
 
 			mock.ExpectQuery(regexp.QuoteMeta(query)).
-				WithArgs(1, 1, 2).
+				WithArgs("user", 1, 1, 2, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
 				WillReturnRows(sqlmock.NewRows([]string{"count"}).
 					AddRow(0))
 
-			value, err := repository.HasAnyDirectPermissions(uint(1), collections.Permission([]models.Permission{{ID: 1}, {ID: 2}}))
+			value, err := repository.HasAnyDirectPermissions(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Permission([]models.Permission{{ID: 1}, {ID: 2}}))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(value).Should(Equal(false))
 		})
 	})
+
+	Context("Add Roles", func() {
+		It("until expiry", func() {
+			expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			const query = `INSERT INTO "user_roles" ("subject_type","user_id","role_id","tenant_id","resource_type","resource_id","starts_at","expires_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT ("subject_type","user_id","role_id","tenant_id","resource_type","resource_id") DO UPDATE SET "starts_at"="excluded"."starts_at","expires_at"="excluded"."expires_at"`
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs("user", 1, 2, "", "", "", nil, expiresAt).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			err := repository.AddRoles(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{Window: scopes.Window{ExpiresAt: &expiresAt}}, collections.Role([]models.Role{{ID: 2}}))
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("without a window", func() {
+			const query = `INSERT INTO "user_roles" ("subject_type","user_id","role_id","tenant_id","resource_type","resource_id","starts_at","expires_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT ("subject_type","user_id","role_id","tenant_id","resource_type","resource_id") DO NOTHING`
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs("user", 1, 2, "", "", "", nil, nil).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			err := repository.AddRoles(context.Background(), models.UserSubject(models.UserIDFromInt(1)), scopes.Assignment{}, collections.Role([]models.Role{{ID: 2}}))
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("Get User IDs Of Role", func() {
+		It("found", func() {
+			const countQuery = `SELECT count(*) FROM (SELECT DISTINCT user_roles.subject_type,user_roles.user_id FROM "user_roles" WHERE user_roles.role_id = $1 AND (user_roles.starts_at IS NULL OR user_roles.starts_at <= $2) AND (user_roles.expires_at IS NULL OR user_roles.expires_at > $3) AND user_roles.tenant_id = $4 AND user_roles.resource_type = $5) AS subjects`
+			const query = `SELECT subjects.subject_type,subjects.user_id FROM (SELECT DISTINCT user_roles.subject_type,user_roles.user_id FROM "user_roles" WHERE user_roles.role_id = $1 AND (user_roles.starts_at IS NULL OR user_roles.starts_at <= $2) AND (user_roles.expires_at IS NULL OR user_roles.expires_at > $3) AND user_roles.tenant_id = $4 AND user_roles.resource_type = $5) AS subjects ORDER BY subjects.subject_type, subjects.user_id LIMIT 2`
+
+			mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+				WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).
+					AddRow(3))
+			mock.ExpectQuery(regexp.QuoteMeta(query)).
+				WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "").
+				WillReturnRows(sqlmock.NewRows([]string{"subject_type", "user_id"}).
+					AddRow("device", 1).
+					AddRow("user", 1))
+
+			subjects, totalCount, err := repository.GetUserIDsOfRole(context.Background(), 1, scopes.Assignment{}, &scopes.GormPagination{Pagination: &utils.Pagination{Page: 1, Limit: 2}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(subjects).Should(Equal([]models.Subject{{Type: "device", ID: models.UserIDFromInt(1)}, models.UserSubject(models.UserIDFromInt(1))}))
+			Expect(totalCount).Should(Equal(int64(3)))
+		})
+	})
+
+	Context("Purge Expired Roles", func() {
+		It("deletes in batches", func() {
+			before := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			const sqlSelect = `SELECT DISTINCT user_roles.user_id FROM "user_roles" WHERE user_roles.expires_at <= $1 LIMIT 2`
+			const sqlDelete = `DELETE FROM "user_roles" WHERE user_roles.user_id IN ($1,$2) AND user_roles.expires_at <= $3`
+
+			mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+				WithArgs(before).
+				WillReturnRows(sqlmock.NewRows([]string{"user_id"}).
+					AddRow(1).
+					AddRow(2))
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(sqlDelete)).
+				WithArgs(1, 2, before).
+				WillReturnResult(sqlmock.NewResult(0, 3))
+			mock.ExpectCommit()
+
+			mock.ExpectQuery(regexp.QuoteMeta(sqlSelect)).
+				WithArgs(before).
+				WillReturnRows(sqlmock.NewRows([]string{"user_id"}).
+					AddRow(3))
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "user_roles" WHERE user_roles.user_id IN ($1) AND user_roles.expires_at <= $2`)).
+				WithArgs(3, before).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			deleted, err := repository.PurgeExpiredRoles(context.Background(), before, 2)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(deleted).Should(Equal(int64(4)))
+		})
+
+		It("nothing expired", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT user_roles.user_id FROM "user_roles" WHERE user_roles.expires_at <= $1 LIMIT 100`)).
+				WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+
+			deleted, err := repository.PurgeExpiredRoles(context.Background(), time.Now(), 100)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(deleted).Should(Equal(int64(0)))
+		})
+	})
 })
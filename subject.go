@@ -0,0 +1,20 @@
+package permify_gorm
+
+import (
+	"fmt"
+)
+
+// Subject is the owner of the assignments when it is not a user, e.g. an api client, a service account or a device.
+// The ids of the different subject types can collide, the type tells them apart. The user methods take it instead of a user id.
+// The ID is converted to the UserIDType like the user ids, the ids that are given alone are of the "user" type. (models.DefaultSubjectType)
+// example: permify.AddRolesToUser(Subject{Type: "device", ID: 7}, "firmware updater")
+type Subject struct {
+	Type string
+	ID   interface{}
+}
+
+// String returns the type and the id of the subject. example: device:7
+// @return string
+func (s Subject) String() string {
+	return fmt.Sprintf("%s:%v", s.Type, s.ID)
+}
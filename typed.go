@@ -0,0 +1,945 @@
+package permify_gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/Permify/go-role/collections"
+	"github.com/Permify/go-role/models"
+	"github.com/Permify/go-role/options"
+)
+
+// Typed has the methods of Permify that take roles and permissions, with RoleRef and PermissionRef parameters instead of interface{}.
+// The role and permission identifiers are checked by the compiler, the user ids are models.UserID.
+// example: permify.Typed().AddPermissionsToRole(RoleByName("admin"), PermissionByName("edit user details", "delete user"))
+type Typed struct {
+	permify     *Permify
+	subjectType string
+}
+
+// Typed returns the type-safe methods of Permify. The tenant, the resource and the time window of Permify are kept.
+// @return *Typed
+func (s *Permify) Typed() *Typed {
+	return &Typed{permify: s}
+}
+
+// Tenant returns the typed methods of Permify.Tenant.
+// @param string
+// @return *Typed
+func (t *Typed) Tenant(tenantID string) *Typed {
+	return &Typed{permify: t.permify.Tenant(tenantID), subjectType: t.subjectType}
+}
+
+// Resource returns the typed methods of Permify.Resource.
+// @param string
+// @param string
+// @return *Typed
+func (t *Typed) Resource(resourceType string, resourceID string) *Typed {
+	return &Typed{permify: t.permify.Resource(resourceType, resourceID), subjectType: t.subjectType}
+}
+
+// Between returns the typed methods of Permify.Between.
+// @param time.Time
+// @param time.Time
+// @return *Typed
+func (t *Typed) Between(startsAt time.Time, expiresAt time.Time) *Typed {
+	return &Typed{permify: t.permify.Between(startsAt, expiresAt), subjectType: t.subjectType}
+}
+
+// ActingAs returns the typed methods of Permify.ActingAs, the acting user is a user. (of the DefaultSubjectType)
+// @param models.UserID
+// @return *Typed
+func (t *Typed) ActingAs(userID models.UserID) *Typed {
+	return &Typed{permify: t.permify.ActingAs(userID), subjectType: t.subjectType}
+}
+
+// SubjectType returns the typed methods whose user ids are the ids of the subjects of the type. (see Subject)
+// example: permify.Typed().SubjectType("device").AddRolesToUser(models.UserIDFromInt(7), RoleByName("firmware updater"))
+// @param string
+// @return *Typed
+func (t *Typed) SubjectType(subjectType string) *Typed {
+	return &Typed{permify: t.permify, subjectType: subjectType}
+}
+
+// subject returns the user id as a Subject of the subject type, or alone if the subject type is not set.
+// @param models.UserID
+// @return interface{}
+func (t *Typed) subject(userID models.UserID) interface{} {
+	if t.subjectType == "" {
+		return userID
+	}
+	return Subject{Type: t.subjectType, ID: userID}
+}
+
+// GetRole is the typed variant of Permify.GetRole.
+// @param RoleRef
+// @param bool
+// @return models.Role, error
+func (t *Typed) GetRole(r RoleRef, withPermissions bool) (role models.Role, err error) {
+	return t.GetRoleCtx(context.Background(), r, withPermissions)
+}
+
+// GetRoleCtx is the context-aware variant of GetRole.
+// @param context.Context
+// @param RoleRef
+// @param bool
+// @return models.Role, error
+func (t *Typed) GetRoleCtx(ctx context.Context, r RoleRef, withPermissions bool) (role models.Role, err error) {
+	return t.permify.GetRoleCtx(ctx, r, withPermissions)
+}
+
+// GetRoles is the typed variant of Permify.GetRoles.
+// @param RoleRef
+// @param bool
+// @return collections.Role, error
+func (t *Typed) GetRoles(r RoleRef, withPermissions bool) (roles collections.Role, err error) {
+	return t.GetRolesCtx(context.Background(), r, withPermissions)
+}
+
+// GetRolesCtx is the context-aware variant of GetRoles.
+// @param context.Context
+// @param RoleRef
+// @param bool
+// @return collections.Role, error
+func (t *Typed) GetRolesCtx(ctx context.Context, r RoleRef, withPermissions bool) (roles collections.Role, err error) {
+	return t.permify.GetRolesCtx(ctx, r, withPermissions)
+}
+
+// GetUserIDsOfRole is the typed variant of Permify.GetUserIDsOfRole.
+// @param RoleRef
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (t *Typed) GetUserIDsOfRole(r RoleRef, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	return t.GetUserIDsOfRoleCtx(context.Background(), r, option)
+}
+
+// GetUserIDsOfRoleCtx is the context-aware variant of GetUserIDsOfRole.
+// @param context.Context
+// @param RoleRef
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (t *Typed) GetUserIDsOfRoleCtx(ctx context.Context, r RoleRef, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	return t.permify.GetUserIDsOfRoleCtx(ctx, r, option)
+}
+
+// DeleteRole is the typed variant of Permify.DeleteRole.
+// @param RoleRef
+// @return error
+func (t *Typed) DeleteRole(r RoleRef) (err error) {
+	return t.DeleteRoleCtx(context.Background(), r)
+}
+
+// DeleteRoleCtx is the context-aware variant of DeleteRole.
+// @param context.Context
+// @param RoleRef
+// @return error
+func (t *Typed) DeleteRoleCtx(ctx context.Context, r RoleRef) (err error) {
+	return t.permify.DeleteRoleCtx(ctx, r)
+}
+
+// AddPermissionsToRole is the typed variant of Permify.AddPermissionsToRole.
+// @param RoleRef
+// @param PermissionRef
+// @return error
+func (t *Typed) AddPermissionsToRole(r RoleRef, p PermissionRef) (err error) {
+	return t.AddPermissionsToRoleCtx(context.Background(), r, p)
+}
+
+// AddPermissionsToRoleCtx is the context-aware variant of AddPermissionsToRole.
+// @param context.Context
+// @param RoleRef
+// @param PermissionRef
+// @return error
+func (t *Typed) AddPermissionsToRoleCtx(ctx context.Context, r RoleRef, p PermissionRef) (err error) {
+	return t.permify.AddPermissionsToRoleCtx(ctx, r, p)
+}
+
+// AddPermissionsToRoleUntil is the typed variant of Permify.AddPermissionsToRoleUntil.
+// @param RoleRef
+// @param PermissionRef
+// @param time.Time
+// @return error
+func (t *Typed) AddPermissionsToRoleUntil(r RoleRef, p PermissionRef, expiresAt time.Time) (err error) {
+	return t.AddPermissionsToRoleUntilCtx(context.Background(), r, p, expiresAt)
+}
+
+// AddPermissionsToRoleUntilCtx is the context-aware variant of AddPermissionsToRoleUntil.
+// @param context.Context
+// @param RoleRef
+// @param PermissionRef
+// @param time.Time
+// @return error
+func (t *Typed) AddPermissionsToRoleUntilCtx(ctx context.Context, r RoleRef, p PermissionRef, expiresAt time.Time) (err error) {
+	return t.permify.AddPermissionsToRoleUntilCtx(ctx, r, p, expiresAt)
+}
+
+// ReplacePermissionsToRole is the typed variant of Permify.ReplacePermissionsToRole.
+// @param RoleRef
+// @param PermissionRef
+// @return error
+func (t *Typed) ReplacePermissionsToRole(r RoleRef, p PermissionRef) (err error) {
+	return t.ReplacePermissionsToRoleCtx(context.Background(), r, p)
+}
+
+// ReplacePermissionsToRoleCtx is the context-aware variant of ReplacePermissionsToRole.
+// @param context.Context
+// @param RoleRef
+// @param PermissionRef
+// @return error
+func (t *Typed) ReplacePermissionsToRoleCtx(ctx context.Context, r RoleRef, p PermissionRef) (err error) {
+	return t.permify.ReplacePermissionsToRoleCtx(ctx, r, p)
+}
+
+// RemovePermissionsFromRole is the typed variant of Permify.RemovePermissionsFromRole.
+// @param RoleRef
+// @param PermissionRef
+// @return error
+func (t *Typed) RemovePermissionsFromRole(r RoleRef, p PermissionRef) (err error) {
+	return t.RemovePermissionsFromRoleCtx(context.Background(), r, p)
+}
+
+// RemovePermissionsFromRoleCtx is the context-aware variant of RemovePermissionsFromRole.
+// @param context.Context
+// @param RoleRef
+// @param PermissionRef
+// @return error
+func (t *Typed) RemovePermissionsFromRoleCtx(ctx context.Context, r RoleRef, p PermissionRef) (err error) {
+	return t.permify.RemovePermissionsFromRoleCtx(ctx, r, p)
+}
+
+// DenyPermissionsToRole is the typed variant of Permify.DenyPermissionsToRole.
+// @param RoleRef
+// @param PermissionRef
+// @return error
+func (t *Typed) DenyPermissionsToRole(r RoleRef, p PermissionRef) (err error) {
+	return t.DenyPermissionsToRoleCtx(context.Background(), r, p)
+}
+
+// DenyPermissionsToRoleCtx is the context-aware variant of DenyPermissionsToRole.
+// @param context.Context
+// @param RoleRef
+// @param PermissionRef
+// @return error
+func (t *Typed) DenyPermissionsToRoleCtx(ctx context.Context, r RoleRef, p PermissionRef) (err error) {
+	return t.permify.DenyPermissionsToRoleCtx(ctx, r, p)
+}
+
+// RemoveDeniedPermissionsFromRole is the typed variant of Permify.RemoveDeniedPermissionsFromRole.
+// @param RoleRef
+// @param PermissionRef
+// @return error
+func (t *Typed) RemoveDeniedPermissionsFromRole(r RoleRef, p PermissionRef) (err error) {
+	return t.RemoveDeniedPermissionsFromRoleCtx(context.Background(), r, p)
+}
+
+// RemoveDeniedPermissionsFromRoleCtx is the context-aware variant of RemoveDeniedPermissionsFromRole.
+// @param context.Context
+// @param RoleRef
+// @param PermissionRef
+// @return error
+func (t *Typed) RemoveDeniedPermissionsFromRoleCtx(ctx context.Context, r RoleRef, p PermissionRef) (err error) {
+	return t.permify.RemoveDeniedPermissionsFromRoleCtx(ctx, r, p)
+}
+
+// AddChildRolesToRole is the typed variant of Permify.AddChildRolesToRole.
+// @param RoleRef
+// @param RoleRef
+// @return error
+func (t *Typed) AddChildRolesToRole(r RoleRef, c RoleRef) (err error) {
+	return t.AddChildRolesToRoleCtx(context.Background(), r, c)
+}
+
+// AddChildRolesToRoleCtx is the context-aware variant of AddChildRolesToRole.
+// @param context.Context
+// @param RoleRef
+// @param RoleRef
+// @return error
+func (t *Typed) AddChildRolesToRoleCtx(ctx context.Context, r RoleRef, c RoleRef) (err error) {
+	return t.permify.AddChildRolesToRoleCtx(ctx, r, c)
+}
+
+// RemoveChildRolesFromRole is the typed variant of Permify.RemoveChildRolesFromRole.
+// @param RoleRef
+// @param RoleRef
+// @return error
+func (t *Typed) RemoveChildRolesFromRole(r RoleRef, c RoleRef) (err error) {
+	return t.RemoveChildRolesFromRoleCtx(context.Background(), r, c)
+}
+
+// RemoveChildRolesFromRoleCtx is the context-aware variant of RemoveChildRolesFromRole.
+// @param context.Context
+// @param RoleRef
+// @param RoleRef
+// @return error
+func (t *Typed) RemoveChildRolesFromRoleCtx(ctx context.Context, r RoleRef, c RoleRef) (err error) {
+	return t.permify.RemoveChildRolesFromRoleCtx(ctx, r, c)
+}
+
+// GetPermission is the typed variant of Permify.GetPermission.
+// @param PermissionRef
+// @return models.Permission, error
+func (t *Typed) GetPermission(p PermissionRef) (permission models.Permission, err error) {
+	return t.GetPermissionCtx(context.Background(), p)
+}
+
+// GetPermissionCtx is the context-aware variant of GetPermission.
+// @param context.Context
+// @param PermissionRef
+// @return models.Permission, error
+func (t *Typed) GetPermissionCtx(ctx context.Context, p PermissionRef) (permission models.Permission, err error) {
+	return t.permify.GetPermissionCtx(ctx, p)
+}
+
+// GetPermissions is the typed variant of Permify.GetPermissions.
+// @param PermissionRef
+// @return collections.Permission, error
+func (t *Typed) GetPermissions(p PermissionRef) (permissions collections.Permission, err error) {
+	return t.GetPermissionsCtx(context.Background(), p)
+}
+
+// GetPermissionsCtx is the context-aware variant of GetPermissions.
+// @param context.Context
+// @param PermissionRef
+// @return collections.Permission, error
+func (t *Typed) GetPermissionsCtx(ctx context.Context, p PermissionRef) (permissions collections.Permission, err error) {
+	return t.permify.GetPermissionsCtx(ctx, p)
+}
+
+// GetPermissionsOfRoles is the typed variant of Permify.GetPermissionsOfRoles.
+// @param RoleRef
+// @param options.PermissionOption
+// @return collections.Permission, int64, error
+func (t *Typed) GetPermissionsOfRoles(r RoleRef, option options.PermissionOption) (permissions collections.Permission, totalCount int64, err error) {
+	return t.GetPermissionsOfRolesCtx(context.Background(), r, option)
+}
+
+// GetPermissionsOfRolesCtx is the context-aware variant of GetPermissionsOfRoles.
+// @param context.Context
+// @param RoleRef
+// @param options.PermissionOption
+// @return collections.Permission, int64, error
+func (t *Typed) GetPermissionsOfRolesCtx(ctx context.Context, r RoleRef, option options.PermissionOption) (permissions collections.Permission, totalCount int64, err error) {
+	return t.permify.GetPermissionsOfRolesCtx(ctx, r, option)
+}
+
+// GetUserIDsWithDirectPermission is the typed variant of Permify.GetUserIDsWithDirectPermission.
+// @param PermissionRef
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (t *Typed) GetUserIDsWithDirectPermission(p PermissionRef, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	return t.GetUserIDsWithDirectPermissionCtx(context.Background(), p, option)
+}
+
+// GetUserIDsWithDirectPermissionCtx is the context-aware variant of GetUserIDsWithDirectPermission.
+// @param context.Context
+// @param PermissionRef
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (t *Typed) GetUserIDsWithDirectPermissionCtx(ctx context.Context, p PermissionRef, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	return t.permify.GetUserIDsWithDirectPermissionCtx(ctx, p, option)
+}
+
+// GetUserIDsWithPermission is the typed variant of Permify.GetUserIDsWithPermission.
+// @param PermissionRef
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (t *Typed) GetUserIDsWithPermission(p PermissionRef, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	return t.GetUserIDsWithPermissionCtx(context.Background(), p, option)
+}
+
+// GetUserIDsWithPermissionCtx is the context-aware variant of GetUserIDsWithPermission.
+// @param context.Context
+// @param PermissionRef
+// @param options.UserOption
+// @return []models.Subject, int64, error
+func (t *Typed) GetUserIDsWithPermissionCtx(ctx context.Context, p PermissionRef, option options.UserOption) (subjects []models.Subject, totalCount int64, err error) {
+	return t.permify.GetUserIDsWithPermissionCtx(ctx, p, option)
+}
+
+// DeletePermission is the typed variant of Permify.DeletePermission.
+// @param PermissionRef
+// @return error
+func (t *Typed) DeletePermission(p PermissionRef) (err error) {
+	return t.DeletePermissionCtx(context.Background(), p)
+}
+
+// DeletePermissionCtx is the context-aware variant of DeletePermission.
+// @param context.Context
+// @param PermissionRef
+// @return error
+func (t *Typed) DeletePermissionCtx(ctx context.Context, p PermissionRef) (err error) {
+	return t.permify.DeletePermissionCtx(ctx, p)
+}
+
+// AddPermissionsToUser is the typed variant of Permify.AddPermissionsToUser.
+// @param models.UserID
+// @param PermissionRef
+// @return error
+func (t *Typed) AddPermissionsToUser(userID models.UserID, p PermissionRef) (err error) {
+	return t.AddPermissionsToUserCtx(context.Background(), userID, p)
+}
+
+// AddPermissionsToUserCtx is the context-aware variant of AddPermissionsToUser.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return error
+func (t *Typed) AddPermissionsToUserCtx(ctx context.Context, userID models.UserID, p PermissionRef) (err error) {
+	return t.permify.AddPermissionsToUserCtx(ctx, t.subject(userID), p)
+}
+
+// AddPermissionsToUserUntil is the typed variant of Permify.AddPermissionsToUserUntil.
+// @param models.UserID
+// @param PermissionRef
+// @param time.Time
+// @return error
+func (t *Typed) AddPermissionsToUserUntil(userID models.UserID, p PermissionRef, expiresAt time.Time) (err error) {
+	return t.AddPermissionsToUserUntilCtx(context.Background(), userID, p, expiresAt)
+}
+
+// AddPermissionsToUserUntilCtx is the context-aware variant of AddPermissionsToUserUntil.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @param time.Time
+// @return error
+func (t *Typed) AddPermissionsToUserUntilCtx(ctx context.Context, userID models.UserID, p PermissionRef, expiresAt time.Time) (err error) {
+	return t.permify.AddPermissionsToUserUntilCtx(ctx, t.subject(userID), p, expiresAt)
+}
+
+// ReplacePermissionsToUser is the typed variant of Permify.ReplacePermissionsToUser.
+// @param models.UserID
+// @param PermissionRef
+// @return error
+func (t *Typed) ReplacePermissionsToUser(userID models.UserID, p PermissionRef) (err error) {
+	return t.ReplacePermissionsToUserCtx(context.Background(), userID, p)
+}
+
+// ReplacePermissionsToUserCtx is the context-aware variant of ReplacePermissionsToUser.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return error
+func (t *Typed) ReplacePermissionsToUserCtx(ctx context.Context, userID models.UserID, p PermissionRef) (err error) {
+	return t.permify.ReplacePermissionsToUserCtx(ctx, t.subject(userID), p)
+}
+
+// RemovePermissionsFromUser is the typed variant of Permify.RemovePermissionsFromUser.
+// @param models.UserID
+// @param PermissionRef
+// @return error
+func (t *Typed) RemovePermissionsFromUser(userID models.UserID, p PermissionRef) (err error) {
+	return t.RemovePermissionsFromUserCtx(context.Background(), userID, p)
+}
+
+// RemovePermissionsFromUserCtx is the context-aware variant of RemovePermissionsFromUser.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return error
+func (t *Typed) RemovePermissionsFromUserCtx(ctx context.Context, userID models.UserID, p PermissionRef) (err error) {
+	return t.permify.RemovePermissionsFromUserCtx(ctx, t.subject(userID), p)
+}
+
+// DenyPermissionsToUser is the typed variant of Permify.DenyPermissionsToUser.
+// @param models.UserID
+// @param PermissionRef
+// @return error
+func (t *Typed) DenyPermissionsToUser(userID models.UserID, p PermissionRef) (err error) {
+	return t.DenyPermissionsToUserCtx(context.Background(), userID, p)
+}
+
+// DenyPermissionsToUserCtx is the context-aware variant of DenyPermissionsToUser.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return error
+func (t *Typed) DenyPermissionsToUserCtx(ctx context.Context, userID models.UserID, p PermissionRef) (err error) {
+	return t.permify.DenyPermissionsToUserCtx(ctx, t.subject(userID), p)
+}
+
+// RemoveDeniedPermissionsFromUser is the typed variant of Permify.RemoveDeniedPermissionsFromUser.
+// @param models.UserID
+// @param PermissionRef
+// @return error
+func (t *Typed) RemoveDeniedPermissionsFromUser(userID models.UserID, p PermissionRef) (err error) {
+	return t.RemoveDeniedPermissionsFromUserCtx(context.Background(), userID, p)
+}
+
+// RemoveDeniedPermissionsFromUserCtx is the context-aware variant of RemoveDeniedPermissionsFromUser.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return error
+func (t *Typed) RemoveDeniedPermissionsFromUserCtx(ctx context.Context, userID models.UserID, p PermissionRef) (err error) {
+	return t.permify.RemoveDeniedPermissionsFromUserCtx(ctx, t.subject(userID), p)
+}
+
+// AddRolesToUser is the typed variant of Permify.AddRolesToUser.
+// @param models.UserID
+// @param RoleRef
+// @return error
+func (t *Typed) AddRolesToUser(userID models.UserID, r RoleRef) (err error) {
+	return t.AddRolesToUserCtx(context.Background(), userID, r)
+}
+
+// AddRolesToUserCtx is the context-aware variant of AddRolesToUser.
+// @param context.Context
+// @param models.UserID
+// @param RoleRef
+// @return error
+func (t *Typed) AddRolesToUserCtx(ctx context.Context, userID models.UserID, r RoleRef) (err error) {
+	return t.permify.AddRolesToUserCtx(ctx, t.subject(userID), r)
+}
+
+// AddRolesToUserUntil is the typed variant of Permify.AddRolesToUserUntil.
+// @param models.UserID
+// @param RoleRef
+// @param time.Time
+// @return error
+func (t *Typed) AddRolesToUserUntil(userID models.UserID, r RoleRef, expiresAt time.Time) (err error) {
+	return t.AddRolesToUserUntilCtx(context.Background(), userID, r, expiresAt)
+}
+
+// AddRolesToUserUntilCtx is the context-aware variant of AddRolesToUserUntil.
+// @param context.Context
+// @param models.UserID
+// @param RoleRef
+// @param time.Time
+// @return error
+func (t *Typed) AddRolesToUserUntilCtx(ctx context.Context, userID models.UserID, r RoleRef, expiresAt time.Time) (err error) {
+	return t.permify.AddRolesToUserUntilCtx(ctx, t.subject(userID), r, expiresAt)
+}
+
+// ReplaceRolesToUser is the typed variant of Permify.ReplaceRolesToUser.
+// @param models.UserID
+// @param RoleRef
+// @return error
+func (t *Typed) ReplaceRolesToUser(userID models.UserID, r RoleRef) (err error) {
+	return t.ReplaceRolesToUserCtx(context.Background(), userID, r)
+}
+
+// ReplaceRolesToUserCtx is the context-aware variant of ReplaceRolesToUser.
+// @param context.Context
+// @param models.UserID
+// @param RoleRef
+// @return error
+func (t *Typed) ReplaceRolesToUserCtx(ctx context.Context, userID models.UserID, r RoleRef) (err error) {
+	return t.permify.ReplaceRolesToUserCtx(ctx, t.subject(userID), r)
+}
+
+// RemoveRolesFromUser is the typed variant of Permify.RemoveRolesFromUser.
+// @param models.UserID
+// @param RoleRef
+// @return error
+func (t *Typed) RemoveRolesFromUser(userID models.UserID, r RoleRef) (err error) {
+	return t.RemoveRolesFromUserCtx(context.Background(), userID, r)
+}
+
+// RemoveRolesFromUserCtx is the context-aware variant of RemoveRolesFromUser.
+// @param context.Context
+// @param models.UserID
+// @param RoleRef
+// @return error
+func (t *Typed) RemoveRolesFromUserCtx(ctx context.Context, userID models.UserID, r RoleRef) (err error) {
+	return t.permify.RemoveRolesFromUserCtx(ctx, t.subject(userID), r)
+}
+
+// AddRolesToUserOn is the typed variant of Permify.AddRolesToUserOn.
+// @param models.UserID
+// @param RoleRef
+// @param string
+// @param string
+// @return error
+func (t *Typed) AddRolesToUserOn(userID models.UserID, r RoleRef, resourceType string, resourceID string) (err error) {
+	return t.AddRolesToUserOnCtx(context.Background(), userID, r, resourceType, resourceID)
+}
+
+// AddRolesToUserOnCtx is the context-aware variant of AddRolesToUserOn.
+// @param context.Context
+// @param models.UserID
+// @param RoleRef
+// @param string
+// @param string
+// @return error
+func (t *Typed) AddRolesToUserOnCtx(ctx context.Context, userID models.UserID, r RoleRef, resourceType string, resourceID string) (err error) {
+	return t.permify.AddRolesToUserOnCtx(ctx, t.subject(userID), r, resourceType, resourceID)
+}
+
+// RemoveRolesFromUserOn is the typed variant of Permify.RemoveRolesFromUserOn.
+// @param models.UserID
+// @param RoleRef
+// @param string
+// @param string
+// @return error
+func (t *Typed) RemoveRolesFromUserOn(userID models.UserID, r RoleRef, resourceType string, resourceID string) (err error) {
+	return t.RemoveRolesFromUserOnCtx(context.Background(), userID, r, resourceType, resourceID)
+}
+
+// RemoveRolesFromUserOnCtx is the context-aware variant of RemoveRolesFromUserOn.
+// @param context.Context
+// @param models.UserID
+// @param RoleRef
+// @param string
+// @param string
+// @return error
+func (t *Typed) RemoveRolesFromUserOnCtx(ctx context.Context, userID models.UserID, r RoleRef, resourceType string, resourceID string) (err error) {
+	return t.permify.RemoveRolesFromUserOnCtx(ctx, t.subject(userID), r, resourceType, resourceID)
+}
+
+// AddPermissionsToUserOn is the typed variant of Permify.AddPermissionsToUserOn.
+// @param models.UserID
+// @param PermissionRef
+// @param string
+// @param string
+// @return error
+func (t *Typed) AddPermissionsToUserOn(userID models.UserID, p PermissionRef, resourceType string, resourceID string) (err error) {
+	return t.AddPermissionsToUserOnCtx(context.Background(), userID, p, resourceType, resourceID)
+}
+
+// AddPermissionsToUserOnCtx is the context-aware variant of AddPermissionsToUserOn.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @param string
+// @param string
+// @return error
+func (t *Typed) AddPermissionsToUserOnCtx(ctx context.Context, userID models.UserID, p PermissionRef, resourceType string, resourceID string) (err error) {
+	return t.permify.AddPermissionsToUserOnCtx(ctx, t.subject(userID), p, resourceType, resourceID)
+}
+
+// RemovePermissionsFromUserOn is the typed variant of Permify.RemovePermissionsFromUserOn.
+// @param models.UserID
+// @param PermissionRef
+// @param string
+// @param string
+// @return error
+func (t *Typed) RemovePermissionsFromUserOn(userID models.UserID, p PermissionRef, resourceType string, resourceID string) (err error) {
+	return t.RemovePermissionsFromUserOnCtx(context.Background(), userID, p, resourceType, resourceID)
+}
+
+// RemovePermissionsFromUserOnCtx is the context-aware variant of RemovePermissionsFromUserOn.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @param string
+// @param string
+// @return error
+func (t *Typed) RemovePermissionsFromUserOnCtx(ctx context.Context, userID models.UserID, p PermissionRef, resourceType string, resourceID string) (err error) {
+	return t.permify.RemovePermissionsFromUserOnCtx(ctx, t.subject(userID), p, resourceType, resourceID)
+}
+
+// RoleHasPermission is the typed variant of Permify.RoleHasPermission.
+// @param RoleRef
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) RoleHasPermission(r RoleRef, p PermissionRef) (b bool, err error) {
+	return t.RoleHasPermissionCtx(context.Background(), r, p)
+}
+
+// RoleHasPermissionCtx is the context-aware variant of RoleHasPermission.
+// @param context.Context
+// @param RoleRef
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) RoleHasPermissionCtx(ctx context.Context, r RoleRef, p PermissionRef) (b bool, err error) {
+	return t.permify.RoleHasPermissionCtx(ctx, r, p)
+}
+
+// RoleHasAllPermissions is the typed variant of Permify.RoleHasAllPermissions.
+// @param RoleRef
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) RoleHasAllPermissions(r RoleRef, p PermissionRef) (b bool, err error) {
+	return t.RoleHasAllPermissionsCtx(context.Background(), r, p)
+}
+
+// RoleHasAllPermissionsCtx is the context-aware variant of RoleHasAllPermissions.
+// @param context.Context
+// @param RoleRef
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) RoleHasAllPermissionsCtx(ctx context.Context, r RoleRef, p PermissionRef) (b bool, err error) {
+	return t.permify.RoleHasAllPermissionsCtx(ctx, r, p)
+}
+
+// RoleHasAnyPermissions is the typed variant of Permify.RoleHasAnyPermissions.
+// @param RoleRef
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) RoleHasAnyPermissions(r RoleRef, p PermissionRef) (b bool, err error) {
+	return t.RoleHasAnyPermissionsCtx(context.Background(), r, p)
+}
+
+// RoleHasAnyPermissionsCtx is the context-aware variant of RoleHasAnyPermissions.
+// @param context.Context
+// @param RoleRef
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) RoleHasAnyPermissionsCtx(ctx context.Context, r RoleRef, p PermissionRef) (b bool, err error) {
+	return t.permify.RoleHasAnyPermissionsCtx(ctx, r, p)
+}
+
+// UserHasRole is the typed variant of Permify.UserHasRole.
+// @param models.UserID
+// @param RoleRef
+// @return bool, error
+func (t *Typed) UserHasRole(userID models.UserID, r RoleRef) (b bool, err error) {
+	return t.UserHasRoleCtx(context.Background(), userID, r)
+}
+
+// UserHasRoleCtx is the context-aware variant of UserHasRole.
+// @param context.Context
+// @param models.UserID
+// @param RoleRef
+// @return bool, error
+func (t *Typed) UserHasRoleCtx(ctx context.Context, userID models.UserID, r RoleRef) (b bool, err error) {
+	return t.permify.UserHasRoleCtx(ctx, t.subject(userID), r)
+}
+
+// UserHasAllRoles is the typed variant of Permify.UserHasAllRoles.
+// @param models.UserID
+// @param RoleRef
+// @return bool, error
+func (t *Typed) UserHasAllRoles(userID models.UserID, r RoleRef) (b bool, err error) {
+	return t.UserHasAllRolesCtx(context.Background(), userID, r)
+}
+
+// UserHasAllRolesCtx is the context-aware variant of UserHasAllRoles.
+// @param context.Context
+// @param models.UserID
+// @param RoleRef
+// @return bool, error
+func (t *Typed) UserHasAllRolesCtx(ctx context.Context, userID models.UserID, r RoleRef) (b bool, err error) {
+	return t.permify.UserHasAllRolesCtx(ctx, t.subject(userID), r)
+}
+
+// UserHasAnyRoles is the typed variant of Permify.UserHasAnyRoles.
+// @param models.UserID
+// @param RoleRef
+// @return bool, error
+func (t *Typed) UserHasAnyRoles(userID models.UserID, r RoleRef) (b bool, err error) {
+	return t.UserHasAnyRolesCtx(context.Background(), userID, r)
+}
+
+// UserHasAnyRolesCtx is the context-aware variant of UserHasAnyRoles.
+// @param context.Context
+// @param models.UserID
+// @param RoleRef
+// @return bool, error
+func (t *Typed) UserHasAnyRolesCtx(ctx context.Context, userID models.UserID, r RoleRef) (b bool, err error) {
+	return t.permify.UserHasAnyRolesCtx(ctx, t.subject(userID), r)
+}
+
+// UserHasDirectPermission is the typed variant of Permify.UserHasDirectPermission.
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasDirectPermission(userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.UserHasDirectPermissionCtx(context.Background(), userID, p)
+}
+
+// UserHasDirectPermissionCtx is the context-aware variant of UserHasDirectPermission.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasDirectPermissionCtx(ctx context.Context, userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.permify.UserHasDirectPermissionCtx(ctx, t.subject(userID), p)
+}
+
+// UserHasAllDirectPermissions is the typed variant of Permify.UserHasAllDirectPermissions.
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasAllDirectPermissions(userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.UserHasAllDirectPermissionsCtx(context.Background(), userID, p)
+}
+
+// UserHasAllDirectPermissionsCtx is the context-aware variant of UserHasAllDirectPermissions.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasAllDirectPermissionsCtx(ctx context.Context, userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.permify.UserHasAllDirectPermissionsCtx(ctx, t.subject(userID), p)
+}
+
+// UserHasAnyDirectPermissions is the typed variant of Permify.UserHasAnyDirectPermissions.
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasAnyDirectPermissions(userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.UserHasAnyDirectPermissionsCtx(context.Background(), userID, p)
+}
+
+// UserHasAnyDirectPermissionsCtx is the context-aware variant of UserHasAnyDirectPermissions.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasAnyDirectPermissionsCtx(ctx context.Context, userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.permify.UserHasAnyDirectPermissionsCtx(ctx, t.subject(userID), p)
+}
+
+// UserHasPermission is the typed variant of Permify.UserHasPermission.
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasPermission(userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.UserHasPermissionCtx(context.Background(), userID, p)
+}
+
+// UserHasPermissionCtx is the context-aware variant of UserHasPermission.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasPermissionCtx(ctx context.Context, userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.permify.UserHasPermissionCtx(ctx, t.subject(userID), p)
+}
+
+// UserHasRoleOn is the typed variant of Permify.UserHasRoleOn.
+// @param models.UserID
+// @param RoleRef
+// @param string
+// @param string
+// @return bool, error
+func (t *Typed) UserHasRoleOn(userID models.UserID, r RoleRef, resourceType string, resourceID string) (b bool, err error) {
+	return t.UserHasRoleOnCtx(context.Background(), userID, r, resourceType, resourceID)
+}
+
+// UserHasRoleOnCtx is the context-aware variant of UserHasRoleOn.
+// @param context.Context
+// @param models.UserID
+// @param RoleRef
+// @param string
+// @param string
+// @return bool, error
+func (t *Typed) UserHasRoleOnCtx(ctx context.Context, userID models.UserID, r RoleRef, resourceType string, resourceID string) (b bool, err error) {
+	return t.permify.UserHasRoleOnCtx(ctx, t.subject(userID), r, resourceType, resourceID)
+}
+
+// UserHasPermissionOn is the typed variant of Permify.UserHasPermissionOn.
+// @param models.UserID
+// @param PermissionRef
+// @param string
+// @param string
+// @return bool, error
+func (t *Typed) UserHasPermissionOn(userID models.UserID, p PermissionRef, resourceType string, resourceID string) (b bool, err error) {
+	return t.UserHasPermissionOnCtx(context.Background(), userID, p, resourceType, resourceID)
+}
+
+// UserHasPermissionOnCtx is the context-aware variant of UserHasPermissionOn.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @param string
+// @param string
+// @return bool, error
+func (t *Typed) UserHasPermissionOnCtx(ctx context.Context, userID models.UserID, p PermissionRef, resourceType string, resourceID string) (b bool, err error) {
+	return t.permify.UserHasPermissionOnCtx(ctx, t.subject(userID), p, resourceType, resourceID)
+}
+
+// UserHasAllPermissions is the typed variant of Permify.UserHasAllPermissions.
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasAllPermissions(userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.UserHasAllPermissionsCtx(context.Background(), userID, p)
+}
+
+// UserHasAllPermissionsCtx is the context-aware variant of UserHasAllPermissions.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasAllPermissionsCtx(ctx context.Context, userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.permify.UserHasAllPermissionsCtx(ctx, t.subject(userID), p)
+}
+
+// UserHasAnyPermissions is the typed variant of Permify.UserHasAnyPermissions.
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasAnyPermissions(userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.UserHasAnyPermissionsCtx(context.Background(), userID, p)
+}
+
+// UserHasAnyPermissionsCtx is the context-aware variant of UserHasAnyPermissions.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return bool, error
+func (t *Typed) UserHasAnyPermissionsCtx(ctx context.Context, userID models.UserID, p PermissionRef) (b bool, err error) {
+	return t.permify.UserHasAnyPermissionsCtx(ctx, t.subject(userID), p)
+}
+
+// ExplainUserPermission is the typed variant of Permify.ExplainUserPermission.
+// @param models.UserID
+// @param PermissionRef
+// @return Explanation, error
+func (t *Typed) ExplainUserPermission(userID models.UserID, p PermissionRef) (explanation Explanation, err error) {
+	return t.ExplainUserPermissionCtx(context.Background(), userID, p)
+}
+
+// ExplainUserPermissionCtx is the context-aware variant of ExplainUserPermission.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return Explanation, error
+func (t *Typed) ExplainUserPermissionCtx(ctx context.Context, userID models.UserID, p PermissionRef) (explanation Explanation, err error) {
+	return t.permify.ExplainUserPermissionCtx(ctx, t.subject(userID), p)
+}
+
+// UserPermissionsMap is the typed variant of Permify.UserPermissionsMap.
+// @param models.UserID
+// @param PermissionRef
+// @return map[string]bool, error
+func (t *Typed) UserPermissionsMap(userID models.UserID, p PermissionRef) (permissions map[string]bool, err error) {
+	return t.UserPermissionsMapCtx(context.Background(), userID, p)
+}
+
+// UserPermissionsMapCtx is the context-aware variant of UserPermissionsMap.
+// @param context.Context
+// @param models.UserID
+// @param PermissionRef
+// @return map[string]bool, error
+func (t *Typed) UserPermissionsMapCtx(ctx context.Context, userID models.UserID, p PermissionRef) (permissions map[string]bool, err error) {
+	return t.permify.UserPermissionsMapCtx(ctx, t.subject(userID), p)
+}
+
+// UsersHavePermission is the typed variant of Permify.UsersHavePermission, the users are keyed by the given ids.
+// @param []models.UserID
+// @param PermissionRef
+// @return map[models.UserID]bool, error
+func (t *Typed) UsersHavePermission(userIDs []models.UserID, p PermissionRef) (users map[models.UserID]bool, err error) {
+	return t.UsersHavePermissionCtx(context.Background(), userIDs, p)
+}
+
+// UsersHavePermissionCtx is the context-aware variant of UsersHavePermission.
+// @param context.Context
+// @param []models.UserID
+// @param PermissionRef
+// @return map[models.UserID]bool, error
+func (t *Typed) UsersHavePermissionCtx(ctx context.Context, userIDs []models.UserID, p PermissionRef) (users map[models.UserID]bool, err error) {
+	given := make([]interface{}, 0, len(userIDs))
+	for _, userID := range userIDs {
+		given = append(given, t.subject(userID))
+	}
+
+	var subjects map[models.Subject]bool
+	if subjects, err = t.permify.SubjectsHavePermissionCtx(ctx, given, p); err != nil {
+		return nil, err
+	}
+
+	users = make(map[models.UserID]bool, len(userIDs))
+	for _, userID := range userIDs {
+		var subject models.Subject
+		if subject, err = t.permify.parseSubject(t.subject(userID)); err != nil {
+			return nil, err
+		}
+		users[userID] = subjects[subject]
+	}
+	return users, nil
+}